@@ -4,9 +4,9 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
-	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
@@ -31,6 +31,7 @@ type client struct {
 
 	initialPacketNumber  protocol.PacketNumber
 	hasNegotiatedVersion bool
+	initialVersion       protocol.VersionNumber // the version used for the first connection attempt
 	version              protocol.VersionNumber
 
 	handshakeChan chan struct{}
@@ -49,6 +50,9 @@ var (
 
 // DialAddr establishes a new QUIC connection to a server.
 // It uses a new UDP connection and closes this connection when the QUIC session is closed.
+// The UDP connection is bound to an unspecified local address and a random port.
+// To use a specific local address or port, or to set socket options (e.g. SO_BINDTODEVICE),
+// create the UDP connection (e.g. using a net.ListenConfig) and use Dial instead.
 // The hostname for SNI is taken from the given address.
 // The tls.Config.CipherSuites allows setting of TLS 1.3 cipher suites.
 func DialAddr(
@@ -83,7 +87,7 @@ func DialAddrEarlyContext(
 	if err != nil {
 		return nil, err
 	}
-	utils.Logger.WithPrefix(utils.DefaultLogger, "client").Debugf("Returning early session")
+	getLogger(config).WithPrefix("client").Debugf("Returning early session")
 	return sess, nil
 }
 
@@ -105,15 +109,133 @@ func dialAddrContext(
 	config *Config,
 	use0RTT bool,
 ) (quicSession, error) {
-	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	primary, fallback, err := resolveAddr(ctx, addr)
 	if err != nil {
 		return nil, err
 	}
-	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4zero, Port: 0})
+	dial := func(ctx context.Context, udpAddr *net.UDPAddr, tlsConf *tls.Config) (quicSession, error) {
+		udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4zero, Port: 0})
+		if err != nil {
+			return nil, err
+		}
+		if err := setDF(udpConn); err != nil {
+			utils.DefaultLogger.Debugf("Failed to set the DF bit: %s", err)
+		}
+		return dialContext(ctx, udpConn, udpAddr, addr, tlsConf, config, use0RTT, true)
+	}
+	if fallback == nil {
+		return dial(ctx, primary, tlsConf)
+	}
+	return dialParallel(ctx, primary, fallback, tlsConf, protocol.HappyEyeballsFallbackDelay, dial)
+}
+
+// resolveAddr resolves the address.
+// If the host has both IPv4 and IPv6 addresses, fallback is the first address
+// of the address family that is not used by primary.
+func resolveAddr(ctx context.Context, addr string) (primary, fallback *net.UDPAddr, _ error) {
+	host, portStr, err := net.SplitHostPort(addr)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	port, err := net.DefaultResolver.LookupPort(ctx, "udp", portStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ips) == 0 {
+		return nil, nil, &net.AddrError{Err: "no suitable address found", Addr: host}
+	}
+	// The resolver already sorted the addresses by preference (RFC 6724).
+	primary = &net.UDPAddr{IP: ips[0].IP, Port: port, Zone: ips[0].Zone}
+	isIPv4 := primary.IP.To4() != nil
+	for _, ip := range ips[1:] {
+		if (ip.IP.To4() != nil) != isIPv4 {
+			fallback = &net.UDPAddr{IP: ip.IP, Port: port, Zone: ip.Zone}
+			break
+		}
+	}
+	return primary, fallback, nil
+}
+
+// dialParallel races connection attempts to the primary and the fallback address (Happy Eyeballs, RFC 8305).
+// The attempt to the fallback address is started after fallbackDelay,
+// or as soon as the attempt to the primary address fails.
+// The first session that is established is returned, the other connection attempt is canceled.
+// If the other attempt succeeds nonetheless, its session is closed.
+func dialParallel(
+	ctx context.Context,
+	primary, fallback *net.UDPAddr,
+	tlsConf *tls.Config,
+	fallbackDelay time.Duration,
+	dial func(context.Context, *net.UDPAddr, *tls.Config) (quicSession, error),
+) (quicSession, error) {
+	type dialResult struct {
+		sess quicSession
+		err  error
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, 2)
+	startDial := func(addr *net.UDPAddr) {
+		// Both connection attempts set the ServerName, if it's not set yet.
+		var conf *tls.Config
+		if tlsConf != nil {
+			conf = tlsConf.Clone()
+		}
+		go func() {
+			sess, err := dial(ctx, addr, conf)
+			results <- dialResult{sess: sess, err: err}
+		}()
+	}
+
+	startDial(primary)
+	fallbackTimer := time.NewTimer(fallbackDelay)
+	defer fallbackTimer.Stop()
+	var firstErr error
+	var fallbackStarted bool
+	started := 1
+	var finished int
+	for {
+		select {
+		case <-fallbackTimer.C:
+			if !fallbackStarted {
+				fallbackStarted = true
+				startDial(fallback)
+				started++
+			}
+		case res := <-results:
+			finished++
+			if res.err == nil {
+				if finished < started {
+					// The other connection attempt might succeed before it notices that it was canceled.
+					// Close that session with a CONNECTION_CLOSE, so the server doesn't have to wait for it to time out.
+					go func() {
+						if res := <-results; res.err == nil {
+							res.sess.CloseWithError(0, "")
+						}
+					}()
+				}
+				return res.sess, nil
+			}
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			if !fallbackStarted {
+				// The primary connection attempt failed. Don't wait for the timer to start the fallback.
+				fallbackStarted = true
+				startDial(fallback)
+				started++
+			}
+			if finished == started {
+				return nil, firstErr
+			}
+		}
 	}
-	return dialContext(ctx, udpConn, udpAddr, addr, tlsConf, config, use0RTT, true)
 }
 
 // Dial establishes a new QUIC connection to a server using a net.PacketConn.
@@ -121,6 +243,8 @@ func dialAddrContext(
 // In this case, ReadMsgUDP will be used instead of ReadFrom to read packets.
 // The same PacketConn can be used for multiple calls to Dial and Listen,
 // QUIC connection IDs are used for demultiplexing the different connections.
+// This allows dialing many sessions, to the same or to different servers, from a single socket.
+// The PacketConn is not closed when a session is closed.
 // The host parameter is used for SNI.
 // The tls.Config must define an application protocol (using NextProtos).
 func Dial(
@@ -191,7 +315,7 @@ func dialContext(
 		return nil, err
 	}
 	config = populateClientConfig(config, createdPacketConn)
-	packetHandlers, err := getMultiplexer().AddConn(pconn, config.ConnectionIDLength, config.StatelessResetKey, config.Tracer)
+	packetHandlers, err := getMultiplexer().AddConn(pconn, config.ConnectionIDLength, config.StatelessResetKey, config.ReceiveBufferSize, config.SendBufferSize, config.Tracer)
 	if err != nil {
 		return nil, err
 	}
@@ -235,15 +359,6 @@ func newClient(
 		tlsConf.ServerName = sni
 	}
 
-	// check that all versions are actually supported
-	if config != nil {
-		for _, v := range config.Versions {
-			if !protocol.IsValidVersion(v) {
-				return nil, fmt.Errorf("%s is not a valid QUIC version", v)
-			}
-		}
-	}
-
 	srcConnID, err := generateConnectionID(config.ConnectionIDLength)
 	if err != nil {
 		return nil, err
@@ -260,9 +375,10 @@ func newClient(
 		use0RTT:           use0RTT,
 		tlsConf:           tlsConf,
 		config:            config,
+		initialVersion:    config.Versions[0],
 		version:           config.Versions[0],
 		handshakeChan:     make(chan struct{}),
-		logger:            utils.DefaultLogger.WithPrefix("client"),
+		logger:            getLogger(config).WithPrefix("client"),
 	}
 	return c, nil
 }
@@ -281,7 +397,7 @@ func (c *client) dial(ctx context.Context) error {
 		c.config,
 		c.tlsConf,
 		c.initialPacketNumber,
-		c.version,
+		c.initialVersion,
 		c.use0RTT,
 		c.hasNegotiatedVersion,
 		c.tracer,
@@ -117,7 +117,7 @@ var _ = Describe("Client", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
 			manager.EXPECT().Destroy()
-			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			remoteAddrChan := make(chan string, 1)
 			newClientSession = func(
@@ -143,14 +143,14 @@ var _ = Describe("Client", func() {
 			}
 			_, err := DialAddr("localhost:17890", tlsConf, &Config{HandshakeTimeout: time.Millisecond})
 			Expect(err).ToNot(HaveOccurred())
-			Eventually(remoteAddrChan).Should(Receive(Equal("127.0.0.1:17890")))
+			Eventually(remoteAddrChan).Should(Receive(Or(Equal("127.0.0.1:17890"), Equal("[::1]:17890"))))
 		})
 
 		It("uses the tls.Config.ServerName as the hostname, if present", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
 			manager.EXPECT().Destroy()
-			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			hostnameChan := make(chan string, 1)
 			newClientSession = func(
@@ -183,7 +183,7 @@ var _ = Describe("Client", func() {
 		It("allows passing host without port as server name", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			hostnameChan := make(chan string, 1)
 			newClientSession = func(
@@ -222,7 +222,7 @@ var _ = Describe("Client", func() {
 		It("returns after the handshake is complete", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			run := make(chan struct{})
 			newClientSession = func(
@@ -264,7 +264,7 @@ var _ = Describe("Client", func() {
 		It("returns early sessions", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			readyChan := make(chan struct{})
 			done := make(chan struct{})
@@ -313,7 +313,7 @@ var _ = Describe("Client", func() {
 		It("returns an error that occurs while waiting for the handshake to complete", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			testErr := errors.New("early handshake error")
 			newClientSession = func(
@@ -350,7 +350,7 @@ var _ = Describe("Client", func() {
 		It("closes the session when the context is canceled", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			sessionRunning := make(chan struct{})
 			defer close(sessionRunning)
@@ -404,7 +404,7 @@ var _ = Describe("Client", func() {
 			}
 
 			manager := NewMockPacketHandlerManager(mockCtrl)
-			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 			manager.EXPECT().Add(gomock.Any(), gomock.Any())
 
 			var conn sendConn
@@ -481,9 +481,6 @@ var _ = Describe("Client", func() {
 			})
 
 			It("errors when the Config contains an invalid version", func() {
-				manager := NewMockPacketHandlerManager(mockCtrl)
-				mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
-
 				version := protocol.VersionNumber(0x1234)
 				_, err := Dial(packetConn, nil, "localhost:1234", tlsConf, &Config{Versions: []protocol.VersionNumber{version}})
 				Expect(err).To(MatchError("0x1234 is not a valid QUIC version"))
@@ -525,7 +522,7 @@ var _ = Describe("Client", func() {
 		It("creates new sessions with the right parameters", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(connID, gomock.Any())
-			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			config := &Config{Versions: []protocol.VersionNumber{protocol.VersionTLS}}
 			c := make(chan struct{})
@@ -569,7 +566,7 @@ var _ = Describe("Client", func() {
 			manager := NewMockPacketHandlerManager(mockCtrl)
 			manager.EXPECT().Add(connID, gomock.Any()).Times(2)
 			manager.EXPECT().Destroy()
-			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+			mockMultiplexer.EXPECT().AddConn(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 			initialVersion := cl.version
 
@@ -582,15 +579,17 @@ var _ = Describe("Client", func() {
 				configP *Config,
 				_ *tls.Config,
 				pn protocol.PacketNumber,
-				version protocol.VersionNumber,
+				initialVersionP protocol.VersionNumber,
 				_ bool,
 				hasNegotiatedVersion bool,
 				_ logging.ConnectionTracer,
 				_ utils.Logger,
-				versionP protocol.VersionNumber,
+				version protocol.VersionNumber,
 			) quicSession {
 				sess := NewMockQuicSession(mockCtrl)
 				sess.EXPECT().HandshakeComplete().Return(context.Background())
+				// the initial version is used to detect that version negotiation was performed
+				Expect(initialVersionP).To(Equal(initialVersion))
 				if counter == 0 {
 					Expect(pn).To(BeZero())
 					Expect(version).To(Equal(initialVersion))
@@ -619,4 +618,110 @@ var _ = Describe("Client", func() {
 			Expect(counter).To(Equal(2))
 		})
 	})
+
+	Context("Happy Eyeballs", func() {
+		primary := &net.UDPAddr{IP: net.IPv6loopback, Port: 1234}
+		fallback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}
+
+		It("resolves IP addresses", func() {
+			p, f, err := resolveAddr(context.Background(), "127.0.0.1:1234")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p.String()).To(Equal("127.0.0.1:1234"))
+			Expect(f).To(BeNil())
+			p, f, err = resolveAddr(context.Background(), "[::1]:1234")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p.String()).To(Equal("[::1]:1234"))
+			Expect(f).To(BeNil())
+		})
+
+		It("errors on invalid addresses", func() {
+			_, _, err := resolveAddr(context.Background(), "localhost")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("uses the primary address, if the connection attempt succeeds quickly", func() {
+			sess := NewMockQuicSession(mockCtrl)
+			var dialed []*net.UDPAddr
+			s, err := dialParallel(context.Background(), primary, fallback, nil, time.Hour, func(_ context.Context, addr *net.UDPAddr, _ *tls.Config) (quicSession, error) {
+				dialed = append(dialed, addr)
+				return sess, nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s).To(Equal(sess))
+			Expect(dialed).To(Equal([]*net.UDPAddr{primary}))
+		})
+
+		It("starts the fallback attempt immediately if the primary attempt fails", func() {
+			sess := NewMockQuicSession(mockCtrl)
+			s, err := dialParallel(context.Background(), primary, fallback, nil, time.Hour, func(_ context.Context, addr *net.UDPAddr, _ *tls.Config) (quicSession, error) {
+				if addr == primary {
+					return nil, errors.New("primary failed")
+				}
+				return sess, nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s).To(Equal(sess))
+		})
+
+		It("starts the fallback attempt after the delay, and cancels the primary attempt", func() {
+			sess := NewMockQuicSession(mockCtrl)
+			primaryCanceled := make(chan struct{})
+			s, err := dialParallel(context.Background(), primary, fallback, nil, 10*time.Millisecond, func(ctx context.Context, addr *net.UDPAddr, _ *tls.Config) (quicSession, error) {
+				if addr == primary {
+					<-ctx.Done()
+					close(primaryCanceled)
+					return nil, ctx.Err()
+				}
+				return sess, nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s).To(Equal(sess))
+			Eventually(primaryCanceled).Should(BeClosed())
+		})
+
+		It("closes the session of the losing connection attempt", func() {
+			sess1 := NewMockQuicSession(mockCtrl)
+			sess2 := NewMockQuicSession(mockCtrl)
+			done := make(chan struct{})
+			sess1.EXPECT().CloseWithError(ErrorCode(0), "").Do(func(ErrorCode, string) { close(done) })
+			unblock := make(chan struct{})
+			s, err := dialParallel(context.Background(), primary, fallback, nil, 10*time.Millisecond, func(ctx context.Context, addr *net.UDPAddr, _ *tls.Config) (quicSession, error) {
+				if addr == primary {
+					<-unblock
+					return sess1, nil
+				}
+				defer close(unblock)
+				return sess2, nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s).To(Equal(sess2))
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("returns the first error if both connection attempts fail", func() {
+			_, err := dialParallel(context.Background(), primary, fallback, nil, time.Hour, func(_ context.Context, addr *net.UDPAddr, _ *tls.Config) (quicSession, error) {
+				if addr == primary {
+					return nil, errors.New("primary failed")
+				}
+				return nil, errors.New("fallback failed")
+			})
+			Expect(err).To(MatchError("primary failed"))
+		})
+
+		It("uses a separate tls.Config for every connection attempt", func() {
+			tlsConf := &tls.Config{}
+			confs := make(chan *tls.Config, 2)
+			_, err := dialParallel(context.Background(), primary, fallback, tlsConf, time.Hour, func(_ context.Context, _ *net.UDPAddr, conf *tls.Config) (quicSession, error) {
+				confs <- conf
+				return nil, errors.New("failed")
+			})
+			Expect(err).To(HaveOccurred())
+			var c1, c2 *tls.Config
+			Eventually(confs).Should(Receive(&c1))
+			Eventually(confs).Should(Receive(&c2))
+			Expect(c1).ToNot(BeIdenticalTo(tlsConf))
+			Expect(c2).ToNot(BeIdenticalTo(tlsConf))
+			Expect(c1).ToNot(BeIdenticalTo(c2))
+		})
+	})
 })
@@ -2,8 +2,11 @@ package quic
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/lucas-clemente/quic-go/internal/logutils"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
 )
 
 // Clone clones a Config
@@ -16,15 +19,111 @@ func validateConfig(config *Config) error {
 	if config == nil {
 		return nil
 	}
+	if config.ConnectionIDLength != 0 && (config.ConnectionIDLength < 4 || config.ConnectionIDLength > 18) {
+		return errors.New("invalid value for Config.ConnectionIDLength")
+	}
 	if config.MaxIncomingStreams > 1<<60 {
 		return errors.New("invalid value for Config.MaxIncomingStreams")
 	}
 	if config.MaxIncomingUniStreams > 1<<60 {
 		return errors.New("invalid value for Config.MaxIncomingUniStreams")
 	}
+	if config.MaxAcceptQueueSize < 0 {
+		return errors.New("invalid value for Config.MaxAcceptQueueSize")
+	}
+	if config.AcceptQueueOverflowPolicy > OverflowDrop {
+		return errors.New("invalid value for Config.AcceptQueueOverflowPolicy")
+	}
+	if config.ConnectionIDRotationInterval < 0 {
+		return errors.New("invalid value for Config.ConnectionIDRotationInterval")
+	}
+	if config.MaxIncomingConnections < 0 {
+		return errors.New("invalid value for Config.MaxIncomingConnections")
+	}
+	if config.MaxIncomingConnectionsPerIP < 0 {
+		return errors.New("invalid value for Config.MaxIncomingConnectionsPerIP")
+	}
+	if config.IPv6PrefixLength < 0 || config.IPv6PrefixLength > 128 {
+		return errors.New("invalid value for Config.IPv6PrefixLength")
+	}
+	if config.SpinBitDisableRate < 0 || config.SpinBitDisableRate > protocol.DefaultSpinBitDisableRate {
+		return errors.New("invalid value for Config.SpinBitDisableRate")
+	}
+	if config.MaxHandshakesPerSecond < 0 {
+		return errors.New("invalid value for Config.MaxHandshakesPerSecond")
+	}
+	if config.MaxConcurrentHandshakes < 0 {
+		return errors.New("invalid value for Config.MaxConcurrentHandshakes")
+	}
+	if config.MaxQueuedHandshakes < 0 {
+		return errors.New("invalid value for Config.MaxQueuedHandshakes")
+	}
+	if config.MaxSessionMemory > 0 && config.MaxSessionMemory < minSessionMemory(config) {
+		return fmt.Errorf("Config.MaxSessionMemory must be at least %d bytes, to allow for the connection-level flow control window", minSessionMemory(config))
+	}
+	if config.LivenessProbeInterval < 0 {
+		return errors.New("invalid value for Config.LivenessProbeInterval")
+	}
+	if config.MaxUnansweredLivenessProbes < 0 {
+		return errors.New("invalid value for Config.MaxUnansweredLivenessProbes")
+	}
+	if config.MaxPacingBurstPackets < 0 {
+		return errors.New("invalid value for Config.MaxPacingBurstPackets")
+	}
+	if config.MinPacingDelay < 0 {
+		return errors.New("invalid value for Config.MinPacingDelay")
+	}
+	if config.ReceiveBufferSize < 0 {
+		return errors.New("invalid value for Config.ReceiveBufferSize")
+	}
+	if config.SendBufferSize < 0 {
+		return errors.New("invalid value for Config.SendBufferSize")
+	}
+	if config.MaxUnprocessedPackets < 0 || (config.MaxUnprocessedPackets > 0 && config.MaxUnprocessedPackets <= protocol.Max0RTTQueueLen) {
+		return errors.New("invalid value for Config.MaxUnprocessedPackets")
+	}
+	if config.SendQueueSize < 0 {
+		return errors.New("invalid value for Config.SendQueueSize")
+	}
+	// check that all versions are actually supported
+	for _, v := range config.Versions {
+		if !protocol.IsValidVersion(v) {
+			return fmt.Errorf("%s is not a valid QUIC version", v)
+		}
+	}
 	return nil
 }
 
+// generateConnectionIDWithConfig generates a new connection ID.
+// If the Config contains a ConnectionIDGenerator, it is used to generate the connection ID.
+func generateConnectionIDWithConfig(config *Config) (protocol.ConnectionID, error) {
+	if config.ConnectionIDGenerator == nil {
+		return protocol.GenerateConnectionID(config.ConnectionIDLength)
+	}
+	connID, err := config.ConnectionIDGenerator.GenerateConnectionID()
+	if err != nil {
+		return nil, err
+	}
+	if len(connID) != config.ConnectionIDLength {
+		return nil, fmt.Errorf("ConnectionIDGenerator generated a connection ID of invalid length (%d bytes, expected %d)", len(connID), config.ConnectionIDLength)
+	}
+	return connID, nil
+}
+
+// minSessionMemory is the amount of memory a peer can make the session hold without violating the flow control limits:
+// the connection-level flow control window, and the queues of unprocessed and undecryptable packets.
+func minSessionMemory(config *Config) uint64 {
+	window := config.MaxReceiveConnectionFlowControlWindow
+	if window == 0 {
+		window = protocol.DefaultMaxReceiveConnectionFlowControlWindow
+	}
+	maxUnprocessedPackets := config.MaxUnprocessedPackets
+	if maxUnprocessedPackets == 0 {
+		maxUnprocessedPackets = protocol.MaxSessionUnprocessedPackets
+	}
+	return window + uint64(maxUnprocessedPackets+protocol.MaxUndecryptablePackets)*uint64(protocol.MaxReceivePacketSize)
+}
+
 // populateServerConfig populates fields in the quic.Config with their default values, if none are set
 // it may be called with nil
 func populateServerConfig(config *Config) *Config {
@@ -35,6 +134,12 @@ func populateServerConfig(config *Config) *Config {
 	if config.AcceptToken == nil {
 		config.AcceptToken = defaultAcceptToken
 	}
+	if config.MaxAcceptQueueSize == 0 {
+		config.MaxAcceptQueueSize = protocol.MaxAcceptQueueSize
+	}
+	if config.IPv6PrefixLength == 0 {
+		config.IPv6PrefixLength = protocol.DefaultIPv6PrefixLength
+	}
 	return config
 }
 
@@ -60,6 +165,38 @@ func populateConfig(config *Config) *Config {
 	if config.HandshakeTimeout != 0 {
 		handshakeTimeout = config.HandshakeTimeout
 	}
+	maxPacingBurstPackets := protocol.DefaultMaxPacingBurstPackets
+	if config.MaxPacingBurstPackets != 0 {
+		maxPacingBurstPackets = config.MaxPacingBurstPackets
+	}
+	minPacingDelay := protocol.MinPacingDelay
+	if config.MinPacingDelay != 0 {
+		minPacingDelay = config.MinPacingDelay
+	}
+	receiveBufferSize := protocol.DesiredReceiveBufferSize
+	if config.ReceiveBufferSize != 0 {
+		receiveBufferSize = config.ReceiveBufferSize
+	}
+	sendBufferSize := protocol.DesiredSendBufferSize
+	if config.SendBufferSize != 0 {
+		sendBufferSize = config.SendBufferSize
+	}
+	maxUnprocessedPackets := protocol.MaxSessionUnprocessedPackets
+	if config.MaxUnprocessedPackets != 0 {
+		maxUnprocessedPackets = config.MaxUnprocessedPackets
+	}
+	sendQueueSize := protocol.DefaultSendQueueSize
+	if config.SendQueueSize != 0 {
+		sendQueueSize = config.SendQueueSize
+	}
+	maxUnansweredLivenessProbes := protocol.DefaultMaxUnansweredLivenessProbes
+	if config.MaxUnansweredLivenessProbes != 0 {
+		maxUnansweredLivenessProbes = config.MaxUnansweredLivenessProbes
+	}
+	spinBitDisableRate := protocol.DefaultSpinBitDisableRate
+	if config.SpinBitDisableRate != 0 {
+		spinBitDisableRate = config.SpinBitDisableRate
+	}
 	idleTimeout := protocol.DefaultIdleTimeout
 	if config.MaxIdleTimeout != 0 {
 		idleTimeout = config.MaxIdleTimeout
@@ -78,6 +215,10 @@ func populateConfig(config *Config) *Config {
 	} else if maxIncomingStreams < 0 {
 		maxIncomingStreams = 0
 	}
+	streamIDsLowThreshold := config.StreamIDsLowThreshold
+	if streamIDsLowThreshold == 0 {
+		streamIDsLowThreshold = protocol.DefaultStreamIDsLowThreshold
+	}
 	maxIncomingUniStreams := config.MaxIncomingUniStreams
 	if maxIncomingUniStreams == 0 {
 		maxIncomingUniStreams = protocol.DefaultMaxIncomingUniStreams
@@ -90,15 +231,55 @@ func populateConfig(config *Config) *Config {
 		HandshakeTimeout:                      handshakeTimeout,
 		MaxIdleTimeout:                        idleTimeout,
 		AcceptToken:                           config.AcceptToken,
+		ConnectionFilter:                      config.ConnectionFilter,
+		OfferedVersions:                       config.OfferedVersions,
+		MaxAcceptQueueSize:                    config.MaxAcceptQueueSize,
+		AcceptQueueOverflowPolicy:             config.AcceptQueueOverflowPolicy,
+		MaxIncomingConnections:                config.MaxIncomingConnections,
+		MaxIncomingConnectionsPerIP:           config.MaxIncomingConnectionsPerIP,
+		IPv6PrefixLength:                      config.IPv6PrefixLength,
+		MaxHandshakesPerSecond:                config.MaxHandshakesPerSecond,
+		MaxConcurrentHandshakes:               config.MaxConcurrentHandshakes,
+		MaxQueuedHandshakes:                   config.MaxQueuedHandshakes,
 		KeepAlive:                             config.KeepAlive,
+		LivenessProbeInterval:                 config.LivenessProbeInterval,
+		MaxUnansweredLivenessProbes:           maxUnansweredLivenessProbes,
+		MaxPacingBurstPackets:                 maxPacingBurstPackets,
+		MinPacingDelay:                        minPacingDelay,
+		ReceiveBufferSize:                     receiveBufferSize,
+		SendBufferSize:                        sendBufferSize,
+		MaxUnprocessedPackets:                 maxUnprocessedPackets,
+		SendQueueSize:                         sendQueueSize,
+		DisableSpinBit:                        config.DisableSpinBit,
+		SpinBitDisableRate:                    spinBitDisableRate,
+		DisableGreasing:                       config.DisableGreasing,
+		StrictFrameValidation:                 config.StrictFrameValidation,
 		MaxReceiveStreamFlowControlWindow:     maxReceiveStreamFlowControlWindow,
 		MaxReceiveConnectionFlowControlWindow: maxReceiveConnectionFlowControlWindow,
+		ReceiveMemoryBudget:                   config.ReceiveMemoryBudget,
+		MaxSessionMemory:                      config.MaxSessionMemory,
 		MaxIncomingStreams:                    maxIncomingStreams,
 		MaxIncomingUniStreams:                 maxIncomingUniStreams,
+		StreamIDsLowThreshold:                 streamIDsLowThreshold,
 		ConnectionIDLength:                    config.ConnectionIDLength,
+		ConnectionIDGenerator:                 config.ConnectionIDGenerator,
+		ConnectionIDRotationInterval:          config.ConnectionIDRotationInterval,
+		ConnectionIDRetired:                   config.ConnectionIDRetired,
+		SessionEventHandler:                   config.SessionEventHandler,
+		CapturePacket:                         config.CapturePacket,
 		StatelessResetKey:                     config.StatelessResetKey,
 		TokenStore:                            config.TokenStore,
 		QuicTracer:                            config.QuicTracer,
 		Tracer:                                config.Tracer,
+		Logger:                                config.Logger,
+	}
+}
+
+// getLogger returns a logger for the Logger configured in the Config.
+// If no Logger is configured, it returns the default logger.
+func getLogger(config *Config) utils.Logger {
+	if config == nil {
+		return utils.DefaultLogger
 	}
+	return logutils.NewLogger(config.Logger)
 }
@@ -1,6 +1,7 @@
 package quic
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"reflect"
@@ -8,12 +9,30 @@ import (
 
 	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
 	"github.com/lucas-clemente/quic-go/quictrace"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
+type mockConnIDGenerator struct {
+	connID []byte
+	err    error
+}
+
+func (g *mockConnIDGenerator) GenerateConnectionID() ([]byte, error) { return g.connID, g.err }
+
+type mockLogger struct {
+	messages []string
+}
+
+func (l *mockLogger) Enabled(level logging.LogLevel) bool { return level <= logging.LogLevelInfo }
+func (l *mockLogger) Log(_ logging.LogLevel, msg string, _ ...interface{}) {
+	l.messages = append(l.messages, msg)
+}
+
 var _ = Describe("Config", func() {
 	Context("validating", func() {
 		It("validates a nil config", func() {
@@ -24,6 +43,13 @@ var _ = Describe("Config", func() {
 			Expect(validateConfig(populateServerConfig(&Config{}))).To(Succeed())
 		})
 
+		It("errors on invalid connection ID lengths", func() {
+			Expect(validateConfig(&Config{ConnectionIDLength: 3})).To(MatchError("invalid value for Config.ConnectionIDLength"))
+			Expect(validateConfig(&Config{ConnectionIDLength: 19})).To(MatchError("invalid value for Config.ConnectionIDLength"))
+			Expect(validateConfig(&Config{ConnectionIDLength: 4})).To(Succeed())
+			Expect(validateConfig(&Config{ConnectionIDLength: 18})).To(Succeed())
+		})
+
 		It("errors on too large values for MaxIncomingStreams", func() {
 			Expect(validateConfig(&Config{MaxIncomingStreams: 1<<60 + 1})).To(MatchError("invalid value for Config.MaxIncomingStreams"))
 		})
@@ -31,6 +57,71 @@ var _ = Describe("Config", func() {
 		It("errors on too large values for MaxIncomingUniStreams", func() {
 			Expect(validateConfig(&Config{MaxIncomingUniStreams: 1<<60 + 1})).To(MatchError("invalid value for Config.MaxIncomingUniStreams"))
 		})
+
+		It("errors on an invalid accept queue size", func() {
+			Expect(validateConfig(&Config{MaxAcceptQueueSize: -1})).To(MatchError("invalid value for Config.MaxAcceptQueueSize"))
+		})
+
+		It("errors on an invalid accept queue overflow policy", func() {
+			Expect(validateConfig(&Config{AcceptQueueOverflowPolicy: 42})).To(MatchError("invalid value for Config.AcceptQueueOverflowPolicy"))
+		})
+
+		It("errors on an invalid connection ID rotation interval", func() {
+			Expect(validateConfig(&Config{ConnectionIDRotationInterval: -time.Second})).To(MatchError("invalid value for Config.ConnectionIDRotationInterval"))
+		})
+
+		It("errors on invalid connection limits", func() {
+			Expect(validateConfig(&Config{MaxIncomingConnections: -1})).To(MatchError("invalid value for Config.MaxIncomingConnections"))
+			Expect(validateConfig(&Config{MaxIncomingConnectionsPerIP: -1})).To(MatchError("invalid value for Config.MaxIncomingConnectionsPerIP"))
+			Expect(validateConfig(&Config{IPv6PrefixLength: -1})).To(MatchError("invalid value for Config.IPv6PrefixLength"))
+			Expect(validateConfig(&Config{IPv6PrefixLength: 129})).To(MatchError("invalid value for Config.IPv6PrefixLength"))
+			Expect(validateConfig(&Config{SpinBitDisableRate: -1})).To(MatchError("invalid value for Config.SpinBitDisableRate"))
+			Expect(validateConfig(&Config{SpinBitDisableRate: 17})).To(MatchError("invalid value for Config.SpinBitDisableRate"))
+			Expect(validateConfig(&Config{MaxHandshakesPerSecond: -1})).To(MatchError("invalid value for Config.MaxHandshakesPerSecond"))
+			Expect(validateConfig(&Config{MaxConcurrentHandshakes: -1})).To(MatchError("invalid value for Config.MaxConcurrentHandshakes"))
+			Expect(validateConfig(&Config{MaxQueuedHandshakes: -1})).To(MatchError("invalid value for Config.MaxQueuedHandshakes"))
+		})
+
+		It("errors if the session memory limit is smaller than the connection-level flow control window", func() {
+			const window = 1 << 20
+			minMemory := window + uint64(protocol.MaxSessionUnprocessedPackets+protocol.MaxUndecryptablePackets)*uint64(protocol.MaxReceivePacketSize)
+			Expect(validateConfig(&Config{
+				MaxReceiveConnectionFlowControlWindow: window,
+				MaxSessionMemory:                      window,
+			})).To(MatchError(fmt.Sprintf("Config.MaxSessionMemory must be at least %d bytes, to allow for the connection-level flow control window", minMemory)))
+			Expect(validateConfig(&Config{
+				MaxReceiveConnectionFlowControlWindow: window,
+				MaxSessionMemory:                      minMemory,
+			})).To(Succeed())
+			Expect(validateConfig(&Config{MaxSessionMemory: 1 << 20})).ToNot(Succeed())
+		})
+
+		It("errors on invalid liveness probing parameters", func() {
+			Expect(validateConfig(&Config{LivenessProbeInterval: -time.Second})).To(MatchError("invalid value for Config.LivenessProbeInterval"))
+			Expect(validateConfig(&Config{MaxUnansweredLivenessProbes: -1})).To(MatchError("invalid value for Config.MaxUnansweredLivenessProbes"))
+		})
+
+		It("errors on invalid pacing parameters", func() {
+			Expect(validateConfig(&Config{MaxPacingBurstPackets: -1})).To(MatchError("invalid value for Config.MaxPacingBurstPackets"))
+			Expect(validateConfig(&Config{MinPacingDelay: -time.Millisecond})).To(MatchError("invalid value for Config.MinPacingDelay"))
+			Expect(validateConfig(&Config{MaxPacingBurstPackets: 1, MinPacingDelay: time.Microsecond})).To(Succeed())
+		})
+
+		It("errors on invalid socket buffer sizes", func() {
+			Expect(validateConfig(&Config{ReceiveBufferSize: -1})).To(MatchError("invalid value for Config.ReceiveBufferSize"))
+			Expect(validateConfig(&Config{SendBufferSize: -1})).To(MatchError("invalid value for Config.SendBufferSize"))
+		})
+
+		It("errors on invalid queue sizes", func() {
+			Expect(validateConfig(&Config{MaxUnprocessedPackets: -1})).To(MatchError("invalid value for Config.MaxUnprocessedPackets"))
+			Expect(validateConfig(&Config{MaxUnprocessedPackets: protocol.Max0RTTQueueLen})).To(MatchError("invalid value for Config.MaxUnprocessedPackets"))
+			Expect(validateConfig(&Config{MaxUnprocessedPackets: protocol.Max0RTTQueueLen + 1})).To(Succeed())
+			Expect(validateConfig(&Config{SendQueueSize: -1})).To(MatchError("invalid value for Config.SendQueueSize"))
+		})
+
+		It("errors on invalid versions", func() {
+			Expect(validateConfig(&Config{Versions: []VersionNumber{0x1234}})).To(MatchError("0x1234 is not a valid QUIC version"))
+		})
 	})
 
 	configWithNonZeroNonFunctionFields := func() *Config {
@@ -46,12 +137,16 @@ var _ = Describe("Config", func() {
 			}
 
 			switch fn := typ.Field(i).Name; fn {
-			case "AcceptToken", "GetLogWriter":
+			case "AcceptToken", "ConnectionFilter", "OfferedVersions", "ConnectionIDRetired", "SessionEventHandler", "CapturePacket", "GetLogWriter":
 				// Can't compare functions.
 			case "Versions":
 				f.Set(reflect.ValueOf([]VersionNumber{1, 2, 3}))
 			case "ConnectionIDLength":
 				f.Set(reflect.ValueOf(8))
+			case "ConnectionIDGenerator":
+				f.Set(reflect.ValueOf(&mockConnIDGenerator{}))
+			case "ConnectionIDRotationInterval":
+				f.Set(reflect.ValueOf(19 * time.Minute))
 			case "HandshakeTimeout":
 				f.Set(reflect.ValueOf(time.Second))
 			case "MaxIdleTimeout":
@@ -62,18 +157,66 @@ var _ = Describe("Config", func() {
 				f.Set(reflect.ValueOf(uint64(9)))
 			case "MaxReceiveConnectionFlowControlWindow":
 				f.Set(reflect.ValueOf(uint64(10)))
+			case "ReceiveMemoryBudget":
+				f.Set(reflect.ValueOf(uint64(1 << 30)))
+			case "MaxSessionMemory":
+				f.Set(reflect.ValueOf(uint64(1 << 25)))
 			case "MaxIncomingStreams":
 				f.Set(reflect.ValueOf(int64(11)))
 			case "MaxIncomingUniStreams":
 				f.Set(reflect.ValueOf(int64(12)))
+			case "StreamIDsLowThreshold":
+				f.Set(reflect.ValueOf(uint64(1000)))
 			case "StatelessResetKey":
 				f.Set(reflect.ValueOf([]byte{1, 2, 3, 4}))
 			case "KeepAlive":
 				f.Set(reflect.ValueOf(true))
+			case "LivenessProbeInterval":
+				f.Set(reflect.ValueOf(5 * time.Second))
+			case "MaxUnansweredLivenessProbes":
+				f.Set(reflect.ValueOf(5))
+			case "MaxAcceptQueueSize":
+				f.Set(reflect.ValueOf(15))
+			case "AcceptQueueOverflowPolicy":
+				f.Set(reflect.ValueOf(OverflowDrop))
+			case "MaxIncomingConnections":
+				f.Set(reflect.ValueOf(16))
+			case "MaxIncomingConnectionsPerIP":
+				f.Set(reflect.ValueOf(17))
+			case "IPv6PrefixLength":
+				f.Set(reflect.ValueOf(56))
+			case "MaxHandshakesPerSecond":
+				f.Set(reflect.ValueOf(18))
+			case "MaxConcurrentHandshakes":
+				f.Set(reflect.ValueOf(19))
+			case "MaxQueuedHandshakes":
+				f.Set(reflect.ValueOf(21))
+			case "MaxPacingBurstPackets":
+				f.Set(reflect.ValueOf(13))
+			case "MinPacingDelay":
+				f.Set(reflect.ValueOf(14 * time.Millisecond))
+			case "ReceiveBufferSize":
+				f.Set(reflect.ValueOf(1 << 21))
+			case "SendBufferSize":
+				f.Set(reflect.ValueOf(1 << 22))
+			case "MaxUnprocessedPackets":
+				f.Set(reflect.ValueOf(100))
+			case "SendQueueSize":
+				f.Set(reflect.ValueOf(20))
+			case "DisableSpinBit":
+				f.Set(reflect.ValueOf(true))
+			case "SpinBitDisableRate":
+				f.Set(reflect.ValueOf(8))
+			case "DisableGreasing":
+				f.Set(reflect.ValueOf(true))
+			case "StrictFrameValidation":
+				f.Set(reflect.ValueOf(true))
 			case "QuicTracer":
 				f.Set(reflect.ValueOf(quictrace.NewTracer()))
 			case "Tracer":
 				f.Set(reflect.ValueOf(mocklogging.NewMockTracer(mockCtrl)))
+			case "Logger":
+				f.Set(reflect.ValueOf(&mockLogger{}))
 			default:
 				Fail(fmt.Sprintf("all fields must be accounted for, but saw unknown field %q", fn))
 			}
@@ -110,15 +253,76 @@ var _ = Describe("Config", func() {
 		})
 	})
 
+	Context("generating connection IDs", func() {
+		It("generates random connection IDs", func() {
+			c1, err := generateConnectionIDWithConfig(&Config{ConnectionIDLength: 6})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c1).To(HaveLen(6))
+			c2, err := generateConnectionIDWithConfig(&Config{ConnectionIDLength: 6})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c2).ToNot(Equal(c1))
+		})
+
+		It("uses the ConnectionIDGenerator", func() {
+			connID, err := generateConnectionIDWithConfig(&Config{
+				ConnectionIDLength:    4,
+				ConnectionIDGenerator: &mockConnIDGenerator{connID: []byte{1, 2, 3, 4}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connID).To(Equal(protocol.ConnectionID{1, 2, 3, 4}))
+		})
+
+		It("returns errors from the ConnectionIDGenerator", func() {
+			_, err := generateConnectionIDWithConfig(&Config{
+				ConnectionIDLength:    4,
+				ConnectionIDGenerator: &mockConnIDGenerator{err: errors.New("generation failed")},
+			})
+			Expect(err).To(MatchError("generation failed"))
+		})
+
+		It("errors if the ConnectionIDGenerator generates a connection ID of the wrong length", func() {
+			_, err := generateConnectionIDWithConfig(&Config{
+				ConnectionIDLength:    4,
+				ConnectionIDGenerator: &mockConnIDGenerator{connID: []byte{1, 2, 3, 4, 5}},
+			})
+			Expect(err).To(MatchError("ConnectionIDGenerator generated a connection ID of invalid length (5 bytes, expected 4)"))
+		})
+	})
+
+	Context("logging", func() {
+		It("uses the default logger if no Logger is configured", func() {
+			Expect(getLogger(nil)).To(Equal(utils.DefaultLogger))
+			Expect(getLogger(&Config{})).To(Equal(utils.DefaultLogger))
+		})
+
+		It("uses the Logger", func() {
+			l := &mockLogger{}
+			logger := getLogger(&Config{Logger: l})
+			logger.Debugf("debug")
+			logger.Infof("info %d", 42)
+			Expect(logger.Debug()).To(BeFalse())
+			Expect(l.messages).To(Equal([]string{"info 42"}))
+		})
+	})
+
 	Context("populating", func() {
 		It("populates function fields", func() {
-			var calledAcceptToken bool
+			var calledAcceptToken, calledConnectionFilter, calledOfferedVersions bool
 			c1 := &Config{
 				AcceptToken: func(_ net.Addr, _ *Token) bool { calledAcceptToken = true; return true },
+				ConnectionFilter: func(net.Addr, VersionNumber, []byte) ConnectionFilterResult {
+					calledConnectionFilter = true
+					return ConnectionFilterAccept
+				},
+				OfferedVersions: func(net.Addr) []VersionNumber { calledOfferedVersions = true; return nil },
 			}
 			c2 := populateConfig(c1)
 			c2.AcceptToken(&net.UDPAddr{}, &Token{})
 			Expect(calledAcceptToken).To(BeTrue())
+			c2.ConnectionFilter(&net.UDPAddr{}, protocol.VersionTLS, nil)
+			Expect(calledConnectionFilter).To(BeTrue())
+			c2.OfferedVersions(&net.UDPAddr{})
+			Expect(calledOfferedVersions).To(BeTrue())
 		})
 
 		It("copies non-function fields", func() {
@@ -134,12 +338,23 @@ var _ = Describe("Config", func() {
 			Expect(c.MaxReceiveConnectionFlowControlWindow).To(BeEquivalentTo(protocol.DefaultMaxReceiveConnectionFlowControlWindow))
 			Expect(c.MaxIncomingStreams).To(BeEquivalentTo(protocol.DefaultMaxIncomingStreams))
 			Expect(c.MaxIncomingUniStreams).To(BeEquivalentTo(protocol.DefaultMaxIncomingUniStreams))
+			Expect(c.StreamIDsLowThreshold).To(BeEquivalentTo(protocol.DefaultStreamIDsLowThreshold))
+			Expect(c.MaxPacingBurstPackets).To(Equal(protocol.DefaultMaxPacingBurstPackets))
+			Expect(c.MinPacingDelay).To(Equal(protocol.MinPacingDelay))
+			Expect(c.ReceiveBufferSize).To(Equal(protocol.DesiredReceiveBufferSize))
+			Expect(c.SendBufferSize).To(Equal(protocol.DesiredSendBufferSize))
+			Expect(c.MaxUnprocessedPackets).To(Equal(protocol.MaxSessionUnprocessedPackets))
+			Expect(c.SendQueueSize).To(Equal(protocol.DefaultSendQueueSize))
+			Expect(c.MaxUnansweredLivenessProbes).To(Equal(protocol.DefaultMaxUnansweredLivenessProbes))
+			Expect(c.SpinBitDisableRate).To(Equal(protocol.DefaultSpinBitDisableRate))
 		})
 
 		It("populates empty fields with default values, for the server", func() {
 			c := populateServerConfig(&Config{})
 			Expect(c.ConnectionIDLength).To(Equal(protocol.DefaultConnectionIDLength))
 			Expect(c.AcceptToken).ToNot(BeNil())
+			Expect(c.MaxAcceptQueueSize).To(Equal(protocol.MaxAcceptQueueSize))
+			Expect(c.IPv6PrefixLength).To(Equal(protocol.DefaultIPv6PrefixLength))
 		})
 
 		It("sets a default connection ID length if we didn't create the conn, for the client", func() {
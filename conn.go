@@ -17,6 +17,12 @@ type connection interface {
 	io.Closer
 }
 
+// A receiveBufferDropCounter is a connection that detects packets
+// that the kernel dropped because the receive buffer was full.
+type receiveBufferDropCounter interface {
+	receiveBufferDrops() uint64
+}
+
 // If the PacketConn passed to Dial or Listen satisfies this interface, quic-go will read the ECN bits from the IP header.
 // In this case, ReadMsgUDP() will be used instead of ReadFrom() to read packets.
 type ECNCapablePacketConn interface {
@@ -27,13 +33,13 @@ type ECNCapablePacketConn interface {
 
 var _ ECNCapablePacketConn = &net.UDPConn{}
 
-func wrapConn(pc net.PacketConn) (connection, error) {
+func wrapConn(pc net.PacketConn, logger utils.Logger) (connection, error) {
 	c, ok := pc.(ECNCapablePacketConn)
 	if !ok {
 		utils.DefaultLogger.Infof("PacketConn is not a net.UDPConn. Disabling optimizations possible on UDP connections.")
 		return &basicConn{PacketConn: pc}, nil
 	}
-	return newConn(c)
+	return newConn(c, logger)
 }
 
 type basicConn struct {
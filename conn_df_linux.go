@@ -0,0 +1,41 @@
+// +build linux
+
+package quic
+
+import (
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// setDF sets the Don't Fragment bit on packets sent on the connection.
+// QUIC packets must not be fragmented. If a packet is too large for a path,
+// it is dropped, and treated as lost.
+func setDF(c *net.UDPConn) error {
+	rawConn, err := c.SyscallConn()
+	if err != nil {
+		return err
+	}
+	// We don't know if this a IPv4-only, IPv6-only or a IPv4-and-IPv6 connection.
+	// Try setting the DF bit for both IP versions.
+	var errIPv4, errIPv6 error
+	if err := rawConn.Control(func(fd uintptr) {
+		errIPv4 = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER, syscall.IP_PMTUDISC_DO)
+		errIPv6 = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_MTU_DISCOVER, syscall.IPV6_PMTUDISC_DO)
+	}); err != nil {
+		return err
+	}
+	switch {
+	case errIPv4 == nil && errIPv6 == nil:
+		utils.DefaultLogger.Debugf("Setting DF for IPv4 and IPv6.")
+	case errIPv4 == nil && errIPv6 != nil:
+		utils.DefaultLogger.Debugf("Setting DF for IPv4.")
+	case errIPv4 != nil && errIPv6 == nil:
+		utils.DefaultLogger.Debugf("Setting DF for IPv6.")
+	case errIPv4 != nil && errIPv6 != nil:
+		return errors.New("setting DF failed for both IPv4 and IPv6")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+// +build linux
+
+package quic
+
+import (
+	"net"
+	"syscall"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Setting the DF bit", func() {
+	It("sets the DF bit", func() {
+		addr, err := net.ResolveUDPAddr("udp", "localhost:0")
+		Expect(err).ToNot(HaveOccurred())
+		conn, err := net.ListenUDP("udp", addr)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+		Expect(setDF(conn)).To(Succeed())
+
+		rawConn, err := conn.SyscallConn()
+		Expect(err).ToNot(HaveOccurred())
+		var val int
+		var serr error
+		Expect(rawConn.Control(func(fd uintptr) {
+			val, serr = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER)
+		})).To(Succeed())
+		Expect(serr).ToNot(HaveOccurred())
+		Expect(val).To(Equal(syscall.IP_PMTUDISC_DO))
+	})
+})
@@ -0,0 +1,47 @@
+// +build windows
+
+package quic
+
+import (
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+const (
+	// not defined in the syscall package, see ws2ipdef.h
+	//nolint:stylecheck
+	IP_DONTFRAGMENT = 14
+	//nolint:stylecheck
+	IPV6_DONTFRAG = 14
+)
+
+// setDF sets the Don't Fragment bit on packets sent on the connection.
+// QUIC packets must not be fragmented. If a packet is too large for a path,
+// it is dropped, and treated as lost.
+func setDF(c *net.UDPConn) error {
+	rawConn, err := c.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errIPv4, errIPv6 error
+	if err := rawConn.Control(func(fd uintptr) {
+		errIPv4 = syscall.SetsockoptInt(syscall.Handle(fd), syscall.IPPROTO_IP, IP_DONTFRAGMENT, 1)
+		errIPv6 = syscall.SetsockoptInt(syscall.Handle(fd), syscall.IPPROTO_IPV6, IPV6_DONTFRAG, 1)
+	}); err != nil {
+		return err
+	}
+	switch {
+	case errIPv4 == nil && errIPv6 == nil:
+		utils.DefaultLogger.Debugf("Setting DF for IPv4 and IPv6.")
+	case errIPv4 == nil && errIPv6 != nil:
+		utils.DefaultLogger.Debugf("Setting DF for IPv4.")
+	case errIPv4 != nil && errIPv6 == nil:
+		utils.DefaultLogger.Debugf("Setting DF for IPv6.")
+	case errIPv4 != nil && errIPv6 != nil:
+		return errors.New("setting DF failed for both IPv4 and IPv6")
+	}
+	return nil
+}
@@ -4,6 +4,8 @@ package quic
 
 import (
 	"errors"
+	"log"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -13,14 +15,30 @@ import (
 
 const ecnMask uint8 = 0x3
 
+// Packets dropped because the receive buffer was full are reported at most once in this interval.
+const receiveBufferDropWarningInterval = 10 * time.Second
+
 type ecnConn struct {
+	// The number of packets dropped since the conn was created, accessed atomically.
+	// It must be the first field, so that it is 64 bit aligned on 32 bit platforms.
+	numDropped uint64
+
 	ECNCapablePacketConn
 	oobBuffer []byte
+
+	// the number of packets the kernel dropped because the receive buffer was full, as reported by the kernel
+	droppedPackets  uint32
+	lastDropWarning time.Time
+
+	logger utils.Logger
 }
 
-var _ connection = &ecnConn{}
+var (
+	_ connection               = &ecnConn{}
+	_ receiveBufferDropCounter = &ecnConn{}
+)
 
-func newConn(c ECNCapablePacketConn) (*ecnConn, error) {
+func newConn(c ECNCapablePacketConn, logger utils.Logger) (*ecnConn, error) {
 	rawConn, err := c.SyscallConn()
 	if err != nil {
 		return nil, err
@@ -49,9 +67,19 @@ func newConn(c ECNCapablePacketConn) (*ecnConn, error) {
 	case errIPv4 != nil && errIPv6 != nil:
 		return nil, errors.New("activating ECN failed for both IPv4 and IPv6")
 	}
+	var errRXQOVFL error
+	if err := rawConn.Control(func(fd uintptr) {
+		errRXQOVFL = setRXQOVFL(fd)
+	}); err != nil {
+		return nil, err
+	}
+	if errRXQOVFL != nil {
+		logger.Debugf("Not detecting drops due to a full receive buffer: %s", errRXQOVFL)
+	}
 	return &ecnConn{
 		ECNCapablePacketConn: c,
 		oobBuffer:            make([]byte, 128),
+		logger:               logger,
 	}, nil
 }
 
@@ -69,22 +97,51 @@ func (c *ecnConn) ReadPacket() (*receivedPacket, error) {
 	if err != nil {
 		return nil, err
 	}
+	rcvTime := time.Now()
 	var ecn protocol.ECN
 	for _, ctrlMsg := range ctrlMsgs {
 		if ctrlMsg.Header.Level == syscall.IPPROTO_IP && ctrlMsg.Header.Type == msgTypeIPTOS {
 			ecn = protocol.ECN(ctrlMsg.Data[0] & ecnMask)
-			break
+			continue
 		}
 		if ctrlMsg.Header.Level == syscall.IPPROTO_IPV6 && ctrlMsg.Header.Type == syscall.IPV6_TCLASS {
 			ecn = protocol.ECN(ctrlMsg.Data[0] & ecnMask)
-			break
+			continue
+		}
+		if dropped, ok := parseRXQOVFL(ctrlMsg); ok {
+			c.updateDroppedPackets(dropped, rcvTime)
 		}
 	}
 	return &receivedPacket{
 		remoteAddr: addr,
-		rcvTime:    time.Now(),
+		rcvTime:    rcvTime,
 		data:       buffer.Data[:n],
 		ecn:        ecn,
 		buffer:     buffer,
 	}, nil
 }
+
+// updateDroppedPackets is called with the total number of packets dropped due to a full receive buffer.
+// A message is logged when this number increased.
+func (c *ecnConn) updateDroppedPackets(dropped uint32, now time.Time) {
+	if dropped == c.droppedPackets {
+		return
+	}
+	// the counter might have wrapped around
+	newlyDropped := dropped - c.droppedPackets
+	c.droppedPackets = dropped
+	total := atomic.AddUint64(&c.numDropped, uint64(newlyDropped))
+	if now.Sub(c.lastDropWarning) < receiveBufferDropWarningInterval {
+		c.logger.Debugf("Receive buffer full. Dropped %d packets.", newlyDropped)
+		return
+	}
+	c.lastDropWarning = now
+	// Dropped packets degrade performance, so the warning is logged independent of the log level.
+	log.Printf("Receive buffer full. Dropped %d packets (%d in total). Consider increasing Config.ReceiveBufferSize.", newlyDropped, total)
+}
+
+// receiveBufferDrops returns the number of packets that the kernel dropped since the conn was created,
+// because the receive buffer was full.
+func (c *ecnConn) receiveBufferDrops() uint64 {
+	return atomic.LoadUint64(&c.numDropped)
+}
@@ -3,7 +3,11 @@
 package quic
 
 import (
+	"bytes"
+	"log"
+	"math"
 	"net"
+	"os"
 	"syscall"
 	"time"
 
@@ -21,7 +25,7 @@ var _ = Describe("Basic Conn Test", func() {
 			Expect(err).ToNot(HaveOccurred())
 			udpConn, err := net.ListenUDP(network, addr)
 			Expect(err).ToNot(HaveOccurred())
-			ecnConn, err := newConn(udpConn)
+			ecnConn, err := newConn(udpConn, utils.DefaultLogger)
 			Expect(err).ToNot(HaveOccurred())
 
 			packetChan := make(chan *receivedPacket)
@@ -124,5 +128,32 @@ var _ = Describe("Basic Conn Test", func() {
 			Expect(utils.IsIPv4(p.remoteAddr.(*net.UDPAddr).IP)).To(BeFalse())
 			Expect(p.ecn).To(Equal(protocol.ECT1))
 		})
+
+		It("keeps track of packets dropped due to a full receive buffer", func() {
+			buf := &bytes.Buffer{}
+			log.SetOutput(buf)
+			defer log.SetOutput(os.Stdout)
+			c := &ecnConn{logger: utils.DefaultLogger}
+			now := time.Now()
+			c.updateDroppedPackets(3, now)
+			Expect(buf.String()).To(ContainSubstring("Receive buffer full. Dropped 3 packets (3 in total)."))
+			Expect(c.droppedPackets).To(BeEquivalentTo(3))
+			Expect(c.receiveBufferDrops()).To(BeEquivalentTo(3))
+			Expect(c.lastDropWarning).To(Equal(now))
+			// don't warn again right away
+			c.updateDroppedPackets(5, now.Add(time.Second))
+			Expect(c.droppedPackets).To(BeEquivalentTo(5))
+			Expect(c.lastDropWarning).To(Equal(now))
+			c.updateDroppedPackets(6, now.Add(receiveBufferDropWarningInterval))
+			Expect(c.droppedPackets).To(BeEquivalentTo(6))
+			Expect(c.lastDropWarning).To(Equal(now.Add(receiveBufferDropWarningInterval)))
+			Expect(c.receiveBufferDrops()).To(BeEquivalentTo(6))
+		})
+
+		It("counts dropped packets when the kernel's counter wraps around", func() {
+			c := &ecnConn{logger: utils.DefaultLogger, droppedPackets: math.MaxUint32 - 1}
+			c.updateDroppedPackets(2, time.Now())
+			Expect(c.receiveBufferDrops()).To(BeEquivalentTo(4))
+		})
 	})
 })
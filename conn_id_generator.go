@@ -12,10 +12,13 @@ import (
 type connIDGenerator struct {
 	connIDLen  int
 	highestSeq uint64
+	// connection IDs with a sequence number smaller than this value were retired by a rotation
+	retirePriorTo uint64
 
 	activeSrcConnIDs        map[uint64]protocol.ConnectionID
 	initialClientDestConnID protocol.ConnectionID
 
+	generateConnectionID   func() (protocol.ConnectionID, error)
 	addConnectionID        func(protocol.ConnectionID)
 	getStatelessResetToken func(protocol.ConnectionID) protocol.StatelessResetToken
 	removeConnectionID     func(protocol.ConnectionID)
@@ -29,6 +32,7 @@ type connIDGenerator struct {
 func newConnIDGenerator(
 	initialConnectionID protocol.ConnectionID,
 	initialClientDestConnID protocol.ConnectionID, // nil for the client
+	generateConnectionID func() (protocol.ConnectionID, error),
 	addConnectionID func(protocol.ConnectionID),
 	getStatelessResetToken func(protocol.ConnectionID) protocol.StatelessResetToken,
 	removeConnectionID func(protocol.ConnectionID),
@@ -40,6 +44,7 @@ func newConnIDGenerator(
 	m := &connIDGenerator{
 		connIDLen:              initialConnectionID.Len(),
 		activeSrcConnIDs:       make(map[uint64]protocol.ConnectionID),
+		generateConnectionID:   generateConnectionID,
 		addConnectionID:        addConnectionID,
 		getStatelessResetToken: getStatelessResetToken,
 		removeConnectionID:     removeConnectionID,
@@ -53,6 +58,13 @@ func newConnIDGenerator(
 	return m
 }
 
+// SetVersion sets the version of the connection.
+// It is called when the connection is upgraded to a compatible version during the handshake,
+// since the version determines if the backwards compatibility mode for the retire bug is used.
+func (m *connIDGenerator) SetVersion(v protocol.VersionNumber) {
+	m.version = v
+}
+
 func (m *connIDGenerator) SetMaxActiveConnIDs(limit uint64) error {
 	if m.connIDLen == 0 {
 		return nil
@@ -86,17 +98,44 @@ func (m *connIDGenerator) Retire(seq uint64, sentWithDestConnID protocol.Connect
 	m.retireConnectionID(connID)
 	delete(m.activeSrcConnIDs, seq)
 	// Don't issue a replacement for the initial connection ID.
-	if seq == 0 {
+	// Connection IDs retired by a rotation were already replaced.
+	if seq == 0 || seq < m.retirePriorTo {
 		return nil
 	}
 	return m.issueNewConnID()
 }
 
+// Rotate issues a new connection ID for every active connection ID,
+// and asks the peer to retire all connection IDs issued before.
+// Since every rotation replaces the connection IDs the peer currently uses, the number of connection IDs
+// the peer needs to store never exceeds its active_connection_id_limit.
+// The rotation is skipped as long as the peer hasn't retired all connection IDs retired by the previous rotation,
+// since we'd have to keep track of an unbounded number of connection IDs otherwise.
+func (m *connIDGenerator) Rotate() error {
+	if m.connIDLen == 0 || protocol.UseRetireBugBackwardsCompatibilityMode(RetireBugBackwardsCompatibilityMode, m.version) {
+		return nil
+	}
+	var num int
+	for seq := range m.activeSrcConnIDs {
+		if seq < m.retirePriorTo {
+			return nil
+		}
+		num++
+	}
+	m.retirePriorTo = m.highestSeq + 1
+	for i := 0; i < num; i++ {
+		if err := m.issueNewConnID(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *connIDGenerator) issueNewConnID() error {
 	if protocol.UseRetireBugBackwardsCompatibilityMode(RetireBugBackwardsCompatibilityMode, m.version) {
 		return nil
 	}
-	connID, err := protocol.GenerateConnectionID(m.connIDLen)
+	connID, err := m.generateConnectionID()
 	if err != nil {
 		return err
 	}
@@ -104,6 +143,7 @@ func (m *connIDGenerator) issueNewConnID() error {
 	m.addConnectionID(connID)
 	m.queueControlFrame(&wire.NewConnectionIDFrame{
 		SequenceNumber:      m.highestSeq + 1,
+		RetirePriorTo:       m.retirePriorTo,
 		ConnectionID:        connID,
 		StatelessResetToken: m.getStatelessResetToken(connID),
 	})
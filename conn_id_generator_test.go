@@ -35,6 +35,7 @@ var _ = Describe("Connection ID Generator", func() {
 		g = newConnIDGenerator(
 			initialConnID,
 			initialClientDestConnID,
+			func() (protocol.ConnectionID, error) { return protocol.GenerateConnectionID(initialConnID.Len()) },
 			func(c protocol.ConnectionID) { addedConnIDs = append(addedConnIDs, c) },
 			connIDToToken,
 			func(c protocol.ConnectionID) { removedConnIDs = append(removedConnIDs, c) },
@@ -72,6 +73,16 @@ var _ = Describe("Connection ID Generator", func() {
 		Expect(addedConnIDs).To(BeEmpty())
 	})
 
+	It("issues new connection IDs in RetireBugBackwardsCompatibilityMode, after upgrading to a version not affected by the bug", func() {
+		RetireBugBackwardsCompatibilityMode = true
+		defer func() { RetireBugBackwardsCompatibilityMode = false }()
+
+		g.SetVersion(protocol.VersionDraft32)
+		Expect(g.SetMaxActiveConnIDs(4)).To(Succeed())
+		Expect(addedConnIDs).To(HaveLen(3))
+		Expect(queuedFrames).To(HaveLen(3))
+	})
+
 	It("limits the number of connection IDs that it issues", func() {
 		Expect(g.SetMaxActiveConnIDs(9999999)).To(Succeed())
 		Expect(retiredConnIDs).To(BeEmpty())
@@ -115,6 +126,73 @@ var _ = Describe("Connection ID Generator", func() {
 		Expect(nf.ConnectionID.Len()).To(Equal(7))
 	})
 
+	It("rotates connection IDs", func() {
+		Expect(g.SetMaxActiveConnIDs(4)).To(Succeed())
+		queuedFrames = nil
+		Expect(g.Rotate()).To(Succeed())
+		// the initial connection ID and the 3 connection IDs issued before
+		Expect(queuedFrames).To(HaveLen(4))
+		for i, f := range queuedFrames {
+			nf := f.(*wire.NewConnectionIDFrame)
+			Expect(nf.SequenceNumber).To(BeEquivalentTo(4 + i))
+			Expect(nf.RetirePriorTo).To(BeEquivalentTo(4))
+		}
+		// retiring the old connection IDs doesn't issue new ones
+		queuedFrames = nil
+		for seq := uint64(0); seq < 4; seq++ {
+			Expect(g.Retire(seq, protocol.ConnectionID{})).To(Succeed())
+		}
+		Expect(retiredConnIDs).To(HaveLen(4))
+		Expect(queuedFrames).To(BeEmpty())
+		// retiring a new connection ID issues a replacement
+		Expect(g.Retire(5, protocol.ConnectionID{})).To(Succeed())
+		Expect(queuedFrames).To(HaveLen(1))
+		nf := queuedFrames[0].(*wire.NewConnectionIDFrame)
+		Expect(nf.SequenceNumber).To(BeEquivalentTo(8))
+		Expect(nf.RetirePriorTo).To(BeEquivalentTo(4))
+	})
+
+	It("only issues replacements for active connection IDs when rotating", func() {
+		Expect(g.SetMaxActiveConnIDs(3)).To(Succeed())
+		Expect(g.Rotate()).To(Succeed())
+		for seq := uint64(0); seq < 3; seq++ {
+			Expect(g.Retire(seq, protocol.ConnectionID{})).To(Succeed())
+		}
+		queuedFrames = nil
+		Expect(g.Rotate()).To(Succeed())
+		Expect(queuedFrames).To(HaveLen(3))
+		for _, f := range queuedFrames {
+			Expect(f.(*wire.NewConnectionIDFrame).RetirePriorTo).To(BeEquivalentTo(6))
+		}
+	})
+
+	It("doesn't rotate as long as the peer hasn't retired the connection IDs of the previous rotation", func() {
+		Expect(g.SetMaxActiveConnIDs(3)).To(Succeed())
+		Expect(g.Rotate()).To(Succeed())
+		Expect(g.Retire(0, protocol.ConnectionID{})).To(Succeed())
+		queuedFrames = nil
+		addedConnIDs = nil
+		for i := 0; i < 10; i++ {
+			Expect(g.Rotate()).To(Succeed())
+		}
+		Expect(queuedFrames).To(BeEmpty())
+		Expect(addedConnIDs).To(BeEmpty())
+		Expect(g.activeSrcConnIDs).To(HaveLen(5))
+		// once the peer retired all the old connection IDs, we rotate again
+		Expect(g.Retire(1, protocol.ConnectionID{})).To(Succeed())
+		Expect(g.Retire(2, protocol.ConnectionID{})).To(Succeed())
+		Expect(g.Rotate()).To(Succeed())
+		Expect(queuedFrames).To(HaveLen(3))
+		Expect(g.activeSrcConnIDs).To(HaveLen(6))
+	})
+
+	It("doesn't rotate connection IDs in RetireBugBackwardsCompatibilityMode", func() {
+		RetireBugBackwardsCompatibilityMode = true
+		defer func() { RetireBugBackwardsCompatibilityMode = false }()
+		Expect(g.Rotate()).To(Succeed())
+		Expect(queuedFrames).To(BeEmpty())
+	})
+
 	It("retires the initial connection ID", func() {
 		Expect(g.Retire(0, protocol.ConnectionID{})).To(Succeed())
 		Expect(removedConnIDs).To(BeEmpty())
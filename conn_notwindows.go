@@ -10,6 +10,14 @@ import (
 )
 
 func inspectReadBuffer(c net.PacketConn) (int, error) {
+	return inspectSocketOption(c, syscall.SO_RCVBUF)
+}
+
+func inspectWriteBuffer(c net.PacketConn) (int, error) {
+	return inspectSocketOption(c, syscall.SO_SNDBUF)
+}
+
+func inspectSocketOption(c net.PacketConn, opt int) (int, error) {
 	conn, ok := c.(interface {
 		SyscallConn() (syscall.RawConn, error)
 	})
@@ -23,7 +31,7 @@ func inspectReadBuffer(c net.PacketConn) (int, error) {
 	var size int
 	var serr error
 	if err := rawConn.Control(func(fd uintptr) {
-		size, serr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF)
+		size, serr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, opt)
 	}); err != nil {
 		return 0, err
 	}
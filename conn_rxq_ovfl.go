@@ -0,0 +1,17 @@
+// +build !linux,!windows
+
+package quic
+
+import (
+	"errors"
+	"syscall"
+)
+
+// setRXQOVFL fails on platforms where we don't know how to detect receive buffer drops.
+func setRXQOVFL(uintptr) error {
+	return errors.New("detecting receive buffer drops not supported on this platform")
+}
+
+func parseRXQOVFL(syscall.SocketControlMessage) (uint32, bool) {
+	return 0, false
+}
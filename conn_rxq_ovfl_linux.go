@@ -0,0 +1,24 @@
+// +build linux
+
+package quic
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// setRXQOVFL requests the kernel to report the number of packets dropped
+// because the receive buffer was full.
+func setRXQOVFL(fd uintptr) error {
+	return syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RXQ_OVFL, 1)
+}
+
+// parseRXQOVFL parses the SO_RXQ_OVFL control message.
+// It contains the total number of packets dropped on this socket.
+func parseRXQOVFL(msg syscall.SocketControlMessage) (uint32, bool) {
+	if msg.Header.Level != syscall.SOL_SOCKET || msg.Header.Type != syscall.SO_RXQ_OVFL || len(msg.Data) < 4 {
+		return 0, false
+	}
+	// the counter is encoded in host byte order
+	return *(*uint32)(unsafe.Pointer(&msg.Data[0])), true
+}
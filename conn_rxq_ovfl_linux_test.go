@@ -0,0 +1,56 @@
+// +build linux
+
+package quic
+
+import (
+	"net"
+	"syscall"
+	"unsafe"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Detecting receive buffer drops", func() {
+	It("enables SO_RXQ_OVFL", func() {
+		addr, err := net.ResolveUDPAddr("udp", "localhost:0")
+		Expect(err).ToNot(HaveOccurred())
+		conn, err := net.ListenUDP("udp", addr)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+		_, err = newConn(conn, utils.DefaultLogger)
+		Expect(err).ToNot(HaveOccurred())
+
+		rawConn, err := conn.SyscallConn()
+		Expect(err).ToNot(HaveOccurred())
+		var val int
+		var serr error
+		Expect(rawConn.Control(func(fd uintptr) {
+			val, serr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RXQ_OVFL)
+		})).To(Succeed())
+		Expect(serr).ToNot(HaveOccurred())
+		Expect(val).To(Equal(1))
+	})
+
+	It("parses the control message", func() {
+		msg := syscall.SocketControlMessage{
+			Header: syscall.Cmsghdr{Level: syscall.SOL_SOCKET, Type: syscall.SO_RXQ_OVFL},
+			Data:   make([]byte, 4),
+		}
+		*(*uint32)(unsafe.Pointer(&msg.Data[0])) = 42
+		dropped, ok := parseRXQOVFL(msg)
+		Expect(ok).To(BeTrue())
+		Expect(dropped).To(BeEquivalentTo(42))
+	})
+
+	It("ignores other control messages", func() {
+		msg := syscall.SocketControlMessage{
+			Header: syscall.Cmsghdr{Level: syscall.IPPROTO_IP, Type: syscall.IP_TOS},
+			Data:   []byte{2},
+		}
+		_, ok := parseRXQOVFL(msg)
+		Expect(ok).To(BeFalse())
+	})
+})
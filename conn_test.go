@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
 
 	"github.com/golang/mock/gomock"
 
@@ -22,7 +23,7 @@ var _ = Describe("Basic Conn Test", func() {
 			return copy(b, data), addr, nil
 		})
 
-		conn, err := wrapConn(c)
+		conn, err := wrapConn(c, utils.DefaultLogger)
 		Expect(err).ToNot(HaveOccurred())
 		p, err := conn.ReadPacket()
 		Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,131 @@
+package quic
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// The connectionLimiter enforces the connection limits configured in the Config.
+// It is used by the server before a new session is created.
+type connectionLimiter struct {
+	mutex sync.Mutex
+
+	maxConns      int
+	maxConnsPerIP int
+	conns         int
+	connsPerIP    map[string]int
+	// IPv6 addresses are grouped by this prefix when counting the connections per IP
+	ipv6Mask net.IPMask
+
+	// token bucket for the handshake rate
+	handshakesPerSecond int
+	tokens              float64
+	lastRefill          time.Time
+
+	maxHandshakes int
+	handshakes    int // number of handshakes currently in progress
+}
+
+// limitResult is the result of checking a connection attempt against the connection limits.
+type limitResult uint8
+
+const (
+	// limitOK means that the connection attempt is allowed.
+	limitOK limitResult = iota
+	// limitReached means that the connection attempt exceeds one of the limits.
+	limitReached
+	// limitHandshakesReached means that the connection attempt only exceeds the number of concurrent handshakes.
+	// It can be allowed as soon as one of the running handshakes completes.
+	limitHandshakesReached
+)
+
+func newConnectionLimiter(maxConns, maxConnsPerIP, ipv6PrefixLen, handshakesPerSecond, maxHandshakes int) *connectionLimiter {
+	return &connectionLimiter{
+		maxConns:            maxConns,
+		maxConnsPerIP:       maxConnsPerIP,
+		connsPerIP:          make(map[string]int),
+		ipv6Mask:            net.CIDRMask(ipv6PrefixLen, 8*net.IPv6len),
+		handshakesPerSecond: handshakesPerSecond,
+		tokens:              float64(handshakesPerSecond),
+		maxHandshakes:       maxHandshakes,
+	}
+}
+
+// key returns the key used to count the connections per IP.
+// A single host usually controls a whole IPv6 prefix (often a /64),
+// so IPv6 addresses are grouped by their prefix.
+func (l *connectionLimiter) key(addr net.Addr) string {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return addr.String()
+	}
+	if ip := udpAddr.IP.To4(); ip != nil {
+		return ip.String()
+	}
+	return udpAddr.IP.Mask(l.ipv6Mask).String()
+}
+
+// Allow checks if a new connection from addr can be accepted.
+// If so, it reserves a slot, which must be released by calling Release when the session is closed.
+// If the number of concurrent handshakes is limited, it also reserves a handshake slot,
+// which must be released by calling HandshakeDone when the handshake completes (or fails).
+func (l *connectionLimiter) Allow(addr net.Addr, now time.Time) limitResult {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.maxConns > 0 && l.conns >= l.maxConns {
+		return limitReached
+	}
+	key := l.key(addr)
+	if l.maxConnsPerIP > 0 && l.connsPerIP[key] >= l.maxConnsPerIP {
+		return limitReached
+	}
+	// Check this before the handshake rate, so that no token is used up.
+	if l.maxHandshakes > 0 && l.handshakes >= l.maxHandshakes {
+		return limitHandshakesReached
+	}
+	if l.handshakesPerSecond > 0 {
+		if !l.lastRefill.IsZero() {
+			l.tokens += now.Sub(l.lastRefill).Seconds() * float64(l.handshakesPerSecond)
+			if l.tokens > float64(l.handshakesPerSecond) {
+				l.tokens = float64(l.handshakesPerSecond)
+			}
+		}
+		l.lastRefill = now
+		if l.tokens < 1 {
+			return limitReached
+		}
+		l.tokens--
+	}
+	l.conns++
+	l.connsPerIP[key]++
+	if l.maxHandshakes > 0 {
+		l.handshakes++
+	}
+	return limitOK
+}
+
+// HandshakeDone releases the handshake slot reserved by Allow.
+func (l *connectionLimiter) HandshakeDone() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.maxHandshakes > 0 {
+		l.handshakes--
+	}
+}
+
+// Release releases the slot reserved for a connection from addr.
+func (l *connectionLimiter) Release(addr net.Addr) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.conns--
+	key := l.key(addr)
+	if l.connsPerIP[key] <= 1 {
+		delete(l.connsPerIP, key)
+		return
+	}
+	l.connsPerIP[key]--
+}
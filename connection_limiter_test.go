@@ -0,0 +1,145 @@
+package quic
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Connection Limiter", func() {
+	addr1 := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
+	addr2 := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 2), Port: 1337}
+
+	It("doesn't limit anything by default", func() {
+		l := newConnectionLimiter(0, 0, 64, 0, 0)
+		now := time.Now()
+		for i := 0; i < 1000; i++ {
+			Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		}
+	})
+
+	It("limits the total number of connections", func() {
+		l := newConnectionLimiter(2, 0, 64, 0, 0)
+		now := time.Now()
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr2, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr2, now)).To(Equal(limitReached))
+		l.Release(addr1)
+		Expect(l.Allow(addr2, now)).To(Equal(limitOK))
+	})
+
+	It("limits the number of connections per IP", func() {
+		l := newConnectionLimiter(0, 2, 64, 0, 0)
+		now := time.Now()
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		Expect(l.Allow(&net.UDPAddr{IP: addr1.IP, Port: 42}, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr1, now)).To(Equal(limitReached))
+		Expect(l.Allow(addr2, now)).To(Equal(limitOK))
+		l.Release(addr1)
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+	})
+
+	It("groups IPv6 addresses by their prefix", func() {
+		l := newConnectionLimiter(0, 2, 64, 0, 0)
+		now := time.Now()
+		Expect(l.Allow(&net.UDPAddr{IP: net.ParseIP("2001:db8:1:2::1"), Port: 1337}, now)).To(Equal(limitOK))
+		Expect(l.Allow(&net.UDPAddr{IP: net.ParseIP("2001:db8:1:2:ffff::42"), Port: 1337}, now)).To(Equal(limitOK))
+		Expect(l.Allow(&net.UDPAddr{IP: net.ParseIP("2001:db8:1:2:1234:5678:9abc:def0"), Port: 1337}, now)).To(Equal(limitReached))
+		// a different /64
+		Expect(l.Allow(&net.UDPAddr{IP: net.ParseIP("2001:db8:1:3::1"), Port: 1337}, now)).To(Equal(limitOK))
+		l.Release(&net.UDPAddr{IP: net.ParseIP("2001:db8:1:2::1"), Port: 1337})
+		Expect(l.Allow(&net.UDPAddr{IP: net.ParseIP("2001:db8:1:2::abcd"), Port: 1337}, now)).To(Equal(limitOK))
+	})
+
+	It("uses the configured IPv6 prefix length", func() {
+		l := newConnectionLimiter(0, 1, 128, 0, 0)
+		now := time.Now()
+		Expect(l.Allow(&net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 1337}, now)).To(Equal(limitOK))
+		Expect(l.Allow(&net.UDPAddr{IP: net.ParseIP("2001:db8::2"), Port: 1337}, now)).To(Equal(limitOK))
+		Expect(l.Allow(&net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 42}, now)).To(Equal(limitReached))
+	})
+
+	It("doesn't group IPv4 addresses", func() {
+		l := newConnectionLimiter(0, 1, 64, 0, 0)
+		now := time.Now()
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr2, now)).To(Equal(limitOK))
+		// IPv4-mapped IPv6 addresses are treated as IPv4 addresses
+		Expect(l.Allow(&net.UDPAddr{IP: net.ParseIP("::ffff:192.168.0.3"), Port: 1337}, now)).To(Equal(limitOK))
+		Expect(l.Allow(&net.UDPAddr{IP: net.IPv4(192, 168, 0, 3), Port: 42}, now)).To(Equal(limitReached))
+	})
+
+	It("deletes IPs that don't have any connections", func() {
+		l := newConnectionLimiter(0, 2, 64, 0, 0)
+		now := time.Now()
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		l.Release(addr1)
+		Expect(l.connsPerIP).To(HaveLen(1))
+		l.Release(addr1)
+		Expect(l.connsPerIP).To(BeEmpty())
+		Expect(l.conns).To(BeZero())
+	})
+
+	It("limits the handshake rate", func() {
+		l := newConnectionLimiter(0, 0, 64, 10, 0)
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		}
+		Expect(l.Allow(addr1, now)).To(Equal(limitReached))
+		now = now.Add(100 * time.Millisecond)
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr1, now)).To(Equal(limitReached))
+		// the bucket doesn't fill up beyond the rate
+		now = now.Add(time.Hour)
+		for i := 0; i < 10; i++ {
+			Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		}
+		Expect(l.Allow(addr1, now)).To(Equal(limitReached))
+	})
+
+	It("limits the number of concurrent handshakes", func() {
+		l := newConnectionLimiter(0, 0, 64, 0, 2)
+		now := time.Now()
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr2, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr1, now)).To(Equal(limitHandshakesReached))
+		l.HandshakeDone()
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		Expect(l.conns).To(Equal(3))
+	})
+
+	It("doesn't use up the handshake rate if the number of concurrent handshakes is exceeded", func() {
+		l := newConnectionLimiter(0, 0, 64, 2, 1)
+		now := time.Now()
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr1, now)).To(Equal(limitHandshakesReached))
+		l.HandshakeDone()
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+	})
+
+	It("reports that a connection limit is reached, even if the number of concurrent handshakes is exceeded as well", func() {
+		l := newConnectionLimiter(1, 0, 64, 0, 1)
+		now := time.Now()
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr2, now)).To(Equal(limitReached))
+	})
+
+	It("doesn't count handshakes if the number of concurrent handshakes is not limited", func() {
+		l := newConnectionLimiter(1, 0, 64, 0, 0)
+		Expect(l.Allow(addr1, time.Now())).To(Equal(limitOK))
+		l.HandshakeDone()
+		Expect(l.handshakes).To(BeZero())
+	})
+
+	It("doesn't count rejected connection attempts", func() {
+		l := newConnectionLimiter(0, 1, 64, 0, 0)
+		now := time.Now()
+		Expect(l.Allow(addr1, now)).To(Equal(limitOK))
+		Expect(l.Allow(addr1, now)).To(Equal(limitReached))
+		Expect(l.conns).To(Equal(1))
+	})
+})
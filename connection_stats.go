@@ -0,0 +1,88 @@
+package quic
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/quictrace"
+)
+
+// The connectionStats collects the ConnectionStats of a session.
+// It is updated from the run loop, and can be read from any goroutine.
+type connectionStats struct {
+	// 64 bit values accessed atomically must be the first fields, for alignment on 32 bit platforms.
+	// These counters are updated for every packet, so they don't take the lock.
+	packetsSent     uint64
+	bytesSent       uint64
+	packetsReceived uint64
+	bytesReceived   uint64
+	streamsOpened   uint64
+
+	// The last values passed to UpdateTransportState and UpdateMemoryUsage.
+	// Only accessed from the run loop, so updates that don't change anything don't need to take the lock.
+	transportState quictrace.TransportState
+	memoryUsage    protocol.ByteCount
+
+	mutex sync.Mutex
+	stats ConnectionStats
+}
+
+func (s *connectionStats) SentPacket(size protocol.ByteCount) {
+	atomic.AddUint64(&s.packetsSent, 1)
+	atomic.AddUint64(&s.bytesSent, uint64(size))
+}
+
+func (s *connectionStats) ReceivedPacket(size protocol.ByteCount) {
+	atomic.AddUint64(&s.packetsReceived, 1)
+	atomic.AddUint64(&s.bytesReceived, uint64(size))
+}
+
+func (s *connectionStats) OpenedStream() {
+	atomic.AddUint64(&s.streamsOpened, 1)
+}
+
+func (s *connectionStats) CompletedHandshake(d time.Duration) {
+	s.mutex.Lock()
+	s.stats.HandshakeDuration = d
+	s.mutex.Unlock()
+}
+
+// UpdateTransportState copies the state reported by the sent packet handler.
+// It is called on every iteration of the run loop, and therefore only takes the lock if the state changed.
+func (s *connectionStats) UpdateTransportState(state quictrace.TransportState) {
+	if state == s.transportState {
+		return
+	}
+	s.transportState = state
+	s.mutex.Lock()
+	s.stats.SmoothedRTT = state.SmoothedRTT
+	s.stats.MinRTT = state.MinRTT
+	s.stats.CongestionWindow = uint64(state.CongestionWindow)
+	s.stats.BytesInFlight = uint64(state.BytesInFlight)
+	s.stats.PacketsLost = state.PacketsLost
+	s.mutex.Unlock()
+}
+
+func (s *connectionStats) UpdateMemoryUsage(usage protocol.ByteCount) {
+	if usage == s.memoryUsage {
+		return
+	}
+	s.memoryUsage = usage
+	s.mutex.Lock()
+	s.stats.MemoryUsage = uint64(usage)
+	s.mutex.Unlock()
+}
+
+func (s *connectionStats) Get() ConnectionStats {
+	s.mutex.Lock()
+	stats := s.stats
+	s.mutex.Unlock()
+	stats.PacketsSent = atomic.LoadUint64(&s.packetsSent)
+	stats.BytesSent = atomic.LoadUint64(&s.bytesSent)
+	stats.PacketsReceived = atomic.LoadUint64(&s.packetsReceived)
+	stats.BytesReceived = atomic.LoadUint64(&s.bytesReceived)
+	stats.StreamsOpened = atomic.LoadUint64(&s.streamsOpened)
+	return stats
+}
@@ -0,0 +1,84 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/quictrace"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Connection Stats", func() {
+	var s *connectionStats
+
+	BeforeEach(func() {
+		s = &connectionStats{}
+	})
+
+	It("counts packets and bytes", func() {
+		s.SentPacket(1000)
+		s.SentPacket(500)
+		s.ReceivedPacket(1200)
+		stats := s.Get()
+		Expect(stats.PacketsSent).To(BeEquivalentTo(2))
+		Expect(stats.BytesSent).To(BeEquivalentTo(1500))
+		Expect(stats.PacketsReceived).To(BeEquivalentTo(1))
+		Expect(stats.BytesReceived).To(BeEquivalentTo(1200))
+	})
+
+	It("counts packets while the stats are read from another goroutine", func() {
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				s.SentPacket(10)
+				s.ReceivedPacket(20)
+			}
+		}()
+		Eventually(func() uint64 { return s.Get().BytesReceived }).Should(BeEquivalentTo(2000))
+		Eventually(done).Should(BeClosed())
+		stats := s.Get()
+		Expect(stats.PacketsSent).To(BeEquivalentTo(100))
+		Expect(stats.BytesSent).To(BeEquivalentTo(1000))
+		Expect(stats.PacketsReceived).To(BeEquivalentTo(100))
+	})
+
+	It("counts streams", func() {
+		s.OpenedStream()
+		s.OpenedStream()
+		Expect(s.Get().StreamsOpened).To(BeEquivalentTo(2))
+	})
+
+	It("saves the handshake duration", func() {
+		Expect(s.Get().HandshakeDuration).To(BeZero())
+		s.CompletedHandshake(time.Second)
+		Expect(s.Get().HandshakeDuration).To(Equal(time.Second))
+	})
+
+	It("copies the transport state", func() {
+		s.UpdateTransportState(quictrace.TransportState{
+			MinRTT:           time.Millisecond,
+			SmoothedRTT:      2 * time.Millisecond,
+			BytesInFlight:    1234,
+			CongestionWindow: 5678,
+			PacketsLost:      42,
+		})
+		stats := s.Get()
+		Expect(stats.MinRTT).To(Equal(time.Millisecond))
+		Expect(stats.SmoothedRTT).To(Equal(2 * time.Millisecond))
+		Expect(stats.BytesInFlight).To(BeEquivalentTo(1234))
+		Expect(stats.CongestionWindow).To(BeEquivalentTo(5678))
+		Expect(stats.PacketsLost).To(BeEquivalentTo(42))
+		s.UpdateTransportState(quictrace.TransportState{MinRTT: time.Millisecond})
+		Expect(s.Get().SmoothedRTT).To(BeZero())
+	})
+
+	It("updates the memory usage", func() {
+		s.UpdateMemoryUsage(1337)
+		Expect(s.Get().MemoryUsage).To(BeEquivalentTo(1337))
+		s.UpdateMemoryUsage(0)
+		Expect(s.Get().MemoryUsage).To(BeZero())
+	})
+})
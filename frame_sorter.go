@@ -1,9 +1,11 @@
 package quic
 
 import (
+	"bytes"
 	"errors"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 )
 
@@ -16,10 +18,15 @@ type frameSorter struct {
 	queue   map[protocol.ByteCount]frameSorterEntry
 	readPos protocol.ByteCount
 	gaps    *utils.ByteIntervalList
+
+	// If set, data that overlaps with queued data must be identical to it.
+	checkConsistency bool
 }
 
 var errDuplicateStreamData = errors.New("duplicate stream data")
 
+var errInconsistentStreamData = qerr.NewError(qerr.ProtocolViolation, "received inconsistent data for the same offset")
+
 func newFrameSorter() *frameSorter {
 	s := frameSorter{
 		gaps:  utils.NewByteIntervalList(),
@@ -44,7 +51,11 @@ func (s *frameSorter) push(data []byte, offset protocol.ByteCount, doneCb func()
 	if len(data) == 0 {
 		return errDuplicateStreamData
 	}
+	if s.checkConsistency && !s.isConsistent(data, offset) {
+		return errInconsistentStreamData
+	}
 
+	origLen := len(data)
 	start := offset
 	end := offset + protocol.ByteCount(len(data))
 
@@ -146,7 +157,9 @@ func (s *frameSorter) push(data []byte, offset protocol.ByteCount, doneCb func()
 		}
 	}
 
-	if wasCut && len(data) < protocol.MinStreamFrameBufferSize {
+	// If only a small part of the frame is new, copy that part,
+	// so we don't hold on to a packet buffer that mostly contains duplicate data.
+	if wasCut && (len(data) < protocol.MinStreamFrameBufferSize || len(data) < origLen/2) {
 		newData := make([]byte, len(data))
 		copy(newData, data)
 		data = newData
@@ -164,6 +177,37 @@ func (s *frameSorter) push(data []byte, offset protocol.ByteCount, doneCb func()
 	return nil
 }
 
+// isConsistent checks that data doesn't contradict any data that is still queued.
+// Data that was already popped can't be checked.
+// The queued entries cover everything between the read position (or the end of a gap) and the next gap
+// without overlapping, so the entries of a covered range can be walked from its start, one entry at a time.
+func (s *frameSorter) isConsistent(data []byte, offset protocol.ByteCount) bool {
+	end := offset + protocol.ByteCount(len(data))
+	coveredStart := s.readPos // start of the range covered by queued entries that ends at the current gap
+	for gap := s.gaps.Front(); gap != nil && coveredStart < end; gap = gap.Next() {
+		if gap.Value.Start > offset && gap.Value.Start > coveredStart {
+			pos := coveredStart
+			for pos < end && pos < gap.Value.Start {
+				entry, ok := s.queue[pos]
+				if !ok || len(entry.Data) == 0 {
+					break
+				}
+				entryEnd := pos + protocol.ByteCount(len(entry.Data))
+				if entryEnd > offset {
+					start := utils.MaxByteCount(pos, offset)
+					stop := utils.MinByteCount(end, entryEnd)
+					if !bytes.Equal(data[start-offset:stop-offset], entry.Data[start-pos:stop-pos]) {
+						return false
+					}
+				}
+				pos = entryEnd
+			}
+		}
+		coveredStart = gap.Value.End
+	}
+	return true
+}
+
 func (s *frameSorter) findStartGap(offset protocol.ByteCount) (*utils.ByteIntervalElement, bool) {
 	for gap := s.gaps.Front(); gap != nil; gap = gap.Next() {
 		if offset >= gap.Value.Start && offset <= gap.Value.End {
@@ -1341,6 +1341,32 @@ var _ = Describe("frame sorter", func() {
 			checkCallbackNotCalled(t3)
 		})
 
+		Context("trimming duplicate data", func() {
+			It("keeps the buffer of a frame if most of its data is new", func() {
+				f1 := bytes.Repeat([]byte{1}, 1000)
+				f2 := bytes.Repeat([]byte{2}, 1000)
+				cb2, t2 := getCallback()
+				Expect(s.Push(f1, 0, nil)).To(Succeed())
+				Expect(s.Push(f2, 600, cb2)).To(Succeed())
+				Expect(s.queue).To(HaveLen(2))
+				Expect(s.queue[1000].Data).To(Equal(f2[400:]))
+				Expect(&s.queue[1000].Data[0]).To(BeIdenticalTo(&f2[400]))
+				checkCallbackNotCalled(t2)
+			})
+
+			It("copies the new data if most of a frame is a duplicate", func() {
+				f1 := bytes.Repeat([]byte{1}, 1000)
+				f2 := bytes.Repeat([]byte{2}, 1000)
+				cb2, t2 := getCallback()
+				Expect(s.Push(f1, 0, nil)).To(Succeed())
+				Expect(s.Push(f2, 300, cb2)).To(Succeed())
+				Expect(s.queue).To(HaveLen(2))
+				Expect(s.queue[1000].Data).To(Equal(f2[700:]))
+				Expect(&s.queue[1000].Data[0]).ToNot(BeIdenticalTo(&f2[700]))
+				checkCallbackCalled(t2)
+			})
+		})
+
 		Context("receiving data after reads", func() {
 			It("ignores duplicate frames", func() {
 				Expect(s.Push([]byte("foobar"), 0, nil)).To(Succeed())
@@ -1372,6 +1398,50 @@ var _ = Describe("frame sorter", func() {
 			})
 		})
 
+		Context("checking consistency", func() {
+			BeforeEach(func() {
+				s.checkConsistency = true
+			})
+
+			It("accepts retransmissions of the same data", func() {
+				Expect(s.Push([]byte("foobar"), 3, nil)).To(Succeed())
+				Expect(s.Push([]byte("obarxy"), 5, nil)).To(Succeed())
+				Expect(s.Push([]byte("xyz"), 0, nil)).To(Succeed())
+				Expect(s.Push([]byte("zfoo"), 2, nil)).To(Succeed())
+			})
+
+			It("rejects overlapping data that doesn't match", func() {
+				Expect(s.Push([]byte("foobar"), 3, nil)).To(Succeed())
+				Expect(s.Push([]byte("oObar"), 4, nil)).To(MatchError(errInconsistentStreamData))
+				Expect(s.Push([]byte("xyzfoX"), 0, nil)).To(MatchError(errInconsistentStreamData))
+			})
+
+			It("checks data overlapping with multiple entries, across gaps", func() {
+				Expect(s.Push([]byte("foo"), 3, nil)).To(Succeed())
+				Expect(s.Push([]byte("bar"), 6, nil)).To(Succeed())
+				Expect(s.Push([]byte("baz"), 12, nil)).To(Succeed())
+				Expect(s.Push([]byte("oobarABCbaz"), 4, nil)).To(Succeed())
+				Expect(s.Push([]byte("obarDEFbaZ"), 5, nil)).To(MatchError(errInconsistentStreamData))
+				Expect(s.Push([]byte("arABCbazXYZ"), 7, nil)).To(Succeed())
+			})
+
+			It("only checks data that is still queued", func() {
+				Expect(s.Push([]byte("foobar"), 0, nil)).To(Succeed())
+				Expect(s.Push([]byte("baz"), 9, nil)).To(Succeed())
+				offset, data, _ := s.Pop()
+				Expect(offset).To(BeZero())
+				Expect(data).To(Equal([]byte("foobar")))
+				Expect(s.Push([]byte("FOOBARxyzbaz"), 0, nil)).To(Succeed())
+				Expect(s.Push([]byte("xyzbaZ"), 6, nil)).To(MatchError(errInconsistentStreamData))
+			})
+
+			It("doesn't check data when not enabled", func() {
+				s.checkConsistency = false
+				Expect(s.Push([]byte("foobar"), 3, nil)).To(Succeed())
+				Expect(s.Push([]byte("oObar"), 4, nil)).To(Succeed())
+			})
+		})
+
 		Context("DoS protection", func() {
 			It("errors when too many gaps are created", func() {
 				for i := 0; i < protocol.MaxStreamFrameSorterGaps; i++ {
@@ -13,23 +13,34 @@ type framer interface {
 	HasData() bool
 
 	QueueControlFrame(wire.Frame)
+	QueueTrackedControlFrame(ackhandler.Frame)
 	AppendControlFrames([]ackhandler.Frame, protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount)
 
 	AddActiveStream(protocol.StreamID)
 	AppendStreamFrames([]ackhandler.Frame, protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount)
+
+	SetVersion(protocol.VersionNumber)
 }
 
 type framerI struct {
-	mutex sync.Mutex
-
 	streamGetter streamGetter
 	version      protocol.VersionNumber
 
-	activeStreams map[protocol.StreamID]struct{}
-	streamQueue   []protocol.StreamID
+	// AddActiveStream is called by the streams, from the application's go routines.
+	// The mutex is only held for short moments, and never while popping STREAM frames,
+	// so that writing to a stream doesn't block while a packet is being packed.
+	mutex sync.Mutex
+	// A stream is active from the moment it reports that it has data until it has no more data to send.
+	// The value is set to true when a stream reports that it has data,
+	// so that we notice if it did so while we were popping a STREAM frame from it.
+	activeStreams map[protocol.StreamID]bool
+	newStreams    []protocol.StreamID // active streams not yet moved to the streamQueue
+
+	// The streamQueue is only used by the send loop. It doesn't need to be protected by the mutex.
+	streamQueue []protocol.StreamID
 
 	controlFrameMutex sync.Mutex
-	controlFrames     []wire.Frame
+	controlFrames     []ackhandler.Frame
 }
 
 var _ framer = &framerI{}
@@ -40,14 +51,23 @@ func newFramer(
 ) framer {
 	return &framerI{
 		streamGetter:  streamGetter,
-		activeStreams: make(map[protocol.StreamID]struct{}),
+		activeStreams: make(map[protocol.StreamID]bool),
 		version:       v,
 	}
 }
 
+// SetVersion sets the version used to calculate frame lengths.
+// It is only called from the run loop, while the framer is not used for packing.
+func (f *framerI) SetVersion(v protocol.VersionNumber) {
+	f.version = v
+}
+
 func (f *framerI) HasData() bool {
+	if len(f.streamQueue) > 0 {
+		return true
+	}
 	f.mutex.Lock()
-	hasData := len(f.streamQueue) > 0
+	hasData := len(f.newStreams) > 0
 	f.mutex.Unlock()
 	if hasData {
 		return true
@@ -59,6 +79,13 @@ func (f *framerI) HasData() bool {
 }
 
 func (f *framerI) QueueControlFrame(frame wire.Frame) {
+	f.controlFrameMutex.Lock()
+	f.controlFrames = append(f.controlFrames, ackhandler.Frame{Frame: frame})
+	f.controlFrameMutex.Unlock()
+}
+
+// QueueTrackedControlFrame queues a control frame whose OnAcked and OnLost callbacks are already set.
+func (f *framerI) QueueTrackedControlFrame(frame ackhandler.Frame) {
 	f.controlFrameMutex.Lock()
 	f.controlFrames = append(f.controlFrames, frame)
 	f.controlFrameMutex.Unlock()
@@ -73,7 +100,7 @@ func (f *framerI) AppendControlFrames(frames []ackhandler.Frame, maxLen protocol
 		if length+frameLen > maxLen {
 			break
 		}
-		frames = append(frames, ackhandler.Frame{Frame: frame})
+		frames = append(frames, frame)
 		length += frameLen
 		f.controlFrames = f.controlFrames[:len(f.controlFrames)-1]
 	}
@@ -84,9 +111,9 @@ func (f *framerI) AppendControlFrames(frames []ackhandler.Frame, maxLen protocol
 func (f *framerI) AddActiveStream(id protocol.StreamID) {
 	f.mutex.Lock()
 	if _, ok := f.activeStreams[id]; !ok {
-		f.streamQueue = append(f.streamQueue, id)
-		f.activeStreams[id] = struct{}{}
+		f.newStreams = append(f.newStreams, id)
 	}
+	f.activeStreams[id] = true
 	f.mutex.Unlock()
 }
 
@@ -94,6 +121,9 @@ func (f *framerI) AppendStreamFrames(frames []ackhandler.Frame, maxLen protocol.
 	var length protocol.ByteCount
 	var lastFrame *ackhandler.Frame
 	f.mutex.Lock()
+	f.streamQueue = append(f.streamQueue, f.newStreams...)
+	f.newStreams = f.newStreams[:0]
+	f.mutex.Unlock()
 	// pop STREAM frames, until less than MinStreamFrameSize bytes are left in the packet
 	numActiveStreams := len(f.streamQueue)
 	for i := 0; i < numActiveStreams; i++ {
@@ -107,19 +137,32 @@ func (f *framerI) AppendStreamFrames(frames []ackhandler.Frame, maxLen protocol.
 		str, err := f.streamGetter.GetOrOpenSendStream(id)
 		// The stream can be nil if it completed after it said it had data.
 		if str == nil || err != nil {
+			f.mutex.Lock()
 			delete(f.activeStreams, id)
+			f.mutex.Unlock()
 			continue
 		}
+		f.mutex.Lock()
+		f.activeStreams[id] = false
+		f.mutex.Unlock()
 		remainingLen := maxLen - length
 		// For the last STREAM frame, we'll remove the DataLen field later.
 		// Therefore, we can pretend to have more bytes available when popping
 		// the STREAM frame (which will always have the DataLen set).
 		remainingLen += utils.VarIntLen(uint64(remainingLen))
 		frame, hasMoreData := str.popStreamFrame(remainingLen)
+		if !hasMoreData {
+			f.mutex.Lock()
+			// The stream might have reported new data while we were popping the frame.
+			if f.activeStreams[id] {
+				hasMoreData = true
+			} else { // no more data to send. Stream is not active any more
+				delete(f.activeStreams, id)
+			}
+			f.mutex.Unlock()
+		}
 		if hasMoreData { // put the stream back in the queue (at the end)
 			f.streamQueue = append(f.streamQueue, id)
-		} else { // no more data to send. Stream is not active any more
-			delete(f.activeStreams, id)
 		}
 		// The frame can be nil
 		// * if the receiveStream was canceled after it said it had data
@@ -131,7 +174,6 @@ func (f *framerI) AppendStreamFrames(frames []ackhandler.Frame, maxLen protocol.
 		length += frame.Length(f.version)
 		lastFrame = frame
 	}
-	f.mutex.Unlock()
 	if lastFrame != nil {
 		lastFrameLen := lastFrame.Length(f.version)
 		// account for the smaller size of the last STREAM frame
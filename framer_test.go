@@ -50,6 +50,20 @@ var _ = Describe("Framer", func() {
 			Expect(length).To(Equal(mdf.Length(version) + msf.Length(version)))
 		})
 
+		It("adds tracked control frames", func() {
+			var acked bool
+			framer.QueueTrackedControlFrame(ackhandler.Frame{
+				Frame:   &wire.PingFrame{},
+				OnAcked: func(wire.Frame) { acked = true },
+			})
+			frames, length := framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(HaveLen(1))
+			Expect(frames[0].Frame).To(Equal(&wire.PingFrame{}))
+			Expect(length).To(Equal(protocol.ByteCount(1)))
+			frames[0].OnAcked(frames[0].Frame)
+			Expect(acked).To(BeTrue())
+		})
+
 		It("says if it has data", func() {
 			Expect(framer.HasData()).To(BeFalse())
 			f := &wire.MaxDataFrame{MaximumData: 0x42}
@@ -265,6 +279,27 @@ var _ = Describe("Framer", func() {
 			Expect(frames).To(HaveLen(1))
 		})
 
+		It("re-queues a stream that reported new data while a frame was popped", func() {
+			streamGetter.EXPECT().GetOrOpenSendStream(id1).Return(stream1, nil).Times(2)
+			f1 := &wire.StreamFrame{StreamID: id1, Data: []byte("foo")}
+			f2 := &wire.StreamFrame{StreamID: id1, Data: []byte("bar")}
+			stream1.EXPECT().popStreamFrame(gomock.Any()).DoAndReturn(func(protocol.ByteCount) (*ackhandler.Frame, bool) {
+				// the stream is written to while the packet is packed
+				framer.AddActiveStream(id1)
+				return &ackhandler.Frame{Frame: f1}, false
+			})
+			stream1.EXPECT().popStreamFrame(gomock.Any()).Return(&ackhandler.Frame{Frame: f2}, false)
+			framer.AddActiveStream(id1)
+			frames, _ := framer.AppendStreamFrames(nil, protocol.MinStreamFrameSize)
+			Expect(frames).To(HaveLen(1))
+			Expect(frames[0].Frame).To(Equal(f1))
+			Expect(framer.HasData()).To(BeTrue())
+			frames, _ = framer.AppendStreamFrames(nil, protocol.MinStreamFrameSize)
+			Expect(frames).To(HaveLen(1))
+			Expect(frames[0].Frame).To(Equal(f2))
+			Expect(framer.HasData()).To(BeFalse())
+		})
+
 		It("does not pop empty frames", func() {
 			fs, length := framer.AppendStreamFrames(nil, 500)
 			Expect(fs).To(BeEmpty())
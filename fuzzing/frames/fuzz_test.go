@@ -0,0 +1,39 @@
+//go:build go1.18
+// +build go1.18
+
+package frames
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+func FuzzFrames(f *testing.F) {
+	frames := []wire.Frame{
+		&wire.PingFrame{},
+		&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 5, Largest: 10}, {Smallest: 1, Largest: 2}}, DelayTime: 1337},
+		&wire.StreamFrame{StreamID: 4, Offset: 1337, Data: []byte("foobar"), DataLenPresent: true, Fin: true},
+		&wire.CryptoFrame{Offset: 42, Data: []byte("foobar")},
+		&wire.MaxDataFrame{MaximumData: 1337},
+		&wire.MaxStreamDataFrame{StreamID: 4, MaximumStreamData: 1337},
+		&wire.ResetStreamFrame{StreamID: 4, ErrorCode: 42, FinalSize: 1337},
+		&wire.NewConnectionIDFrame{SequenceNumber: 2, RetirePriorTo: 1, ConnectionID: protocol.ConnectionID{1, 2, 3, 4}},
+		&wire.NewTokenFrame{Token: []byte("token")},
+		&wire.ConnectionCloseFrame{ErrorCode: 42, ReasonPhrase: "foobar"},
+	}
+	for _, frame := range frames {
+		b := &bytes.Buffer{}
+		if err := frame.Write(b, version); err != nil {
+			f.Fatal(err)
+		}
+		for encLevel := byte(0); encLevel < 3; encLevel++ {
+			f.Add(append([]byte{encLevel}, b.Bytes()...))
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Fuzz(data)
+	})
+}
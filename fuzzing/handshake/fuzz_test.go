@@ -0,0 +1,18 @@
+//go:build go1.18
+// +build go1.18
+
+package handshake
+
+import "testing"
+
+func FuzzHandshake(f *testing.F) {
+	for i := 0; i < 8; i++ {
+		prefix := make([]byte, PrefixLen)
+		prefix[0] = byte(i) << 5 // send post-handshake messages and session tickets
+		prefix[confLen] = byte(i)
+		f.Add(append(prefix, []byte("foobar")...))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Fuzz(data)
+	})
+}
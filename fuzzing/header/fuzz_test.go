@@ -0,0 +1,63 @@
+//go:build go1.18
+// +build go1.18
+
+package header
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+func FuzzHeader(f *testing.F) {
+	headers := []*wire.ExtendedHeader{
+		{
+			Header: wire.Header{
+				IsLongHeader:     true,
+				Type:             protocol.PacketTypeInitial,
+				DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8},
+				SrcConnectionID:  protocol.ConnectionID{8, 7, 6, 5},
+				Token:            []byte("token"),
+				Length:           1337,
+				Version:          version,
+			},
+			PacketNumber:    0x42,
+			PacketNumberLen: protocol.PacketNumberLen2,
+		},
+		{
+			Header: wire.Header{
+				IsLongHeader:     true,
+				Type:             protocol.PacketTypeHandshake,
+				DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8},
+				SrcConnectionID:  protocol.ConnectionID{8, 7, 6, 5},
+				Length:           1337,
+				Version:          version,
+			},
+			PacketNumber:    0x1337,
+			PacketNumberLen: protocol.PacketNumberLen4,
+		},
+		{
+			Header:          wire.Header{DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}},
+			PacketNumber:    0x42,
+			PacketNumberLen: protocol.PacketNumberLen1,
+			KeyPhase:        protocol.KeyPhaseOne,
+		},
+	}
+	for _, hdr := range headers {
+		b := &bytes.Buffer{}
+		if err := hdr.Write(b, version); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(append([]byte{byte(hdr.DestConnectionID.Len())}, b.Bytes()...))
+	}
+	vnp, err := wire.ComposeVersionNegotiation(protocol.ConnectionID{1, 2, 3, 4}, protocol.ConnectionID{4, 3, 2, 1}, []protocol.VersionNumber{version, 0x1a2a3a4a})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(append([]byte{4}, vnp...))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Fuzz(data)
+	})
+}
@@ -0,0 +1,15 @@
+//go:build go1.18
+// +build go1.18
+
+package tokens
+
+import "testing"
+
+func FuzzTokens(f *testing.F) {
+	for i := byte(0); i < 3; i++ {
+		f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7, i, 't', 'o', 'k', 'e', 'n'})
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Fuzz(data)
+	})
+}
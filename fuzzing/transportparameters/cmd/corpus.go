@@ -76,7 +76,7 @@ func main() {
 			if rand.Int()%2 == 0 {
 				pers = protocol.PerspectiveClient
 			}
-			data = tp.Marshal(pers)
+			data = tp.Marshal(pers, true)
 		} else {
 			b := &bytes.Buffer{}
 			tp.MarshalForSessionTicket(b)
@@ -0,0 +1,39 @@
+//go:build go1.18
+// +build go1.18
+
+package transportparameters
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+func FuzzTransportParameters(f *testing.F) {
+	tp := &wire.TransportParameters{
+		InitialMaxStreamDataBidiLocal:  1000,
+		InitialMaxStreamDataBidiRemote: 2000,
+		InitialMaxStreamDataUni:        3000,
+		InitialMaxData:                 4000,
+		MaxBidiStreamNum:               10,
+		MaxUniStreamNum:                20,
+		MaxIdleTimeout:                 30 * time.Second,
+		MaxAckDelay:                    25 * time.Millisecond,
+		AckDelayExponent:               3,
+		ActiveConnectionIDLimit:        4,
+		InitialSourceConnectionID:      protocol.ConnectionID{1, 2, 3, 4},
+	}
+	f.Add(append([]byte{0}, tp.Marshal(protocol.PerspectiveClient, true)...))
+	tp.OriginalDestinationConnectionID = protocol.ConnectionID{4, 3, 2, 1}
+	tp.StatelessResetToken = &protocol.StatelessResetToken{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	f.Add(append([]byte{0b10}, tp.Marshal(protocol.PerspectiveServer, true)...))
+	b := &bytes.Buffer{}
+	tp.MarshalForSessionTicket(b)
+	f.Add(append([]byte{1}, b.Bytes()...))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Fuzz(data)
+	})
+}
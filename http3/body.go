@@ -3,8 +3,14 @@ package http3
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/marten-seemann/qpack"
 )
 
 // The body of a http.Request or http.Response.
@@ -17,17 +23,29 @@ type body struct {
 	reqDone       chan<- struct{}
 	reqDoneClosed bool
 
+	// only set for the http.Request
+	// The deadline set by the server to apply the ReadTimeout.
+	// The application might set a read deadline on the stream as well,
+	// so a timeout only means that the client was too slow once this deadline has passed.
+	readDeadline time.Time
+
 	onFrameError func()
 
 	bytesRemainingInFrame uint64
+
+	// only set if trailers are parsed, see parseTrailersInto
+	trailer        *http.Header
+	decoder        *qpack.Decoder
+	maxHeaderBytes uint64
 }
 
 var _ io.ReadCloser = &body{}
 
-func newRequestBody(str quic.Stream, onFrameError func()) *body {
+func newRequestBody(str quic.Stream, readDeadline time.Time, onFrameError func()) *body {
 	return &body{
 		str:          str,
 		onFrameError: onFrameError,
+		readDeadline: readDeadline,
 	}
 }
 
@@ -39,14 +57,32 @@ func newResponseBody(str quic.Stream, done chan<- struct{}, onFrameError func())
 	}
 }
 
+// parseTrailersInto makes the body parse a HEADERS frame following the DATA frames.
+// The trailers are added to trailer, which is allocated if it is nil.
+// If it is not called, HEADERS frames are skipped.
+func (r *body) parseTrailersInto(trailer *http.Header, decoder *qpack.Decoder, maxHeaderBytes uint64) {
+	r.trailer = trailer
+	r.decoder = decoder
+	r.maxHeaderBytes = maxHeaderBytes
+}
+
 func (r *body) Read(b []byte) (int, error) {
 	n, err := r.readImpl(b)
 	if err != nil {
 		r.requestDone()
+		if nerr, ok := err.(net.Error); ok && nerr.Timeout() && r.readTimeoutExpired() {
+			// The client didn't send the request body in time.
+			r.str.CancelRead(quic.ErrorCode(errorRequestIncomplete))
+			r.str.CancelWrite(quic.ErrorCode(errorRequestIncomplete))
+		}
 	}
 	return n, err
 }
 
+func (r *body) readTimeoutExpired() bool {
+	return !r.readDeadline.IsZero() && !time.Now().Before(r.readDeadline)
+}
+
 func (r *body) readImpl(b []byte) (int, error) {
 	if r.bytesRemainingInFrame == 0 {
 	parseLoop:
@@ -57,7 +93,9 @@ func (r *body) readImpl(b []byte) (int, error) {
 			}
 			switch f := frame.(type) {
 			case *headersFrame:
-				// skip HEADERS frames
+				if err := r.handleHeadersFrame(f); err != nil {
+					return 0, err
+				}
 				continue
 			case *dataFrame:
 				r.bytesRemainingInFrame = f.Length
@@ -82,6 +120,57 @@ func (r *body) readImpl(b []byte) (int, error) {
 	return n, err
 }
 
+func (r *body) handleHeadersFrame(f *headersFrame) error {
+	if r.trailer == nil {
+		// skip HEADERS frames
+		_, err := io.CopyN(ioutil.Discard, r.str, int64(f.Length))
+		return err
+	}
+	if f.Length > r.maxHeaderBytes {
+		return fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", f.Length, r.maxHeaderBytes)
+	}
+	headerBlock := make([]byte, f.Length)
+	if _, err := io.ReadFull(r.str, headerBlock); err != nil {
+		return err
+	}
+	hfs, err := r.decoder.DecodeFull(headerBlock)
+	if err != nil {
+		return err
+	}
+	if size := headerListSize(hfs); size > r.maxHeaderBytes {
+		return fmt.Errorf("trailers too large: %d bytes (max: %d)", size, r.maxHeaderBytes)
+	}
+	if *r.trailer == nil {
+		*r.trailer = http.Header{}
+	}
+	for _, hf := range hfs {
+		if hf.IsPseudo() {
+			return fmt.Errorf("trailers must not contain pseudo header %s", hf.Name)
+		}
+		r.trailer.Add(hf.Name, hf.Value)
+	}
+	return nil
+}
+
+// declaredTrailers returns the trailers announced in the Trailer header.
+// As in net/http, the values are nil until the body has been read.
+func declaredTrailers(h http.Header) http.Header {
+	var trailer http.Header
+	for _, v := range h["Trailer"] {
+		for _, k := range strings.Split(v, ",") {
+			k = http.CanonicalHeaderKey(strings.TrimSpace(k))
+			if k == "" {
+				continue
+			}
+			if trailer == nil {
+				trailer = http.Header{}
+			}
+			trailer[k] = nil
+		}
+	}
+	return trailer
+}
+
 func (r *body) requestDone() {
 	if r.reqDoneClosed || r.reqDone == nil {
 		return
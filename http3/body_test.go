@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/lucas-clemente/quic-go"
 	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/marten-seemann/qpack"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -65,7 +70,7 @@ var _ = Describe("Body", func() {
 
 				switch bodyType {
 				case bodyTypeRequest:
-					rb = newRequestBody(str, errorCb)
+					rb = newRequestBody(str, time.Time{}, errorCb)
 				case bodyTypeResponse:
 					reqDone = make(chan struct{})
 					rb = newResponseBody(str, reqDone, errorCb)
@@ -142,6 +147,68 @@ var _ = Describe("Body", func() {
 				Expect(b).To(Equal([]byte("foobar")))
 			})
 
+			Context("trailers", func() {
+				var trailer http.Header
+
+				getHeadersFrame := func(hfs ...qpack.HeaderField) []byte {
+					headers := &bytes.Buffer{}
+					enc := qpack.NewEncoder(headers)
+					for _, hf := range hfs {
+						enc.WriteField(hf)
+					}
+					b := &bytes.Buffer{}
+					(&headersFrame{Length: uint64(headers.Len())}).Write(b)
+					b.Write(headers.Bytes())
+					return b.Bytes()
+				}
+
+				BeforeEach(func() {
+					trailer = nil
+					rb.parseTrailersInto(&trailer, qpack.NewDecoder(nil), 100)
+				})
+
+				It("parses trailers", func() {
+					buf.Write(getDataFrame([]byte("foobar")))
+					buf.Write(getHeadersFrame(
+						qpack.HeaderField{Name: "grpc-status", Value: "0"},
+						qpack.HeaderField{Name: "grpc-message", Value: "OK"},
+					))
+					data, err := ioutil.ReadAll(rb)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(data).To(Equal([]byte("foobar")))
+					Expect(trailer).To(Equal(http.Header{
+						"Grpc-Status":  {"0"},
+						"Grpc-Message": {"OK"},
+					}))
+				})
+
+				It("adds trailers to the declared trailers", func() {
+					trailer = http.Header{"Grpc-Status": nil}
+					buf.Write(getHeadersFrame(qpack.HeaderField{Name: "grpc-status", Value: "0"}))
+					_, err := rb.Read([]byte{0})
+					Expect(err).To(MatchError(io.EOF))
+					Expect(trailer).To(Equal(http.Header{"Grpc-Status": {"0"}}))
+				})
+
+				It("errors when the HEADERS frame is too large", func() {
+					(&headersFrame{Length: 101}).Write(buf)
+					_, err := rb.Read([]byte{0})
+					Expect(err).To(MatchError("HEADERS frame too large: 101 bytes (max: 100)"))
+				})
+
+				It("errors when the decoded trailers are too large", func() {
+					buf.Write(getHeadersFrame(qpack.HeaderField{Name: "foo", Value: strings.Repeat("a", 66)}))
+					_, err := rb.Read([]byte{0})
+					Expect(err).To(MatchError("trailers too large: 101 bytes (max: 100)"))
+				})
+
+				It("errors on pseudo headers", func() {
+					buf.Write(getHeadersFrame(qpack.HeaderField{Name: ":status", Value: "200"}))
+					_, err := rb.Read([]byte{0})
+					Expect(err).To(MatchError("trailers must not contain pseudo header :status"))
+				})
+			})
+
 			It("errors when it can't parse the frame", func() {
 				buf.Write([]byte("invalid"))
 				_, err := rb.Read([]byte{0})
@@ -186,4 +253,34 @@ var _ = Describe("Body", func() {
 			}
 		})
 	}
+
+	It("resets the stream when reading the request body runs into the ReadTimeout", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().Read(gomock.Any()).Return(0, &timeoutError{})
+		str.EXPECT().CancelRead(quic.ErrorCode(errorRequestIncomplete))
+		str.EXPECT().CancelWrite(quic.ErrorCode(errorRequestIncomplete))
+		_, err := newRequestBody(str, time.Now().Add(-time.Millisecond), errorCb).Read([]byte{0})
+		Expect(err).To(MatchError(&timeoutError{}))
+	})
+
+	It("doesn't reset the stream when a deadline set by the application expires", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().Read(gomock.Any()).Return(0, &timeoutError{})
+		_, err := newRequestBody(str, time.Now().Add(time.Hour), errorCb).Read([]byte{0})
+		Expect(err).To(MatchError(&timeoutError{}))
+	})
+
+	It("doesn't reset the stream on timeouts if there's no ReadTimeout", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().Read(gomock.Any()).Return(0, &timeoutError{})
+		_, err := newRequestBody(str, time.Time{}, errorCb).Read([]byte{0})
+		Expect(err).To(MatchError(&timeoutError{}))
+	})
+
+	It("doesn't reset the stream when reading a response body times out", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().Read(gomock.Any()).Return(0, &timeoutError{})
+		_, err := newResponseBody(str, make(chan struct{}), errorCb).Read([]byte{0})
+		Expect(err).To(MatchError(&timeoutError{}))
+	})
 })
@@ -2,15 +2,20 @@ package http3
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/logutils"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qtls"
 	"github.com/lucas-clemente/quic-go/internal/utils"
@@ -24,6 +29,7 @@ const MethodGet0RTT = "GET_0RTT"
 const (
 	defaultUserAgent              = "quic-go HTTP/3"
 	defaultMaxResponseHeaderBytes = 10 * 1 << 20 // 10 MB
+	max1xxResponses               = 5            // the same limit that net/http uses
 )
 
 var defaultQuicConfig = &quic.Config{
@@ -32,11 +38,19 @@ var defaultQuicConfig = &quic.Config{
 	Versions:           []protocol.VersionNumber{protocol.VersionTLS},
 }
 
-var dialAddr = quic.DialAddrEarly
+var dialAddr = quic.DialAddrEarlyContext
 
 type roundTripperOpts struct {
 	DisableCompression bool
 	MaxHeaderBytes     int64
+	OnRequestDone      func(*RequestMetrics)
+}
+
+// A dialAttempt is a single attempt to dial the session.
+// done is closed when the attempt completes. err must only be read after that.
+type dialAttempt struct {
+	done chan struct{}
+	err  error
 }
 
 // client is a HTTP3 client doing requests
@@ -45,9 +59,13 @@ type client struct {
 	config  *quic.Config
 	opts    *roundTripperOpts
 
-	dialOnce     sync.Once
-	dialer       func(network, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error)
-	handshakeErr error
+	dialer func(ctx context.Context, network, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error)
+	// The session is dialed using a context owned by the client, not the context of the request that triggered the dial.
+	// That way, canceling a request doesn't affect the other requests waiting for the handshake.
+	dialCtx    context.Context
+	dialCancel context.CancelFunc
+	dialMutex  sync.Mutex
+	dialing    *dialAttempt
 
 	requestWriter *requestWriter
 
@@ -55,6 +73,7 @@ type client struct {
 
 	hostname string
 	session  quic.EarlySession
+	closed   utils.AtomicBool // set when the session can't be used for new requests any more
 
 	logger utils.Logger
 }
@@ -64,7 +83,7 @@ func newClient(
 	tlsConf *tls.Config,
 	opts *roundTripperOpts,
 	quicConfig *quic.Config,
-	dialer func(network, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error),
+	dialer func(ctx context.Context, network, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error),
 ) (*client, error) {
 	if quicConfig == nil {
 		quicConfig = defaultQuicConfig
@@ -76,7 +95,7 @@ func newClient(
 		return nil, errors.New("can only use a single QUIC version for dialing a HTTP/3 connection")
 	}
 	quicConfig.MaxIncomingStreams = -1 // don't allow any bidirectional streams
-	logger := utils.DefaultLogger.WithPrefix("h3 client")
+	logger := logutils.NewLogger(quicConfig.Logger).WithPrefix("h3 client")
 
 	if tlsConf == nil {
 		tlsConf = &tls.Config{}
@@ -86,7 +105,10 @@ func newClient(
 	// Replace existing ALPNs by H3
 	tlsConf.NextProtos = []string{versionToALPN(quicConfig.Versions[0])}
 
+	dialCtx, dialCancel := context.WithCancel(context.Background())
 	return &client{
+		dialCtx:       dialCtx,
+		dialCancel:    dialCancel,
 		hostname:      authorityAddr("https", hostname),
 		tlsConf:       tlsConf,
 		requestWriter: newRequestWriter(logger),
@@ -98,23 +120,70 @@ func newClient(
 	}, nil
 }
 
-func (c *client) dial() error {
+// getSession dials the session, if it hasn't been dialed yet, and waits until dialing completes.
+// Waiting is aborted when ctx is canceled, but dialing continues for other requests.
+func (c *client) getSession(ctx context.Context) error {
+	c.dialMutex.Lock()
+	attempt := c.dialing
+	if attempt == nil {
+		attempt = &dialAttempt{done: make(chan struct{})}
+		c.dialing = attempt
+		go func() {
+			defer close(attempt.done)
+			attempt.err = c.dial(c.dialCtx)
+			if attempt.err == nil {
+				return
+			}
+			if isContextError(attempt.err) && !c.closed.Get() {
+				// The dialer gave up. This is not a permanent error, so the next request dials again.
+				c.dialMutex.Lock()
+				c.dialing = nil
+				c.dialMutex.Unlock()
+				return
+			}
+			// The session can't be used for any new requests.
+			c.closed.Set(true)
+		}()
+	}
+	c.dialMutex.Unlock()
+
+	select {
+	case <-attempt.done:
+		return attempt.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (c *client) dial(ctx context.Context) error {
+	var sess quic.EarlySession
 	var err error
 	if c.dialer != nil {
-		c.session, err = c.dialer("udp", c.hostname, c.tlsConf, c.config)
+		sess, err = c.dialer(ctx, "udp", c.hostname, c.tlsConf, c.config)
 	} else {
-		c.session, err = dialAddr(c.hostname, c.tlsConf, c.config)
+		sess, err = dialAddr(ctx, c.hostname, c.tlsConf, c.config)
 	}
 	if err != nil {
 		return err
 	}
+	c.dialMutex.Lock()
+	c.session = sess
+	c.dialMutex.Unlock()
 
 	// run the sesssion setup using 0-RTT data
 	go func() {
 		if err := c.setupSession(); err != nil {
 			c.logger.Debugf("Setting up session failed: %s", err)
+			c.closed.Set(true)
 			c.session.CloseWithError(quic.ErrorCode(errorInternalError), "")
+			return
 		}
+		<-c.session.Context().Done()
+		c.closed.Set(true)
 	}()
 
 	return nil
@@ -130,7 +199,7 @@ func (c *client) setupSession() error {
 	// write the type byte
 	buf.Write([]byte{0x0})
 	// send the SETTINGS frame
-	(&settingsFrame{}).Write(buf)
+	(&settingsFrame{settings: map[uint64]uint64{settingMaxFieldSectionSize: c.maxHeaderBytes()}}).Write(buf)
 	if _, err := str.Write(buf.Bytes()); err != nil {
 		return err
 	}
@@ -139,10 +208,21 @@ func (c *client) setupSession() error {
 }
 
 func (c *client) Close() error {
-	if c.session == nil {
+	c.closed.Set(true)
+	c.dialCancel()
+	c.dialMutex.Lock()
+	sess := c.session
+	c.dialMutex.Unlock()
+	if sess == nil {
 		return nil
 	}
-	return c.session.CloseWithError(quic.ErrorCode(errorNoError), "")
+	return sess.CloseWithError(quic.ErrorCode(errorNoError), "")
+}
+
+// broken says if the session was closed, or if dialing it failed.
+// A broken client can't be used for any new requests.
+func (c *client) broken() bool {
+	return c.closed.Get()
 }
 
 func (c *client) maxHeaderBytes() uint64 {
@@ -161,12 +241,8 @@ func (c *client) RoundTrip(req *http.Request) (*http.Response, error) {
 		return nil, fmt.Errorf("http3 client BUG: RoundTrip called for the wrong client (expected %s, got %s)", c.hostname, req.Host)
 	}
 
-	c.dialOnce.Do(func() {
-		c.handshakeErr = c.dial()
-	})
-
-	if c.handshakeErr != nil {
-		return nil, c.handshakeErr
+	if err := c.getSession(req.Context()); err != nil {
+		return nil, err
 	}
 
 	// Immediately send out this request, if this is a 0-RTT request.
@@ -186,21 +262,39 @@ func (c *client) RoundTrip(req *http.Request) (*http.Response, error) {
 		return nil, err
 	}
 
+	metrics := &RequestMetrics{Request: req, StreamID: str.StreamID(), StreamOpened: time.Now()}
+
 	// Request Cancellation:
 	// This go routine keeps running even after RoundTrip() returns.
 	// It is shut down when the application is done processing the body.
 	reqDone := make(chan struct{})
 	go func() {
+		var canceled bool
 		select {
 		case <-req.Context().Done():
 			str.CancelWrite(quic.ErrorCode(errorRequestCanceled))
 			str.CancelRead(quic.ErrorCode(errorRequestCanceled))
+			if c.opts.OnRequestDone == nil {
+				return
+			}
+			canceled = true
+			// Reading the response fails now, or the application closes the body.
+			<-reqDone
 		case <-reqDone:
 		}
+		if c.opts.OnRequestDone != nil {
+			metrics.BodyDone = time.Now()
+			if canceled && metrics.Err == nil {
+				metrics.ErrorCode = quic.ErrorCode(errorRequestCanceled)
+				metrics.Err = req.Context().Err()
+			}
+			c.opts.OnRequestDone(metrics)
+		}
 	}()
 
-	rsp, rerr := c.doRequest(req, str, reqDone)
+	rsp, rerr := c.doRequest(req, str, reqDone, metrics)
 	if rerr.err != nil { // if any error occurred
+		metrics.setError(rerr)
 		close(reqDone)
 		if rerr.streamErr != 0 { // if it was a stream error
 			str.CancelWrite(quic.ErrorCode(rerr.streamErr))
@@ -210,6 +304,7 @@ func (c *client) RoundTrip(req *http.Request) (*http.Response, error) {
 			if rerr.err != nil {
 				reason = rerr.err.Error()
 			}
+			c.closed.Set(true)
 			c.session.CloseWithError(quic.ErrorCode(rerr.connErr), reason)
 		}
 	}
@@ -220,6 +315,7 @@ func (c *client) doRequest(
 	req *http.Request,
 	str quic.Stream,
 	reqDone chan struct{},
+	metrics *RequestMetrics,
 ) (*http.Response, requestError) {
 	var requestGzip bool
 	if !c.opts.DisableCompression && req.Method != "HEAD" && req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" {
@@ -229,6 +325,57 @@ func (c *client) doRequest(
 		return nil, newStreamError(errorInternalError, err)
 	}
 
+	var res *http.Response
+	for num1xx := 0; ; num1xx++ {
+		var rerr requestError
+		res, rerr = c.readResponseHeaders(str)
+		if rerr.err != nil {
+			return nil, rerr
+		}
+		if num1xx == 0 {
+			metrics.FirstByte = time.Now()
+		}
+		// 1xx responses are interim responses, they are followed by the final response.
+		if res.StatusCode < 100 || res.StatusCode > 199 || res.StatusCode == http.StatusSwitchingProtocols {
+			break
+		}
+		if num1xx >= max1xxResponses {
+			return nil, newStreamError(errorExcessiveLoad, errors.New("too many 1xx informational responses"))
+		}
+		if trace := httptrace.ContextClientTrace(req.Context()); trace != nil {
+			if res.StatusCode == http.StatusContinue && trace.Got100Continue != nil {
+				trace.Got100Continue()
+			}
+			if trace.Got1xxResponse != nil {
+				if err := trace.Got1xxResponse(res.StatusCode, textproto.MIMEHeader(res.Header)); err != nil {
+					return nil, newStreamError(errorRequestCanceled, err)
+				}
+			}
+		}
+	}
+
+	metrics.HeadersDecoded = time.Now()
+	metrics.StatusCode = res.StatusCode
+	res.Trailer = declaredTrailers(res.Header)
+	respBody := newResponseBody(str, reqDone, func() {
+		c.session.CloseWithError(quic.ErrorCode(errorFrameUnexpected), "")
+	})
+	respBody.parseTrailersInto(&res.Trailer, c.decoder, c.maxHeaderBytes())
+	if requestGzip && res.Header.Get("Content-Encoding") == "gzip" {
+		res.Header.Del("Content-Encoding")
+		res.Header.Del("Content-Length")
+		res.ContentLength = -1
+		res.Body = newGzipReader(respBody)
+		res.Uncompressed = true
+	} else {
+		res.Body = respBody
+	}
+
+	return res, requestError{}
+}
+
+// readResponseHeaders reads a HEADERS frame and decodes it into a response.
+func (c *client) readResponseHeaders(str quic.Stream) (*http.Response, requestError) {
 	frame, err := parseNextFrame(str)
 	if err != nil {
 		return nil, newStreamError(errorFrameError, err)
@@ -238,7 +385,7 @@ func (c *client) doRequest(
 		return nil, newConnError(errorFrameUnexpected, errors.New("expected first frame to be a HEADERS frame"))
 	}
 	if hf.Length > c.maxHeaderBytes() {
-		return nil, newStreamError(errorFrameError, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, c.maxHeaderBytes()))
+		return nil, newStreamError(errorIDError, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, c.maxHeaderBytes()))
 	}
 	headerBlock := make([]byte, hf.Length)
 	if _, err := io.ReadFull(str, headerBlock); err != nil {
@@ -249,6 +396,9 @@ func (c *client) doRequest(
 		// TODO: use the right error code
 		return nil, newConnError(errorGeneralProtocolError, err)
 	}
+	if size := headerListSize(hfs); size > c.maxHeaderBytes() {
+		return nil, newStreamError(errorIDError, fmt.Errorf("header list too large: %d bytes (max: %d)", size, c.maxHeaderBytes()))
+	}
 
 	connState := qtls.ToTLSConnectionState(c.session.ConnectionState())
 	res := &http.Response{
@@ -270,18 +420,5 @@ func (c *client) doRequest(
 			res.Header.Add(hf.Name, hf.Value)
 		}
 	}
-	respBody := newResponseBody(str, reqDone, func() {
-		c.session.CloseWithError(quic.ErrorCode(errorFrameUnexpected), "")
-	})
-	if requestGzip && res.Header.Get("Content-Encoding") == "gzip" {
-		res.Header.Del("Content-Encoding")
-		res.Header.Del("Content-Length")
-		res.ContentLength = -1
-		res.Body = newGzipReader(respBody)
-		res.Uncompressed = true
-	} else {
-		res.Body = respBody
-	}
-
 	return res, requestError{}
 }
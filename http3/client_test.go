@@ -9,6 +9,10 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/mock/gomock"
@@ -64,7 +68,7 @@ var _ = Describe("Client", func() {
 		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, nil)
 		Expect(err).ToNot(HaveOccurred())
 		var dialAddrCalled bool
-		dialAddr = func(_ string, tlsConf *tls.Config, quicConf *quic.Config) (quic.EarlySession, error) {
+		dialAddr = func(_ context.Context, _ string, tlsConf *tls.Config, quicConf *quic.Config) (quic.EarlySession, error) {
 			Expect(quicConf).To(Equal(defaultQuicConfig))
 			Expect(tlsConf.NextProtos).To(Equal([]string{nextProtoH3Draft29}))
 			Expect(quicConf.Versions).To(Equal([]protocol.VersionNumber{protocol.VersionTLS}))
@@ -79,7 +83,7 @@ var _ = Describe("Client", func() {
 		client, err := newClient("quic.clemente.io", nil, &roundTripperOpts{}, nil, nil)
 		Expect(err).ToNot(HaveOccurred())
 		var dialAddrCalled bool
-		dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+		dialAddr = func(_ context.Context, hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
 			Expect(hostname).To(Equal("quic.clemente.io:443"))
 			dialAddrCalled = true
 			return nil, errors.New("test done")
@@ -100,6 +104,7 @@ var _ = Describe("Client", func() {
 		Expect(err).ToNot(HaveOccurred())
 		var dialAddrCalled bool
 		dialAddr = func(
+			_ context.Context,
 			hostname string,
 			tlsConfP *tls.Config,
 			quicConfP *quic.Config,
@@ -122,7 +127,7 @@ var _ = Describe("Client", func() {
 		tlsConf := &tls.Config{ServerName: "foo.bar"}
 		quicConf := &quic.Config{MaxIdleTimeout: 1337 * time.Second}
 		var dialerCalled bool
-		dialer := func(network, address string, tlsConfP *tls.Config, quicConfP *quic.Config) (quic.EarlySession, error) {
+		dialer := func(ctxP context.Context, network, address string, tlsConfP *tls.Config, quicConfP *quic.Config) (quic.EarlySession, error) {
 			Expect(network).To(Equal("udp"))
 			Expect(address).To(Equal("localhost:1337"))
 			Expect(tlsConfP.ServerName).To(Equal("foo.bar"))
@@ -137,11 +142,89 @@ var _ = Describe("Client", func() {
 		Expect(dialerCalled).To(BeTrue())
 	})
 
+	It("doesn't fail the handshake for other requests when the request that triggered it is canceled", func() {
+		testErr := errors.New("test done")
+		dialCtxChan := make(chan context.Context, 1)
+		unblock := make(chan struct{})
+		var numDials int32
+		dialer := func(ctx context.Context, _, _ string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+			atomic.AddInt32(&numDials, 1)
+			dialCtxChan <- ctx
+			<-unblock
+			return nil, testErr
+		}
+		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, dialer)
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errChan1 := make(chan error, 1)
+		go func() {
+			_, err := client.RoundTrip(req.WithContext(ctx))
+			errChan1 <- err
+		}()
+		var dialCtx context.Context
+		Eventually(dialCtxChan).Should(Receive(&dialCtx))
+		errChan2 := make(chan error, 1)
+		go func() {
+			_, err := client.RoundTrip(req)
+			errChan2 <- err
+		}()
+		cancel()
+		Eventually(errChan1).Should(Receive(MatchError(context.Canceled)))
+		Expect(dialCtx.Err()).ToNot(HaveOccurred())
+		Consistently(errChan2).ShouldNot(Receive())
+		close(unblock)
+		Eventually(errChan2).Should(Receive(MatchError(testErr)))
+		Expect(atomic.LoadInt32(&numDials)).To(BeEquivalentTo(1))
+		Expect(client.broken()).To(BeTrue())
+	})
+
+	It("dials again if dialing failed with a context error", func() {
+		testErr := errors.New("test done")
+		var numDials int
+		dialer := func(context.Context, string, string, *tls.Config, *quic.Config) (quic.EarlySession, error) {
+			numDials++
+			if numDials == 1 {
+				return nil, context.DeadlineExceeded
+			}
+			return nil, testErr
+		}
+		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, dialer)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = client.RoundTrip(req)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(client.broken()).To(BeFalse())
+		_, err = client.RoundTrip(req)
+		Expect(err).To(MatchError(testErr))
+		Expect(numDials).To(Equal(2))
+		Expect(client.broken()).To(BeTrue())
+	})
+
+	It("cancels dialing when the client is closed", func() {
+		dialCtxChan := make(chan context.Context, 1)
+		dialer := func(ctx context.Context, _, _ string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+			dialCtxChan <- ctx
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, dialer)
+		Expect(err).ToNot(HaveOccurred())
+		errChan := make(chan error, 1)
+		go func() {
+			_, err := client.RoundTrip(req)
+			errChan <- err
+		}()
+		Eventually(dialCtxChan).Should(Receive())
+		Expect(client.Close()).To(Succeed())
+		Eventually(errChan).Should(Receive(MatchError(context.Canceled)))
+		Expect(client.broken()).To(BeTrue())
+	})
+
 	It("errors when dialing fails", func() {
 		testErr := errors.New("handshake error")
 		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, nil)
 		Expect(err).ToNot(HaveOccurred())
-		dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+		dialAddr = func(_ context.Context, hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
 			return nil, testErr
 		}
 		_, err = client.RoundTrip(req)
@@ -157,7 +240,7 @@ var _ = Describe("Client", func() {
 		session.EXPECT().HandshakeComplete().Return(handshakeCtx).MaxTimes(1)
 		session.EXPECT().OpenStreamSync(context.Background()).Return(nil, testErr).MaxTimes(1)
 		session.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).MaxTimes(1)
-		dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+		dialAddr = func(_ context.Context, hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
 			return session, nil
 		}
 		defer GinkgoRecover()
@@ -202,9 +285,11 @@ var _ = Describe("Client", func() {
 			controlStr.EXPECT().Write([]byte{0x0}).Return(1, nil).MaxTimes(1)
 			controlStr.EXPECT().Write(gomock.Any()).MaxTimes(1) // SETTINGS frame
 			str = mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().StreamID().AnyTimes()
 			sess = mockquic.NewMockEarlySession(mockCtrl)
 			sess.EXPECT().OpenUniStream().Return(controlStr, nil).MaxTimes(1)
-			dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+			sess.EXPECT().Context().Return(context.Background()).AnyTimes()
+			dialAddr = func(_ context.Context, hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
 				return sess, nil
 			}
 			var err error
@@ -254,6 +339,198 @@ var _ = Describe("Client", func() {
 			Expect(rsp.StatusCode).To(Equal(418))
 		})
 
+		It("returns the trailers", func() {
+			rspBuf := &bytes.Buffer{}
+			rw := newResponseWriter(rspBuf, utils.DefaultLogger)
+			rw.Header().Set("Trailer", "Grpc-Status")
+			rw.Write([]byte("foobar"))
+			rw.Header().Set("Grpc-Status", "0")
+			rw.Header().Set(http.TrailerPrefix+"Grpc-Message", "OK")
+			rw.writeTrailers()
+			rw.Flush()
+
+			gomock.InOrder(
+				sess.EXPECT().HandshakeComplete().Return(handshakeCtx),
+				sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil),
+				sess.EXPECT().ConnectionState().Return(qtls.ConnectionState{}),
+			)
+			str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
+			str.EXPECT().Close()
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				if rspBuf.Len() == 0 {
+					return 0, io.EOF
+				}
+				return rspBuf.Read(p)
+			}).AnyTimes()
+			rsp, err := client.RoundTrip(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rsp.Trailer).To(Equal(http.Header{"Grpc-Status": nil}))
+			data, err := ioutil.ReadAll(rsp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("foobar")))
+			Expect(rsp.Trailer).To(Equal(http.Header{
+				"Grpc-Status":  {"0"},
+				"Grpc-Message": {"OK"},
+			}))
+		})
+
+		Context("metrics", func() {
+			var (
+				rspBuf      *bytes.Buffer
+				metricsChan chan *RequestMetrics
+			)
+
+			BeforeEach(func() {
+				rspBuf = &bytes.Buffer{}
+				metricsChan = make(chan *RequestMetrics, 1)
+				client.opts.OnRequestDone = func(m *RequestMetrics) { metricsChan <- m }
+				sess.EXPECT().HandshakeComplete().Return(handshakeCtx)
+				sess.EXPECT().ConnectionState().Return(qtls.ConnectionState{}).AnyTimes()
+				str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
+				str.EXPECT().Close().MaxTimes(1)
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					return rspBuf.Read(p)
+				}).AnyTimes()
+			})
+
+			It("reports metrics when the response body was read", func() {
+				rw := newResponseWriter(rspBuf, utils.DefaultLogger)
+				rw.WriteHeader(http.StatusTeapot)
+				rw.Write([]byte("foobar"))
+				rw.Flush()
+
+				sess.EXPECT().OpenStreamSync(gomock.Any()).Return(str, nil)
+				rsp, err := client.RoundTrip(request)
+				Expect(err).ToNot(HaveOccurred())
+				Consistently(metricsChan).ShouldNot(Receive())
+				data, err := ioutil.ReadAll(rsp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal([]byte("foobar")))
+				var m *RequestMetrics
+				Eventually(metricsChan).Should(Receive(&m))
+				Expect(m.Request).To(Equal(request))
+				Expect(m.StatusCode).To(Equal(http.StatusTeapot))
+				Expect(m.ErrorCode).To(BeZero())
+				Expect(m.Err).ToNot(HaveOccurred())
+				Expect(m.FirstByte).To(BeTemporally(">=", m.StreamOpened))
+				Expect(m.HeadersDecoded).To(BeTemporally(">=", m.FirstByte))
+				Expect(m.BodyDone).To(BeTemporally(">=", m.HeadersDecoded))
+			})
+
+			It("reports failed requests", func() {
+				(&headersFrame{Length: 1338}).Write(rspBuf)
+				sess.EXPECT().OpenStreamSync(gomock.Any()).Return(str, nil)
+				str.EXPECT().CancelWrite(quic.ErrorCode(errorIDError))
+				_, err := client.RoundTrip(request)
+				Expect(err).To(HaveOccurred())
+				var m *RequestMetrics
+				Eventually(metricsChan).Should(Receive(&m))
+				Expect(m.StatusCode).To(BeZero())
+				Expect(m.ErrorCode).To(Equal(quic.ErrorCode(errorIDError)))
+				Expect(m.Err).To(MatchError(err))
+			})
+
+			It("reports canceled requests", func() {
+				rw := newResponseWriter(rspBuf, utils.DefaultLogger)
+				rw.WriteHeader(http.StatusTeapot)
+				rw.Flush()
+
+				ctx, cancel := context.WithCancel(context.Background())
+				sess.EXPECT().OpenStreamSync(ctx).Return(str, nil)
+				canceled := make(chan struct{})
+				str.EXPECT().CancelWrite(quic.ErrorCode(errorRequestCanceled))
+				str.EXPECT().CancelRead(quic.ErrorCode(errorRequestCanceled)).Do(func(quic.ErrorCode) { close(canceled) })
+				rsp, err := client.RoundTrip(request.WithContext(ctx))
+				Expect(err).ToNot(HaveOccurred())
+				cancel()
+				Eventually(canceled).Should(BeClosed())
+				// the metrics are reported once the application is done with the body
+				Consistently(metricsChan).ShouldNot(Receive())
+				str.EXPECT().CancelRead(quic.ErrorCode(errorRequestCanceled))
+				Expect(rsp.Body.Close()).To(Succeed())
+				var m *RequestMetrics
+				Eventually(metricsChan).Should(Receive(&m))
+				Expect(m.StatusCode).To(Equal(http.StatusTeapot))
+				Expect(m.ErrorCode).To(Equal(quic.ErrorCode(errorRequestCanceled)))
+				Expect(m.Err).To(MatchError(context.Canceled))
+			})
+		})
+
+		Context("informational responses", func() {
+			var rspBuf *bytes.Buffer
+
+			BeforeEach(func() {
+				rspBuf = &bytes.Buffer{}
+				gomock.InOrder(
+					sess.EXPECT().HandshakeComplete().Return(handshakeCtx),
+					sess.EXPECT().OpenStreamSync(gomock.Any()).Return(str, nil),
+				)
+				sess.EXPECT().ConnectionState().Return(qtls.ConnectionState{}).AnyTimes()
+				str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
+				str.EXPECT().Close()
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					if rspBuf.Len() == 0 {
+						return 0, io.EOF
+					}
+					return rspBuf.Read(p)
+				}).AnyTimes()
+			})
+
+			It("reports 1xx responses to the client trace", func() {
+				rw := newResponseWriter(rspBuf, utils.DefaultLogger)
+				rw.Header().Set("Link", "</style.css>; rel=preload")
+				rw.WriteHeader(http.StatusEarlyHints)
+				rw.Header().Del("Link")
+				rw.WriteHeader(http.StatusOK)
+				rw.Flush()
+
+				var got1xx []int
+				var hints []textproto.MIMEHeader
+				trace := &httptrace.ClientTrace{
+					Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+						got1xx = append(got1xx, code)
+						hints = append(hints, header)
+						return nil
+					},
+				}
+				req := request.WithContext(httptrace.WithClientTrace(context.Background(), trace))
+				rsp, err := client.RoundTrip(req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+				Expect(rsp.Header).ToNot(HaveKey("Link"))
+				Expect(got1xx).To(Equal([]int{http.StatusEarlyHints}))
+				Expect(hints[0].Get("Link")).To(Equal("</style.css>; rel=preload"))
+			})
+
+			It("cancels the stream when the client trace returns an error", func() {
+				rw := newResponseWriter(rspBuf, utils.DefaultLogger)
+				rw.WriteHeader(http.StatusEarlyHints)
+				rw.Flush()
+
+				testErr := errors.New("test err")
+				trace := &httptrace.ClientTrace{
+					Got1xxResponse: func(int, textproto.MIMEHeader) error { return testErr },
+				}
+				str.EXPECT().CancelWrite(quic.ErrorCode(errorRequestCanceled))
+				req := request.WithContext(httptrace.WithClientTrace(context.Background(), trace))
+				_, err := client.RoundTrip(req)
+				Expect(err).To(MatchError(testErr))
+			})
+
+			It("limits the number of 1xx responses", func() {
+				rw := newResponseWriter(rspBuf, utils.DefaultLogger)
+				for i := 0; i <= max1xxResponses; i++ {
+					rw.WriteHeader(http.StatusEarlyHints)
+				}
+				rw.WriteHeader(http.StatusOK)
+				rw.Flush()
+
+				str.EXPECT().CancelWrite(quic.ErrorCode(errorExcessiveLoad))
+				_, err := client.RoundTrip(request)
+				Expect(err).To(MatchError("too many 1xx informational responses"))
+			})
+		})
+
 		Context("validating the address", func() {
 			It("refuses to do requests for the wrong host", func() {
 				req, err := http.NewRequest("https", "https://quic.clemente.io:1336/foobar.html", nil)
@@ -343,7 +620,7 @@ var _ = Describe("Client", func() {
 			It("cancels the stream when the HEADERS frame is too large", func() {
 				buf := &bytes.Buffer{}
 				(&headersFrame{Length: 1338}).Write(buf)
-				str.EXPECT().CancelWrite(quic.ErrorCode(errorFrameError))
+				str.EXPECT().CancelWrite(quic.ErrorCode(errorIDError))
 				closed := make(chan struct{})
 				str.EXPECT().Close().Do(func() { close(closed) })
 				str.EXPECT().Read(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
@@ -353,6 +630,24 @@ var _ = Describe("Client", func() {
 				Expect(err).To(MatchError("HEADERS frame too large: 1338 bytes (max: 1337)"))
 				Eventually(closed).Should(BeClosed())
 			})
+
+			It("cancels the stream when the decoded header list is too large", func() {
+				buf := &bytes.Buffer{}
+				rw := newResponseWriter(buf, utils.DefaultLogger)
+				// The Huffman encoding compresses this header below the limit.
+				rw.Header().Set("X-Foo", strings.Repeat("a", 1400))
+				rw.WriteHeader(http.StatusOK)
+				rw.Flush()
+				str.EXPECT().CancelWrite(quic.ErrorCode(errorIDError))
+				closed := make(chan struct{})
+				str.EXPECT().Close().Do(func() { close(closed) })
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+					return buf.Read(b)
+				}).AnyTimes()
+				_, err := client.RoundTrip(request)
+				Expect(err).To(MatchError(ContainSubstring("header list too large")))
+				Eventually(closed).Should(BeClosed())
+			})
 		})
 
 		Context("request cancellations", func() {
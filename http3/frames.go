@@ -32,38 +32,41 @@ func parseNextFrame(b io.Reader) (frame, error) {
 	if !ok {
 		br = &byteReaderImpl{b}
 	}
-	t, err := utils.ReadVarInt(br)
-	if err != nil {
-		return nil, err
-	}
-	l, err := utils.ReadVarInt(br)
-	if err != nil {
-		return nil, err
-	}
-
-	switch t {
-	case 0x0:
-		return &dataFrame{Length: l}, nil
-	case 0x1:
-		return &headersFrame{Length: l}, nil
-	case 0x4:
-		return parseSettingsFrame(br, l)
-	case 0x3: // CANCEL_PUSH
-		fallthrough
-	case 0x5: // PUSH_PROMISE
-		fallthrough
-	case 0x7: // GOAWAY
-		fallthrough
-	case 0xd: // MAX_PUSH_ID
-		fallthrough
-	case 0xe: // DUPLICATE_PUSH
-		fallthrough
-	default:
-		// skip over unknown frames
-		if _, err := io.CopyN(ioutil.Discard, br, int64(l)); err != nil {
+	// Unknown frames are skipped in a loop (and not by recursing),
+	// so that a peer sending lots of small unknown frames can't grow the stack.
+	for {
+		t, err := utils.ReadVarInt(br)
+		if err != nil {
 			return nil, err
 		}
-		return parseNextFrame(b)
+		l, err := utils.ReadVarInt(br)
+		if err != nil {
+			return nil, err
+		}
+
+		switch t {
+		case 0x0:
+			return &dataFrame{Length: l}, nil
+		case 0x1:
+			return &headersFrame{Length: l}, nil
+		case 0x4:
+			return parseSettingsFrame(br, l)
+		case 0x7: // GOAWAY
+			return parseGoAwayFrame(br, l)
+		case 0xd: // MAX_PUSH_ID
+			return parseMaxPushIDFrame(br, l)
+		case 0x3: // CANCEL_PUSH
+			fallthrough
+		case 0x5: // PUSH_PROMISE
+			fallthrough
+		case 0xe: // DUPLICATE_PUSH
+			fallthrough
+		default:
+			// skip over unknown frames
+			if _, err := io.CopyN(ioutil.Discard, br, int64(l)); err != nil {
+				return nil, err
+			}
+		}
 	}
 }
 
@@ -85,6 +88,10 @@ func (f *headersFrame) Write(b *bytes.Buffer) {
 	utils.WriteVarInt(b, f.Length)
 }
 
+// settingMaxFieldSectionSize is the SETTINGS_MAX_FIELD_SECTION_SIZE setting.
+// It advertises the maximum size of a header list the endpoint accepts.
+const settingMaxFieldSectionSize = 0x6
+
 type settingsFrame struct {
 	settings map[uint64]uint64
 }
@@ -131,3 +138,82 @@ func (f *settingsFrame) Write(b *bytes.Buffer) {
 		utils.WriteVarInt(b, val)
 	}
 }
+
+type maxPushIDFrame struct {
+	PushID uint64
+}
+
+func parseMaxPushIDFrame(r io.Reader, l uint64) (*maxPushIDFrame, error) {
+	id, err := parseVarIntFramePayload(r, l)
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid MAX_PUSH_ID frame")
+	}
+	return &maxPushIDFrame{PushID: id}, nil
+}
+
+// parseVarIntFramePayload parses the payload of a frame that consists of a single varint.
+func parseVarIntFramePayload(r io.Reader, l uint64) (uint64, error) {
+	if l == 0 || l > 8 {
+		return 0, fmt.Errorf("unexpected frame size: %d", l)
+	}
+	buf := make([]byte, l)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, io.EOF
+		}
+		return 0, err
+	}
+	b := bytes.NewReader(buf)
+	val, err := utils.ReadVarInt(b)
+	if err != nil {
+		return 0, err
+	}
+	if b.Len() > 0 {
+		return 0, fmt.Errorf("unexpected frame size: %d", l)
+	}
+	return val, nil
+}
+
+func (f *maxPushIDFrame) Write(b *bytes.Buffer) {
+	utils.WriteVarInt(b, 0xd)
+	utils.WriteVarInt(b, uint64(utils.VarIntLen(f.PushID)))
+	utils.WriteVarInt(b, f.PushID)
+}
+
+// A pushPromiseFrame is the frame header of a PUSH_PROMISE frame.
+// Length is the length of the encoded header block following the Push ID.
+type pushPromiseFrame struct {
+	PushID uint64
+	Length uint64
+}
+
+func (f *pushPromiseFrame) Write(b *bytes.Buffer) {
+	utils.WriteVarInt(b, 0x5)
+	utils.WriteVarInt(b, uint64(utils.VarIntLen(f.PushID))+f.Length)
+	utils.WriteVarInt(b, f.PushID)
+}
+
+// A goAwayFrame contains a stream ID when sent by the server, and a push ID when sent by the client.
+type goAwayFrame struct {
+	ID uint64
+}
+
+func parseGoAwayFrame(r io.Reader, l uint64) (*goAwayFrame, error) {
+	id, err := parseVarIntFramePayload(r, l)
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid GOAWAY frame")
+	}
+	return &goAwayFrame{ID: id}, nil
+}
+
+func (f *goAwayFrame) Write(b *bytes.Buffer) {
+	utils.WriteVarInt(b, 0x7)
+	utils.WriteVarInt(b, uint64(utils.VarIntLen(f.ID)))
+	utils.WriteVarInt(b, f.ID)
+}
@@ -29,6 +29,19 @@ var _ = Describe("Frames", func() {
 		Expect(frame.(*dataFrame).Length).To(Equal(uint64(0x1234)))
 	})
 
+	It("skips a large number of unknown frames", func() {
+		var data []byte
+		for i := 0; i < 1e5; i++ {
+			data = appendVarInt(data, 0x21) // type byte of a reserved frame type
+			data = appendVarInt(data, 0)
+		}
+		buf := bytes.NewBuffer(data)
+		(&dataFrame{Length: 0x1234}).Write(buf)
+		frame, err := parseNextFrame(buf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(&dataFrame{Length: 0x1234}))
+	})
+
 	Context("DATA frames", func() {
 		It("parses", func() {
 			data := appendVarInt(nil, 0) // type byte
@@ -133,4 +146,87 @@ var _ = Describe("Frames", func() {
 			}
 		})
 	})
+
+	Context("MAX_PUSH_ID frames", func() {
+		It("parses", func() {
+			data := appendVarInt(nil, 0xd) // type byte
+			data = appendVarInt(data, 2)
+			data = appendVarInt(data, 0x1337)
+			frame, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&maxPushIDFrame{PushID: 0x1337}))
+		})
+
+		It("writes", func() {
+			buf := &bytes.Buffer{}
+			(&maxPushIDFrame{PushID: 0xdeadbeef}).Write(buf)
+			frame, err := parseNextFrame(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&maxPushIDFrame{PushID: 0xdeadbeef}))
+			Expect(buf.Len()).To(BeZero())
+		})
+
+		It("rejects frames with an invalid length", func() {
+			data := appendVarInt(nil, 0xd) // type byte
+			data = appendVarInt(data, 3)
+			data = appendVarInt(data, 0x1337)
+			data = append(data, 0)
+			_, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).To(MatchError("invalid MAX_PUSH_ID frame"))
+		})
+
+		It("errors on EOF", func() {
+			buf := &bytes.Buffer{}
+			(&maxPushIDFrame{PushID: 0xdeadbeef}).Write(buf)
+			data := buf.Bytes()
+			for i := range data {
+				_, err := parseNextFrame(bytes.NewReader(data[:i]))
+				Expect(err).To(MatchError(io.EOF))
+			}
+		})
+	})
+
+	Context("GOAWAY frames", func() {
+		It("parses", func() {
+			data := appendVarInt(nil, 0x7) // type byte
+			data = appendVarInt(data, 1)
+			data = appendVarInt(data, 12)
+			frame, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&goAwayFrame{ID: 12}))
+		})
+
+		It("writes", func() {
+			buf := &bytes.Buffer{}
+			(&goAwayFrame{ID: 0x1337}).Write(buf)
+			frame, err := parseNextFrame(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&goAwayFrame{ID: 0x1337}))
+			Expect(buf.Len()).To(BeZero())
+		})
+
+		It("rejects empty frames", func() {
+			data := appendVarInt(nil, 0x7) // type byte
+			data = appendVarInt(data, 0)
+			_, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).To(MatchError("invalid GOAWAY frame"))
+		})
+	})
+
+	Context("PUSH_PROMISE frames", func() {
+		It("writes", func() {
+			buf := &bytes.Buffer{}
+			(&pushPromiseFrame{PushID: 0x1337, Length: 0x42}).Write(buf)
+			t, err := utils.ReadVarInt(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal(uint64(0x5)))
+			l, err := utils.ReadVarInt(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l).To(Equal(uint64(2 + 0x42)))
+			id, err := utils.ReadVarInt(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(Equal(uint64(0x1337)))
+			Expect(buf.Len()).To(BeZero())
+		})
+	})
 })
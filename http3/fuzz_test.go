@@ -0,0 +1,105 @@
+//go:build go1.18
+// +build go1.18
+
+package http3
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/marten-seemann/qpack"
+)
+
+func FuzzFrames(f *testing.F) {
+	frames := []interface{ Write(*bytes.Buffer) }{
+		&dataFrame{Length: 1337},
+		&headersFrame{Length: 42},
+		&settingsFrame{settings: map[uint64]uint64{settingMaxFieldSectionSize: 1337, 0x1f: 42}},
+		&goAwayFrame{ID: 100},
+		&maxPushIDFrame{PushID: 1337},
+		&pushPromiseFrame{PushID: 3, Length: 0},
+	}
+	for _, frame := range frames {
+		b := &bytes.Buffer{}
+		frame.Write(b)
+		f.Add(b.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		parseFrames(t, bytes.NewReader(data))
+		// QUIC streams don't implement io.ByteReader
+		parseFrames(t, io.MultiReader(bytes.NewReader(data)))
+	})
+}
+
+func parseFrames(t *testing.T, r io.Reader) {
+	for {
+		frame, err := parseNextFrame(r)
+		if err != nil {
+			return
+		}
+		switch fr := frame.(type) {
+		case *dataFrame:
+			if _, err := io.CopyN(ioutil.Discard, r, int64(fr.Length)); err != nil {
+				return
+			}
+		case *headersFrame:
+			if _, err := io.CopyN(ioutil.Discard, r, int64(fr.Length)); err != nil {
+				return
+			}
+		case *settingsFrame:
+			b := &bytes.Buffer{}
+			fr.Write(b)
+			parsed, err := parseNextFrame(b)
+			if err != nil {
+				t.Fatalf("failed to parse serialized SETTINGS frame: %s", err)
+			}
+			if len(parsed.(*settingsFrame).settings) != len(fr.settings) {
+				t.Fatal("SETTINGS frame changed after serialization")
+			}
+		}
+	}
+}
+
+func FuzzHeaders(f *testing.F) {
+	seeds := [][]qpack.HeaderField{
+		{
+			{Name: ":method", Value: http.MethodGet},
+			{Name: ":path", Value: "/foo?bar=baz"},
+			{Name: ":authority", Value: "quic.clemente.io"},
+			{Name: ":scheme", Value: "https"},
+			{Name: "content-length", Value: "1337"},
+			{Name: "cookie", Value: "foo=bar"},
+			{Name: "cookie", Value: "lorem=ipsum"},
+		},
+		{
+			{Name: ":method", Value: http.MethodConnect},
+			{Name: ":authority", Value: "quic.clemente.io:443"},
+		},
+		{
+			{Name: "trailer-field", Value: "foobar"},
+		},
+	}
+	for _, hfs := range seeds {
+		b := &bytes.Buffer{}
+		enc := qpack.NewEncoder(b)
+		for _, hf := range hfs {
+			if err := enc.WriteField(hf); err != nil {
+				f.Fatal(err)
+			}
+		}
+		f.Add(b.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		hfs, err := qpack.NewDecoder(nil).DecodeFull(data)
+		if err != nil {
+			return
+		}
+		headerListSize(hfs)
+		if req, err := requestFromHeaders(hfs); err == nil {
+			declaredTrailers(req.Header)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package http3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+// The Hijacker interface is implemented by the http.ResponseWriter of the Server.
+// It allows an HTTP handler to take over the request stream,
+// e.g. to implement a bidirectional protocol on top of a single request.
+type Hijacker interface {
+	// HijackStream takes over the request stream.
+	// If the response header was not written yet, a 200 status code is sent.
+	// The response header is flushed before the stream is returned.
+	// Data written to the stream is sent in DATA frames, and reading from the stream returns
+	// the payload of the DATA frames sent by the client.
+	// After a call to HijackStream, the Server doesn't use the stream any more:
+	// It is the caller's responsibility to close it, even after the handler returned.
+	HijackStream() (quic.Stream, error)
+}
+
+var errHijacked = errors.New("http3: stream hijacked")
+
+// A dataStream wraps a request stream.
+// It frames all data written to the stream in DATA frames,
+// and it returns the payload of the DATA frames when reading.
+type dataStream struct {
+	quic.Stream
+
+	body io.Reader
+}
+
+var _ quic.Stream = &dataStream{}
+
+func newDataStream(str quic.Stream, body io.Reader) *dataStream {
+	return &dataStream{Stream: str, body: body}
+}
+
+func (s *dataStream) Read(b []byte) (int, error) {
+	return s.body.Read(b)
+}
+
+func (s *dataStream) Write(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	buf := &bytes.Buffer{}
+	(&dataFrame{Length: uint64(len(b))}).Write(buf)
+	if _, err := s.Stream.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return s.Stream.Write(b)
+}
@@ -0,0 +1,44 @@
+package http3
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+// RequestMetrics contains the timings and the outcome of a single request.
+// It is passed to the OnRequestDone callback of the Server and the RoundTripper.
+type RequestMetrics struct {
+	// Request is the request. On the server, it is nil if the request couldn't be parsed.
+	Request  *http.Request
+	StreamID quic.StreamID
+
+	// StreamOpened is the time when the request stream was opened (client) or accepted (server).
+	StreamOpened time.Time
+	// HeadersDecoded is the time when the response header (client) or the request header (server) was decoded.
+	HeadersDecoded time.Time
+	// FirstByte is the time when the first response HEADERS frame was received (client) or written (server).
+	FirstByte time.Time
+	// BodyDone is the time when the response body was completely read or closed (client),
+	// or when the handler returned and the response was flushed (server).
+	BodyDone time.Time
+
+	// StatusCode is the status code of the final response.
+	// It is 0 if no response was received (client) or sent (server).
+	StatusCode int
+	// ErrorCode is the HTTP/3 error code that the stream or the connection was closed with.
+	// It is 0 if the request succeeded.
+	ErrorCode quic.ErrorCode
+	// Err is the error that occurred, if any.
+	Err error
+}
+
+func (m *RequestMetrics) setError(rerr requestError) {
+	m.Err = rerr.err
+	if rerr.streamErr != 0 {
+		m.ErrorCode = quic.ErrorCode(rerr.streamErr)
+	} else if rerr.connErr != 0 {
+		m.ErrorCode = quic.ErrorCode(rerr.connErr)
+	}
+}
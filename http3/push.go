@@ -0,0 +1,169 @@
+package http3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/marten-seemann/qpack"
+)
+
+// The pushManager keeps track of the push IDs the client allows us to use.
+// Server push is only enabled once the client sent a MAX_PUSH_ID frame.
+type pushManager struct {
+	conn *serverConn // pushed requests are tracked as requests of this connection
+
+	mutex sync.Mutex
+
+	enabled    bool
+	maxPushID  uint64
+	nextPushID uint64
+}
+
+func (m *pushManager) SetMaxPushID(id uint64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.enabled && id < m.maxPushID {
+		return fmt.Errorf("MAX_PUSH_ID reduced the limit from %d to %d", m.maxPushID, id)
+	}
+	m.enabled = true
+	m.maxPushID = id
+	return nil
+}
+
+// OpenPushStream opens a new push stream, and allocates the next push ID for it.
+// The push ID is only used up if the stream was opened successfully.
+// It returns http.ErrNotSupported if the client didn't enable server push.
+func (m *pushManager) OpenPushStream(sess quic.Session) (uint64, quic.SendStream, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.enabled {
+		return 0, nil, http.ErrNotSupported
+	}
+	if m.nextPushID > m.maxPushID {
+		return 0, nil, errors.New("http3: push ID limit reached")
+	}
+	// This fails if the client doesn't allow us to open any more unidirectional streams.
+	str, err := sess.OpenUniStream()
+	if err != nil {
+		return 0, nil, err
+	}
+	id := m.nextPushID
+	m.nextPushID++
+	return id, str, nil
+}
+
+// A pusher initiates server pushes for the promises made on a request stream.
+type pusher struct {
+	server  *Server
+	sess    quic.Session
+	manager *pushManager
+	req     *http.Request // the request that the pushes are associated with
+}
+
+func (p *pusher) Push(w *responseWriter, target string, opts *http.PushOptions) error {
+	if opts == nil {
+		opts = &http.PushOptions{}
+	}
+	method := opts.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		return fmt.Errorf("http3: method %q is not allowed for a pushed request", method)
+	}
+	u, err := p.pushTarget(target)
+	if err != nil {
+		return err
+	}
+
+	hfs := []qpack.HeaderField{
+		{Name: ":method", Value: method},
+		{Name: ":scheme", Value: u.Scheme},
+		{Name: ":authority", Value: u.Host},
+		{Name: ":path", Value: u.RequestURI()},
+	}
+	for k, vv := range opts.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, ":") {
+			return fmt.Errorf("http3: pushed request must not contain pseudo header %q", k)
+		}
+		for _, v := range vv {
+			hfs = append(hfs, qpack.HeaderField{Name: k, Value: v})
+		}
+	}
+	req, err := requestFromHeaders(hfs)
+	if err != nil {
+		return err
+	}
+
+	pushID, str, err := p.manager.OpenPushStream(p.sess)
+	if err != nil {
+		return err
+	}
+	// Push is called by the handler of a running request, so a graceful shutdown can't have completed yet.
+	conn := p.manager.conn
+	conn.pushStarted()
+
+	// send the PUSH_PROMISE on the request stream
+	headers := &bytes.Buffer{}
+	enc := qpack.NewEncoder(headers)
+	for _, hf := range hfs {
+		enc.WriteField(hf)
+	}
+	buf := &bytes.Buffer{}
+	(&pushPromiseFrame{PushID: pushID, Length: uint64(headers.Len())}).Write(buf)
+	buf.Write(headers.Bytes())
+	if _, err := w.stream.Write(buf.Bytes()); err != nil {
+		str.CancelWrite(quic.ErrorCode(errorRequestCanceled))
+		conn.requestDone()
+		return err
+	}
+	w.Flush()
+
+	req.RemoteAddr = p.req.RemoteAddr
+	req.Body = http.NoBody
+	req = req.WithContext(p.server.requestContext(str.Context(), p.sess))
+
+	p.server.logger.Debugf("Pushing %s %s%s (push ID %d) on stream %d", req.Method, req.Host, req.RequestURI, pushID, str.StreamID())
+	go func() {
+		defer conn.requestDone()
+		b := &bytes.Buffer{}
+		utils.WriteVarInt(b, streamTypePushStream)
+		utils.WriteVarInt(b, pushID)
+		if _, err := str.Write(b.Bytes()); err != nil {
+			p.server.logger.Debugf("Writing the push stream header failed: %s", err)
+			return
+		}
+		rw := newResponseWriter(str, p.server.logger)
+		p.server.serveHTTP(rw, req)
+		rw.Flush()
+		str.Close()
+	}()
+	return nil
+}
+
+// pushTarget resolves the target of a push.
+// Paths are resolved relative to the authority of the original request.
+func (p *pusher) pushTarget(target string) (*url.URL, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/") {
+		u.Scheme = "https"
+		u.Host = p.req.Host
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("http3: invalid push target %q", target)
+	}
+	return u, nil
+}
@@ -0,0 +1,249 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go"
+	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/marten-seemann/qpack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server Push", func() {
+	Context("push manager", func() {
+		var sess *mockquic.MockEarlySession
+
+		BeforeEach(func() {
+			sess = mockquic.NewMockEarlySession(mockCtrl)
+		})
+
+		It("doesn't allow pushes before the client sent a MAX_PUSH_ID", func() {
+			_, _, err := (&pushManager{}).OpenPushStream(sess)
+			Expect(err).To(MatchError(http.ErrNotSupported))
+		})
+
+		It("hands out push IDs up to the maximum", func() {
+			m := &pushManager{}
+			Expect(m.SetMaxPushID(1)).To(Succeed())
+			str := mockquic.NewMockStream(mockCtrl)
+			sess.EXPECT().OpenUniStream().Return(str, nil).Times(3)
+			id, s, err := m.OpenPushStream(sess)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(BeZero())
+			Expect(s).To(Equal(str))
+			id, _, err = m.OpenPushStream(sess)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(Equal(uint64(1)))
+			_, _, err = m.OpenPushStream(sess)
+			Expect(err).To(MatchError("http3: push ID limit reached"))
+			Expect(m.SetMaxPushID(2)).To(Succeed())
+			id, _, err = m.OpenPushStream(sess)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(Equal(uint64(2)))
+		})
+
+		It("doesn't use up a push ID if the stream can't be opened", func() {
+			m := &pushManager{}
+			Expect(m.SetMaxPushID(10)).To(Succeed())
+			testErr := errors.New("too many open streams")
+			sess.EXPECT().OpenUniStream().Return(nil, testErr)
+			_, _, err := m.OpenPushStream(sess)
+			Expect(err).To(MatchError(testErr))
+			sess.EXPECT().OpenUniStream().Return(mockquic.NewMockStream(mockCtrl), nil)
+			id, _, err := m.OpenPushStream(sess)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(BeZero())
+		})
+
+		It("errors when the maximum push ID is reduced", func() {
+			m := &pushManager{}
+			Expect(m.SetMaxPushID(10)).To(Succeed())
+			Expect(m.SetMaxPushID(9)).To(MatchError("MAX_PUSH_ID reduced the limit from 10 to 9"))
+		})
+	})
+
+	Context("pushing", func() {
+		var (
+			s       *Server
+			sess    *mockquic.MockEarlySession
+			pm      *pushManager
+			rw      *responseWriter
+			reqBuf  *bytes.Buffer
+			request *http.Request
+		)
+
+		BeforeEach(func() {
+			s = &Server{Server: &http.Server{}, logger: utils.DefaultLogger}
+			sess = mockquic.NewMockEarlySession(mockCtrl)
+			sess.EXPECT().LocalAddr().AnyTimes()
+			pm = &pushManager{conn: &serverConn{}}
+			reqBuf = &bytes.Buffer{}
+			var err error
+			request, err = http.NewRequest(http.MethodGet, "https://www.example.com/index.html", nil)
+			Expect(err).ToNot(HaveOccurred())
+			request.RemoteAddr = "127.0.0.1:1337"
+			rw = newResponseWriter(reqBuf, utils.DefaultLogger)
+			rw.pusher = &pusher{server: s, sess: sess, manager: pm, req: request}
+		})
+
+		decodePushPromise := func(r *bytes.Buffer) (uint64, map[string]string) {
+			t, err := utils.ReadVarInt(r)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			ExpectWithOffset(1, t).To(Equal(uint64(0x5)))
+			l, err := utils.ReadVarInt(r)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			id, err := utils.ReadVarInt(r)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			data := make([]byte, l-uint64(utils.VarIntLen(id)))
+			_, err = io.ReadFull(r, data)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			hfs, err := qpack.NewDecoder(nil).DecodeFull(data)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			fields := make(map[string]string)
+			for _, hf := range hfs {
+				fields[hf.Name] = hf.Value
+			}
+			return id, fields
+		}
+
+		It("returns http.ErrNotSupported if the client didn't enable push", func() {
+			Expect(rw.Push("/style.css", nil)).To(MatchError(http.ErrNotSupported))
+		})
+
+		It("returns http.ErrNotSupported for responses to pushed requests", func() {
+			rw.pusher = nil
+			Expect(rw.Push("/style.css", nil)).To(MatchError(http.ErrNotSupported))
+		})
+
+		It("rejects methods other than GET and HEAD", func() {
+			Expect(pm.SetMaxPushID(10)).To(Succeed())
+			Expect(rw.Push("/style.css", &http.PushOptions{Method: http.MethodPost})).To(MatchError(`http3: method "POST" is not allowed for a pushed request`))
+		})
+
+		It("rejects invalid targets", func() {
+			Expect(pm.SetMaxPushID(10)).To(Succeed())
+			Expect(rw.Push("style.css", nil)).To(MatchError(`http3: invalid push target "style.css"`))
+			Expect(rw.Push("http://www.example.com/style.css", nil)).To(MatchError(`http3: invalid push target "http://www.example.com/style.css"`))
+		})
+
+		It("returns the error when the stream limit is reached", func() {
+			Expect(pm.SetMaxPushID(10)).To(Succeed())
+			testErr := errors.New("too many open streams")
+			sess.EXPECT().OpenUniStream().Return(nil, testErr)
+			Expect(rw.Push("/style.css", nil)).To(MatchError(testErr))
+		})
+
+		requestsDone := func() <-chan struct{} {
+			done := make(chan struct{})
+			go func() {
+				pm.conn.requests.Wait()
+				close(done)
+			}()
+			return done
+		}
+
+		It("sends a PUSH_PROMISE and the pushed response", func() {
+			Expect(pm.SetMaxPushID(10)).To(Succeed())
+			handlerCalled := make(chan *http.Request, 1)
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				handlerCalled <- r
+				Expect(w.(http.Pusher).Push("/other.css", nil)).To(MatchError(http.ErrNotSupported))
+				w.Write([]byte("body { color: red; }"))
+			})
+			pushBuf := &bytes.Buffer{}
+			closed := make(chan struct{})
+			pushStr := mockquic.NewMockStream(mockCtrl)
+			pushStr.EXPECT().StreamID().AnyTimes()
+			pushStr.EXPECT().Context().Return(context.Background())
+			pushStr.EXPECT().Write(gomock.Any()).DoAndReturn(pushBuf.Write).AnyTimes()
+			pushStr.EXPECT().Close().Do(func() { close(closed) })
+			sess.EXPECT().OpenUniStream().Return(pushStr, nil)
+
+			Expect(rw.Push("/style.css?v=2", &http.PushOptions{Header: http.Header{"Accept-Encoding": {"gzip"}}})).To(Succeed())
+			id, fields := decodePushPromise(reqBuf)
+			Expect(id).To(BeZero())
+			Expect(fields).To(Equal(map[string]string{
+				":method":         "GET",
+				":scheme":         "https",
+				":authority":      "www.example.com",
+				":path":           "/style.css?v=2",
+				"accept-encoding": "gzip",
+			}))
+
+			var req *http.Request
+			Eventually(handlerCalled).Should(Receive(&req))
+			Expect(req.URL.Path).To(Equal("/style.css"))
+			Expect(req.Host).To(Equal("www.example.com"))
+			Expect(req.Header.Get("Accept-Encoding")).To(Equal("gzip"))
+			Expect(req.RemoteAddr).To(Equal("127.0.0.1:1337"))
+			Expect(req.Context().Value(ServerContextKey)).To(Equal(s))
+			Expect(req.Context().Value(SessionContextKey)).To(Equal(sess))
+			Expect(req.Context().Value(StreamContextKey)).To(BeNil())
+			Eventually(closed).Should(BeClosed())
+
+			streamType, err := utils.ReadVarInt(pushBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(streamType).To(Equal(uint64(streamTypePushStream)))
+			pushID, err := utils.ReadVarInt(pushBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pushID).To(BeZero())
+			frame, err := parseNextFrame(pushBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(BeAssignableToTypeOf(&headersFrame{}))
+			hfs, err := qpack.NewDecoder(nil).DecodeFull(pushBuf.Next(int(frame.(*headersFrame).Length)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hfs).To(ContainElement(qpack.HeaderField{Name: ":status", Value: "200"}))
+			frame, err = parseNextFrame(pushBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&dataFrame{Length: 20}))
+			Expect(pushBuf.String()).To(Equal("body { color: red; }"))
+		})
+
+		It("cancels the push stream if the PUSH_PROMISE can't be sent", func() {
+			Expect(pm.SetMaxPushID(10)).To(Succeed())
+			testErr := errors.New("stream reset")
+			reqStr := mockquic.NewMockStream(mockCtrl)
+			reqStr.EXPECT().Write(gomock.Any()).Return(0, testErr)
+			rw = newResponseWriter(reqStr, utils.DefaultLogger)
+			rw.pusher = &pusher{server: s, sess: sess, manager: pm, req: request}
+			// a write that doesn't fit into the buffer fails immediately
+			rw.stream.Write(make([]byte, rw.stream.Available()+1))
+			pushStr := mockquic.NewMockStream(mockCtrl)
+			pushStr.EXPECT().CancelWrite(quic.ErrorCode(errorRequestCanceled))
+			sess.EXPECT().OpenUniStream().Return(pushStr, nil)
+			Expect(rw.Push("/style.css", nil)).To(MatchError(testErr))
+			Eventually(requestsDone()).Should(BeClosed())
+		})
+
+		It("waits for pushed requests when shutting down gracefully", func() {
+			Expect(pm.SetMaxPushID(10)).To(Succeed())
+			unblock := make(chan struct{})
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-unblock
+			})
+			closed := make(chan struct{})
+			pushStr := mockquic.NewMockStream(mockCtrl)
+			pushStr.EXPECT().StreamID().AnyTimes()
+			pushStr.EXPECT().Context().Return(context.Background())
+			pushStr.EXPECT().Write(gomock.Any()).DoAndReturn(func(b []byte) (int, error) { return len(b), nil }).AnyTimes()
+			pushStr.EXPECT().Close().Do(func() { close(closed) })
+			sess.EXPECT().OpenUniStream().Return(pushStr, nil)
+
+			Expect(rw.Push("/style.css", nil)).To(Succeed())
+			done := requestsDone()
+			Consistently(done).ShouldNot(BeClosed())
+			close(unblock)
+			Eventually(closed).Should(BeClosed())
+			Eventually(done).Should(BeClosed())
+		})
+	})
+})
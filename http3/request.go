@@ -11,6 +11,16 @@ import (
 	"github.com/marten-seemann/qpack"
 )
 
+// headerListSize calculates the size of a header list,
+// as defined for the SETTINGS_MAX_FIELD_SECTION_SIZE setting.
+func headerListSize(hfs []qpack.HeaderField) uint64 {
+	var size uint64
+	for _, hf := range hfs {
+		size += uint64(len(hf.Name)+len(hf.Value)) + 32
+	}
+	return size
+}
+
 func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
 	var path, authority, method, contentLengthStr string
 	httpHeaders := http.Header{}
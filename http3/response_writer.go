@@ -3,11 +3,14 @@ package http3
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/marten-seemann/qpack"
 )
@@ -18,6 +21,15 @@ type responseWriter struct {
 	header        http.Header
 	status        int // status code passed to WriteHeader
 	headerWritten bool
+	firstByte     time.Time // when the first HEADERS frame was written
+	trailers      []string  // trailers declared in the Trailer header
+
+	pusher *pusher // nil if server push is not possible
+
+	// the request stream and body, nil if the stream can't be hijacked
+	reqStream quic.Stream
+	reqBody   io.Reader
+	hijacked  bool
 
 	logger utils.Logger
 }
@@ -25,6 +37,8 @@ type responseWriter struct {
 var (
 	_ http.ResponseWriter = &responseWriter{}
 	_ http.Flusher        = &responseWriter{}
+	_ http.Pusher         = &responseWriter{}
+	_ Hijacker            = &responseWriter{}
 )
 
 func newResponseWriter(stream io.Writer, logger utils.Logger) *responseWriter {
@@ -39,26 +53,104 @@ func (w *responseWriter) Header() http.Header {
 	return w.header
 }
 
+// WriteHeader sends the response header.
+// Informational (1xx) responses, e.g. 103 Early Hints, can be sent any number of times
+// before the final response. They are sent immediately and include the headers set so far.
 func (w *responseWriter) WriteHeader(status int) {
 	if w.headerWritten {
 		return
 	}
+	if status >= 100 && status <= 199 && status != http.StatusSwitchingProtocols {
+		w.logger.Infof("Sending informational response %d", status)
+		w.writeHeaders(w.headerFields(status))
+		w.Flush()
+		return
+	}
 	w.headerWritten = true
 	w.status = status
+	w.declareTrailers()
 
-	var headers bytes.Buffer
-	enc := qpack.NewEncoder(&headers)
-	enc.WriteField(qpack.HeaderField{Name: ":status", Value: strconv.Itoa(status)})
+	w.logger.Infof("Responding with %d", status)
+	w.writeHeaders(w.headerFields(status))
+}
 
+func (w *responseWriter) headerFields(status int) []qpack.HeaderField {
+	fields := []qpack.HeaderField{{Name: ":status", Value: strconv.Itoa(status)}}
 	for k, v := range w.header {
+		if strings.HasPrefix(k, http.TrailerPrefix) || w.isDeclaredTrailer(k) {
+			continue
+		}
 		for index := range v {
-			enc.WriteField(qpack.HeaderField{Name: strings.ToLower(k), Value: v[index]})
+			fields = append(fields, qpack.HeaderField{Name: strings.ToLower(k), Value: v[index]})
+		}
+	}
+	return fields
+}
+
+// declareTrailers parses the trailers announced in the Trailer header.
+func (w *responseWriter) declareTrailers() {
+	for _, v := range w.header["Trailer"] {
+		for _, k := range strings.Split(v, ",") {
+			k = http.CanonicalHeaderKey(strings.TrimSpace(k))
+			switch k {
+			case "", "Transfer-Encoding", "Trailer", "Content-Length":
+				// these headers are not allowed as trailers
+				continue
+			}
+			w.trailers = append(w.trailers, k)
+		}
+	}
+}
+
+func (w *responseWriter) isDeclaredTrailer(k string) bool {
+	for _, t := range w.trailers {
+		if t == k {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTrailers sends the trailers in a HEADERS frame after the body.
+// Trailers are either declared in the Trailer header before the header is written,
+// or set using the http.TrailerPrefix, as documented for the net/http package.
+func (w *responseWriter) writeTrailers() {
+	if w.hijacked {
+		return
+	}
+	var fields []qpack.HeaderField
+	for _, k := range w.trailers {
+		for _, v := range w.header[k] {
+			fields = append(fields, qpack.HeaderField{Name: strings.ToLower(k), Value: v})
+		}
+	}
+	for k, vv := range w.header {
+		if !strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		name := strings.ToLower(strings.TrimPrefix(k, http.TrailerPrefix))
+		for _, v := range vv {
+			fields = append(fields, qpack.HeaderField{Name: name, Value: v})
 		}
 	}
+	if len(fields) == 0 {
+		return
+	}
+	w.writeHeaders(fields)
+}
+
+func (w *responseWriter) writeHeaders(fields []qpack.HeaderField) {
+	if w.firstByte.IsZero() {
+		w.firstByte = time.Now()
+	}
+	var headers bytes.Buffer
+	enc := qpack.NewEncoder(&headers)
+	for _, f := range fields {
+		enc.WriteField(f)
+	}
 
 	buf := &bytes.Buffer{}
 	(&headersFrame{Length: uint64(headers.Len())}).Write(buf)
-	w.logger.Infof("Responding with %d", status)
 	if _, err := w.stream.Write(buf.Bytes()); err != nil {
 		w.logger.Errorf("could not write headers frame: %s", err.Error())
 	}
@@ -68,6 +160,9 @@ func (w *responseWriter) WriteHeader(status int) {
 }
 
 func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.hijacked {
+		return 0, http.ErrHijacked
+	}
 	if !w.headerWritten {
 		w.WriteHeader(200)
 	}
@@ -83,12 +178,42 @@ func (w *responseWriter) Write(p []byte) (int, error) {
 	return w.stream.Write(p)
 }
 
+// Flush sends all buffered data to the stream, see http.Flusher.
 func (w *responseWriter) Flush() {
 	if err := w.stream.Flush(); err != nil {
 		w.logger.Errorf("could not flush to stream: %s", err.Error())
 	}
 }
 
+// Push initiates a server push, see http.Pusher.
+// It returns http.ErrNotSupported if the client disabled server push,
+// or if this is a response to a pushed request.
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if w.pusher == nil {
+		return http.ErrNotSupported
+	}
+	return w.pusher.Push(w, target, opts)
+}
+
+// HijackStream takes over the request stream, see Hijacker.
+// It returns http.ErrNotSupported for responses to pushed requests.
+func (w *responseWriter) HijackStream() (quic.Stream, error) {
+	if w.reqStream == nil {
+		return nil, http.ErrNotSupported
+	}
+	if w.hijacked {
+		return nil, errors.New("http3: stream already hijacked")
+	}
+	if !w.headerWritten {
+		w.WriteHeader(200)
+	}
+	if err := w.stream.Flush(); err != nil {
+		return nil, err
+	}
+	w.hijacked = true
+	return newDataStream(w.reqStream, w.reqBody), nil
+}
+
 // copied from http2/http2.go
 // bodyAllowedForStatus reports whether a given response status code
 // permits a body. See RFC 2616, section 4.4.
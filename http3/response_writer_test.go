@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"time"
 
+	"github.com/golang/mock/gomock"
+	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/marten-seemann/qpack"
 
@@ -104,6 +107,15 @@ var _ = Describe("Response Writer", func() {
 		Expect(getData(strBuf)).To(Equal([]byte("foobar")))
 	})
 
+	It("buffers data until Flush is called", func() {
+		rw.Write([]byte("foobar"))
+		Expect(strBuf.Len()).To(BeZero())
+		rw.Flush()
+		fields := decodeHeader(strBuf)
+		Expect(fields).To(HaveKeyWithValue(":status", []string{"200"}))
+		Expect(getData(strBuf)).To(Equal([]byte("foobar")))
+	})
+
 	It("does not WriteHeader() twice", func() {
 		rw.WriteHeader(200)
 		rw.WriteHeader(500)
@@ -112,10 +124,128 @@ var _ = Describe("Response Writer", func() {
 		Expect(fields).To(HaveKeyWithValue(":status", []string{"200"}))
 	})
 
+	It("sends informational responses before the final response", func() {
+		rw.Header().Add("Link", "</style.css>; rel=preload; as=style")
+		rw.WriteHeader(http.StatusEarlyHints)
+		// interim responses are sent immediately
+		Expect(strBuf.Len()).ToNot(BeZero())
+		rw.WriteHeader(http.StatusEarlyHints)
+		rw.WriteHeader(http.StatusOK)
+		rw.WriteHeader(http.StatusTeapot)
+		for i := 0; i < 2; i++ {
+			fields := decodeHeader(strBuf)
+			Expect(fields).To(HaveKeyWithValue(":status", []string{"103"}))
+			Expect(fields).To(HaveKeyWithValue("link", []string{"</style.css>; rel=preload; as=style"}))
+		}
+		fields := decodeHeader(strBuf)
+		Expect(fields).To(HaveKeyWithValue(":status", []string{"200"}))
+		Expect(strBuf.Len()).To(BeZero())
+	})
+
 	It("doesn't allow writes if the status code doesn't allow a body", func() {
 		rw.WriteHeader(304)
 		n, err := rw.Write([]byte("foobar"))
 		Expect(n).To(BeZero())
 		Expect(err).To(MatchError(http.ErrBodyNotAllowed))
 	})
+
+	Context("hijacking", func() {
+		var str *mockquic.MockStream
+
+		BeforeEach(func() {
+			str = mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(strBuf.Write).AnyTimes()
+			rw = newResponseWriter(str, utils.DefaultLogger)
+			rw.reqStream = str
+		})
+
+		It("returns http.ErrNotSupported if the stream can't be hijacked", func() {
+			rw.reqStream = nil
+			_, err := rw.HijackStream()
+			Expect(err).To(MatchError(http.ErrNotSupported))
+		})
+
+		It("flushes the response header, and frames the data", func() {
+			reqBody := &bytes.Buffer{}
+			(&dataFrame{Length: 6}).Write(reqBody)
+			reqBody.Write([]byte("foobar"))
+			rw.reqBody = newRequestBody(str, time.Time{}, nil)
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(reqBody.Read).AnyTimes()
+
+			rw.Header().Set("Foo", "bar")
+			hijacked, err := rw.HijackStream()
+			Expect(err).ToNot(HaveOccurred())
+			fields := decodeHeader(strBuf)
+			Expect(fields).To(HaveKeyWithValue(":status", []string{"200"}))
+			Expect(fields).To(HaveKeyWithValue("foo", []string{"bar"}))
+			Expect(hijacked.Write([]byte("lorem ipsum"))).To(Equal(11))
+			Expect(getData(strBuf)).To(Equal([]byte("lorem ipsum")))
+			b := make([]byte, 10)
+			n, err := hijacked.Read(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b[:n]).To(Equal([]byte("foobar")))
+		})
+
+		It("doesn't allow using the response writer after hijacking the stream", func() {
+			rw.WriteHeader(http.StatusTeapot)
+			_, err := rw.HijackStream()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decodeHeader(strBuf)).To(HaveKeyWithValue(":status", []string{"418"}))
+			_, err = rw.Write([]byte("foobar"))
+			Expect(err).To(MatchError(http.ErrHijacked))
+			rw.Header().Set(http.TrailerPrefix+"Foo", "bar")
+			rw.writeTrailers()
+			rw.Flush()
+			Expect(strBuf.Len()).To(BeZero())
+			_, err = rw.HijackStream()
+			Expect(err).To(MatchError("http3: stream already hijacked"))
+		})
+	})
+
+	Context("trailers", func() {
+		It("writes declared trailers", func() {
+			rw.Header().Set("Trailer", "Grpc-Status, grpc-message")
+			rw.Header().Set("Grpc-Status", "1")
+			rw.WriteHeader(http.StatusOK)
+			rw.Write([]byte("foobar"))
+			rw.Header().Set("Grpc-Status", "0")
+			rw.Header().Set("Grpc-Message", "OK")
+			rw.writeTrailers()
+			fields := decodeHeader(strBuf)
+			Expect(fields).To(HaveKeyWithValue("trailer", []string{"Grpc-Status, grpc-message"}))
+			Expect(fields).ToNot(HaveKey("grpc-status"))
+			Expect(getData(strBuf)).To(Equal([]byte("foobar")))
+			trailers := decodeHeader(strBuf)
+			Expect(trailers).To(Equal(map[string][]string{
+				"grpc-status":  {"0"},
+				"grpc-message": {"OK"},
+			}))
+		})
+
+		It("writes undeclared trailers set with the TrailerPrefix", func() {
+			rw.WriteHeader(http.StatusOK)
+			rw.Header().Set(http.TrailerPrefix+"Checksum", "deadbeef")
+			rw.writeTrailers()
+			decodeHeader(strBuf)
+			Expect(decodeHeader(strBuf)).To(Equal(map[string][]string{"checksum": {"deadbeef"}}))
+		})
+
+		It("doesn't allow forbidden trailers", func() {
+			rw.Header().Set("Trailer", "Content-Length, Foo")
+			rw.WriteHeader(http.StatusOK)
+			rw.Header().Set("Content-Length", "42")
+			rw.Header().Set("Foo", "bar")
+			rw.writeTrailers()
+			decodeHeader(strBuf)
+			Expect(decodeHeader(strBuf)).To(Equal(map[string][]string{"foo": {"bar"}}))
+		})
+
+		It("doesn't write a HEADERS frame if there are no trailers", func() {
+			rw.Header().Set("Trailer", "Foo")
+			rw.WriteHeader(http.StatusOK)
+			rw.writeTrailers()
+			decodeHeader(strBuf)
+			Expect(strBuf.Len()).To(BeZero())
+		})
+	})
 })
@@ -1,6 +1,7 @@
 package http3
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -17,6 +18,7 @@ import (
 type roundTripCloser interface {
 	http.RoundTripper
 	io.Closer
+	broken() bool
 }
 
 // RoundTripper implements the http.RoundTripper interface
@@ -43,14 +45,26 @@ type RoundTripper struct {
 
 	// Dial specifies an optional dial function for creating QUIC
 	// connections for requests.
-	// If Dial is nil, quic.DialAddr will be used.
+	// If Dial and DialContext are nil, quic.DialAddrEarlyContext will be used.
+	// Dial is ignored if DialContext is set.
 	Dial func(network, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error)
 
+	// DialContext specifies an optional dial function for creating QUIC
+	// connections for requests.
+	// The connection is shared by all requests to the same host, so the context is not the context of any single request.
+	// It is canceled when the RoundTripper is closed.
+	DialContext func(ctx context.Context, network, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error)
+
 	// MaxResponseHeaderBytes specifies a limit on how many response bytes are
 	// allowed in the server's response header.
 	// Zero means to use a default limit.
 	MaxResponseHeaderBytes int64
 
+	// OnRequestDone is an optional callback that is called when a request is done,
+	// i.e. when the response body was completely read or closed, or when the request failed.
+	// It is only called for requests that were sent on a QUIC stream.
+	OnRequestDone func(*RequestMetrics)
+
 	clients map[string]roundTripCloser
 }
 
@@ -63,7 +77,10 @@ type RoundTripOpt struct {
 	OnlyCachedConn bool
 }
 
-var _ roundTripCloser = &RoundTripper{}
+var (
+	_ http.RoundTripper = &RoundTripper{}
+	_ io.Closer         = &RoundTripper{}
+)
 
 // ErrNoCachedConn is returned when RoundTripper.OnlyCachedConn is set
 var ErrNoCachedConn = errors.New("http3: no cached connection was available")
@@ -126,6 +143,12 @@ func (r *RoundTripper) getClient(hostname string, onlyCached bool) (http.RoundTr
 	}
 
 	client, ok := r.clients[hostname]
+	if ok && client.broken() {
+		// The session can't be used any more. Dial a new one.
+		client.Close()
+		delete(r.clients, hostname)
+		ok = false
+	}
 	if !ok {
 		if onlyCached {
 			return nil, ErrNoCachedConn
@@ -137,9 +160,10 @@ func (r *RoundTripper) getClient(hostname string, onlyCached bool) (http.RoundTr
 			&roundTripperOpts{
 				DisableCompression: r.DisableCompression,
 				MaxHeaderBytes:     r.MaxResponseHeaderBytes,
+				OnRequestDone:      r.OnRequestDone,
 			},
 			r.QuicConfig,
-			r.Dial,
+			r.dialer(),
 		)
 		if err != nil {
 			return nil, err
@@ -149,6 +173,18 @@ func (r *RoundTripper) getClient(hostname string, onlyCached bool) (http.RoundTr
 	return client, nil
 }
 
+func (r *RoundTripper) dialer() func(ctx context.Context, network, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error) {
+	if r.DialContext != nil {
+		return r.DialContext
+	}
+	if r.Dial == nil {
+		return nil
+	}
+	return func(_ context.Context, network, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error) {
+		return r.Dial(network, addr, tlsCfg, cfg)
+	}
+}
+
 // Close closes the QUIC connections that this RoundTripper has used
 func (r *RoundTripper) Close() error {
 	r.mutex.Lock()
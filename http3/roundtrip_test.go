@@ -17,7 +17,8 @@ import (
 )
 
 type mockClient struct {
-	closed bool
+	closed   bool
+	isBroken bool
 }
 
 func (m *mockClient) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -29,6 +30,10 @@ func (m *mockClient) Close() error {
 	return nil
 }
 
+func (m *mockClient) broken() bool {
+	return m.isBroken
+}
+
 var _ roundTripCloser = &mockClient{}
 
 type mockBody struct {
@@ -82,7 +87,7 @@ var _ = Describe("RoundTripper", func() {
 		BeforeEach(func() {
 			session = mockquic.NewMockEarlySession(mockCtrl)
 			origDialAddr = dialAddr
-			dialAddr = func(addr string, tlsConf *tls.Config, config *quic.Config) (quic.EarlySession, error) {
+			dialAddr = func(_ context.Context, addr string, tlsConf *tls.Config, config *quic.Config) (quic.EarlySession, error) {
 				// return an error when trying to open a stream
 				// we don't want to test all the dial logic here, just that dialing happens at all
 				return session, nil
@@ -111,7 +116,7 @@ var _ = Describe("RoundTripper", func() {
 		It("uses the quic.Config, if provided", func() {
 			config := &quic.Config{HandshakeTimeout: time.Millisecond}
 			var receivedConfig *quic.Config
-			dialAddr = func(addr string, tlsConf *tls.Config, config *quic.Config) (quic.EarlySession, error) {
+			dialAddr = func(_ context.Context, addr string, tlsConf *tls.Config, config *quic.Config) (quic.EarlySession, error) {
 				receivedConfig = config
 				return nil, errors.New("handshake error")
 			}
@@ -133,13 +138,31 @@ var _ = Describe("RoundTripper", func() {
 			Expect(dialed).To(BeTrue())
 		})
 
+		It("uses the custom context dialer, if provided", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var dialCtx context.Context
+			rt.Dial = func(_, _ string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+				Fail("Dial should not be called")
+				return nil, nil
+			}
+			rt.DialContext = func(ctx context.Context, _, _ string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+				dialCtx = ctx
+				return nil, errors.New("handshake error")
+			}
+			_, err := rt.RoundTrip(req1.WithContext(ctx))
+			Expect(err).To(MatchError("handshake error"))
+			Expect(dialCtx).To(Equal(ctx))
+		})
+
 		It("reuses existing clients", func() {
-			closed := make(chan struct{})
 			testErr := errors.New("test err")
-			session.EXPECT().OpenUniStream().AnyTimes().Return(nil, testErr)
+			controlStr := mockquic.NewMockStream(mockCtrl)
+			controlStr.EXPECT().Write(gomock.Any()).MaxTimes(1)
+			session.EXPECT().OpenUniStream().Return(controlStr, nil).MaxTimes(1)
+			session.EXPECT().Context().Return(context.Background()).MaxTimes(1)
 			session.EXPECT().HandshakeComplete().Return(handshakeCtx).Times(2)
 			session.EXPECT().OpenStreamSync(context.Background()).Return(nil, testErr).Times(2)
-			session.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(quic.ErrorCode, string) { close(closed) })
 			req, err := http.NewRequest("GET", "https://quic.clemente.io/file1.html", nil)
 			Expect(err).ToNot(HaveOccurred())
 			_, err = rt.RoundTrip(req)
@@ -150,7 +173,55 @@ var _ = Describe("RoundTripper", func() {
 			_, err = rt.RoundTrip(req2)
 			Expect(err).To(MatchError(testErr))
 			Expect(rt.clients).To(HaveLen(1))
+		})
+
+		It("dials a new session if the session was closed", func() {
+			closed := make(chan struct{})
+			testErr := errors.New("test err")
+			var dialCount int
+			dialAddr = func(_ context.Context, addr string, tlsConf *tls.Config, config *quic.Config) (quic.EarlySession, error) {
+				dialCount++
+				if dialCount > 1 {
+					return nil, testErr
+				}
+				return session, nil
+			}
+			sessCtx, cancel := context.WithCancel(context.Background())
+			controlStr := mockquic.NewMockStream(mockCtrl)
+			controlStr.EXPECT().Write(gomock.Any())
+			session.EXPECT().OpenUniStream().Return(controlStr, nil)
+			session.EXPECT().Context().DoAndReturn(func() context.Context {
+				defer close(closed)
+				return sessCtx
+			})
+			session.EXPECT().HandshakeComplete().Return(handshakeCtx)
+			session.EXPECT().OpenStreamSync(context.Background()).Return(nil, testErr)
+			req, err := http.NewRequest("GET", "https://quic.clemente.io/file1.html", nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = rt.RoundTrip(req)
+			Expect(err).To(MatchError(testErr))
 			Eventually(closed).Should(BeClosed())
+			cl := rt.clients["quic.clemente.io:443"]
+			Expect(cl.broken()).To(BeFalse())
+			cancel()
+			Eventually(cl.broken).Should(BeTrue())
+			session.EXPECT().CloseWithError(gomock.Any(), gomock.Any())
+			_, err = rt.RoundTrip(req)
+			Expect(err).To(MatchError(testErr))
+			Expect(dialCount).To(Equal(2))
+			Expect(rt.clients).To(HaveLen(1))
+			Expect(rt.clients["quic.clemente.io:443"]).ToNot(Equal(cl))
+		})
+
+		It("doesn't use broken clients", func() {
+			cl := &mockClient{isBroken: true}
+			rt.clients = map[string]roundTripCloser{"quic.clemente.io:443": cl}
+			req, err := http.NewRequest("GET", "https://quic.clemente.io/foobar.html", nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = rt.RoundTripOpt(req, RoundTripOpt{OnlyCachedConn: true})
+			Expect(err).To(MatchError(ErrNoCachedConn))
+			Expect(cl.closed).To(BeTrue())
+			Expect(rt.clients).To(BeEmpty())
 		})
 
 		It("doesn't create new clients if RoundTripOpt.OnlyCachedConn is set", func() {
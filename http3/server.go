@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"runtime"
@@ -17,8 +18,10 @@ import (
 
 	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
+	"github.com/lucas-clemente/quic-go/internal/logutils"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
 	"github.com/marten-seemann/qpack"
 )
 
@@ -33,6 +36,13 @@ const (
 	nextProtoH3Draft32 = "h3-32"
 )
 
+const (
+	streamTypeControlStream      = 0
+	streamTypePushStream         = 1
+	streamTypeQPACKEncoderStream = 2
+	streamTypeQPACKDecoderStream = 3
+)
+
 func versionToALPN(v protocol.VersionNumber) string {
 	if v == protocol.VersionTLS || v == protocol.VersionDraft29 {
 		return nextProtoH3Draft29
@@ -57,6 +67,19 @@ func (k *contextKey) String() string { return "quic-go/http3 context value " + k
 // type *http3.Server.
 var ServerContextKey = &contextKey{"http3-server"}
 
+// SessionContextKey is a context key. It can be used in HTTP
+// handlers with Context.Value to access the QUIC session
+// that the request was received on. The associated value
+// will be of type quic.Session.
+var SessionContextKey = &contextKey{"http3-session"}
+
+// StreamContextKey is a context key. It can be used in HTTP
+// handlers with Context.Value to access the QUIC stream
+// that the request was received on. The associated value
+// will be of type quic.Stream.
+// It is not set for pushed requests.
+var StreamContextKey = &contextKey{"http3-stream"}
+
 type requestError struct {
 	err       error
 	streamErr errorCode
@@ -72,17 +95,26 @@ func newConnError(code errorCode, err error) requestError {
 }
 
 // Server is a HTTP2 server listening for QUIC connections.
+// The ReadHeaderTimeout and ReadTimeout of the http.Server are applied to every request stream,
+// starting when the stream is opened. If they expire while the request is being read,
+// the stream is reset with an H3_REQUEST_INCOMPLETE error, and reading the request body returns a timeout error.
 type Server struct {
 	*http.Server
 
 	// By providing a quic.Config, it is possible to set parameters of the QUIC connection.
 	// If nil, it uses reasonable default values.
+	// The time a client has to complete the handshake is limited by the HandshakeTimeout.
 	QuicConfig *quic.Config
 
+	// OnRequestDone is an optional callback that is called after a request was handled.
+	// It is called concurrently for requests on different streams.
+	OnRequestDone func(*RequestMetrics)
+
 	port uint32 // used atomically
 
 	mutex     sync.Mutex
 	listeners map[*quic.EarlyListener]struct{}
+	conns     map[*serverConn]struct{}
 	closed    utils.AtomicBool
 
 	loggerOnce sync.Once
@@ -128,7 +160,11 @@ func (s *Server) serveImpl(tlsConf *tls.Config, conn net.PacketConn) error {
 		return errors.New("use of http3.Server without http.Server")
 	}
 	s.loggerOnce.Do(func() {
-		s.logger = utils.DefaultLogger.WithPrefix("server")
+		var logger logging.Logger
+		if s.QuicConfig != nil {
+			logger = s.QuicConfig.Logger
+		}
+		s.logger = logutils.NewLogger(logger).WithPrefix("server")
 	})
 
 	// The tls.Config we pass to Listen needs to have the GetConfigForClient callback set.
@@ -195,8 +231,27 @@ func (s *Server) removeListener(l *quic.EarlyListener) {
 	s.mutex.Unlock()
 }
 
+func (s *Server) addConn(c *serverConn) {
+	s.mutex.Lock()
+	if s.conns == nil {
+		s.conns = make(map[*serverConn]struct{})
+	}
+	s.conns[c] = struct{}{}
+	shuttingDown := s.closed.Get()
+	s.mutex.Unlock()
+	// Shutdown was called while this connection was being set up.
+	if shuttingDown {
+		c.goAway()
+	}
+}
+
+func (s *Server) removeConn(c *serverConn) {
+	s.mutex.Lock()
+	delete(s.conns, c)
+	s.mutex.Unlock()
+}
+
 func (s *Server) handleConn(sess quic.EarlySession) {
-	// TODO: accept control streams
 	decoder := qpack.NewDecoder(nil)
 
 	// send a SETTINGS frame
@@ -205,10 +260,17 @@ func (s *Server) handleConn(sess quic.EarlySession) {
 		s.logger.Debugf("Opening the control stream failed.")
 		return
 	}
-	buf := bytes.NewBuffer([]byte{0})
-	(&settingsFrame{}).Write(buf)
+	buf := bytes.NewBuffer([]byte{streamTypeControlStream})
+	(&settingsFrame{settings: map[uint64]uint64{settingMaxFieldSectionSize: s.maxHeaderBytes()}}).Write(buf)
 	str.Write(buf.Bytes())
 
+	conn := &serverConn{controlStr: str}
+	s.addConn(conn)
+	defer s.removeConn(conn)
+	pm := &pushManager{conn: conn}
+
+	go s.handleUnidirectionalStreams(sess, pm)
+
 	// Process all requests immediately.
 	// It's the client's responsibility to decide which requests are eligible for 0-RTT.
 	for {
@@ -217,12 +279,27 @@ func (s *Server) handleConn(sess quic.EarlySession) {
 			s.logger.Debugf("Accepting stream failed: %s", err)
 			return
 		}
+		if !conn.acceptRequest(str.StreamID()) {
+			// The client opened this stream after we sent the GOAWAY frame.
+			str.CancelRead(quic.ErrorCode(errorRequestRejected))
+			str.CancelWrite(quic.ErrorCode(errorRequestRejected))
+			continue
+		}
+		metrics := &RequestMetrics{StreamID: str.StreamID(), StreamOpened: time.Now()}
 		go func() {
-			rerr := s.handleRequest(sess, str, decoder, func() {
+			defer conn.requestDone()
+			rerr := s.handleRequest(sess, str, pm, decoder, metrics, func() {
 				sess.CloseWithError(quic.ErrorCode(errorFrameUnexpected), "")
 			})
+			if s.OnRequestDone != nil {
+				defer s.OnRequestDone(metrics)
+			}
+			if rerr.err == errHijacked {
+				return
+			}
 			if rerr.err != nil || rerr.streamErr != 0 || rerr.connErr != 0 {
-				s.logger.Debugf("Handling request failed: %s", err)
+				metrics.setError(rerr)
+				s.logger.Debugf("Handling request failed: %s", rerr.err)
 				if rerr.streamErr != 0 {
 					str.CancelWrite(quic.ErrorCode(rerr.streamErr))
 				}
@@ -240,6 +317,88 @@ func (s *Server) handleConn(sess quic.EarlySession) {
 	}
 }
 
+func (s *Server) handleUnidirectionalStreams(sess quic.EarlySession, pm *pushManager) {
+	var rcvdControlStream int32
+	for {
+		str, err := sess.AcceptUniStream(context.Background())
+		if err != nil {
+			s.logger.Debugf("Accepting unidirectional stream failed: %s", err)
+			return
+		}
+		go func(str quic.ReceiveStream) {
+			streamType, err := utils.ReadVarInt(&byteReaderImpl{str})
+			if err != nil {
+				s.logger.Debugf("Reading the stream type on stream %d failed: %s", str.StreamID(), err)
+				return
+			}
+			switch streamType {
+			case streamTypeControlStream:
+				// only one control stream is allowed
+				if !atomic.CompareAndSwapInt32(&rcvdControlStream, 0, 1) {
+					sess.CloseWithError(quic.ErrorCode(errorStreamCreationError), "duplicate control stream")
+					return
+				}
+				s.handleControlStream(sess, str, pm)
+			case streamTypeQPACKEncoderStream, streamTypeQPACKDecoderStream:
+				// We don't use the QPACK dynamic table, so there are no instructions we need to process.
+				// These streams must not be closed, so just discard the data until the session is closed.
+				io.Copy(ioutil.Discard, str)
+			case streamTypePushStream:
+				// only servers can push
+				sess.CloseWithError(quic.ErrorCode(errorStreamCreationError), "")
+			default:
+				str.CancelRead(quic.ErrorCode(errorStreamCreationError))
+			}
+		}(str)
+	}
+}
+
+func (s *Server) handleControlStream(sess quic.EarlySession, str quic.ReceiveStream, pm *pushManager) {
+	f, err := parseNextFrame(str)
+	if err != nil {
+		sess.CloseWithError(quic.ErrorCode(errorClosedCriticalStream), "")
+		return
+	}
+	if _, ok := f.(*settingsFrame); !ok {
+		sess.CloseWithError(quic.ErrorCode(errorMissingSettings), "")
+		return
+	}
+	for {
+		f, err := parseNextFrame(str)
+		if err != nil {
+			sess.CloseWithError(quic.ErrorCode(errorClosedCriticalStream), "")
+			return
+		}
+		switch f := f.(type) {
+		case *goAwayFrame:
+			// The client doesn't want to receive any more pushes.
+			// We don't push anything after the handler of a request returned, so there's nothing to do.
+		case *maxPushIDFrame:
+			if err := pm.SetMaxPushID(f.PushID); err != nil {
+				sess.CloseWithError(quic.ErrorCode(errorIDError), err.Error())
+				return
+			}
+		default:
+			sess.CloseWithError(quic.ErrorCode(errorFrameUnexpected), fmt.Sprintf("unexpected frame on the control stream: %T", f))
+			return
+		}
+	}
+}
+
+// rejectHeaders is called when the request headers exceed the MaxHeaderBytes.
+// It stops reading the request with an H3_ID_ERROR,
+// and responds with a 431 (Request Header Fields Too Large) status.
+func (s *Server) rejectHeaders(str quic.Stream, metrics *RequestMetrics, err error) requestError {
+	str.CancelRead(quic.ErrorCode(errorIDError))
+	rw := newResponseWriter(str, s.logger)
+	rw.WriteHeader(http.StatusRequestHeaderFieldsTooLarge)
+	rw.Flush()
+	str.Close()
+	metrics.StatusCode = http.StatusRequestHeaderFieldsTooLarge
+	metrics.ErrorCode = quic.ErrorCode(errorIDError)
+	return requestError{err: err}
+}
+
 func (s *Server) maxHeaderBytes() uint64 {
 	if s.Server.MaxHeaderBytes <= 0 {
 		return http.DefaultMaxHeaderBytes
@@ -247,9 +406,25 @@ func (s *Server) maxHeaderBytes() uint64 {
 	return uint64(s.Server.MaxHeaderBytes)
 }
 
-func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpack.Decoder, onFrameError func()) requestError {
+// readHeaderTimeout returns the time a client has to send the request headers.
+// As for HTTP/1.1 and HTTP/2, it defaults to the ReadTimeout.
+func (s *Server) readHeaderTimeout() time.Duration {
+	if s.Server.ReadHeaderTimeout > 0 {
+		return s.Server.ReadHeaderTimeout
+	}
+	return s.Server.ReadTimeout
+}
+
+func (s *Server) handleRequest(sess quic.Session, str quic.Stream, pm *pushManager, decoder *qpack.Decoder, metrics *RequestMetrics, onFrameError func()) requestError {
+	if timeout := s.readHeaderTimeout(); timeout > 0 {
+		str.SetReadDeadline(metrics.StreamOpened.Add(timeout))
+	}
 	frame, err := parseNextFrame(str)
 	if err != nil {
+		if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+			// The client didn't send the request headers in time.
+			str.CancelRead(quic.ErrorCode(errorRequestIncomplete))
+		}
 		return newStreamError(errorRequestIncomplete, err)
 	}
 	hf, ok := frame.(*headersFrame)
@@ -257,17 +432,33 @@ func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpac
 		return newConnError(errorFrameUnexpected, errors.New("expected first frame to be a HEADERS frame"))
 	}
 	if hf.Length > s.maxHeaderBytes() {
-		return newStreamError(errorFrameError, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, s.maxHeaderBytes()))
+		return s.rejectHeaders(str, metrics, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, s.maxHeaderBytes()))
 	}
 	headerBlock := make([]byte, hf.Length)
 	if _, err := io.ReadFull(str, headerBlock); err != nil {
+		if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+			// The client didn't send the request headers in time.
+			str.CancelRead(quic.ErrorCode(errorRequestIncomplete))
+		}
 		return newStreamError(errorRequestIncomplete, err)
 	}
+	// The ReadTimeout applies to the whole request, including the body.
+	var readDeadline time.Time
+	if s.Server.ReadTimeout > 0 {
+		readDeadline = metrics.StreamOpened.Add(s.Server.ReadTimeout)
+	}
+	if s.readHeaderTimeout() > 0 {
+		str.SetReadDeadline(readDeadline)
+	}
 	hfs, err := decoder.DecodeFull(headerBlock)
 	if err != nil {
 		// TODO: use the right error code
 		return newConnError(errorGeneralProtocolError, err)
 	}
+	if size := headerListSize(hfs); size > s.maxHeaderBytes() {
+		return s.rejectHeaders(str, metrics, fmt.Errorf("header list too large: %d bytes (max: %d)", size, s.maxHeaderBytes()))
+	}
+	metrics.HeadersDecoded = time.Now()
 	req, err := requestFromHeaders(hfs)
 	if err != nil {
 		// TODO: use the right error code
@@ -275,7 +466,6 @@ func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpac
 	}
 
 	req.RemoteAddr = sess.RemoteAddr().String()
-	req.Body = newRequestBody(str, onFrameError)
 
 	if s.logger.Debug() {
 		s.logger.Infof("%s %s%s, on stream %d", req.Method, req.Host, req.RequestURI, str.StreamID())
@@ -283,12 +473,44 @@ func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpac
 		s.logger.Infof("%s %s%s", req.Method, req.Host, req.RequestURI)
 	}
 
-	ctx := str.Context()
-	ctx = context.WithValue(ctx, ServerContextKey, s)
-	ctx = context.WithValue(ctx, http.LocalAddrContextKey, sess.LocalAddr())
-	req = req.WithContext(ctx)
+	req = req.WithContext(context.WithValue(s.requestContext(str.Context(), sess), StreamContextKey, str))
+	// Set the body after copying the request, so that trailers are parsed into req.Trailer of this request.
+	req.Trailer = declaredTrailers(req.Header)
+	body := newRequestBody(str, readDeadline, onFrameError)
+	body.parseTrailersInto(&req.Trailer, decoder, s.maxHeaderBytes())
+	req.Body = body
+	metrics.Request = req
 	responseWriter := newResponseWriter(str, s.logger)
-	defer responseWriter.Flush()
+	responseWriter.reqStream = str
+	responseWriter.reqBody = body
+	if pm != nil {
+		responseWriter.pusher = &pusher{server: s, sess: sess, manager: pm, req: req}
+	}
+	s.serveHTTP(responseWriter, req)
+	responseWriter.Flush()
+	metrics.FirstByte = responseWriter.firstByte
+	metrics.StatusCode = responseWriter.status
+	metrics.BodyDone = time.Now()
+	if responseWriter.hijacked {
+		// The handler is responsible for the stream now.
+		return requestError{err: errHijacked}
+	}
+
+	// If the EOF was read by the handler, CancelRead() is a no-op.
+	str.CancelRead(quic.ErrorCode(errorNoError))
+	return requestError{}
+}
+
+func (s *Server) requestContext(ctx context.Context, sess quic.Session) context.Context {
+	ctx = context.WithValue(ctx, ServerContextKey, s)
+	ctx = context.WithValue(ctx, SessionContextKey, sess)
+	return context.WithValue(ctx, http.LocalAddrContextKey, sess.LocalAddr())
+}
+
+// serveHTTP calls the handler, and writes the response header if the handler didn't do so,
+// followed by the trailers.
+// If the handler panics, a 500 status code is sent.
+func (s *Server) serveHTTP(w *responseWriter, req *http.Request) {
 	handler := s.Handler
 	if handler == nil {
 		handler = http.DefaultServeMux
@@ -306,18 +528,15 @@ func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpac
 				panicked = true
 			}
 		}()
-		handler.ServeHTTP(responseWriter, req)
+		handler.ServeHTTP(w, req)
 	}()
 
 	if panicked {
-		responseWriter.WriteHeader(500)
-	} else {
-		responseWriter.WriteHeader(200)
+		w.WriteHeader(500)
+		return
 	}
-
-	// If the EOF was read by the handler, CancelRead() is a no-op.
-	str.CancelRead(quic.ErrorCode(errorNoError))
-	return requestError{}
+	w.WriteHeader(200)
+	w.writeTrailers()
 }
 
 // Close the server immediately, aborting requests and sending CONNECTION_CLOSE frames to connected clients.
@@ -340,13 +559,56 @@ func (s *Server) Close() error {
 // CloseGracefully shuts down the server gracefully. The server sends a GOAWAY frame first, then waits for either timeout to trigger, or for all running requests to complete.
 // CloseGracefully in combination with ListenAndServe() (instead of Serve()) may race if it is called before a UDP socket is established.
 func (s *Server) CloseGracefully(timeout time.Duration) error {
-	// TODO: implement
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+// Shutdown shuts down the server gracefully, without interrupting any active requests.
+// It sends a GOAWAY frame on all connections. Requests on streams opened after that are rejected.
+// Once all running requests have completed, or when the context expires, it closes the server.
+// If the context expires first, Shutdown returns the context's error.
+// Shutdown in combination with ListenAndServe() (instead of Serve()) may race if it is called before a UDP socket is established.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.closed.Set(true)
+
+	s.mutex.Lock()
+	conns := make([]*serverConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mutex.Unlock()
+
+	var err error
+	if len(conns) > 0 {
+		done := make(chan struct{})
+		go func() {
+			for _, c := range conns {
+				c.goAway()
+			}
+			for _, c := range conns {
+				c.requests.Wait()
+			}
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if cerr := s.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
 // SetQuicHeaders can be used to set the proper headers that announce that this server supports QUIC.
-// The values that are set depend on the port information from s.Server.Addr, and currently look like this (if Addr has port 443):
-//  Alt-Svc: quic=":443"; ma=2592000; v="33,32,31,30"
+// The values that are set depend on the port information from s.Server.Addr,
+// and on the QUIC versions configured in the quic.Config.
+// They currently look like this (if Addr has port 443):
+//  Alt-Svc: h3-29=":443"; ma=2592000
 func (s *Server) SetQuicHeaders(hdr http.Header) error {
 	port := atomic.LoadUint32(&s.port)
 
@@ -376,10 +638,23 @@ func (s *Server) SetQuicHeaders(hdr http.Header) error {
 			altSvc = append(altSvc, fmt.Sprintf(`%s=":%d"; ma=2592000`, v, port))
 		}
 	}
+	if len(altSvc) == 0 {
+		return nil
+	}
 	hdr.Add("Alt-Svc", strings.Join(altSvc, ","))
 	return nil
 }
 
+// QuicHeadersHandler wraps a handler, such that the Alt-Svc header announcing this server is set
+// on every response, see SetQuicHeaders.
+// It can be used for the HTTP/1.1 and HTTP/2 server that clients use to discover the QUIC server.
+func (s *Server) QuicHeadersHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.SetQuicHeaders(w.Header())
+		handler.ServeHTTP(w, r)
+	})
+}
+
 // ListenAndServeQUIC listens on the UDP network address addr and calls the
 // handler for HTTP/3 requests on incoming connections. http.DefaultServeMux is
 // used when handler is nil.
@@ -448,10 +723,7 @@ func ListenAndServe(addr, certFile, keyFile string, handler http.Handler) error
 	if handler == nil {
 		handler = http.DefaultServeMux
 	}
-	httpServer.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		quicServer.SetQuicHeaders(w.Header())
-		handler.ServeHTTP(w, r)
-	})
+	httpServer.Handler = quicServer.QuicHeadersHandler(handler)
 
 	hErr := make(chan error)
 	qErr := make(chan error)
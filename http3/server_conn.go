@@ -0,0 +1,63 @@
+package http3
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+// A serverConn keeps track of the requests on a connection,
+// so that the server can shut down gracefully.
+type serverConn struct {
+	controlStr quic.SendStream
+
+	mutex        sync.Mutex
+	goingAway    bool
+	nextStreamID quic.StreamID // the stream ID following the last request stream that was accepted
+	requests     sync.WaitGroup
+}
+
+// acceptRequest registers a new request.
+// It returns false if the request stream was opened after the GOAWAY frame was sent.
+// For every accepted request, requestDone must be called once the request completed.
+func (c *serverConn) acceptRequest(id quic.StreamID) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.goingAway {
+		return false
+	}
+	if id >= c.nextStreamID {
+		c.nextStreamID = id + 4
+	}
+	c.requests.Add(1)
+	return true
+}
+
+// pushStarted registers a pushed request, so that a graceful shutdown waits for it to complete.
+// For every pushed request, requestDone must be called once the request completed.
+func (c *serverConn) pushStarted() {
+	c.requests.Add(1)
+}
+
+func (c *serverConn) requestDone() {
+	c.requests.Done()
+}
+
+// goAway sends a GOAWAY frame on the control stream.
+// All requests on streams that the client opens afterwards are rejected.
+func (c *serverConn) goAway() {
+	c.mutex.Lock()
+	if c.goingAway {
+		c.mutex.Unlock()
+		return
+	}
+	c.goingAway = true
+	id := c.nextStreamID
+	c.mutex.Unlock()
+
+	buf := &bytes.Buffer{}
+	(&goAwayFrame{ID: uint64(id)}).Write(buf)
+	c.controlStr.Write(buf.Bytes())
+}
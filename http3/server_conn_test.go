@@ -0,0 +1,69 @@
+package http3
+
+import (
+	"bytes"
+
+	"github.com/golang/mock/gomock"
+	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server Connection", func() {
+	var (
+		conn       *serverConn
+		controlBuf *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		controlBuf = &bytes.Buffer{}
+		controlStr := mockquic.NewMockStream(mockCtrl)
+		controlStr.EXPECT().Write(gomock.Any()).DoAndReturn(controlBuf.Write).AnyTimes()
+		conn = &serverConn{controlStr: controlStr}
+	})
+
+	It("sends a GOAWAY frame with the stream ID following the last accepted request", func() {
+		Expect(conn.acceptRequest(0)).To(BeTrue())
+		Expect(conn.acceptRequest(4)).To(BeTrue())
+		conn.goAway()
+		frame, err := parseNextFrame(controlBuf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(&goAwayFrame{ID: 8}))
+	})
+
+	It("sends a GOAWAY frame if no requests were accepted", func() {
+		conn.goAway()
+		frame, err := parseNextFrame(controlBuf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(&goAwayFrame{ID: 0}))
+	})
+
+	It("only sends a single GOAWAY frame", func() {
+		conn.goAway()
+		conn.goAway()
+		_, err := parseNextFrame(controlBuf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(controlBuf.Len()).To(BeZero())
+	})
+
+	It("rejects requests after sending the GOAWAY frame", func() {
+		Expect(conn.acceptRequest(0)).To(BeTrue())
+		conn.goAway()
+		Expect(conn.acceptRequest(4)).To(BeFalse())
+	})
+
+	It("keeps track of running requests", func() {
+		Expect(conn.acceptRequest(0)).To(BeTrue())
+		Expect(conn.acceptRequest(4)).To(BeTrue())
+		done := make(chan struct{})
+		go func() {
+			conn.requests.Wait()
+			close(done)
+		}()
+		conn.requestDone()
+		Consistently(done).ShouldNot(BeClosed())
+		conn.requestDone()
+		Eventually(done).Should(BeClosed())
+	})
+})
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"time"
 
 	"github.com/golang/mock/gomock"
@@ -137,12 +138,14 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			Expect(s.handleRequest(sess, str, qpackDecoder, nil)).To(Equal(requestError{}))
+			Expect(s.handleRequest(sess, str, nil, qpackDecoder, &RequestMetrics{}, nil)).To(Equal(requestError{}))
 			var req *http.Request
 			Eventually(requestChan).Should(Receive(&req))
 			Expect(req.Host).To(Equal("www.example.com"))
 			Expect(req.RemoteAddr).To(Equal("127.0.0.1:1337"))
 			Expect(req.Context().Value(ServerContextKey)).To(Equal(s))
+			Expect(req.Context().Value(SessionContextKey)).To(Equal(sess))
+			Expect(req.Context().Value(StreamContextKey)).To(Equal(str))
 		})
 
 		It("returns 200 with an empty handler", func() {
@@ -156,12 +159,38 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(sess, str, nil, qpackDecoder, &RequestMetrics{}, nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
 		})
 
+		It("sends the trailers after the handler returns", func() {
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", "Grpc-Status")
+				w.Write([]byte("foobar"))
+				w.Header().Set("Grpc-Status", "0")
+			})
+
+			responseBuf := &bytes.Buffer{}
+			setRequest(encodeRequest(exampleGetRequest))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				return responseBuf.Write(p)
+			}).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+
+			serr := s.handleRequest(sess, str, nil, qpackDecoder, &RequestMetrics{}, nil)
+			Expect(serr.err).ToNot(HaveOccurred())
+			hfs := decodeHeader(responseBuf)
+			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
+			frame, err := parseNextFrame(responseBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&dataFrame{Length: 6}))
+			Expect(responseBuf.Next(6)).To(Equal([]byte("foobar")))
+			Expect(decodeHeader(responseBuf)).To(Equal(map[string][]string{"grpc-status": {"0"}}))
+		})
+
 		It("handles a panicking handler", func() {
 			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				panic("foobar")
@@ -175,7 +204,7 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(sess, str, nil, qpackDecoder, &RequestMetrics{}, nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"500"}))
@@ -190,6 +219,8 @@ var _ = Describe("Server", func() {
 				controlStr := mockquic.NewMockStream(mockCtrl)
 				controlStr.EXPECT().Write(gomock.Any())
 				sess.EXPECT().OpenUniStream().Return(controlStr, nil)
+				sess.EXPECT().AcceptUniStream(gomock.Any()).Return(nil, errors.New("done")).MaxTimes(1)
+				str.EXPECT().StreamID().AnyTimes()
 				sess.EXPECT().AcceptStream(gomock.Any()).Return(str, nil)
 				sess.EXPECT().AcceptStream(gomock.Any()).Return(nil, errors.New("done"))
 				sess.EXPECT().RemoteAddr().Return(addr).AnyTimes()
@@ -222,7 +253,7 @@ var _ = Describe("Server", func() {
 				Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
 			})
 
-			It("errors when the client sends a too large header frame", func() {
+			It("responds with 431 when the client sends a too large header frame", func() {
 				s.Server.MaxHeaderBytes = 20
 				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 					Fail("Handler should not be called.")
@@ -238,10 +269,114 @@ var _ = Describe("Server", func() {
 				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
 					return responseBuf.Write(p)
 				}).AnyTimes()
-				str.EXPECT().CancelWrite(quic.ErrorCode(errorFrameError)).Do(func(quic.ErrorCode) { close(done) })
+				str.EXPECT().CancelRead(quic.ErrorCode(errorIDError))
+				str.EXPECT().Close().Do(func() { close(done) })
+
+				s.handleConn(sess)
+				Eventually(done).Should(BeClosed())
+				hfs := decodeHeader(responseBuf)
+				Expect(hfs).To(HaveKeyWithValue(":status", []string{"431"}))
+			})
+
+			It("responds with 431 when the decoded header list is too large", func() {
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					Fail("Handler should not be called.")
+				})
+
+				requestData := encodeRequest(exampleGetRequest)
+				// the HEADERS frame fits, but the uncompressed header list doesn't
+				s.Server.MaxHeaderBytes = len(requestData)
+				setRequest(requestData)
+				done := make(chan struct{})
+				responseBuf := &bytes.Buffer{}
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					return responseBuf.Write(p)
+				}).AnyTimes()
+				str.EXPECT().CancelRead(quic.ErrorCode(errorIDError))
+				str.EXPECT().Close().Do(func() { close(done) })
 
 				s.handleConn(sess)
 				Eventually(done).Should(BeClosed())
+				hfs := decodeHeader(responseBuf)
+				Expect(hfs).To(HaveKeyWithValue(":status", []string{"431"}))
+			})
+
+			It("reports metrics for handled requests", func() {
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusTeapot)
+				})
+				metricsChan := make(chan *RequestMetrics, 1)
+				s.OnRequestDone = func(m *RequestMetrics) { metricsChan <- m }
+
+				setRequest(encodeRequest(exampleGetRequest))
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					return len(p), nil
+				}).AnyTimes()
+				str.EXPECT().CancelRead(gomock.Any())
+				str.EXPECT().Close()
+
+				s.handleConn(sess)
+				var m *RequestMetrics
+				Eventually(metricsChan).Should(Receive(&m))
+				Expect(m.Request.Host).To(Equal("www.example.com"))
+				Expect(m.StatusCode).To(Equal(http.StatusTeapot))
+				Expect(m.ErrorCode).To(BeZero())
+				Expect(m.Err).ToNot(HaveOccurred())
+				Expect(m.StreamOpened).ToNot(BeZero())
+				Expect(m.HeadersDecoded).To(BeTemporally(">=", m.StreamOpened))
+				Expect(m.FirstByte).To(BeTemporally(">=", m.HeadersDecoded))
+				Expect(m.BodyDone).To(BeTemporally(">=", m.FirstByte))
+			})
+
+			It("doesn't close hijacked streams", func() {
+				hijacked := make(chan quic.Stream, 1)
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+					str, err := w.(Hijacker).HijackStream()
+					Expect(err).ToNot(HaveOccurred())
+					hijacked <- str
+				})
+				metricsChan := make(chan *RequestMetrics, 1)
+				s.OnRequestDone = func(m *RequestMetrics) { metricsChan <- m }
+
+				responseBuf := &bytes.Buffer{}
+				setRequest(encodeRequest(exampleGetRequest))
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+
+				s.handleConn(sess)
+				var hijackedStr quic.Stream
+				Eventually(hijacked).Should(Receive(&hijackedStr))
+				var m *RequestMetrics
+				Eventually(metricsChan).Should(Receive(&m))
+				Expect(m.Err).ToNot(HaveOccurred())
+				Expect(m.StatusCode).To(Equal(200))
+				Expect(decodeHeader(responseBuf)).To(HaveKeyWithValue(":status", []string{"200"}))
+				Expect(responseBuf.Len()).To(BeZero())
+				str.EXPECT().Close()
+				Expect(hijackedStr.Close()).To(Succeed())
+			})
+
+			It("reports metrics for failed requests", func() {
+				s.Server.MaxHeaderBytes = 20
+				metricsChan := make(chan *RequestMetrics, 1)
+				s.OnRequestDone = func(m *RequestMetrics) { metricsChan <- m }
+
+				setRequest(encodeRequest(exampleGetRequest))
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					return len(p), nil
+				}).AnyTimes()
+				str.EXPECT().CancelRead(quic.ErrorCode(errorIDError))
+				str.EXPECT().Close()
+
+				s.handleConn(sess)
+				var m *RequestMetrics
+				Eventually(metricsChan).Should(Receive(&m))
+				Expect(m.Request).To(BeNil())
+				Expect(m.StatusCode).To(Equal(http.StatusRequestHeaderFieldsTooLarge))
+				Expect(m.ErrorCode).To(Equal(quic.ErrorCode(errorIDError)))
+				Expect(m.Err).To(MatchError(ContainSubstring("HEADERS frame too large")))
 			})
 
 			It("handles a request for which the client immediately resets the stream", func() {
@@ -259,6 +394,50 @@ var _ = Describe("Server", func() {
 				Consistently(handlerCalled).ShouldNot(BeClosed())
 			})
 
+			It("resets the stream when the client doesn't send the headers in time", func() {
+				s.Server.ReadHeaderTimeout = 5 * time.Second
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					Fail("Handler should not be called.")
+				})
+
+				done := make(chan struct{})
+				str.EXPECT().SetReadDeadline(gomock.Any()).Do(func(t time.Time) {
+					Expect(t).To(BeTemporally("~", time.Now().Add(5*time.Second), time.Second))
+				})
+				str.EXPECT().Read(gomock.Any()).Return(0, &timeoutError{})
+				str.EXPECT().CancelRead(quic.ErrorCode(errorRequestIncomplete))
+				str.EXPECT().CancelWrite(quic.ErrorCode(errorRequestIncomplete)).Do(func(quic.ErrorCode) { close(done) })
+
+				s.handleConn(sess)
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("applies the ReadTimeout after the headers were received", func() {
+				s.Server.ReadHeaderTimeout = 5 * time.Second
+				s.Server.ReadTimeout = 10 * time.Second
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+				setRequest(encodeRequest(exampleGetRequest))
+				done := make(chan struct{})
+				gomock.InOrder(
+					str.EXPECT().SetReadDeadline(gomock.Any()).Do(func(t time.Time) {
+						Expect(t).To(BeTemporally("~", time.Now().Add(5*time.Second), time.Second))
+					}),
+					str.EXPECT().SetReadDeadline(gomock.Any()).Do(func(t time.Time) {
+						Expect(t).To(BeTemporally("~", time.Now().Add(10*time.Second), time.Second))
+					}),
+				)
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					return len(p), nil
+				}).AnyTimes()
+				str.EXPECT().CancelRead(quic.ErrorCode(errorNoError))
+				str.EXPECT().Close().Do(func() { close(done) })
+
+				s.handleConn(sess)
+				Eventually(done).Should(BeClosed())
+			})
+
 			It("closes the connection when the first frame is not a HEADERS frame", func() {
 				handlerCalled := make(chan struct{})
 				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -298,7 +477,8 @@ var _ = Describe("Server", func() {
 					return len(p), nil
 				}).AnyTimes()
 				done := make(chan struct{})
-				str.EXPECT().CancelWrite(quic.ErrorCode(errorFrameError)).Do(func(quic.ErrorCode) { close(done) })
+				str.EXPECT().CancelRead(quic.ErrorCode(errorIDError))
+				str.EXPECT().Close().Do(func() { close(done) })
 
 				s.handleConn(sess)
 				Eventually(done).Should(BeClosed())
@@ -322,7 +502,7 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(quic.ErrorCode(errorNoError))
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(sess, str, nil, qpackDecoder, &RequestMetrics{}, nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			Eventually(handlerCalled).Should(BeClosed())
 		})
@@ -345,12 +525,141 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(quic.ErrorCode(errorNoError))
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(sess, str, nil, qpackDecoder, &RequestMetrics{}, nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			Eventually(handlerCalled).Should(BeClosed())
 		})
 	})
 
+	Context("control stream", func() {
+		var (
+			sess *mockquic.MockEarlySession
+			pm   *pushManager
+		)
+
+		BeforeEach(func() {
+			sess = mockquic.NewMockEarlySession(mockCtrl)
+			pm = &pushManager{}
+		})
+
+		newControlStream := func(data []byte) *mockquic.MockStream {
+			buf := bytes.NewBuffer(data)
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				if buf.Len() == 0 {
+					return 0, io.EOF
+				}
+				return buf.Read(p)
+			}).AnyTimes()
+			return str
+		}
+
+		It("enables server push when the client sends a MAX_PUSH_ID frame", func() {
+			buf := &bytes.Buffer{}
+			(&settingsFrame{}).Write(buf)
+			(&maxPushIDFrame{PushID: 3}).Write(buf)
+			done := make(chan struct{})
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorClosedCriticalStream), gomock.Any()).Do(func(quic.ErrorCode, string) { close(done) })
+			s.handleControlStream(sess, newControlStream(buf.Bytes()), pm)
+			Eventually(done).Should(BeClosed())
+			Expect(pm.enabled).To(BeTrue())
+			Expect(pm.maxPushID).To(Equal(uint64(3)))
+		})
+
+		It("closes the connection when the first frame is not a SETTINGS frame", func() {
+			buf := &bytes.Buffer{}
+			(&maxPushIDFrame{PushID: 3}).Write(buf)
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorMissingSettings), gomock.Any())
+			s.handleControlStream(sess, newControlStream(buf.Bytes()), pm)
+			Expect(pm.enabled).To(BeFalse())
+		})
+
+		It("closes the connection when the client reduces the maximum push ID", func() {
+			buf := &bytes.Buffer{}
+			(&settingsFrame{}).Write(buf)
+			(&maxPushIDFrame{PushID: 3}).Write(buf)
+			(&maxPushIDFrame{PushID: 2}).Write(buf)
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorIDError), gomock.Any())
+			s.handleControlStream(sess, newControlStream(buf.Bytes()), pm)
+		})
+
+		It("closes the connection on unexpected frames", func() {
+			buf := &bytes.Buffer{}
+			(&settingsFrame{}).Write(buf)
+			(&dataFrame{Length: 6}).Write(buf)
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorFrameUnexpected), gomock.Any())
+			s.handleControlStream(sess, newControlStream(buf.Bytes()), pm)
+		})
+
+		It("keeps the QPACK streams open", func() {
+			done := make(chan struct{})
+			str := mockquic.NewMockStream(mockCtrl)
+			gomock.InOrder(
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+					b[0] = streamTypeQPACKEncoderStream
+					return 1, nil
+				}),
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+					return copy(b, "foobar"), nil
+				}),
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+					close(done)
+					return 0, errors.New("session closed")
+				}),
+			)
+			sess.EXPECT().AcceptUniStream(gomock.Any()).Return(str, nil)
+			sess.EXPECT().AcceptUniStream(gomock.Any()).Return(nil, errors.New("done"))
+			s.handleUnidirectionalStreams(sess, pm)
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("closes the connection when the client opens a push stream", func() {
+			done := make(chan struct{})
+			sess.EXPECT().AcceptUniStream(gomock.Any()).Return(newControlStream([]byte{streamTypePushStream}), nil)
+			sess.EXPECT().AcceptUniStream(gomock.Any()).Return(nil, errors.New("done"))
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorStreamCreationError), gomock.Any()).Do(func(quic.ErrorCode, string) { close(done) })
+			s.handleUnidirectionalStreams(sess, pm)
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("closes the connection when the client opens a second control stream", func() {
+			// The control streams block after the stream type was read.
+			// The stream that is accepted first is handled as the control stream.
+			block := make(chan struct{})
+			newBlockingControlStream := func() *mockquic.MockStream {
+				str := mockquic.NewMockStream(mockCtrl)
+				gomock.InOrder(
+					str.EXPECT().Read(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+						b[0] = streamTypeControlStream
+						return 1, nil
+					}),
+					str.EXPECT().Read(gomock.Any()).DoAndReturn(func([]byte) (int, error) {
+						<-block
+						return 0, errors.New("test done")
+					}).MaxTimes(1),
+				)
+				return str
+			}
+			done := make(chan struct{})
+			sess.EXPECT().AcceptUniStream(gomock.Any()).Return(newBlockingControlStream(), nil)
+			sess.EXPECT().AcceptUniStream(gomock.Any()).Return(newBlockingControlStream(), nil)
+			sess.EXPECT().AcceptUniStream(gomock.Any()).Return(nil, errors.New("done"))
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorStreamCreationError), gomock.Any()).Do(func(quic.ErrorCode, string) { close(done) })
+			s.handleUnidirectionalStreams(sess, pm)
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("ignores unknown unidirectional streams", func() {
+			str := newControlStream([]byte{0x21})
+			done := make(chan struct{})
+			str.EXPECT().CancelRead(quic.ErrorCode(errorStreamCreationError)).Do(func(quic.ErrorCode) { close(done) })
+			sess.EXPECT().AcceptUniStream(gomock.Any()).Return(str, nil)
+			sess.EXPECT().AcceptUniStream(gomock.Any()).Return(nil, errors.New("done"))
+			s.handleUnidirectionalStreams(sess, pm)
+			Eventually(done).Should(BeClosed())
+		})
+	})
+
 	Context("setting http headers", func() {
 		BeforeEach(func() {
 			s.QuicConfig = &quic.Config{Versions: []protocol.VersionNumber{protocol.VersionDraft29}}
@@ -398,6 +707,29 @@ var _ = Describe("Server", func() {
 			Expect(s.SetQuicHeaders(hdr)).To(Succeed())
 			Expect(hdr).To(Equal(http.Header{"Alt-Svc": {`h3-32=":443"; ma=2592000,h3-29=":443"; ma=2592000`}}))
 		})
+
+		It("doesn't set the header if none of the QUIC versions can be used for HTTP/3", func() {
+			s.Server.Addr = ":443"
+			s.QuicConfig.Versions = []quic.VersionNumber{0x1337}
+			hdr := http.Header{}
+			Expect(s.SetQuicHeaders(hdr)).To(Succeed())
+			Expect(hdr).To(BeEmpty())
+		})
+
+		It("sets the headers in the wrapped handler", func() {
+			s.Server.Addr = ":443"
+			var called bool
+			handler := s.QuicHeadersHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				Expect(w.Header()).To(Equal(expected))
+				w.Header().Set("Content-Type", "text/plain")
+			}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			Expect(called).To(BeTrue())
+			Expect(w.Header().Get("Alt-Svc")).To(Equal(`h3-29=":443"; ma=2592000`))
+			Expect(w.Header().Get("Content-Type")).To(Equal("text/plain"))
+		})
 	})
 
 	It("errors when ListenAndServe is called with s.Server nil", func() {
@@ -622,6 +954,65 @@ var _ = Describe("Server", func() {
 		Expect(s.CloseGracefully(0)).To(Succeed())
 	})
 
+	Context("shutting down", func() {
+		var (
+			conn          *serverConn
+			controlFrames chan []byte
+		)
+
+		BeforeEach(func() {
+			frames := make(chan []byte, 1)
+			controlFrames = frames
+			controlStr := mockquic.NewMockStream(mockCtrl)
+			controlStr.EXPECT().Write(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+				frames <- b
+				return len(b), nil
+			}).MaxTimes(1)
+			conn = &serverConn{controlStr: controlStr}
+			s.addConn(conn)
+		})
+
+		It("waits for running requests to complete", func() {
+			Expect(conn.acceptRequest(0)).To(BeTrue())
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				Expect(s.Shutdown(context.Background())).To(Succeed())
+			}()
+			var data []byte
+			Eventually(controlFrames).Should(Receive(&data))
+			Consistently(done).ShouldNot(BeClosed())
+			Expect(conn.acceptRequest(4)).To(BeFalse())
+			frame, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&goAwayFrame{ID: 4}))
+			conn.requestDone()
+			Eventually(done).Should(BeClosed())
+			Expect(s.ListenAndServe()).To(MatchError(http.ErrServerClosed))
+		})
+
+		It("returns when the context expires", func() {
+			Expect(conn.acceptRequest(0)).To(BeTrue())
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			Expect(s.Shutdown(ctx)).To(MatchError(context.DeadlineExceeded))
+			Eventually(controlFrames).Should(Receive())
+			conn.requestDone()
+		})
+
+		It("sends a GOAWAY frame on new connections", func() {
+			Expect(s.Shutdown(context.Background())).To(Succeed())
+			controlStr := mockquic.NewMockStream(mockCtrl)
+			written := make(chan struct{})
+			controlStr.EXPECT().Write(gomock.Any()).Do(func([]byte) { close(written) })
+			c := &serverConn{controlStr: controlStr}
+			s.addConn(c)
+			Eventually(written).Should(BeClosed())
+			Expect(c.acceptRequest(0)).To(BeFalse())
+		})
+	})
+
 	It("errors when listening fails", func() {
 		testErr := errors.New("listen error")
 		quicListenAddr = func(addr string, tlsConf *tls.Config, config *quic.Config) (quic.EarlyListener, error) {
@@ -631,3 +1022,9 @@ var _ = Describe("Server", func() {
 		Expect(ListenAndServeQUIC("", fullpem, privkey, nil)).To(MatchError(testErr))
 	})
 })
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "deadline exceeded" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
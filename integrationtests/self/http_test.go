@@ -161,6 +161,25 @@ var _ = Describe("HTTP tests", func() {
 				Expect(resp.Header.Get("lorem")).To(Equal("ipsum"))
 			})
 
+			It("sends and receives response trailers", func() {
+				mux.HandleFunc("/trailers", func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+					w.Header().Set("Trailer", "Foo")
+					w.Write([]byte("foobar"))
+					w.Header().Set("Foo", "bar")
+					w.Header().Set(http.TrailerPrefix+"Lorem", "ipsum")
+				})
+
+				resp, err := client.Get("https://localhost:" + port + "/trailers")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(200))
+				body, err := ioutil.ReadAll(gbytes.TimeoutReader(resp.Body, 3*time.Second))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("foobar"))
+				Expect(resp.Trailer.Get("foo")).To(Equal("bar"))
+				Expect(resp.Trailer.Get("lorem")).To(Equal("ipsum"))
+			})
+
 			It("downloads a small file", func() {
 				resp, err := client.Get("https://localhost:" + port + "/prdata")
 				Expect(err).ToNot(HaveOccurred())
@@ -282,6 +301,23 @@ var _ = Describe("HTTP tests", func() {
 				Expect(err).To(HaveOccurred())
 			})
 
+			It("cancels the request context when the response body is closed early", func() {
+				handlerDone := make(chan struct{})
+				mux.HandleFunc("/close", func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+					defer close(handlerDone)
+					w.WriteHeader(200)
+					w.(http.Flusher).Flush()
+					Eventually(r.Context().Done()).Should(BeClosed())
+				})
+
+				resp, err := client.Get("https://localhost:" + port + "/close")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(200))
+				Expect(resp.Body.Close()).To(Succeed())
+				Eventually(handlerDone).Should(BeClosed())
+			})
+
 			It("allows streamed HTTP requests", func() {
 				done := make(chan struct{})
 				mux.HandleFunc("/echoline", func(w http.ResponseWriter, r *http.Request) {
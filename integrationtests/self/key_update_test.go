@@ -80,7 +80,12 @@ func (t *connTracer) UpdatedMetrics(rttStats *logging.RTTStats, cwnd, bytesInFli
 
 func (t *connTracer) LostPacket(logging.EncryptionLevel, logging.PacketNumber, logging.PacketLossReason) {
 }
+func (t *connTracer) QueuedForRetransmission(logging.EncryptionLevel, logging.PacketNumber, []logging.Frame) {
+}
+func (t *connTracer) DetectedSpuriousLoss(logging.EncryptionLevel, logging.PacketNumber) {}
+func (t *connTracer) CompletedStream(logging.StreamID, logging.StreamStats)              {}
 func (t *connTracer) UpdatedCongestionState(logging.CongestionState)                     {}
+func (t *connTracer) SetCorrelationID(string)                                            {}
 func (t *connTracer) UpdatedPTOCount(value uint32)                                       {}
 func (t *connTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective)     {}
 func (t *connTracer) UpdatedKey(generation logging.KeyPhase, remote bool)                {}
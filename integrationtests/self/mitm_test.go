@@ -289,7 +289,7 @@ var _ = Describe("MITM test", func() {
 				sendForgedVersionNegotationPacket := func(conn net.PacketConn, remoteAddr net.Addr, hdr *wire.Header) {
 					// Create fake version negotiation packet with no supported versions
 					versions := []protocol.VersionNumber{}
-					packet, _ := wire.ComposeVersionNegotiation(hdr.SrcConnectionID, hdr.DestConnectionID, versions)
+					packet, _ := wire.ComposeVersionNegotiation(hdr.SrcConnectionID, hdr.DestConnectionID, protocol.GetGreasedVersions(versions))
 
 					// Send the packet
 					_, err := conn.WriteTo(packet, remoteAddr)
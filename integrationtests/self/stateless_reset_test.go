@@ -2,6 +2,7 @@ package self_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net"
@@ -99,7 +100,8 @@ var _ = Describe("Stateless Resets", func() {
 				_, serr = str.Read([]byte{0})
 			}
 			Expect(serr).To(HaveOccurred())
-			Expect(serr.Error()).To(ContainSubstring("INTERNAL_ERROR: received a stateless reset"))
+			Expect(serr.Error()).To(ContainSubstring("received a stateless reset"))
+			Expect(errors.Is(serr, quic.ErrStatelessReset)).To(BeTrue())
 
 			Expect(ln2.Close()).To(Succeed())
 			Eventually(acceptStopped).Should(BeClosed())
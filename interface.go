@@ -8,6 +8,7 @@ import (
 
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/logging"
 	"github.com/lucas-clemente/quic-go/quictrace"
 )
@@ -62,6 +63,26 @@ type TokenStore interface {
 	Put(key string, token *ClientToken)
 }
 
+// A ConnectionIDGenerator generates connection IDs.
+type ConnectionIDGenerator interface {
+	// GenerateConnectionID generates a new connection ID.
+	// It must not return the same connection ID twice.
+	// Connection IDs issued for the same connection must not be linkable by on-path observers.
+	GenerateConnectionID() ([]byte, error)
+}
+
+// A ConnectionFilterResult is the decision made by the Config.ConnectionFilter.
+type ConnectionFilterResult uint8
+
+const (
+	// ConnectionFilterAccept continues processing the connection attempt.
+	ConnectionFilterAccept ConnectionFilterResult = iota
+	// ConnectionFilterReject rejects the connection attempt with a CONNECTION_REFUSED error.
+	ConnectionFilterReject
+	// ConnectionFilterDrop silently drops the packet.
+	ConnectionFilterDrop
+)
+
 // An ErrorCode is an application-defined error code.
 // Valid values range between 0 and MAX_UINT62.
 type ErrorCode = protocol.ApplicationErrorCode
@@ -142,8 +163,126 @@ type StreamError interface {
 	ErrorCode() ErrorCode
 }
 
+// These errors can be used with errors.Is to check why a session was closed.
+// This works for all errors returned after the session was closed,
+// e.g. by Stream.Read, Stream.Write, Session.OpenStreamSync, Session.AcceptStream and DialAddr.
+// All these errors implement net.Error.
+var (
+	// ErrHandshakeTimeout is returned when the handshake didn't complete within the Config.HandshakeTimeout.
+	// Timeout() returns true for this error.
+	ErrHandshakeTimeout = qerr.ErrHandshakeTimeout
+	// ErrIdleTimeout is returned when the session was closed due to an idle timeout (see Config.MaxIdleTimeout),
+	// or because the peer didn't respond to liveness probes (see Config.LivenessProbeInterval).
+	// Timeout() returns true for this error.
+	ErrIdleTimeout = qerr.ErrIdleTimeout
+	// ErrHandshakeFailed is returned when the session was closed due to a TLS error, by either peer.
+	ErrHandshakeFailed = qerr.ErrHandshakeFailed
+	// ErrApplicationClose is returned when the session was closed by the application, by either peer.
+	ErrApplicationClose = qerr.ErrApplicationClose
+	// ErrStatelessReset is returned when the session was closed by a stateless reset sent by the peer.
+	ErrStatelessReset = qerr.ErrStatelessReset
+	// ErrMemoryLimitExceeded is returned when the session was closed because it exceeded the Config.MaxSessionMemory.
+	ErrMemoryLimitExceeded = qerr.ErrMemoryLimitExceeded
+)
+
+// A ConnectionCloseError is the error that a session was closed with.
+// It can be obtained by calling errors.As on the error returned by Session.CloseError,
+// as well as on errors returned by Stream.Read, Stream.Write, Session.OpenStreamSync etc.
+// after the session was closed.
+// Errors that are not sent on the wire, e.g. timeouts and stateless resets, use the NO_ERROR (0) error code.
+type ConnectionCloseError interface {
+	error
+	// IsRemote says if the error was received from the peer in a CONNECTION_CLOSE frame.
+	IsRemote() bool
+	// IsApplicationError says if the session was closed by the application (see Session.CloseWithError).
+	// If so, Code returns an application error code, otherwise a QUIC transport error code.
+	IsApplicationError() bool
+	Code() uint64
+	ReasonPhrase() string
+}
+
 type ConnectionState = handshake.ConnectionState
 
+// ConnectionStats is a snapshot of the transport state of a session.
+type ConnectionStats struct {
+	SmoothedRTT time.Duration
+	MinRTT      time.Duration
+	// CongestionWindow and BytesInFlight are in bytes.
+	CongestionWindow uint64
+	BytesInFlight    uint64
+
+	PacketsSent     uint64
+	PacketsReceived uint64
+	PacketsLost     uint64
+	BytesSent       uint64
+	BytesReceived   uint64
+
+	// StreamsOpened is the number of streams opened, by us and by the peer.
+	StreamsOpened uint64
+	// HandshakeDuration is the time it took to complete the handshake.
+	// It is 0 if the handshake hasn't completed yet.
+	HandshakeDuration time.Duration
+	// MemoryUsage is an estimate of the number of bytes that the peer made the session hold.
+	// It includes received packets that haven't been processed yet, stream data that
+	// was received but not yet read by the application, and frames queued for retransmission.
+	MemoryUsage uint64
+}
+
+// SessionEventType is the type of a SessionEvent.
+type SessionEventType uint8
+
+const (
+	// SessionEventHandshakeComplete means that the handshake completed.
+	SessionEventHandshakeComplete SessionEventType = 1 + iota
+	// SessionEventVersionNegotiated means that the session uses a different version than the one initially offered.
+	// It is only used for the client, for a session started after version negotiation.
+	SessionEventVersionNegotiated
+	// SessionEventIdleTimeout means that no network activity happened for the idle timeout.
+	// It is followed by a SessionEventClosed.
+	SessionEventIdleTimeout
+	// SessionEventClosed means that the session was closed.
+	SessionEventClosed
+	// SessionEventStreamIDsLow means that only few stream IDs of a type are left (see Config.StreamIDsLowThreshold).
+	// Once all stream IDs are used, no new streams of this type can be opened.
+	// Applications that use long-lived sessions should use a new session for new streams.
+	SessionEventStreamIDsLow
+)
+
+// A SessionEvent is an event in the lifecycle of a session.
+type SessionEvent struct {
+	Type SessionEventType
+	// Version is the negotiated version.
+	// It is set for SessionEventVersionNegotiated.
+	Version VersionNumber
+	// Err is the error that caused the session to be closed.
+	// It is set for SessionEventClosed.
+	Err error
+	// Unidirectional says if the event is about unidirectional (or bidirectional) streams.
+	// It is set for SessionEventStreamIDsLow.
+	Unidirectional bool
+}
+
+// PacketDirection is the direction of a CapturedPacket.
+type PacketDirection uint8
+
+const (
+	// PacketSent is a packet sent by us.
+	PacketSent PacketDirection = 1 + iota
+	// PacketReceived is a packet received from the peer.
+	PacketReceived
+)
+
+// A CapturedPacket is a packet passed to the Config.CapturePacket callback.
+type CapturedPacket struct {
+	Direction       PacketDirection
+	Header          *logging.ExtendedHeader
+	PacketNumber    logging.PacketNumber
+	EncryptionLevel logging.EncryptionLevel
+	// Payload is the decrypted payload of the packet.
+	// It is only valid until the callback returns.
+	Payload []byte
+}
+
 // A Session is a QUIC connection between two peers.
 type Session interface {
 	// AcceptStream returns the next stream opened by the peer, blocking until one is available.
@@ -186,10 +325,26 @@ type Session interface {
 	// The context is cancelled when the session is closed.
 	// Warning: This API should not be considered stable and might change soon.
 	Context() context.Context
+	// CloseError returns the error that the session was closed with.
+	// It returns nil if the session is not closed yet.
+	// Use errors.As with a ConnectionCloseError to get the error code and reason phrase.
+	CloseError() error
 	// ConnectionState returns basic details about the QUIC connection.
 	// It blocks until the handshake completes.
 	// Warning: This API should not be considered stable and might change soon.
 	ConnectionState() ConnectionState
+	// Stats returns a snapshot of the transport state of the session.
+	// It is safe to call from any goroutine.
+	Stats() ConnectionStats
+	// SetCorrelationID attaches an application-defined ID to the session.
+	// The ID is added to all log messages logged by this session, and passed to the ConnectionTracer.
+	// It is safe to call from any goroutine.
+	SetCorrelationID(string)
+	// Ping sends a PING frame and blocks until it is acknowledged by the peer.
+	// It returns the time it took to receive the acknowledgement, which includes the peer's ACK delay.
+	// Lost PING frames are retransmitted until the context is done.
+	// It is safe to call from any goroutine.
+	Ping(context.Context) (time.Duration, error)
 }
 
 // An EarlySession is a session that is handshaking.
@@ -209,6 +364,10 @@ type EarlySession interface {
 type Config struct {
 	// The QUIC versions that can be negotiated.
 	// If not set, it uses all versions available.
+	// The versions are ordered by preference (most preferred first).
+	// A client uses the first version for its first connection attempt. If it receives a Version Negotiation packet,
+	// it switches to the first version in this list that is also supported by the server.
+	// The client is notified about the version switch by a SessionEventVersionNegotiated.
 	// Warning: This API should not be considered stable and will change soon.
 	Versions []VersionNumber
 	// The length of the connection ID in bytes.
@@ -218,6 +377,35 @@ type Config struct {
 	// If used for a server, or dialing on a packet conn, a 4 byte connection ID will be used.
 	// When dialing on a packet conn, the ConnectionIDLength value must be the same for every Dial call.
 	ConnectionIDLength int
+	// ConnectionIDGenerator generates the connection IDs used by the server.
+	// It can be used to encode routing information into the connection ID,
+	// e.g. for use with a load balancer that routes packets based on the connection ID (see QUIC-LB).
+	// The generated connection IDs must have the length configured in ConnectionIDLength.
+	// If not set, connection IDs are chosen randomly.
+	// This option is only valid for the server.
+	ConnectionIDGenerator ConnectionIDGenerator
+	// ConnectionIDRotationInterval is the interval in which new connection IDs are issued to the peer.
+	// When rotating, the peer is asked to retire all connection IDs issued before.
+	// Rotation is skipped if the peer hasn't retired all connection IDs that were retired by the previous rotation yet.
+	// If not set, connection IDs are only replaced when the peer retires them.
+	ConnectionIDRotationInterval time.Duration
+	// ConnectionIDRetired is called when a connection ID is no longer used to route packets to a session,
+	// i.e. when it was retired by the peer, or when the session is closed.
+	// This can be used to update external routing tables, e.g. in a load balancer.
+	// It is called from the session's run loop, so it must not block.
+	ConnectionIDRetired func(connID []byte)
+	// SessionEventHandler is called for lifecycle events of every session,
+	// e.g. when the handshake completes, or when the session is closed.
+	// This allows supervisory code to react to these events without polling the session.
+	// It is called synchronously from the session's run loop: the session doesn't process any packets
+	// (and doesn't send any) until it returns. It must not block, and it must not call any methods
+	// of the session that wait for the run loop, e.g. CloseWithError.
+	// The only exception is the SessionEventStreamIDsLow, which is called from a separate goroutine.
+	SessionEventHandler func(Session, SessionEvent)
+	// CapturePacket is called for every packet sent and received, with the decrypted payload of the packet.
+	// This allows building wire-level recorders without having access to the packet protection keys.
+	// It is called from the session's run loop, so it must not block.
+	CapturePacket func(Session, CapturedPacket)
 	// HandshakeTimeout is the maximum duration that the cryptographic handshake may take.
 	// If the timeout is exceeded, the connection is closed.
 	// If this value is zero, the timeout is set to 10 seconds.
@@ -230,12 +418,82 @@ type Config struct {
 	MaxIdleTimeout time.Duration
 	// AcceptToken determines if a Token is accepted.
 	// It is called with token = nil if the client didn't send a token.
+	// If the token is not accepted, the server doesn't create a session for this connection attempt.
+	// Instead, it sends a Retry packet containing a new token, thereby validating the client's address
+	// before allocating any per-connection state. If the client sent an invalid Retry token,
+	// the connection attempt is rejected with an INVALID_TOKEN error.
 	// If not set, a default verification function is used:
 	// * it verifies that the address matches, and
-	//   * if the token is a retry token, that it was issued within the last 5 seconds
+	//   * if the token is a retry token, that it was issued within the last 10 seconds
 	//   * else, that it was issued within the last 24 hours.
+	// The default function rejects connection attempts without a token, so a Retry is performed for every new client.
 	// This option is only valid for the server.
 	AcceptToken func(clientAddr net.Addr, token *Token) bool
+	// ConnectionFilter is called for every Initial packet that would start a new connection,
+	// before performing any cryptographic operations (including the validation of the Token).
+	// It is called with the client's address, the QUIC version and the destination connection ID chosen by the client.
+	// This is the cheapest place to enforce IP block lists or per-source rate limits.
+	// It is called from the server's run loop, so it must not block.
+	// If not set, all connection attempts are processed.
+	// This option is only valid for the server.
+	ConnectionFilter func(clientAddr net.Addr, version VersionNumber, destConnID []byte) ConnectionFilterResult
+	// OfferedVersions determines which versions the server offers to a client, e.g. to roll out a new version gradually.
+	// It is called for every packet that would start a new connection, with the client's address.
+	// Since it is called before the handshake, the SNI is not available yet.
+	// The returned versions are ordered by preference. Versions that are not contained in Versions are ignored.
+	// If a client uses a version not offered to it, the server sends a Version Negotiation packet.
+	// It is called from the server's run loop, so it must not block.
+	// If not set, all Versions are offered to every client.
+	// This option is only valid for the server.
+	OfferedVersions func(clientAddr net.Addr) []VersionNumber
+	// MaxAcceptQueueSize is the maximum number of sessions that the server queues until they are accepted.
+	// Sessions are queued as soon as the handshake completes (or, for an EarlyListener, as soon as 0-RTT is possible).
+	// When the queue is full, new connection attempts are handled according to the AcceptQueueOverflowPolicy.
+	// If not set, it will default to 32.
+	// This option is only valid for the server.
+	MaxAcceptQueueSize int
+	// AcceptQueueOverflowPolicy defines how new connection attempts are handled when the accept queue is full.
+	// If not set, they are rejected with a CONNECTION_REFUSED error.
+	// The number of connection attempts that hit a full accept queue is reported by Listener.Stats.
+	// This option is only valid for the server.
+	AcceptQueueOverflowPolicy OverflowPolicy
+	// MaxIncomingConnections is the maximum number of concurrent connections that the server accepts.
+	// Connection attempts exceeding this limit are rejected with a CONNECTION_REFUSED error.
+	// If not set, the number of connections is not limited.
+	// This option is only valid for the server.
+	MaxIncomingConnections int
+	// MaxIncomingConnectionsPerIP is the maximum number of concurrent connections that the server accepts from a single IP address.
+	// Connection attempts exceeding this limit are rejected with a CONNECTION_REFUSED error.
+	// If not set, the number of connections per IP address is not limited.
+	// This option is only valid for the server.
+	MaxIncomingConnectionsPerIP int
+	// IPv6PrefixLength is the prefix length by which IPv6 addresses are grouped for MaxIncomingConnectionsPerIP.
+	// Since a single host often controls a whole /64, limiting individual IPv6 addresses would be ineffective.
+	// It must be between 1 and 128. If not set, it defaults to 64.
+	// This option is only valid for the server.
+	IPv6PrefixLength int
+	// MaxHandshakesPerSecond is the maximum rate at which the server starts new handshakes.
+	// Bursts of up to MaxHandshakesPerSecond handshakes are allowed.
+	// The connection limits only apply to connection attempts whose token was accepted (see AcceptToken).
+	// Connection attempts that are answered with a Retry don't count towards any of the limits.
+	// Connection attempts exceeding this limit are rejected with a CONNECTION_REFUSED error.
+	// If not set, the handshake rate is not limited.
+	// This option is only valid for the server.
+	MaxHandshakesPerSecond int
+	// MaxConcurrentHandshakes is the maximum number of handshakes that the server runs concurrently.
+	// Handshakes are CPU expensive, limiting their number protects established connections during a handshake flood.
+	// Connection attempts exceeding this limit are queued (see MaxQueuedHandshakes),
+	// or rejected with a CONNECTION_REFUSED error if the queue is full.
+	// If not set, the number of concurrent handshakes is not limited.
+	// This option is only valid for the server.
+	MaxConcurrentHandshakes int
+	// MaxQueuedHandshakes is the maximum number of connection attempts that are queued when MaxConcurrentHandshakes is reached.
+	// Queued connection attempts are started as soon as a running handshake completes.
+	// They are dropped if they don't get started within the HandshakeTimeout.
+	// Connection attempts exceeding this limit are rejected with a CONNECTION_REFUSED error.
+	// If not set, connection attempts exceeding MaxConcurrentHandshakes are rejected right away.
+	// This option is only valid for the server.
+	MaxQueuedHandshakes int
 	// The TokenStore stores tokens received from the server.
 	// Tokens are used to skip address validation on future connection attempts.
 	// The key used to store tokens is the ServerName from the tls.Config, if set
@@ -247,6 +505,22 @@ type Config struct {
 	// MaxReceiveConnectionFlowControlWindow is the connection-level flow control window for receiving data.
 	// If this value is zero, it will default to 1.5 MB for the server and 15 MB for the client.
 	MaxReceiveConnectionFlowControlWindow uint64
+	// ReceiveMemoryBudget is the maximum number of bytes that all sessions of a server together
+	// allow their peers to send before the data is read by the application.
+	// It is enforced by limiting the sum of the connection-level flow control windows of all sessions:
+	// When the budget is exhausted, flow control windows are not increased any more,
+	// and when it is exceeded (which can happen since every session starts with a fixed initial window),
+	// windows are shrunk back towards their initial size.
+	// If not set, there's no limit apart from the limits for every single session.
+	// This option is only valid for the server.
+	ReceiveMemoryBudget uint64
+	// MaxSessionMemory is the maximum number of bytes a single session may hold,
+	// as reported by ConnectionStats.MemoryUsage.
+	// If it is exceeded, the session is closed with a NO_ERROR, and ErrMemoryLimitExceeded is returned to the application.
+	// A peer that respects the flow control limits must not be able to exceed it. It therefore must not be smaller than
+	// the MaxReceiveConnectionFlowControlWindow plus the maximum size of the queues of unprocessed packets.
+	// If not set, there's no limit.
+	MaxSessionMemory uint64
 	// MaxIncomingStreams is the maximum number of concurrent bidirectional streams that a peer is allowed to open.
 	// Values above 2^60 are invalid.
 	// If not set, it will default to 100.
@@ -257,28 +531,151 @@ type Config struct {
 	// If not set, it will default to 100.
 	// If set to a negative value, it doesn't allow any unidirectional streams.
 	MaxIncomingUniStreams int64
+	// StreamIDsLowThreshold is the number of stream IDs of a type (bidirectional or unidirectional) that are left
+	// when the SessionEventStreamIDsLow is emitted.
+	// If not set, it will default to 2^20.
+	StreamIDsLowThreshold uint64
 	// The StatelessResetKey is used to generate stateless reset tokens.
 	// If no key is configured, sending of stateless resets is disabled.
 	StatelessResetKey []byte
 	// KeepAlive defines whether this peer will periodically send a packet to keep the connection alive.
 	KeepAlive bool
+	// LivenessProbeInterval enables active liveness probing.
+	// If set, a PING frame is sent at this interval once the handshake has completed.
+	// If MaxUnansweredLivenessProbes probes in a row go unacknowledged, the session is closed
+	// with a timeout error, without waiting for the idle timeout.
+	// The interval should be well above the round-trip time of the connection.
+	LivenessProbeInterval time.Duration
+	// MaxUnansweredLivenessProbes is the number of unanswered liveness probes after which the session is closed.
+	// It is only used if LivenessProbeInterval is set.
+	// If not set, it will default to 3.
+	MaxUnansweredLivenessProbes int
+	// MaxPacingBurstPackets is the number of full-size packets that the pacer allows to be sent back-to-back.
+	// For high bandwidths, larger bursts might be sent, such that the pacing interval doesn't drop below MinPacingDelay.
+	// Smaller values reduce burst-induced packet loss on links with shallow buffers, at the cost of setting more timers.
+	// If not set, it will default to 10.
+	MaxPacingBurstPackets int
+	// MinPacingDelay is the minimum interval between two bursts of packets.
+	// If not set, it will default to 1 ms.
+	MinPacingDelay time.Duration
+	// ReceiveBufferSize is the size of the kernel receive buffer (SO_RCVBUF) that quic-go tries to set
+	// on the packet conn. The buffer is only ever increased, never decreased.
+	// If the buffer can't be increased to this size, a warning is logged.
+	// Setting the buffer size beyond the system limit might require elevated privileges.
+	// If not set, it will default to 2 MB.
+	ReceiveBufferSize int
+	// SendBufferSize is the size of the kernel send buffer (SO_SNDBUF) that quic-go tries to set
+	// on the packet conn. The same rules as for the ReceiveBufferSize apply.
+	// If not set, it will default to 2 MB.
+	SendBufferSize int
+	// MaxUnprocessedPackets is the maximum number of received packets that are queued for a session
+	// until they are processed by its run loop. Packets arriving while the queue is full are dropped.
+	// Larger values reduce packet drops during bursts, at the cost of memory.
+	// It has to be larger than 32, the number of 0-RTT packets the server buffers for a connection before the session is created.
+	// If not set, it will default to 256.
+	MaxUnprocessedPackets int
+	// SendQueueSize is the number of packets that a session queues for sending.
+	// When the queue is full, the session's run loop blocks until the packet conn accepted the next packet.
+	// If not set, it will default to 1.
+	SendQueueSize int
+	// DisableSpinBit disables the latency spin bit.
+	// The spin bit allows on-path observers to passively measure the RTT of a connection.
+	// Even if not disabled, the spin bit is disabled on a random subset of connections (see SpinBitDisableRate), as required by the specification.
+	// If the spin bit is disabled, it is set to a random value.
+	DisableSpinBit bool
+	// SpinBitDisableRate determines how often the spin bit is disabled, if it wasn't disabled by DisableSpinBit.
+	// The spin bit is disabled on a random 1 in SpinBitDisableRate connections.
+	// It must be between 1 and 16, since the specification requires the spin bit to be disabled on at least 1 in 16 connections.
+	// A value of 1 disables the spin bit on all connections.
+	// If not set, it defaults to 16.
+	SpinBitDisableRate int
+	// DisableGreasing disables greasing.
+	// By default, a reserved version number is added to Version Negotiation packets,
+	// and a reserved transport parameter is sent during the handshake.
+	// This makes sure that peers (and middleboxes) correctly ignore unknown values.
+	// It should only be disabled for testing purposes.
+	DisableGreasing bool
+	// StrictFrameValidation makes the session reject retransmitted STREAM frames
+	// whose data doesn't match the data received before with a PROTOCOL_VIOLATION.
+	// By default, this is not checked, since it requires comparing every retransmission with the queued data.
+	// It is intended for interop testing and hardened deployments.
+	StrictFrameValidation bool
 	// QUIC Event Tracer (see https://github.com/google/quic-trace).
 	// Warning: Support for quic-trace will soon be dropped in favor of qlog.
 	// It is disabled by default. Use the "quictrace" build tag to enable (e.g. go build -tags quictrace).
 	QuicTracer quictrace.Tracer
 	Tracer     logging.Tracer
+	// Logger receives the log messages of the listener (or the client) and of all its sessions.
+	// If not set, log messages are written using the standard library's log package,
+	// if enabled by setting the QUIC_GO_LOG_LEVEL environment variable (disabled by default).
+	Logger logging.Logger
 }
 
+// An OverflowPolicy defines how the server handles new connection attempts when it can't take any more sessions.
+type OverflowPolicy uint8
+
+const (
+	// OverflowRefuse rejects the connection attempt with a CONNECTION_REFUSED error.
+	// QUIC doesn't provide a way to tell the client when to try again.
+	OverflowRefuse OverflowPolicy = iota
+	// OverflowDrop drops the packet that would have started the connection.
+	// The client retransmits it after a timeout, so it is accepted if the overload is short enough,
+	// at the cost of a longer handshake. Otherwise the client's handshake times out.
+	OverflowDrop
+)
+
 // A Listener for incoming QUIC connections
 type Listener interface {
 	// Close the server. All active sessions will be closed.
 	Close() error
 	// Addr returns the local network addr that the server is listening on.
 	Addr() net.Addr
+	// Shutdown gracefully shuts down the server.
+	// It stops accepting new connections and waits for all active sessions to be closed.
+	// When the context is done before that, the remaining sessions are closed with a NO_ERROR transport error.
+	// The server is closed in both cases.
+	Shutdown(context.Context) error
+	// CloseIdleSessions closes all sessions that haven't received any packets for at least the given duration.
+	// It returns the number of sessions that were closed.
+	CloseIdleSessions(olderThan time.Duration) int
+	// NumSessions returns the number of sessions, by state.
+	NumSessions() SessionCount
+	// Stats returns statistics about the listener.
+	Stats() ListenerStats
+	// SetDebugLogging enables or disables debug logging (including the contents of every packet)
+	// for a single session, independent of the log level.
+	// The session is identified by the original destination connection ID chosen by the client,
+	// i.e. the connection ID passed to the Tracer.
+	// It returns false if no such session exists.
+	SetDebugLogging(connID []byte, enabled bool) bool
 	// Accept returns new sessions. It should be called in a loop.
 	Accept(context.Context) (Session, error)
 }
 
+// SessionCount is the number of sessions of a listener, by state.
+type SessionCount struct {
+	// Handshaking is the number of sessions that are still handshaking.
+	Handshaking int
+	// Established is the number of sessions that completed the handshake.
+	Established int
+}
+
+// ListenerStats are statistics about a listener.
+type ListenerStats struct {
+	// AcceptQueueOverflows is the number of connection attempts that were refused or dropped
+	// because the accept queue was full.
+	AcceptQueueOverflows uint64
+	// ReceiveBufferDrops is the number of packets that the kernel dropped because the socket's receive buffer was full.
+	// If this number increases, consider increasing Config.ReceiveBufferSize.
+	// Drops are only detected on Linux. If the PacketConn is used by multiple listeners or clients,
+	// all packets dropped on the PacketConn are counted.
+	ReceiveBufferDrops uint64
+	// RejectedHandshakes is the number of connection attempts that were refused or dropped
+	// because of the connection limits (MaxIncomingConnections, MaxIncomingConnectionsPerIP,
+	// MaxHandshakesPerSecond, MaxConcurrentHandshakes and MaxQueuedHandshakes).
+	RejectedHandshakes uint64
+}
+
 // An EarlyListener listens for incoming QUIC connections,
 // and returns them before the handshake completes.
 type EarlyListener interface {
@@ -286,6 +683,21 @@ type EarlyListener interface {
 	Close() error
 	// Addr returns the local network addr that the server is listening on.
 	Addr() net.Addr
+	// Shutdown gracefully shuts down the server.
+	// It stops accepting new connections and waits for all active sessions to be closed.
+	// When the context is done before that, the remaining sessions are closed with a NO_ERROR transport error.
+	// The server is closed in both cases.
+	Shutdown(context.Context) error
+	// CloseIdleSessions closes all sessions that haven't received any packets for at least the given duration.
+	// It returns the number of sessions that were closed.
+	CloseIdleSessions(olderThan time.Duration) int
+	// NumSessions returns the number of sessions, by state.
+	NumSessions() SessionCount
+	// Stats returns statistics about the listener.
+	Stats() ListenerStats
+	// SetDebugLogging enables or disables debug logging for a single session.
+	// See Listener.SetDebugLogging for details.
+	SetDebugLogging(connID []byte, enabled bool) bool
 	// Accept returns new early sessions. It should be called in a loop.
 	Accept(context.Context) (EarlySession, error)
 }
@@ -1,6 +1,9 @@
 package ackhandler
 
 import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/logging"
@@ -11,12 +14,16 @@ import (
 func NewAckHandler(
 	initialPacketNumber protocol.PacketNumber,
 	rttStats *utils.RTTStats,
+	clientAddressValidated bool,
+	maxPacingBurstPackets int,
+	minPacingDelay time.Duration,
 	pers protocol.Perspective,
+	clock congestion.Clock,
 	traceCallback func(quictrace.Event),
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 	version protocol.VersionNumber,
 ) (SentPacketHandler, ReceivedPacketHandler) {
-	sph := newSentPacketHandler(initialPacketNumber, rttStats, pers, traceCallback, tracer, logger)
-	return sph, newReceivedPacketHandler(sph, rttStats, logger, version)
+	sph := newSentPacketHandler(initialPacketNumber, rttStats, clientAddressValidated, maxPacingBurstPackets, minPacingDelay, pers, clock, traceCallback, tracer, logger)
+	return sph, newReceivedPacketHandler(sph, rttStats, clock, logger, version)
 }
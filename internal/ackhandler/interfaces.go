@@ -19,6 +19,7 @@ type Packet struct {
 
 	includedInBytesInFlight bool
 	declaredLost            bool
+	lostByLossDetection     bool // declared lost by loss detection, not only retransmitted in a probe packet
 	skippedPacket           bool
 }
 
@@ -49,8 +50,8 @@ type SentPacketHandler interface {
 	GetLossDetectionTimeout() time.Time
 	OnLossDetectionTimeout() error
 
-	// report some congestion statistics. For tracing only.
-	GetStats() *quictrace.TransportState
+	// report some congestion statistics. Used for tracing and for the connection statistics.
+	GetStats() quictrace.TransportState
 }
 
 type sentPacketTracker interface {
@@ -65,5 +66,6 @@ type ReceivedPacketHandler interface {
 	DropPackets(protocol.EncryptionLevel)
 
 	GetAlarmTimeout() time.Time
+	// The ACK frame is reused. It is only valid until the next call to GetAckFrame for the same encryption level.
 	GetAckFrame(encLevel protocol.EncryptionLevel, onlyIfQueued bool) *wire.AckFrame
 }
@@ -200,13 +200,13 @@ var _ = Describe("receivedPacketHistory", func() {
 
 	Context("ACK range export", func() {
 		It("returns nil if there are no ranges", func() {
-			Expect(hist.GetAckRanges()).To(BeNil())
+			Expect(hist.AppendAckRanges(nil)).To(BeNil())
 		})
 
 		It("gets a single ACK range", func() {
 			Expect(hist.ReceivedPacket(4)).To(BeTrue())
 			Expect(hist.ReceivedPacket(5)).To(BeTrue())
-			ackRanges := hist.GetAckRanges()
+			ackRanges := hist.AppendAckRanges(nil)
 			Expect(ackRanges).To(HaveLen(1))
 			Expect(ackRanges[0]).To(Equal(wire.AckRange{Smallest: 4, Largest: 5}))
 		})
@@ -219,12 +219,21 @@ var _ = Describe("receivedPacketHistory", func() {
 			Expect(hist.ReceivedPacket(11)).To(BeTrue())
 			Expect(hist.ReceivedPacket(10)).To(BeTrue())
 			Expect(hist.ReceivedPacket(2)).To(BeTrue())
-			ackRanges := hist.GetAckRanges()
+			ackRanges := hist.AppendAckRanges(nil)
 			Expect(ackRanges).To(HaveLen(3))
 			Expect(ackRanges[0]).To(Equal(wire.AckRange{Smallest: 10, Largest: 11}))
 			Expect(ackRanges[1]).To(Equal(wire.AckRange{Smallest: 4, Largest: 6}))
 			Expect(ackRanges[2]).To(Equal(wire.AckRange{Smallest: 1, Largest: 2}))
 		})
+
+		It("appends to the slice given", func() {
+			Expect(hist.ReceivedPacket(4)).To(BeTrue())
+			Expect(hist.ReceivedPacket(6)).To(BeTrue())
+			ackRanges := make([]wire.AckRange, 0, 10)
+			ackRanges = hist.AppendAckRanges(ackRanges)
+			Expect(ackRanges).To(Equal([]wire.AckRange{{Smallest: 6, Largest: 6}, {Smallest: 4, Largest: 4}}))
+			Expect(cap(ackRanges)).To(Equal(10))
+		})
 	})
 
 	Context("Getting the highest ACK range", func() {
@@ -3,6 +3,7 @@ package ackhandler
 import (
 	"time"
 
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
@@ -21,13 +22,17 @@ type receivedPacketTracker struct {
 
 	maxAckDelay time.Duration
 	rttStats    *utils.RTTStats
+	clock       congestion.Clock
 
 	hasNewAck bool // true as soon as we received an ack-eliciting new packet
 	ackQueued bool // true once we received more than 2 (or later in the connection 10) ack-eliciting packets
 
 	ackElicitingPacketsReceivedSinceLastAck int
 	ackAlarm                                time.Time
-	lastAck                                 *wire.AckFrame
+	lastAck                                 *wire.AckFrame // nil until the first ACK is sent, then it points to ackFrame
+
+	// The ACK frame (and its slice of ACK ranges) is reused for every ACK we send.
+	ackFrame wire.AckFrame
 
 	logger utils.Logger
 
@@ -36,6 +41,7 @@ type receivedPacketTracker struct {
 
 func newReceivedPacketTracker(
 	rttStats *utils.RTTStats,
+	clock congestion.Clock,
 	logger utils.Logger,
 	version protocol.VersionNumber,
 ) *receivedPacketTracker {
@@ -43,6 +49,7 @@ func newReceivedPacketTracker(
 		packetHistory: newReceivedPacketHistory(),
 		maxAckDelay:   protocol.MaxAckDelay,
 		rttStats:      rttStats,
+		clock:         clock,
 		logger:        logger,
 		version:       version,
 	}
@@ -161,7 +168,7 @@ func (h *receivedPacketTracker) GetAckFrame(onlyIfQueued bool) *wire.AckFrame {
 	if !h.hasNewAck {
 		return nil
 	}
-	now := time.Now()
+	now := h.clock.Now()
 	if onlyIfQueued {
 		if !h.ackQueued && (h.ackAlarm.IsZero() || h.ackAlarm.After(now)) {
 			return nil
@@ -171,15 +178,14 @@ func (h *receivedPacketTracker) GetAckFrame(onlyIfQueued bool) *wire.AckFrame {
 		}
 	}
 
-	ack := &wire.AckFrame{
-		AckRanges: h.packetHistory.GetAckRanges(),
-		// Make sure that the DelayTime is always positive.
-		// This is not guaranteed on systems that don't have a monotonic clock.
-		DelayTime: utils.MaxDuration(0, now.Sub(h.largestObservedReceivedTime)),
-		ECT0:      h.ect0,
-		ECT1:      h.ect1,
-		ECNCE:     h.ecnce,
-	}
+	ack := &h.ackFrame
+	ack.AckRanges = h.packetHistory.AppendAckRanges(ack.AckRanges[:0])
+	// Make sure that the DelayTime is always positive.
+	// This is not guaranteed on systems that don't have a monotonic clock.
+	ack.DelayTime = utils.MaxDuration(0, now.Sub(h.largestObservedReceivedTime))
+	ack.ECT0 = h.ect0
+	ack.ECT1 = h.ect1
+	ack.ECNCE = h.ecnce
 
 	h.lastAck = ack
 	h.ackAlarm = time.Time{}
@@ -3,6 +3,7 @@ package ackhandler
 import (
 	"time"
 
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
@@ -19,7 +20,7 @@ var _ = Describe("Received Packet Tracker", func() {
 
 	BeforeEach(func() {
 		rttStats = &utils.RTTStats{}
-		tracker = newReceivedPacketTracker(rttStats, utils.DefaultLogger, protocol.VersionWhatever)
+		tracker = newReceivedPacketTracker(rttStats, congestion.DefaultClock{}, utils.DefaultLogger, protocol.VersionWhatever)
 	})
 
 	Context("accepting packets", func() {
@@ -263,6 +264,20 @@ var _ = Describe("Received Packet Tracker", func() {
 					Expect(tracker.lastAck).To(Equal(ack))
 				})
 
+				It("reuses the ACK frame", func() {
+					tracker.ReceivedPacket(1, protocol.ECNNon, time.Now(), true)
+					ack := tracker.GetAckFrame(true)
+					Expect(ack).ToNot(BeNil())
+					tracker.ReceivedPacket(3, protocol.ECNNon, time.Now(), true)
+					tracker.ackQueued = true
+					ack2 := tracker.GetAckFrame(true)
+					Expect(ack2).To(BeIdenticalTo(ack))
+					Expect(ack2.AckRanges).To(Equal([]wire.AckRange{
+						{Smallest: 3, Largest: 3},
+						{Smallest: 1, Largest: 1},
+					}))
+				})
+
 				It("generates an ACK frame with missing packets", func() {
 					tracker.ReceivedPacket(1, protocol.ECNNon, time.Now(), true)
 					tracker.ReceivedPacket(4, protocol.ECNNon, time.Now(), true)
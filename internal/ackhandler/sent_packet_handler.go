@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/congestion"
+	"github.com/lucas-clemente/quic-go/internal/logutils"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
@@ -56,6 +57,7 @@ type sentPacketHandler struct {
 	bytesSent                      protocol.ByteCount
 	// Have we validated the peer's address yet?
 	// Always true for the client.
+	// The server considers the address validated if the client presented a valid token.
 	peerAddressValidated bool
 
 	handshakeConfirmed bool
@@ -67,6 +69,8 @@ type sentPacketHandler struct {
 	lowestNotConfirmedAcked protocol.PacketNumber
 
 	bytesInFlight protocol.ByteCount
+	// the number of packets declared lost (not counting PTO probes)
+	packetsLost uint64
 
 	congestion congestion.SendAlgorithmWithDebugInfos
 	rttStats   *utils.RTTStats
@@ -82,6 +86,7 @@ type sentPacketHandler struct {
 	alarm time.Time
 
 	perspective protocol.Perspective
+	clock       congestion.Clock
 
 	traceCallback func(quictrace.Event)
 	tracer        logging.ConnectionTracer
@@ -96,27 +101,34 @@ var (
 func newSentPacketHandler(
 	initialPacketNumber protocol.PacketNumber,
 	rttStats *utils.RTTStats,
+	clientAddressValidated bool,
+	maxPacingBurstPackets int,
+	minPacingDelay time.Duration,
 	pers protocol.Perspective,
+	clock congestion.Clock,
 	traceCallback func(quictrace.Event),
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 ) *sentPacketHandler {
 	congestion := congestion.NewCubicSender(
-		congestion.DefaultClock{},
+		clock,
 		rttStats,
 		true, // use Reno
+		maxPacingBurstPackets,
+		minPacingDelay,
 		tracer,
 	)
 
 	return &sentPacketHandler{
 		peerCompletedAddressValidation: pers == protocol.PerspectiveServer,
-		peerAddressValidated:           pers == protocol.PerspectiveClient,
+		peerAddressValidated:           pers == protocol.PerspectiveClient || clientAddressValidated,
 		initialPackets:                 newPacketNumberSpace(initialPacketNumber, rttStats),
 		handshakePackets:               newPacketNumberSpace(0, rttStats),
 		appDataPackets:                 newPacketNumberSpace(0, rttStats),
 		rttStats:                       rttStats,
 		congestion:                     congestion,
 		perspective:                    pers,
+		clock:                          clock,
 		traceCallback:                  traceCallback,
 		tracer:                         tracer,
 		logger:                         logger,
@@ -387,6 +399,9 @@ func (h *sentPacketHandler) detectAndRemoveAckedPackets(ack *wire.AckFrame, encL
 	}
 
 	for _, p := range ackedPackets {
+		if p.lostByLossDetection && h.tracer != nil {
+			h.tracer.DetectedSpuriousLoss(p.EncryptionLevel, p.PacketNumber)
+		}
 		if p.LargestAcked != protocol.InvalidPacketNumber && encLevel == protocol.Encryption1RTT {
 			h.lowestNotConfirmedAcked = utils.MaxPacketNumber(h.lowestNotConfirmedAcked, p.LargestAcked+1)
 		}
@@ -426,7 +441,7 @@ func (h *sentPacketHandler) getLossTimeAndSpace() (time.Time, protocol.Encryptio
 // same logic as getLossTimeAndSpace, but for lastAckElicitingPacketTime instead of lossTime
 func (h *sentPacketHandler) getPTOTimeAndSpace() (time.Time, protocol.EncryptionLevel) {
 	if !h.hasOutstandingPackets() {
-		t := time.Now().Add(h.rttStats.PTO(false) << h.ptoCount)
+		t := h.clock.Now().Add(h.rttStats.PTO(false) << h.ptoCount)
 		if h.initialPackets != nil {
 			return t, protocol.EncryptionInitial
 		}
@@ -561,8 +576,10 @@ func (h *sentPacketHandler) detectLostPackets(now time.Time, encLevel protocol.E
 		h.logger.Debugf("\tlost packets (%d): %d", len(pns), pns)
 	}
 
+	h.packetsLost += uint64(len(lostPackets))
 	for _, p := range lostPackets {
 		p.declaredLost = true
+		p.lostByLossDetection = true
 		h.queueFramesForRetransmission(p)
 		// the bytes in flight need to be reduced no matter if this packet will be retransmitted
 		h.removeFromBytesInFlight(p)
@@ -571,6 +588,7 @@ func (h *sentPacketHandler) detectLostPackets(now time.Time, encLevel protocol.E
 			for _, f := range p.Frames {
 				frames = append(frames, f.Frame)
 			}
+			transportState := h.GetStats()
 			h.traceCallback(quictrace.Event{
 				Time:            now,
 				EventType:       quictrace.PacketLost,
@@ -578,7 +596,7 @@ func (h *sentPacketHandler) detectLostPackets(now time.Time, encLevel protocol.E
 				PacketNumber:    p.PacketNumber,
 				PacketSize:      p.Length,
 				Frames:          frames,
-				TransportState:  h.GetStats(),
+				TransportState:  &transportState,
 			})
 		}
 	}
@@ -610,7 +628,7 @@ func (h *sentPacketHandler) onVerifiedLossDetectionTimeout() error {
 		}
 		// Early retransmit or time loss detection
 		priorInFlight := h.bytesInFlight
-		lostPackets, err := h.detectLostPackets(time.Now(), encLevel)
+		lostPackets, err := h.detectLostPackets(h.clock.Now(), encLevel)
 		if err != nil {
 			return err
 		}
@@ -758,6 +776,13 @@ func (h *sentPacketHandler) queueFramesForRetransmission(p *Packet) {
 	if len(p.Frames) == 0 {
 		panic("no frames")
 	}
+	if h.tracer != nil {
+		frames := make([]logging.Frame, 0, len(p.Frames))
+		for _, f := range p.Frames {
+			frames = append(frames, logutils.ConvertFrame(f.Frame))
+		}
+		h.tracer.QueuedForRetransmission(p.EncryptionLevel, p.PacketNumber, frames)
+	}
 	for _, f := range p.Frames {
 		f.OnLost(f.Frame)
 	}
@@ -789,7 +814,7 @@ func (h *sentPacketHandler) ResetForRetry() error {
 	// Only use the Retry to estimate the RTT if we didn't send any retransmission for the Initial.
 	// Otherwise, we don't know which Initial the Retry was sent in response to.
 	if h.ptoCount == 0 {
-		now := time.Now()
+		now := h.clock.Now()
 		h.rttStats.UpdateRTT(now.Sub(firstPacketSendTime), 0, now)
 		if h.logger.Debug() {
 			h.logger.Debugf("\tupdated RTT: %s (σ: %s)", h.rttStats.SmoothedRTT(), h.rttStats.MeanDeviation())
@@ -819,8 +844,8 @@ func (h *sentPacketHandler) SetHandshakeConfirmed() {
 	h.setLossDetectionTimer()
 }
 
-func (h *sentPacketHandler) GetStats() *quictrace.TransportState {
-	return &quictrace.TransportState{
+func (h *sentPacketHandler) GetStats() quictrace.TransportState {
+	return quictrace.TransportState{
 		MinRTT:           h.rttStats.MinRTT(),
 		SmoothedRTT:      h.rttStats.SmoothedRTT(),
 		LatestRTT:        h.rttStats.LatestRTT(),
@@ -828,5 +853,6 @@ func (h *sentPacketHandler) GetStats() *quictrace.TransportState {
 		CongestionWindow: h.congestion.GetCongestionWindow(),
 		InSlowStart:      h.congestion.InSlowStart(),
 		InRecovery:       h.congestion.InRecovery(),
+		PacketsLost:      h.packetsLost,
 	}
 }
@@ -5,10 +5,13 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/mocks"
+	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -27,7 +30,7 @@ var _ = Describe("SentPacketHandler", func() {
 	JustBeforeEach(func() {
 		lostPackets = nil
 		rttStats := utils.NewRTTStats()
-		handler = newSentPacketHandler(42, rttStats, perspective, nil, nil, utils.DefaultLogger)
+		handler = newSentPacketHandler(42, rttStats, false, protocol.DefaultMaxPacingBurstPackets, protocol.MinPacingDelay, perspective, congestion.DefaultClock{}, nil, nil, utils.DefaultLogger)
 		streamFrame = wire.StreamFrame{
 			StreamID: 5,
 			Data:     []byte{0x13, 0x37},
@@ -535,6 +538,21 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(handler.SendMode()).To(Equal(SendNone))
 		})
 
+		It("doesn't limit the window if the client's address was validated using a token", func() {
+			handler = newSentPacketHandler(42, utils.NewRTTStats(), true, protocol.DefaultMaxPacingBurstPackets, protocol.MinPacingDelay, protocol.PerspectiveServer, congestion.DefaultClock{}, nil, nil, utils.DefaultLogger)
+			handler.congestion = cong
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), true)
+			handler.SentPacket(&Packet{
+				PacketNumber:    1,
+				Length:          1000,
+				EncryptionLevel: protocol.EncryptionInitial,
+				Frames:          []Frame{{Frame: &wire.PingFrame{}}},
+				SendTime:        time.Now(),
+			})
+			cong.EXPECT().CanSend(protocol.ByteCount(1000)).Return(true)
+			Expect(handler.SendMode()).To(Equal(SendAny))
+		})
+
 		It("allows sending of ACKs when congestion limited", func() {
 			handler.ReceivedPacket(protocol.EncryptionHandshake)
 			cong.EXPECT().CanSend(gomock.Any()).Return(true)
@@ -904,6 +922,30 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())).To(Succeed())
 			expectInPacketHistory([]protocol.PacketNumber{4, 5}, protocol.Encryption1RTT)
 			Expect(lostPackets).To(Equal([]protocol.PacketNumber{1, 2, 3}))
+			Expect(handler.GetStats().PacketsLost).To(BeEquivalentTo(3))
+		})
+
+		It("traces the frames queued for retransmission, and spurious losses", func() {
+			tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+			handler.tracer = tracer
+			tracer.EXPECT().UpdatedMetrics(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			tracer.EXPECT().SetLossTimer(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			tracer.EXPECT().LossTimerCanceled().AnyTimes()
+			for i := protocol.PacketNumber(1); i <= 6; i++ {
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: i}))
+			}
+			for i := protocol.PacketNumber(1); i <= 3; i++ {
+				tracer.EXPECT().LostPacket(protocol.Encryption1RTT, i, logging.PacketLossReorderingThreshold)
+				tracer.EXPECT().QueuedForRetransmission(protocol.Encryption1RTT, i, []logging.Frame{&logging.PingFrame{}})
+			}
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 6, Largest: 6}}}
+			Expect(handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())).To(Succeed())
+			// now the peer acknowledges the packets that were declared lost
+			for i := protocol.PacketNumber(1); i <= 3; i++ {
+				tracer.EXPECT().DetectedSpuriousLoss(protocol.Encryption1RTT, i)
+			}
+			ack = &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 6}}}
+			Expect(handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())).To(Succeed())
 		})
 	})
 
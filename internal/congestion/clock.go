@@ -16,3 +16,32 @@ var _ Clock = DefaultClock{}
 func (DefaultClock) Now() time.Time {
 	return time.Now()
 }
+
+// A CachedClock caches the time of an underlying clock.
+// The time only advances when Update is called.
+// It is used to avoid reading the clock for every packet on the hot paths:
+// the session updates it once per iteration of its run loop.
+type CachedClock struct {
+	clock Clock
+	now   time.Time
+}
+
+var _ Clock = &CachedClock{}
+
+// NewCachedClock creates a new CachedClock, initialized to the current time of clock.
+func NewCachedClock(clock Clock) *CachedClock {
+	c := &CachedClock{clock: clock}
+	c.Update()
+	return c
+}
+
+// Update reads the underlying clock, and returns the new time.
+func (c *CachedClock) Update() time.Time {
+	c.now = c.clock.Now()
+	return c.now
+}
+
+// Now returns the time of the last update.
+func (c *CachedClock) Now() time.Time {
+	return c.now
+}
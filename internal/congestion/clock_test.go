@@ -0,0 +1,21 @@
+package congestion
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cached Clock", func() {
+	It("only advances when updated", func() {
+		clock := mockClock(time.Now())
+		c := NewCachedClock(&clock)
+		Expect(c.Now()).To(Equal(clock.Now()))
+		start := c.Now()
+		clock.Advance(time.Second)
+		Expect(c.Now()).To(Equal(start))
+		Expect(c.Update()).To(Equal(start.Add(time.Second)))
+		Expect(c.Now()).To(Equal(start.Add(time.Second)))
+	})
+})
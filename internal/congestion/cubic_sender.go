@@ -69,11 +69,19 @@ var (
 )
 
 // NewCubicSender makes a new cubic sender
-func NewCubicSender(clock Clock, rttStats *utils.RTTStats, reno bool, tracer logging.ConnectionTracer) *cubicSender {
-	return newCubicSender(clock, rttStats, reno, initialCongestionWindow, maxCongestionWindow, tracer)
+func NewCubicSender(clock Clock, rttStats *utils.RTTStats, reno bool, maxPacingBurstPackets int, minPacingDelay time.Duration, tracer logging.ConnectionTracer) *cubicSender {
+	return newCubicSender(clock, rttStats, reno, initialCongestionWindow, maxCongestionWindow, maxPacingBurstPackets, minPacingDelay, tracer)
 }
 
-func newCubicSender(clock Clock, rttStats *utils.RTTStats, reno bool, initialCongestionWindow, initialMaxCongestionWindow protocol.ByteCount, tracer logging.ConnectionTracer) *cubicSender {
+func newCubicSender(
+	clock Clock,
+	rttStats *utils.RTTStats,
+	reno bool,
+	initialCongestionWindow, initialMaxCongestionWindow protocol.ByteCount,
+	maxPacingBurstPackets int,
+	minPacingDelay time.Duration,
+	tracer logging.ConnectionTracer,
+) *cubicSender {
 	c := &cubicSender{
 		rttStats:                   rttStats,
 		largestSentPacketNumber:    protocol.InvalidPacketNumber,
@@ -90,7 +98,7 @@ func newCubicSender(clock Clock, rttStats *utils.RTTStats, reno bool, initialCon
 		reno:                       reno,
 		tracer:                     tracer,
 	}
-	c.pacer = newPacer(c.BandwidthEstimate)
+	c.pacer = newPacer(c.BandwidthEstimate, maxPacingBurstPackets, minPacingDelay)
 	if c.tracer != nil {
 		c.lastState = logging.CongestionStateSlowStart
 		c.tracer.UpdatedCongestionState(logging.CongestionStateSlowStart)
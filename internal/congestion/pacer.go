@@ -8,17 +8,22 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/utils"
 )
 
-const maxBurstSize = 10 * maxDatagramSize
-
 // The pacer implements a token bucket pacing algorithm.
 type pacer struct {
 	budgetAtLastSent     protocol.ByteCount
 	lastSentTime         time.Time
 	getAdjustedBandwidth func() uint64 // in bytes/s
+
+	minBurstSize   protocol.ByteCount
+	minPacingDelay time.Duration
 }
 
-func newPacer(getBandwidth func() Bandwidth) *pacer {
-	p := &pacer{getAdjustedBandwidth: func() uint64 {
+func newPacer(getBandwidth func() Bandwidth, maxBurstPackets int, minPacingDelay time.Duration) *pacer {
+	p := &pacer{
+		minBurstSize:   protocol.ByteCount(maxBurstPackets) * maxDatagramSize,
+		minPacingDelay: minPacingDelay,
+	}
+	p.getAdjustedBandwidth = func() uint64 {
 		// Bandwidth is in bits/s. We need the value in bytes/s.
 		bw := uint64(getBandwidth() / BytesPerSecond)
 		// Use a slightly higher value than the actual measured bandwidth.
@@ -26,7 +31,7 @@ func newPacer(getBandwidth func() Bandwidth) *pacer {
 		// Ultimately, this will  result in sending packets as acknowledgments are received rather than when timers fire,
 		// provided the congestion window is fully utilized and acknowledgments arrive at regular intervals.
 		return bw * 5 / 4
-	}}
+	}
 	p.budgetAtLastSent = p.maxBurstSize()
 	return p
 }
@@ -51,8 +56,8 @@ func (p *pacer) Budget(now time.Time) protocol.ByteCount {
 
 func (p *pacer) maxBurstSize() protocol.ByteCount {
 	return utils.MaxByteCount(
-		protocol.ByteCount(uint64((protocol.MinPacingDelay+protocol.TimerGranularity).Nanoseconds())*p.getAdjustedBandwidth())/1e9,
-		maxBurstSize,
+		protocol.ByteCount(uint64((p.minPacingDelay+protocol.TimerGranularity).Nanoseconds())*p.getAdjustedBandwidth())/1e9,
+		p.minBurstSize,
 	)
 }
 
@@ -62,7 +67,7 @@ func (p *pacer) TimeUntilSend() time.Time {
 		return time.Time{}
 	}
 	return p.lastSentTime.Add(utils.MaxDuration(
-		protocol.MinPacingDelay,
+		p.minPacingDelay,
 		time.Duration(math.Ceil(float64(maxDatagramSize-p.budgetAtLastSent)*1e9/float64(p.getAdjustedBandwidth())))*time.Nanosecond,
 	))
 }
@@ -10,6 +10,8 @@ import (
 )
 
 var _ = Describe("Pacer", func() {
+	const maxBurstSize = protocol.DefaultMaxPacingBurstPackets * maxDatagramSize
+
 	var p *pacer
 
 	const packetsPerSecond = 50
@@ -19,7 +21,7 @@ var _ = Describe("Pacer", func() {
 		bandwidth = uint64(packetsPerSecond * maxDatagramSize) // 50 full-size packets per second
 		// The pacer will multiply the bandwidth with 1.25 to achieve a slightly higher pacing speed.
 		// For the tests, cancel out this factor, so we can do the math using the exact bandwidth.
-		p = newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond * 4 / 5 })
+		p = newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond * 4 / 5 }, protocol.DefaultMaxPacingBurstPackets, protocol.MinPacingDelay)
 	})
 
 	It("allows a burst at the beginning", func() {
@@ -109,4 +111,20 @@ var _ = Describe("Pacer", func() {
 		Expect(p.TimeUntilSend()).To(Equal(t.Add(protocol.MinPacingDelay)))
 		Expect(p.Budget(t.Add(protocol.MinPacingDelay))).To(Equal(protocol.ByteCount(protocol.MinPacingDelay) * maxDatagramSize * 1e6 / 1e9))
 	})
+
+	It("uses the configured burst size", func() {
+		p = newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond * 4 / 5 }, 3, protocol.MinPacingDelay)
+		t := time.Now()
+		Expect(p.Budget(t)).To(BeEquivalentTo(3 * maxDatagramSize))
+		sendBurst(t)
+		Expect(p.Budget(t.Add(time.Hour))).To(BeEquivalentTo(3 * maxDatagramSize))
+	})
+
+	It("uses the configured minimum pacing duration", func() {
+		p = newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond * 4 / 5 }, protocol.DefaultMaxPacingBurstPackets, 5*time.Millisecond)
+		t := time.Now()
+		sendBurst(t)
+		bandwidth = uint64(1e6 * maxDatagramSize)
+		Expect(p.TimeUntilSend()).To(Equal(t.Add(5 * time.Millisecond)))
+	})
 })
@@ -21,6 +21,8 @@ type baseFlowController struct {
 	receiveWindow        protocol.ByteCount
 	receiveWindowSize    protocol.ByteCount
 	maxReceiveWindowSize protocol.ByteCount
+	// If set, increases of the receive window size are reserved from this budget.
+	memoryBudget *MemoryBudget
 
 	epochStartTime   time.Time
 	epochStartOffset protocol.ByteCount
@@ -86,7 +88,8 @@ func (c *baseFlowController) getWindowUpdate() protocol.ByteCount {
 	}
 
 	c.maybeAdjustWindowSize()
-	c.receiveWindow = c.bytesRead + c.receiveWindowSize
+	// The window size might have been decreased, but we must never decrease the window itself.
+	c.receiveWindow = utils.MaxByteCount(c.receiveWindow, c.bytesRead+c.receiveWindowSize)
 	return c.receiveWindow
 }
 
@@ -107,11 +110,20 @@ func (c *baseFlowController) maybeAdjustWindowSize() {
 	now := time.Now()
 	if now.Sub(c.epochStartTime) < time.Duration(4*fraction*float64(rtt)) {
 		// window is consumed too fast, try to increase the window size
-		c.receiveWindowSize = utils.MinByteCount(2*c.receiveWindowSize, c.maxReceiveWindowSize)
+		c.growReceiveWindowSize(utils.MinByteCount(2*c.receiveWindowSize, c.maxReceiveWindowSize))
 	}
 	c.startNewAutoTuningEpoch(now)
 }
 
+// growReceiveWindowSize increases the receive window size to size,
+// as far as the memory budget allows.
+func (c *baseFlowController) growReceiveWindowSize(size protocol.ByteCount) {
+	if size <= c.receiveWindowSize {
+		return
+	}
+	c.receiveWindowSize += c.memoryBudget.Reserve(size - c.receiveWindowSize)
+}
+
 func (c *baseFlowController) startNewAutoTuningEpoch(now time.Time) {
 	c.epochStartTime = now
 	c.epochStartOffset = c.bytesRead
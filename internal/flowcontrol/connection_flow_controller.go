@@ -12,6 +12,8 @@ import (
 type connectionFlowController struct {
 	baseFlowController
 
+	initialReceiveWindowSize protocol.ByteCount
+
 	queueWindowUpdate func()
 }
 
@@ -19,22 +21,28 @@ var _ ConnectionFlowController = &connectionFlowController{}
 
 // NewConnectionFlowController gets a new flow controller for the connection
 // It is created before we receive the peer's transport paramenters, thus it starts with a sendWindow of 0.
+// The memoryBudget may be nil.
 func NewConnectionFlowController(
 	receiveWindow protocol.ByteCount,
 	maxReceiveWindow protocol.ByteCount,
+	memoryBudget *MemoryBudget,
 	queueWindowUpdate func(),
 	rttStats *utils.RTTStats,
 	logger utils.Logger,
 ) ConnectionFlowController {
+	// The initial receive window is sent in the transport parameters, so we can't refuse it.
+	memoryBudget.Add(receiveWindow)
 	return &connectionFlowController{
 		baseFlowController: baseFlowController{
 			rttStats:             rttStats,
 			receiveWindow:        receiveWindow,
 			receiveWindowSize:    receiveWindow,
 			maxReceiveWindowSize: maxReceiveWindow,
+			memoryBudget:         memoryBudget,
 			logger:               logger,
 		},
-		queueWindowUpdate: queueWindowUpdate,
+		initialReceiveWindowSize: receiveWindow,
+		queueWindowUpdate:        queueWindowUpdate,
 	}
 }
 
@@ -70,6 +78,9 @@ func (c *connectionFlowController) maybeQueueWindowUpdate() {
 
 func (c *connectionFlowController) GetWindowUpdate() protocol.ByteCount {
 	c.mutex.Lock()
+	if c.memoryBudget.Exceeded() {
+		c.shrinkReceiveWindowSize()
+	}
 	oldWindowSize := c.receiveWindowSize
 	offset := c.baseFlowController.getWindowUpdate()
 	if oldWindowSize < c.receiveWindowSize {
@@ -79,14 +90,41 @@ func (c *connectionFlowController) GetWindowUpdate() protocol.ByteCount {
 	return offset
 }
 
+// shrinkReceiveWindowSize halves the receive window size (but not below the initial window size),
+// and returns the memory to the memory budget.
+func (c *connectionFlowController) shrinkReceiveWindowSize() {
+	newSize := utils.MaxByteCount(c.receiveWindowSize/2, c.initialReceiveWindowSize)
+	if newSize >= c.receiveWindowSize {
+		return
+	}
+	c.logger.Debugf("Memory budget exceeded. Decreasing receive flow control window for the connection to %d kB", newSize/(1<<10))
+	c.memoryBudget.Release(c.receiveWindowSize - newSize)
+	c.receiveWindowSize = newSize
+	c.startNewAutoTuningEpoch(time.Now())
+}
+
 // EnsureMinimumWindowSize sets a minimum window size
 // it should make sure that the connection-level window is increased when a stream-level window grows
 func (c *connectionFlowController) EnsureMinimumWindowSize(inc protocol.ByteCount) {
 	c.mutex.Lock()
 	if inc > c.receiveWindowSize {
 		c.logger.Debugf("Increasing receive flow control window for the connection to %d kB, in response to stream flow control window increase", c.receiveWindowSize/(1<<10))
-		c.receiveWindowSize = utils.MinByteCount(inc, c.maxReceiveWindowSize)
+		c.growReceiveWindowSize(utils.MinByteCount(inc, c.maxReceiveWindowSize))
 		c.startNewAutoTuningEpoch(time.Now())
 	}
 	c.mutex.Unlock()
 }
+
+func (c *connectionFlowController) BufferedBytes() protocol.ByteCount {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.highestReceived - c.bytesRead
+}
+
+// Close returns the memory reserved for the receive window to the memory budget.
+func (c *connectionFlowController) Close() {
+	c.mutex.Lock()
+	c.memoryBudget.Release(c.receiveWindowSize)
+	c.receiveWindowSize = 0
+	c.mutex.Unlock()
+}
@@ -36,7 +36,7 @@ var _ = Describe("Connection Flow controller", func() {
 			receiveWindow := protocol.ByteCount(2000)
 			maxReceiveWindow := protocol.ByteCount(3000)
 
-			fc := NewConnectionFlowController(receiveWindow, maxReceiveWindow, nil, rttStats, utils.DefaultLogger).(*connectionFlowController)
+			fc := NewConnectionFlowController(receiveWindow, maxReceiveWindow, nil, nil, rttStats, utils.DefaultLogger).(*connectionFlowController)
 			Expect(fc.receiveWindow).To(Equal(receiveWindow))
 			Expect(fc.maxReceiveWindowSize).To(Equal(maxReceiveWindow))
 		})
@@ -49,6 +49,14 @@ var _ = Describe("Connection Flow controller", func() {
 			Expect(controller.highestReceived).To(Equal(protocol.ByteCount(1337 + 123)))
 		})
 
+		It("says how many bytes are buffered", func() {
+			controller.receiveWindow = 10000
+			Expect(controller.IncrementHighestReceived(1000)).To(Succeed())
+			Expect(controller.BufferedBytes()).To(Equal(protocol.ByteCount(1000)))
+			controller.AddBytesRead(400)
+			Expect(controller.BufferedBytes()).To(Equal(protocol.ByteCount(600)))
+		})
+
 		Context("getting window updates", func() {
 			BeforeEach(func() {
 				controller.receiveWindow = 100
@@ -77,6 +85,51 @@ var _ = Describe("Connection Flow controller", func() {
 				Expect(offset).To(Equal(oldOffset + dataRead + 60))
 			})
 
+			It("doesn't autotune the window beyond the memory budget", func() {
+				controller.memoryBudget = NewMemoryBudget(90)
+				controller.memoryBudget.Add(controller.receiveWindowSize)
+				oldOffset := controller.bytesRead
+				oldWindowSize := controller.receiveWindowSize
+				setRtt(scaleDuration(20 * time.Millisecond))
+				controller.epochStartTime = time.Now().Add(-time.Millisecond)
+				controller.epochStartOffset = oldOffset
+				dataRead := oldWindowSize/2 + 1
+				controller.AddBytesRead(dataRead)
+				offset := controller.GetWindowUpdate()
+				Expect(controller.receiveWindowSize).To(Equal(protocol.ByteCount(90)))
+				Expect(offset).To(Equal(oldOffset + dataRead + 90))
+				Expect(controller.memoryBudget.Reserve(1)).To(BeZero())
+			})
+
+			It("shrinks the window when the memory budget is exceeded", func() {
+				controller.initialReceiveWindowSize = 20
+				controller.memoryBudget = NewMemoryBudget(50)
+				controller.memoryBudget.Add(controller.receiveWindowSize + 10)
+				oldReceiveWindow := controller.receiveWindow
+				controller.AddBytesRead(50)
+				offset := controller.GetWindowUpdate()
+				Expect(controller.receiveWindowSize).To(Equal(protocol.ByteCount(30)))
+				Expect(offset).To(Equal(controller.bytesRead + 30))
+				Expect(offset).To(BeNumerically(">", oldReceiveWindow))
+				Expect(controller.memoryBudget.Exceeded()).To(BeFalse())
+			})
+
+			It("doesn't shrink the window below the initial window size", func() {
+				controller.initialReceiveWindowSize = 60
+				controller.memoryBudget = NewMemoryBudget(50)
+				controller.memoryBudget.Add(controller.receiveWindowSize)
+				controller.AddBytesRead(30)
+				controller.GetWindowUpdate()
+				Expect(controller.receiveWindowSize).To(Equal(protocol.ByteCount(60)))
+			})
+
+			It("releases the memory when closed", func() {
+				controller.memoryBudget = NewMemoryBudget(100)
+				controller.memoryBudget.Add(controller.receiveWindowSize)
+				controller.Close()
+				Expect(controller.memoryBudget.Reserve(100)).To(Equal(protocol.ByteCount(100)))
+			})
+
 			It("autotunes the window", func() {
 				oldOffset := controller.bytesRead
 				oldWindowSize := controller.receiveWindowSize
@@ -124,6 +177,13 @@ var _ = Describe("Connection Flow controller", func() {
 			Expect(controller.receiveWindowSize).To(Equal(max))
 		})
 
+		It("doesn't increase the window size beyond the memory budget", func() {
+			controller.memoryBudget = NewMemoryBudget(1500)
+			controller.memoryBudget.Add(receiveWindowSize)
+			controller.EnsureMinimumWindowSize(1800)
+			Expect(controller.receiveWindowSize).To(Equal(protocol.ByteCount(1500)))
+		})
+
 		It("starts a new epoch after the window size was increased", func() {
 			controller.EnsureMinimumWindowSize(1912)
 			Expect(controller.epochStartTime).To(BeTemporally("~", time.Now(), 100*time.Millisecond))
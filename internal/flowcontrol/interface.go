@@ -29,6 +29,11 @@ type StreamFlowController interface {
 // The ConnectionFlowController is the flow controller for the connection.
 type ConnectionFlowController interface {
 	flowController
+	// BufferedBytes returns the number of bytes that were received, but not yet read by the application.
+	BufferedBytes() protocol.ByteCount
+	// Close should be called when the connection is closed.
+	// It releases the memory reserved from the memory budget.
+	Close()
 }
 
 type connectionFlowControllerI interface {
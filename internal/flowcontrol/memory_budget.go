@@ -0,0 +1,73 @@
+package flowcontrol
+
+import (
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// A MemoryBudget limits the sum of the receive windows of multiple connections.
+// Since the receive window limits the amount of data a peer can send,
+// this limits the memory used for buffering received data.
+// A nil MemoryBudget doesn't impose any limit.
+type MemoryBudget struct {
+	mutex sync.Mutex
+
+	max  protocol.ByteCount
+	used protocol.ByteCount
+}
+
+// NewMemoryBudget creates a new memory budget
+func NewMemoryBudget(max protocol.ByteCount) *MemoryBudget {
+	return &MemoryBudget{max: max}
+}
+
+// Add adds n bytes to the budget, even if this exceeds the budget.
+// It is used for the initial receive window, which is sent in the transport parameters.
+func (b *MemoryBudget) Add(n protocol.ByteCount) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	b.used += n
+	b.mutex.Unlock()
+}
+
+// Reserve tries to reserve n bytes.
+// It returns the number of bytes that were actually reserved, which might be less than n.
+func (b *MemoryBudget) Reserve(n protocol.ByteCount) protocol.ByteCount {
+	if b == nil {
+		return n
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.used >= b.max {
+		return 0
+	}
+	if b.used+n > b.max {
+		n = b.max - b.used
+	}
+	b.used += n
+	return n
+}
+
+// Release releases n bytes.
+func (b *MemoryBudget) Release(n protocol.ByteCount) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	b.used -= n
+	b.mutex.Unlock()
+}
+
+// Exceeded says if more than the budget is used.
+func (b *MemoryBudget) Exceeded() bool {
+	if b == nil {
+		return false
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.used > b.max
+}
@@ -0,0 +1,41 @@
+package flowcontrol
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Memory Budget", func() {
+	It("reserves memory", func() {
+		b := NewMemoryBudget(100)
+		Expect(b.Reserve(60)).To(Equal(protocol.ByteCount(60)))
+		Expect(b.Reserve(60)).To(Equal(protocol.ByteCount(40)))
+		Expect(b.Reserve(1)).To(BeZero())
+		b.Release(30)
+		Expect(b.Reserve(60)).To(Equal(protocol.ByteCount(30)))
+	})
+
+	It("adds memory beyond the budget", func() {
+		b := NewMemoryBudget(100)
+		b.Add(80)
+		Expect(b.Exceeded()).To(BeFalse())
+		b.Add(80)
+		Expect(b.Exceeded()).To(BeTrue())
+		Expect(b.Reserve(10)).To(BeZero())
+		b.Release(60)
+		Expect(b.Exceeded()).To(BeFalse())
+		Expect(b.Reserve(100)).To(Equal(protocol.ByteCount(0)))
+		b.Release(1)
+		Expect(b.Reserve(100)).To(Equal(protocol.ByteCount(1)))
+	})
+
+	It("doesn't limit anything if nil", func() {
+		var b *MemoryBudget
+		b.Add(1000)
+		Expect(b.Reserve(1337)).To(Equal(protocol.ByteCount(1337)))
+		Expect(b.Exceeded()).To(BeFalse())
+		b.Release(1000)
+	})
+})
@@ -99,6 +99,8 @@ type cryptoSetup struct {
 	ourParams  *wire.TransportParameters
 	peerParams *wire.TransportParameters
 	paramsChan <-chan []byte
+	// if set, no greased transport parameter is sent
+	disableGreasing bool
 
 	runner handshakeRunner
 
@@ -158,6 +160,7 @@ func NewCryptoSetupClient(
 	runner handshakeRunner,
 	tlsConf *tls.Config,
 	enable0RTT bool,
+	disableGreasing bool,
 	rttStats *utils.RTTStats,
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
@@ -171,6 +174,7 @@ func NewCryptoSetupClient(
 		runner,
 		tlsConf,
 		enable0RTT,
+		disableGreasing,
 		rttStats,
 		tracer,
 		logger,
@@ -191,6 +195,7 @@ func NewCryptoSetupServer(
 	runner handshakeRunner,
 	tlsConf *tls.Config,
 	enable0RTT bool,
+	disableGreasing bool,
 	rttStats *utils.RTTStats,
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
@@ -204,6 +209,7 @@ func NewCryptoSetupServer(
 		runner,
 		tlsConf,
 		enable0RTT,
+		disableGreasing,
 		rttStats,
 		tracer,
 		logger,
@@ -221,6 +227,7 @@ func newCryptoSetup(
 	runner handshakeRunner,
 	tlsConf *tls.Config,
 	enable0RTT bool,
+	disableGreasing bool,
 	rttStats *utils.RTTStats,
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
@@ -231,7 +238,6 @@ func newCryptoSetup(
 		tracer.UpdatedKeyFromTLS(protocol.EncryptionInitial, protocol.PerspectiveClient)
 		tracer.UpdatedKeyFromTLS(protocol.EncryptionInitial, protocol.PerspectiveServer)
 	}
-	extHandler := newExtensionHandler(tp.Marshal(perspective), perspective)
 	cs := &cryptoSetup{
 		tlsConf:                   tlsConf,
 		initialStream:             initialStream,
@@ -243,7 +249,7 @@ func newCryptoSetup(
 		writeEncLevel:             protocol.EncryptionInitial,
 		runner:                    runner,
 		ourParams:                 tp,
-		paramsChan:                extHandler.TransportParameters(),
+		disableGreasing:           disableGreasing,
 		rttStats:                  rttStats,
 		tracer:                    tracer,
 		logger:                    logger,
@@ -255,6 +261,12 @@ func newCryptoSetup(
 		isReadingHandshakeMessage: make(chan struct{}),
 		closeChan:                 make(chan struct{}),
 	}
+	var updateParams func([]byte) []byte
+	if perspective == protocol.PerspectiveServer {
+		updateParams = cs.upgradeVersion
+	}
+	extHandler := newExtensionHandler(tp.Marshal(perspective, !disableGreasing), perspective, updateParams)
+	cs.paramsChan = extHandler.TransportParameters()
 	var maxEarlyData uint32
 	if enable0RTT {
 		maxEarlyData = 0xffffffff
@@ -441,6 +453,31 @@ func (h *cryptoSetup) handleDataFromSessionStateImpl(data []byte) (*wire.Transpo
 	return &tp, nil
 }
 
+// upgradeVersion is called by the server when it receives the client's transport parameters.
+// If both support a compatible version that we prefer over the version of the connection attempt,
+// the connection is upgraded to that version, without an additional round trip.
+// The session makes the same decision when it processes the client's transport parameters.
+// It returns the transport parameters with the updated version_information,
+// or nil if the version isn't changed.
+func (h *cryptoSetup) upgradeVersion(data []byte) []byte {
+	vi := h.ourParams.VersionInformation
+	if vi == nil {
+		return nil
+	}
+	var tp wire.TransportParameters
+	// Invalid transport parameters are rejected when they are handled by the run loop.
+	if err := tp.Unmarshal(data, protocol.PerspectiveClient); err != nil || tp.VersionInformation == nil {
+		return nil
+	}
+	v := protocol.ChooseCompatibleVersion(vi.ChosenVersion, vi.AvailableVersions, tp.VersionInformation.AvailableVersions)
+	if v == vi.ChosenVersion {
+		return nil
+	}
+	params := *h.ourParams
+	params.VersionInformation = &wire.VersionInformation{ChosenVersion: v, AvailableVersions: vi.AvailableVersions}
+	return params.Marshal(protocol.PerspectiveServer, !h.disableGreasing)
+}
+
 // only valid for the server
 func (h *cryptoSetup) GetSessionTicket() ([]byte, error) {
 	var appData []byte
@@ -96,6 +96,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 			runner,
 			testdata.GetTLSConfig(),
 			false,
+			false,
 			&utils.RTTStats{},
 			nil,
 			utils.DefaultLogger.WithPrefix("server"),
@@ -121,6 +122,51 @@ var _ = Describe("Crypto Setup TLS", func() {
 		Eventually(done).Should(BeClosed())
 	})
 
+	It("upgrades to a compatible version supported by the client", func() {
+		_, sInitialStream, sHandshakeStream := initStreams()
+		var token protocol.StatelessResetToken
+		server := NewCryptoSetupServer(
+			sInitialStream,
+			sHandshakeStream,
+			protocol.ConnectionID{},
+			nil,
+			nil,
+			&wire.TransportParameters{
+				StatelessResetToken: &token,
+				VersionInformation: &wire.VersionInformation{
+					ChosenVersion:     protocol.VersionDraft29,
+					AvailableVersions: []protocol.VersionNumber{protocol.VersionDraft32, protocol.VersionDraft29},
+				},
+			},
+			NewMockHandshakeRunner(mockCtrl),
+			testdata.GetTLSConfig(),
+			false,
+			false,
+			&utils.RTTStats{},
+			nil,
+			utils.DefaultLogger.WithPrefix("server"),
+			protocol.VersionDraft29,
+		)
+		clientParams := func(versions ...protocol.VersionNumber) []byte {
+			return (&wire.TransportParameters{
+				InitialSourceConnectionID: protocol.ConnectionID{1, 2, 3, 4},
+				VersionInformation: &wire.VersionInformation{
+					ChosenVersion:     protocol.VersionDraft29,
+					AvailableVersions: versions,
+				},
+			}).Marshal(protocol.PerspectiveClient, true)
+		}
+		// the client doesn't support draft-32
+		Expect(server.(*cryptoSetup).upgradeVersion(clientParams(protocol.VersionDraft29))).To(BeNil())
+		data := server.(*cryptoSetup).upgradeVersion(clientParams(protocol.VersionDraft29, protocol.VersionDraft32))
+		Expect(data).ToNot(BeNil())
+		var tp wire.TransportParameters
+		Expect(tp.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
+		Expect(tp.VersionInformation.ChosenVersion).To(Equal(protocol.VersionDraft32))
+		Expect(tp.VersionInformation.AvailableVersions).To(Equal([]protocol.VersionNumber{protocol.VersionDraft32, protocol.VersionDraft29}))
+		Expect(tp.StatelessResetToken).To(Equal(&token))
+	})
+
 	It("errors when a message is received at the wrong encryption level", func() {
 		sErrChan := make(chan error, 1)
 		_, sInitialStream, sHandshakeStream := initStreams()
@@ -137,6 +183,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 			runner,
 			testdata.GetTLSConfig(),
 			false,
+			false,
 			&utils.RTTStats{},
 			nil,
 			utils.DefaultLogger.WithPrefix("server"),
@@ -181,6 +228,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 			runner,
 			serverConf,
 			false,
+			false,
 			&utils.RTTStats{},
 			nil,
 			utils.DefaultLogger.WithPrefix("server"),
@@ -218,6 +266,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 			NewMockHandshakeRunner(mockCtrl),
 			serverConf,
 			false,
+			false,
 			&utils.RTTStats{},
 			nil,
 			utils.DefaultLogger.WithPrefix("server"),
@@ -335,6 +384,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				cRunner,
 				clientConf,
 				enable0RTT,
+				false,
 				clientRTTStats,
 				nil,
 				utils.DefaultLogger.WithPrefix("client"),
@@ -362,6 +412,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				sRunner,
 				serverConf,
 				enable0RTT,
+				false,
 				serverRTTStats,
 				nil,
 				utils.DefaultLogger.WithPrefix("server"),
@@ -432,6 +483,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				runner,
 				&tls.Config{InsecureSkipVerify: true},
 				false,
+				false,
 				&utils.RTTStats{},
 				nil,
 				utils.DefaultLogger.WithPrefix("client"),
@@ -475,6 +527,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				cRunner,
 				clientConf,
 				false,
+				false,
 				&utils.RTTStats{},
 				nil,
 				utils.DefaultLogger.WithPrefix("client"),
@@ -500,6 +553,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				sRunner,
 				serverConf,
 				false,
+				false,
 				&utils.RTTStats{},
 				nil,
 				utils.DefaultLogger.WithPrefix("server"),
@@ -534,6 +588,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 					cRunner,
 					clientConf,
 					false,
+					false,
 					&utils.RTTStats{},
 					nil,
 					utils.DefaultLogger.WithPrefix("client"),
@@ -555,6 +610,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 					sRunner,
 					serverConf,
 					false,
+					false,
 					&utils.RTTStats{},
 					nil,
 					utils.DefaultLogger.WithPrefix("server"),
@@ -596,6 +652,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 					cRunner,
 					clientConf,
 					false,
+					false,
 					&utils.RTTStats{},
 					nil,
 					utils.DefaultLogger.WithPrefix("client"),
@@ -617,6 +674,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 					sRunner,
 					serverConf,
 					false,
+					false,
 					&utils.RTTStats{},
 					nil,
 					utils.DefaultLogger.WithPrefix("server"),
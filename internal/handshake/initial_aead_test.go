@@ -2,6 +2,7 @@ package handshake
 
 import (
 	"math/rand"
+	"testing"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 
@@ -84,6 +85,24 @@ var _ = Describe("Initial AEAD using AES-GCM", func() {
 		Expect(m).To(Equal([]byte("raboof")))
 	})
 
+	It("doesn't allocate when sealing and opening in place", func() {
+		connID := protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad}
+		sealer, _ := NewInitialAEAD(connID, protocol.PerspectiveClient)
+		_, opener := NewInitialAEAD(connID, protocol.PerspectiveServer)
+		hdr := []byte{0xc3, 0, 1, 2, 3, 4, 0xde, 0xad, 0xbe, 0xef}
+		buf := make([]byte, 0, 100+sealer.Overhead())
+		allocs := testing.AllocsPerRun(100, func() {
+			data := buf[:100]
+			sealed := sealer.Seal(data[:0], data, 42, hdr)
+			sealer.EncryptHeader(sealed[:16], &hdr[0], hdr[6:10])
+			opener.DecryptHeader(sealed[:16], &hdr[0], hdr[6:10])
+			if _, err := opener.Open(sealed[:0], sealed, 42, hdr); err != nil {
+				Fail(err.Error())
+			}
+		})
+		Expect(allocs).To(BeZero())
+	})
+
 	It("doesn't work if initialized with different connection IDs", func() {
 		c1 := protocol.ConnectionID{0, 0, 0, 0, 0, 0, 0, 1}
 		c2 := protocol.ConnectionID{0, 0, 0, 0, 0, 0, 0, 2}
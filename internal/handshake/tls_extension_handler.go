@@ -11,17 +11,23 @@ type extensionHandler struct {
 	ourParams  []byte
 	paramsChan chan []byte
 
+	// Only used by the server.
+	// It is called with the client's transport parameters, before we send our transport parameters.
+	// If our transport parameters need to be changed, it returns the new transport parameters.
+	updateParams func(clientParams []byte) []byte
+
 	perspective protocol.Perspective
 }
 
 var _ tlsExtensionHandler = &extensionHandler{}
 
 // newExtensionHandler creates a new extension handler
-func newExtensionHandler(params []byte, pers protocol.Perspective) tlsExtensionHandler {
+func newExtensionHandler(params []byte, pers protocol.Perspective, updateParams func([]byte) []byte) tlsExtensionHandler {
 	return &extensionHandler{
-		ourParams:   params,
-		paramsChan:  make(chan []byte),
-		perspective: pers,
+		ourParams:    params,
+		paramsChan:   make(chan []byte),
+		updateParams: updateParams,
+		perspective:  pers,
 	}
 }
 
@@ -50,6 +56,12 @@ func (h *extensionHandler) ReceivedExtensions(msgType uint8, exts []qtls.Extensi
 		}
 	}
 
+	// The ClientHello is received before the EncryptedExtensions are sent.
+	if h.updateParams != nil {
+		if params := h.updateParams(data); params != nil {
+			h.ourParams = params
+		}
+	}
 	h.paramsChan <- data
 }
 
@@ -18,10 +18,12 @@ var _ = Describe("TLS Extension Handler, for the server", func() {
 		handlerServer = newExtensionHandler(
 			[]byte("foobar"),
 			protocol.PerspectiveServer,
+			nil,
 		)
 		handlerClient = newExtensionHandler(
 			[]byte("raboof"),
 			protocol.PerspectiveClient,
+			nil,
 		)
 	})
 
@@ -83,6 +85,28 @@ var _ = Describe("TLS Extension Handler, for the server", func() {
 				Expect(data).To(BeEmpty())
 			})
 
+			It("updates the TransportParameters when receiving the ClientHello", func() {
+				var clientParams []byte
+				handlerServer = newExtensionHandler(
+					[]byte("foobar"),
+					protocol.PerspectiveServer,
+					func(data []byte) []byte {
+						clientParams = data
+						return []byte("updated")
+					},
+				)
+				go func() {
+					defer GinkgoRecover()
+					handlerServer.ReceivedExtensions(uint8(typeClientHello), chExts)
+				}()
+
+				Eventually(handlerServer.TransportParameters()).Should(Receive())
+				Expect(clientParams).To(Equal([]byte("raboof")))
+				exts := handlerServer.GetExtensions(uint8(typeEncryptedExtensions))
+				Expect(exts).To(HaveLen(1))
+				Expect(exts[0].Data).To(Equal([]byte("updated")))
+			})
+
 			It("ignores extensions that are not sent with the ClientHello", func() {
 				done := make(chan struct{})
 				go func() {
@@ -0,0 +1,41 @@
+package logutils
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// NewLogger returns a utils.Logger that passes all messages to the Logger configured by the application.
+// If no Logger is configured, it returns the default logger.
+func NewLogger(l logging.Logger) utils.Logger {
+	if l == nil {
+		return utils.DefaultLogger
+	}
+	return utils.NewStructuredLogger(&structuredLogger{logger: l})
+}
+
+// structuredLogger converts the log levels used internally to the log levels of the logging package.
+type structuredLogger struct {
+	logger logging.Logger
+}
+
+var _ utils.StructuredLogger = &structuredLogger{}
+
+func (l *structuredLogger) Enabled(level utils.LogLevel) bool {
+	return l.logger.Enabled(convertLogLevel(level))
+}
+
+func (l *structuredLogger) Log(level utils.LogLevel, msg string, keyvals ...interface{}) {
+	l.logger.Log(convertLogLevel(level), msg, keyvals...)
+}
+
+func convertLogLevel(level utils.LogLevel) logging.LogLevel {
+	switch level {
+	case utils.LogLevelError:
+		return logging.LogLevelError
+	case utils.LogLevelInfo:
+		return logging.LogLevelInfo
+	default:
+		return logging.LogLevelDebug
+	}
+}
@@ -0,0 +1,54 @@
+package logutils
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type message struct {
+	level logging.LogLevel
+	msg   string
+}
+
+type recordingLogger struct {
+	level    logging.LogLevel
+	messages []message
+}
+
+func (l *recordingLogger) Enabled(level logging.LogLevel) bool { return level <= l.level }
+
+func (l *recordingLogger) Log(level logging.LogLevel, msg string, _ ...interface{}) {
+	l.messages = append(l.messages, message{level: level, msg: msg})
+}
+
+var _ = Describe("Logger", func() {
+	It("returns the default logger if no Logger is configured", func() {
+		Expect(NewLogger(nil)).To(Equal(utils.DefaultLogger))
+	})
+
+	It("passes messages to the Logger", func() {
+		l := &recordingLogger{level: logging.LogLevelDebug}
+		logger := NewLogger(l)
+		Expect(logger.Debug()).To(BeTrue())
+		logger.Errorf("error")
+		logger.Infof("info %d", 42)
+		logger.Debugf("debug")
+		Expect(l.messages).To(Equal([]message{
+			{level: logging.LogLevelError, msg: "error"},
+			{level: logging.LogLevelInfo, msg: "info 42"},
+			{level: logging.LogLevelDebug, msg: "debug"},
+		}))
+	})
+
+	It("asks the Logger which levels are enabled", func() {
+		l := &recordingLogger{level: logging.LogLevelInfo}
+		logger := NewLogger(l)
+		Expect(logger.Debug()).To(BeFalse())
+		logger.Debugf("debug")
+		logger.Infof("info")
+		Expect(l.messages).To(Equal([]message{{level: logging.LogLevelInfo, msg: "info"}}))
+	})
+})
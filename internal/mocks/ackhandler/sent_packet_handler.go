@@ -65,10 +65,10 @@ func (mr *MockSentPacketHandlerMockRecorder) GetLossDetectionTimeout() *gomock.C
 }
 
 // GetStats mocks base method
-func (m *MockSentPacketHandler) GetStats() *quictrace.TransportState {
+func (m *MockSentPacketHandler) GetStats() quictrace.TransportState {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetStats")
-	ret0, _ := ret[0].(*quictrace.TransportState)
+	ret0, _ := ret[0].(quictrace.TransportState)
 	return ret0
 }
 
@@ -8,6 +8,7 @@ import (
 	context "context"
 	net "net"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	quic "github.com/lucas-clemente/quic-go"
@@ -78,3 +79,73 @@ func (mr *MockEarlyListenerMockRecorder) Close() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockEarlyListener)(nil).Close))
 }
+
+// CloseIdleSessions mocks base method
+func (m *MockEarlyListener) CloseIdleSessions(arg0 time.Duration) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseIdleSessions", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// CloseIdleSessions indicates an expected call of CloseIdleSessions
+func (mr *MockEarlyListenerMockRecorder) CloseIdleSessions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseIdleSessions", reflect.TypeOf((*MockEarlyListener)(nil).CloseIdleSessions), arg0)
+}
+
+// NumSessions mocks base method
+func (m *MockEarlyListener) NumSessions() quic.SessionCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NumSessions")
+	ret0, _ := ret[0].(quic.SessionCount)
+	return ret0
+}
+
+// NumSessions indicates an expected call of NumSessions
+func (mr *MockEarlyListenerMockRecorder) NumSessions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumSessions", reflect.TypeOf((*MockEarlyListener)(nil).NumSessions))
+}
+
+// SetDebugLogging mocks base method
+func (m *MockEarlyListener) SetDebugLogging(arg0 []byte, arg1 bool) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDebugLogging", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// SetDebugLogging indicates an expected call of SetDebugLogging
+func (mr *MockEarlyListenerMockRecorder) SetDebugLogging(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDebugLogging", reflect.TypeOf((*MockEarlyListener)(nil).SetDebugLogging), arg0, arg1)
+}
+
+// Shutdown mocks base method
+func (m *MockEarlyListener) Shutdown(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shutdown", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Shutdown indicates an expected call of Shutdown
+func (mr *MockEarlyListenerMockRecorder) Shutdown(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockEarlyListener)(nil).Shutdown), arg0)
+}
+
+// Stats mocks base method
+func (m *MockEarlyListener) Stats() quic.ListenerStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(quic.ListenerStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats
+func (mr *MockEarlyListenerMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockEarlyListener)(nil).Stats))
+}
@@ -5,6 +5,9 @@ import "time"
 // DesiredReceiveBufferSize is the kernel UDP receive buffer size that we'd like to use.
 const DesiredReceiveBufferSize = (1 << 20) * 2 // 2 MB
 
+// DesiredSendBufferSize is the kernel UDP send buffer size that we'd like to use.
+const DesiredSendBufferSize = (1 << 20) * 2 // 2 MB
+
 // MaxPacketSizeIPv4 is the maximum packet size that we use for sending IPv4 packets.
 const MaxPacketSizeIPv4 = 1252
 
@@ -42,16 +45,25 @@ const DefaultMaxIncomingStreams = 100
 // DefaultMaxIncomingUniStreams is the maximum number of unidirectional streams that a peer may open
 const DefaultMaxIncomingUniStreams = 100
 
+// DefaultIPv6PrefixLength is the default prefix length by which IPv6 addresses are grouped when limiting the number of connections per IP
+const DefaultIPv6PrefixLength = 64
+
+// DefaultStreamIDsLowThreshold is the number of stream IDs left at which the application is notified
+const DefaultStreamIDsLowThreshold = 1 << 20
+
 // MaxServerUnprocessedPackets is the max number of packets stored in the server that are not yet processed.
 const MaxServerUnprocessedPackets = 1024
 
-// MaxSessionUnprocessedPackets is the max number of packets stored in each session that are not yet processed.
+// MaxSessionUnprocessedPackets is the default max number of packets stored in each session that are not yet processed.
 const MaxSessionUnprocessedPackets = 256
 
+// DefaultSendQueueSize is the default number of packets that a session queues for sending.
+const DefaultSendQueueSize = 1
+
 // SkipPacketAveragePeriodLength is the average period length in which one packet number is skipped to prevent an Optimistic ACK attack
 const SkipPacketAveragePeriodLength PacketNumber = 500
 
-// MaxAcceptQueueSize is the maximum number of sessions that the server queues for accepting.
+// MaxAcceptQueueSize is the default maximum number of sessions that the server queues for accepting.
 // If the queue is full, new connection attempts will be rejected.
 const MaxAcceptQueueSize = 32
 
@@ -105,6 +117,9 @@ const DefaultHandshakeTimeout = 10 * time.Second
 // It should be shorter than the time that NATs clear their mapping.
 const MaxKeepAliveInterval = 20 * time.Second
 
+// DefaultMaxUnansweredLivenessProbes is the default number of unanswered liveness probes after which a session is closed.
+const DefaultMaxUnansweredLivenessProbes = 3
+
 // RetiredConnectionIDDeleteTimeout is the time we keep closed sessions around in order to retransmit the CONNECTION_CLOSE.
 // after this time all information about the old connection will be deleted
 const RetiredConnectionIDDeleteTimeout = 5 * time.Second
@@ -130,11 +145,14 @@ const MaxAckFrameSize ByteCount = 1000
 // If at any point we keep track of more ranges, old ranges are discarded.
 const MaxNumAckRanges = 500
 
-// MinPacingDelay is the minimum duration that is used for packet pacing
+// MinPacingDelay is the default minimum duration that is used for packet pacing
 // If the packet packing frequency is higher, multiple packets might be sent at once.
 // Example: For a packet pacing delay of 200μs, we would send 5 packets at once, wait for 1ms, and so forth.
 const MinPacingDelay = time.Millisecond
 
+// DefaultMaxPacingBurstPackets is the default number of packets that the pacer allows to be sent back-to-back.
+const DefaultMaxPacingBurstPackets = 10
+
 // DefaultConnectionIDLength is the connection ID length that is used for multiplexed connections
 // if no other value is configured.
 const DefaultConnectionIDLength = 4
@@ -174,6 +192,15 @@ const Max0RTTQueues = 32
 
 // Max0RTTQueueLen is the maximum number of 0-RTT packets that we buffer for each connection.
 // When a new session is created, all buffered packets are passed to the session immediately.
-// To avoid blocking, this value has to be smaller than MaxSessionUnprocessedPackets.
+// To avoid blocking, this value has to be smaller than the number of unprocessed packets a session stores (see Config.MaxUnprocessedPackets).
 // To avoid packets being dropped as undecryptable by the session, this value has to be smaller than MaxUndecryptablePackets.
 const Max0RTTQueueLen = 32
+
+// DefaultSpinBitDisableRate determines how often the latency spin bit is disabled, if not configured otherwise.
+// The spin bit is disabled on 1 in DefaultSpinBitDisableRate connections, even if it was enabled in the Config.
+// The specification requires the spin bit to be disabled on at least 1 in 16 connections.
+const DefaultSpinBitDisableRate = 16
+
+// HappyEyeballsFallbackDelay is the time to wait before starting a connection attempt using the other address family,
+// if a hostname resolves to both IPv4 and IPv6 addresses.
+const HappyEyeballsFallbackDelay = 250 * time.Millisecond
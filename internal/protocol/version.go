@@ -97,6 +97,35 @@ func ChooseSupportedVersion(ours, theirs []VersionNumber) (VersionNumber, bool)
 	return 0, false
 }
 
+// IsCompatibleVersion says if a connection attempt that was started with version from
+// can be upgraded to version to during the handshake, without an additional round trip.
+// This is the case for all IETF QUIC versions known to quic-go, since they use the same
+// Initial packet protection. Everything else that depends on the version is switched
+// to the new version when the connection is upgraded.
+func IsCompatibleVersion(from, to VersionNumber) bool {
+	return isIETFVersion(from) && isIETFVersion(to)
+}
+
+func isIETFVersion(v VersionNumber) bool {
+	return v == VersionTLS || v == VersionDraft29 || v == VersionDraft32
+}
+
+// ChooseCompatibleVersion chooses the version to upgrade a connection attempt to, that was started with version current.
+// ours is a slice of versions that we support, sorted by our preference (descending).
+// theirs is a slice of versions supported by the peer. The order does not matter.
+// If both don't support a compatible version that we prefer over current, current is returned.
+func ChooseCompatibleVersion(current VersionNumber, ours, theirs []VersionNumber) VersionNumber {
+	for _, v := range ours {
+		if v == current {
+			break
+		}
+		if IsCompatibleVersion(current, v) && IsSupportedVersion(theirs, v) {
+			return v
+		}
+	}
+	return current
+}
+
 // generateReservedVersion generates a reserved version number (v & 0x0f0f0f0f == 0x0a0a0a0a)
 func generateReservedVersion() VersionNumber {
 	b := make([]byte, 4)
@@ -88,6 +88,35 @@ var _ = Describe("Version", func() {
 		})
 	})
 
+	Context("compatible versions", func() {
+		It("says which versions are compatible", func() {
+			Expect(IsCompatibleVersion(VersionDraft29, VersionDraft32)).To(BeTrue())
+			Expect(IsCompatibleVersion(VersionDraft32, VersionTLS)).To(BeTrue())
+			Expect(IsCompatibleVersion(VersionTLS, 0x1234)).To(BeFalse())
+			Expect(IsCompatibleVersion(0x1234, VersionTLS)).To(BeFalse())
+		})
+
+		It("upgrades to a preferred compatible version", func() {
+			ours := []VersionNumber{VersionDraft32, VersionDraft29}
+			Expect(ChooseCompatibleVersion(VersionDraft29, ours, []VersionNumber{VersionDraft29, VersionDraft32})).To(Equal(VersionDraft32))
+		})
+
+		It("doesn't upgrade to versions that we don't prefer", func() {
+			ours := []VersionNumber{VersionDraft29, VersionDraft32}
+			Expect(ChooseCompatibleVersion(VersionDraft29, ours, []VersionNumber{VersionDraft32, VersionDraft29})).To(Equal(VersionDraft29))
+		})
+
+		It("doesn't upgrade to versions that the peer doesn't support", func() {
+			ours := []VersionNumber{VersionDraft32, VersionDraft29}
+			Expect(ChooseCompatibleVersion(VersionDraft29, ours, []VersionNumber{VersionDraft29})).To(Equal(VersionDraft29))
+		})
+
+		It("doesn't upgrade to incompatible versions", func() {
+			ours := []VersionNumber{0x1234, VersionDraft29}
+			Expect(ChooseCompatibleVersion(VersionDraft29, ours, []VersionNumber{0x1234, VersionDraft29})).To(Equal(VersionDraft29))
+		})
+	})
+
 	Context("reserved versions", func() {
 		It("adds a greased version if passed an empty slice", func() {
 			greased := GetGreasedVersions([]VersionNumber{})
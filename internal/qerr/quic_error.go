@@ -1,17 +1,39 @@
 package qerr
 
 import (
+	"errors"
 	"fmt"
 	"net"
 )
 
+// These errors can be used with errors.Is to check why a connection was closed.
+var (
+	// ErrHandshakeTimeout is returned when the handshake didn't complete in time.
+	ErrHandshakeTimeout = errors.New("handshake timeout")
+	// ErrIdleTimeout is returned when the connection timed out because there was no network activity,
+	// or because the peer didn't respond to liveness probes.
+	ErrIdleTimeout = errors.New("idle timeout")
+	// ErrHandshakeFailed is returned when the connection was closed with a CRYPTO_ERROR (i.e. a TLS alert) by either peer.
+	ErrHandshakeFailed = errors.New("handshake failed")
+	// ErrApplicationClose is returned when the connection was closed by the application, on either side.
+	ErrApplicationClose = errors.New("application close")
+	// ErrStatelessReset is returned when the connection was closed by a stateless reset.
+	ErrStatelessReset = errors.New("stateless reset")
+	// ErrMemoryLimitExceeded is returned when the connection was closed because it held too much memory.
+	ErrMemoryLimitExceeded = errors.New("memory limit exceeded")
+)
+
 // A QuicError consists of an error code plus a error reason
 type QuicError struct {
 	ErrorCode          ErrorCode
 	FrameType          uint64 // only valid if this not an application error
 	ErrorMessage       string
+	Remote             bool // set if the error was received from the peer in a CONNECTION_CLOSE frame
 	isTimeout          bool
 	isApplicationError bool
+
+	kind  error // one of the errors above, if this error doesn't have a distinct error code
+	cause error // the error converted by ToQuicError
 }
 
 var _ net.Error = &QuicError{}
@@ -41,6 +63,41 @@ func NewTimeoutError(errorMessage string) *QuicError {
 	}
 }
 
+// NewHandshakeTimeoutError creates a new QuicError instance for a handshake timeout
+func NewHandshakeTimeoutError(errorMessage string) *QuicError {
+	e := NewTimeoutError(errorMessage)
+	e.kind = ErrHandshakeTimeout
+	return e
+}
+
+// NewIdleTimeoutError creates a new QuicError instance for an idle timeout
+func NewIdleTimeoutError(errorMessage string) *QuicError {
+	e := NewTimeoutError(errorMessage)
+	e.kind = ErrIdleTimeout
+	return e
+}
+
+// NewMemoryLimitError creates a new QuicError instance for a connection that exceeded its memory limit.
+// The peer didn't necessarily violate the protocol, so the connection is closed with a NO_ERROR.
+func NewMemoryLimitError(errorMessage string) *QuicError {
+	return &QuicError{
+		ErrorCode:    NoError,
+		ErrorMessage: errorMessage,
+		kind:         ErrMemoryLimitExceeded,
+	}
+}
+
+// NewStatelessResetError creates a new QuicError instance for a connection that was closed by a stateless reset.
+// The peer didn't send a CONNECTION_CLOSE frame, so there's no error code, and NO_ERROR is used.
+func NewStatelessResetError(cause error) *QuicError {
+	return &QuicError{
+		ErrorCode:    NoError,
+		ErrorMessage: cause.Error(),
+		kind:         ErrStatelessReset,
+		cause:        cause,
+	}
+}
+
 // NewCryptoError create a new QuicError instance for a crypto error
 func NewCryptoError(tlsAlert uint8, errorMessage string) *QuicError {
 	return &QuicError{
@@ -89,6 +146,39 @@ func (e *QuicError) IsApplicationError() bool {
 	return e.isApplicationError
 }
 
+// IsRemote says if the error was received from the peer.
+func (e *QuicError) IsRemote() bool {
+	return e.Remote
+}
+
+// Code returns the error code.
+// Depending on IsApplicationError, this is either a transport or an application error code.
+func (e *QuicError) Code() uint64 {
+	return uint64(e.ErrorCode)
+}
+
+// ReasonPhrase returns the reason phrase.
+func (e *QuicError) ReasonPhrase() string {
+	return e.ErrorMessage
+}
+
+// Is says if this error belongs to one of the error classes defined in this package.
+// It is used by errors.Is.
+func (e *QuicError) Is(target error) bool {
+	switch target {
+	case ErrHandshakeFailed:
+		return !e.isApplicationError && e.IsCryptoError()
+	case ErrApplicationClose:
+		return e.isApplicationError
+	}
+	return e.kind != nil && e.kind == target
+}
+
+// Unwrap returns the error that was converted to this QuicError by ToQuicError, if any.
+func (e *QuicError) Unwrap() error {
+	return e.cause
+}
+
 // Temporary says if the error is temporary.
 func (e *QuicError) Temporary() bool {
 	return false
@@ -108,5 +198,9 @@ func ToQuicError(err error) *QuicError {
 	case ErrorCode:
 		return NewError(e, "")
 	}
-	return NewError(InternalError, err.Error())
+	return &QuicError{
+		ErrorCode:    InternalError,
+		ErrorMessage: err.Error(),
+		cause:        err,
+	}
 }
@@ -1,6 +1,7 @@
 package qerr
 
 import (
+	"errors"
 	"io"
 
 	. "github.com/onsi/ginkgo"
@@ -15,6 +16,15 @@ var _ = Describe("QUIC Transport Errors", func() {
 		Expect(err.Error()).To(Equal("FLOW_CONTROL_ERROR: foobar"))
 	})
 
+	It("exposes the error code and reason phrase", func() {
+		err := NewError(FlowControlError, "foobar")
+		Expect(err.Code()).To(BeEquivalentTo(0x3))
+		Expect(err.ReasonPhrase()).To(Equal("foobar"))
+		Expect(err.IsRemote()).To(BeFalse())
+		err.Remote = true
+		Expect(err.IsRemote()).To(BeTrue())
+	})
+
 	It("has a string representation for empty error phrases", func() {
 		err := NewError(FlowControlError, "")
 		Expect(err.Error()).To(Equal("FLOW_CONTROL_ERROR"))
@@ -68,6 +78,50 @@ var _ = Describe("QUIC Transport Errors", func() {
 		})
 	})
 
+	Context("matching errors", func() {
+		It("matches timeout errors", func() {
+			err := NewHandshakeTimeoutError("foobar")
+			Expect(err.Timeout()).To(BeTrue())
+			Expect(errors.Is(err, ErrHandshakeTimeout)).To(BeTrue())
+			Expect(errors.Is(err, ErrIdleTimeout)).To(BeFalse())
+			err = NewIdleTimeoutError("foobar")
+			Expect(err.Timeout()).To(BeTrue())
+			Expect(errors.Is(err, ErrIdleTimeout)).To(BeTrue())
+			Expect(errors.Is(err, ErrHandshakeTimeout)).To(BeFalse())
+			Expect(errors.Is(NewTimeoutError("foobar"), ErrIdleTimeout)).To(BeFalse())
+		})
+
+		It("matches memory limit errors", func() {
+			err := NewMemoryLimitError("foobar")
+			Expect(err.ErrorCode).To(Equal(NoError))
+			Expect(err.Timeout()).To(BeFalse())
+			Expect(errors.Is(err, ErrMemoryLimitExceeded)).To(BeTrue())
+			Expect(errors.Is(NewError(NoError, "foobar"), ErrMemoryLimitExceeded)).To(BeFalse())
+		})
+
+		It("matches stateless reset errors", func() {
+			cause := errors.New("received a stateless reset")
+			err := NewStatelessResetError(cause)
+			Expect(err.ErrorCode).To(Equal(NoError))
+			Expect(err.IsRemote()).To(BeFalse())
+			Expect(err.IsApplicationError()).To(BeFalse())
+			Expect(err.ReasonPhrase()).To(Equal("received a stateless reset"))
+			Expect(errors.Is(err, ErrStatelessReset)).To(BeTrue())
+			Expect(errors.Unwrap(err)).To(Equal(cause))
+		})
+
+		It("matches crypto errors", func() {
+			Expect(errors.Is(NewCryptoError(0x2a, ""), ErrHandshakeFailed)).To(BeTrue())
+			Expect(errors.Is(NewError(FlowControlError, ""), ErrHandshakeFailed)).To(BeFalse())
+			Expect(errors.Is(NewApplicationError(0x12a, ""), ErrHandshakeFailed)).To(BeFalse())
+		})
+
+		It("matches application errors", func() {
+			Expect(errors.Is(NewApplicationError(0x42, ""), ErrApplicationClose)).To(BeTrue())
+			Expect(errors.Is(NewError(0x42, ""), ErrApplicationClose)).To(BeFalse())
+		})
+	})
+
 	Context("ErrorCode", func() {
 		It("works as error", func() {
 			var err error = StreamStateError
@@ -92,7 +146,10 @@ var _ = Describe("QUIC Transport Errors", func() {
 		})
 
 		It("changes default errors to InternalError", func() {
-			Expect(ToQuicError(io.EOF)).To(Equal(NewError(InternalError, "EOF")))
+			err := ToQuicError(io.EOF)
+			Expect(err.ErrorCode).To(Equal(InternalError))
+			Expect(err.Error()).To(Equal("INTERNAL_ERROR: EOF"))
+			Expect(errors.Is(err, io.EOF)).To(BeTrue())
 		})
 	})
 })
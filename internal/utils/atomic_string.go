@@ -0,0 +1,24 @@
+package utils
+
+import "sync/atomic"
+
+// An AtomicString is an atomic string.
+// It implements fmt.Stringer, and can therefore be used as the value of a log field.
+type AtomicString struct {
+	v atomic.Value
+}
+
+// Set sets the value
+func (a *AtomicString) Set(value string) {
+	a.v.Store(value)
+}
+
+// Get gets the value
+func (a *AtomicString) Get() string {
+	s, _ := a.v.Load().(string)
+	return s
+}
+
+func (a *AtomicString) String() string {
+	return a.Get()
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Atomic String", func() {
+	var a *AtomicString
+
+	BeforeEach(func() {
+		a = &AtomicString{}
+	})
+
+	It("has the right default value", func() {
+		Expect(a.Get()).To(BeEmpty())
+		Expect(a.String()).To(BeEmpty())
+	})
+
+	It("sets the value", func() {
+		a.Set("foo")
+		Expect(a.Get()).To(Equal("foo"))
+		a.Set("bar")
+		Expect(a.String()).To(Equal("bar"))
+	})
+})
@@ -0,0 +1,63 @@
+package utils
+
+import "fmt"
+
+// A DebugToggleLogger is a Logger for which debug logging can be enabled at runtime.
+// When enabled, debug messages are logged, independent of the log level of the underlying Logger.
+type DebugToggleLogger struct {
+	Logger
+
+	debugLogger Logger
+	debug       *AtomicBool
+}
+
+var _ Logger = &DebugToggleLogger{}
+
+// NewDebugToggleLogger creates a new DebugToggleLogger.
+// Debug logging is disabled initially.
+func NewDebugToggleLogger(l Logger) *DebugToggleLogger {
+	return &DebugToggleLogger{
+		Logger:      l,
+		debugLogger: l.WithLogLevel(LogLevelDebug),
+		debug:       &AtomicBool{},
+	}
+}
+
+// SetDebug enables or disables debug logging.
+// It can be called from any goroutine.
+// The setting applies to all Loggers derived from this Logger using WithPrefix.
+func (l *DebugToggleLogger) SetDebug(enabled bool) {
+	l.debug.Set(enabled)
+}
+
+// WithPrefix returns a Logger with a prefix that shares the debug setting of this Logger.
+func (l *DebugToggleLogger) WithPrefix(prefix string) Logger {
+	return &DebugToggleLogger{
+		Logger:      l.Logger.WithPrefix(prefix),
+		debugLogger: l.debugLogger.WithPrefix(prefix),
+		debug:       l.debug,
+	}
+}
+
+// With returns a Logger with a key-value pair that shares the debug setting of this Logger.
+func (l *DebugToggleLogger) With(key string, value fmt.Stringer) Logger {
+	return &DebugToggleLogger{
+		Logger:      l.Logger.With(key, value),
+		debugLogger: l.debugLogger.With(key, value),
+		debug:       l.debug,
+	}
+}
+
+// Debug returns true if debug logging is enabled, either at runtime or by the underlying Logger.
+func (l *DebugToggleLogger) Debug() bool {
+	return l.debug.Get() || l.Logger.Debug()
+}
+
+// Debugf logs a debug message.
+func (l *DebugToggleLogger) Debugf(format string, args ...interface{}) {
+	if l.debug.Get() {
+		l.debugLogger.Debugf(format, args...)
+		return
+	}
+	l.Logger.Debugf(format, args...)
+}
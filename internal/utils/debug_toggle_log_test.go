@@ -0,0 +1,58 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Debug Toggle Logger", func() {
+	var (
+		rl *recordingLogger
+		l  *DebugToggleLogger
+	)
+
+	BeforeEach(func() {
+		rl = &recordingLogger{level: LogLevelInfo}
+		l = NewDebugToggleLogger(NewStructuredLogger(rl))
+	})
+
+	It("doesn't log debug messages by default", func() {
+		Expect(l.Debug()).To(BeFalse())
+		l.Debugf("debug")
+		l.Infof("info")
+		Expect(rl.entries).To(Equal([]logEntry{{level: LogLevelInfo, msg: "info"}}))
+	})
+
+	It("logs debug messages when enabled", func() {
+		l.SetDebug(true)
+		Expect(l.Debug()).To(BeTrue())
+		l.Debugf("debug")
+		Expect(rl.entries).To(Equal([]logEntry{{level: LogLevelDebug, msg: "debug"}}))
+		l.SetDebug(false)
+		Expect(l.Debug()).To(BeFalse())
+		l.Debugf("debug")
+		Expect(rl.entries).To(HaveLen(1))
+	})
+
+	It("applies the setting to prefixed loggers", func() {
+		prefixLogger := l.WithPrefix("prefix")
+		l.SetDebug(true)
+		Expect(prefixLogger.Debug()).To(BeTrue())
+		prefixLogger.Debugf("debug")
+		Expect(rl.entries).To(Equal([]logEntry{
+			{level: LogLevelDebug, msg: "debug", keyvals: []interface{}{"component", "prefix"}},
+		}))
+	})
+
+	It("applies the setting to loggers with fields", func() {
+		val := &AtomicString{}
+		val.Set("value")
+		fieldLogger := l.With("key", val)
+		l.SetDebug(true)
+		Expect(fieldLogger.Debug()).To(BeTrue())
+		fieldLogger.Debugf("debug")
+		Expect(rl.entries).To(Equal([]logEntry{
+			{level: LogLevelDebug, msg: "debug", keyvals: []interface{}{"key", "value"}},
+		}))
+	})
+})
@@ -29,6 +29,11 @@ type Logger interface {
 	SetLogLevel(LogLevel)
 	SetLogTimeFormat(format string)
 	WithPrefix(prefix string) Logger
+	// WithLogLevel returns a Logger that also logs all messages up to the given level.
+	WithLogLevel(LogLevel) Logger
+	// With returns a Logger that adds a key-value pair to all messages.
+	// The value is evaluated every time a message is logged. Empty values are omitted.
+	With(key string, value fmt.Stringer) Logger
 	Debug() bool
 
 	Errorf(format string, args ...interface{})
@@ -39,8 +44,20 @@ type Logger interface {
 // DefaultLogger is used by quic-go for logging.
 var DefaultLogger Logger
 
+type logField struct {
+	key   string
+	value fmt.Stringer
+}
+
+func appendLogField(fields []logField, key string, value fmt.Stringer) []logField {
+	f := make([]logField, len(fields), len(fields)+1)
+	copy(f, fields)
+	return append(f, logField{key: key, value: value})
+}
+
 type defaultLogger struct {
 	prefix string
+	fields []logField
 
 	logLevel   LogLevel
 	timeFormat string
@@ -90,7 +107,12 @@ func (l *defaultLogger) logMessage(format string, args ...interface{}) {
 	if len(l.prefix) > 0 {
 		pre += l.prefix + " "
 	}
-	log.Printf(pre+format, args...)
+	for _, f := range l.fields {
+		if v := f.value.String(); len(v) > 0 {
+			pre += f.key + "=" + v + " "
+		}
+	}
+	log.Print(pre + fmt.Sprintf(format, args...))
 }
 
 func (l *defaultLogger) WithPrefix(prefix string) Logger {
@@ -101,6 +123,28 @@ func (l *defaultLogger) WithPrefix(prefix string) Logger {
 		logLevel:   l.logLevel,
 		timeFormat: l.timeFormat,
 		prefix:     prefix,
+		fields:     l.fields,
+	}
+}
+
+func (l *defaultLogger) WithLogLevel(level LogLevel) Logger {
+	if level < l.logLevel {
+		level = l.logLevel
+	}
+	return &defaultLogger{
+		logLevel:   level,
+		timeFormat: l.timeFormat,
+		prefix:     l.prefix,
+		fields:     l.fields,
+	}
+}
+
+func (l *defaultLogger) With(key string, value fmt.Stringer) Logger {
+	return &defaultLogger{
+		logLevel:   l.logLevel,
+		timeFormat: l.timeFormat,
+		prefix:     l.prefix,
+		fields:     appendLogField(l.fields, key, value),
 	}
 }
 
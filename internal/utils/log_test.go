@@ -108,6 +108,28 @@ var _ = Describe("Log", func() {
 		Expect(b.String()).To(ContainSubstring("debug"))
 	})
 
+	It("adds key-value pairs", func() {
+		DefaultLogger.SetLogLevel(LogLevelDebug)
+		DefaultLogger.SetLogTimeFormat("")
+		val := &AtomicString{}
+		l := DefaultLogger.WithPrefix("prefix").With("key", val)
+		l.Debugf("debug %s", "foo")
+		Expect(b.String()).To(Equal("prefix debug foo\n"))
+		b.Reset()
+		val.Set("value")
+		l.Debugf("debug %s", "foo")
+		Expect(b.String()).To(Equal("prefix key=value debug foo\n"))
+	})
+
+	It("increases the log level", func() {
+		DefaultLogger.SetLogLevel(LogLevelInfo)
+		debugLogger := DefaultLogger.WithLogLevel(LogLevelDebug)
+		Expect(debugLogger.Debug()).To(BeTrue())
+		Expect(DefaultLogger.WithLogLevel(LogLevelError).Debug()).To(BeFalse())
+		debugLogger.Debugf("debug")
+		Expect(b.String()).To(ContainSubstring("debug"))
+	})
+
 	Context("reading from env", func() {
 		BeforeEach(func() {
 			Expect(DefaultLogger.(*defaultLogger).logLevel).To(Equal(LogLevelNothing))
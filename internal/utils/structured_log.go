@@ -0,0 +1,88 @@
+package utils
+
+import "fmt"
+
+// A StructuredLogger is a logger provided by the application.
+// Messages are passed as a message string and key-value pairs.
+type StructuredLogger interface {
+	Enabled(LogLevel) bool
+	Log(level LogLevel, msg string, keyvals ...interface{})
+}
+
+type structuredLogger struct {
+	logger StructuredLogger
+	prefix string
+	fields []logField
+	// messages up to this level are logged, even if not enabled by the StructuredLogger
+	level LogLevel
+}
+
+var _ Logger = &structuredLogger{}
+
+// NewStructuredLogger creates a Logger that passes all messages to a StructuredLogger.
+// The prefix is passed as the value of the "component" key.
+// Fields added using With are passed as additional key-value pairs.
+func NewStructuredLogger(l StructuredLogger) Logger {
+	return &structuredLogger{logger: l}
+}
+
+// SetLogLevel does nothing. The StructuredLogger decides which levels are enabled.
+func (l *structuredLogger) SetLogLevel(LogLevel) {}
+
+// SetLogTimeFormat does nothing. Timestamps are added by the StructuredLogger.
+func (l *structuredLogger) SetLogTimeFormat(string) {}
+
+func (l *structuredLogger) WithPrefix(prefix string) Logger {
+	if len(l.prefix) > 0 {
+		prefix = l.prefix + " " + prefix
+	}
+	return &structuredLogger{logger: l.logger, prefix: prefix, fields: l.fields, level: l.level}
+}
+
+func (l *structuredLogger) WithLogLevel(level LogLevel) Logger {
+	if level < l.level {
+		level = l.level
+	}
+	return &structuredLogger{logger: l.logger, prefix: l.prefix, fields: l.fields, level: level}
+}
+
+func (l *structuredLogger) With(key string, value fmt.Stringer) Logger {
+	return &structuredLogger{logger: l.logger, prefix: l.prefix, fields: appendLogField(l.fields, key, value), level: l.level}
+}
+
+func (l *structuredLogger) Debug() bool {
+	return l.enabled(LogLevelDebug)
+}
+
+func (l *structuredLogger) enabled(level LogLevel) bool {
+	return level <= l.level || l.logger.Enabled(level)
+}
+
+func (l *structuredLogger) Errorf(format string, args ...interface{}) {
+	l.logMessage(LogLevelError, format, args...)
+}
+
+func (l *structuredLogger) Infof(format string, args ...interface{}) {
+	l.logMessage(LogLevelInfo, format, args...)
+}
+
+func (l *structuredLogger) Debugf(format string, args ...interface{}) {
+	l.logMessage(LogLevelDebug, format, args...)
+}
+
+func (l *structuredLogger) logMessage(level LogLevel, format string, args ...interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	var keyvals []interface{}
+	if len(l.prefix) > 0 {
+		keyvals = append(keyvals, "component", l.prefix)
+	}
+	for _, f := range l.fields {
+		if v := f.value.String(); len(v) > 0 {
+			keyvals = append(keyvals, f.key, v)
+		}
+	}
+	l.logger.Log(level, msg, keyvals...)
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type logEntry struct {
+	level   LogLevel
+	msg     string
+	keyvals []interface{}
+}
+
+type recordingLogger struct {
+	level   LogLevel
+	entries []logEntry
+}
+
+func (l *recordingLogger) Enabled(level LogLevel) bool { return level <= l.level }
+
+func (l *recordingLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, keyvals: keyvals})
+}
+
+var _ = Describe("Structured Logger", func() {
+	It("only logs enabled levels", func() {
+		rl := &recordingLogger{level: LogLevelInfo}
+		l := NewStructuredLogger(rl)
+		Expect(l.Debug()).To(BeFalse())
+		l.Debugf("debug %d", 1)
+		l.Infof("info %d", 2)
+		l.Errorf("error %d", 3)
+		Expect(rl.entries).To(Equal([]logEntry{
+			{level: LogLevelInfo, msg: "info 2"},
+			{level: LogLevelError, msg: "error 3"},
+		}))
+	})
+
+	It("passes the prefix as a key-value pair", func() {
+		rl := &recordingLogger{level: LogLevelDebug}
+		l := NewStructuredLogger(rl).WithPrefix("server").WithPrefix("session")
+		Expect(l.Debug()).To(BeTrue())
+		l.Debugf("foo")
+		Expect(rl.entries).To(Equal([]logEntry{
+			{level: LogLevelDebug, msg: "foo", keyvals: []interface{}{"component", "server session"}},
+		}))
+	})
+
+	It("passes fields as key-value pairs", func() {
+		rl := &recordingLogger{level: LogLevelDebug}
+		val := &AtomicString{}
+		l := NewStructuredLogger(rl).With("key", val).WithPrefix("server")
+		l.Debugf("foo")
+		val.Set("value")
+		l.Debugf("bar")
+		Expect(rl.entries).To(Equal([]logEntry{
+			{level: LogLevelDebug, msg: "foo", keyvals: []interface{}{"component", "server"}},
+			{level: LogLevelDebug, msg: "bar", keyvals: []interface{}{"component", "server", "key", "value"}},
+		}))
+	})
+})
@@ -28,7 +28,7 @@ func parseAckFrame(r *bytes.Reader, ackDelayExponent uint8, _ protocol.VersionNu
 	}
 	ecn := typeByte&0x1 > 0
 
-	frame := &AckFrame{}
+	frame := getAckFrame()
 
 	la, err := utils.ReadVarInt(r)
 	if err != nil {
@@ -63,6 +63,12 @@ func parseAckFrame(r *bytes.Reader, ackDelayExponent uint8, _ protocol.VersionNu
 	}
 	smallest := largestAcked - ackBlock
 
+	// Allocate the slice for all ACK ranges at once, unless the slice of the pooled frame is large enough.
+	// Every ACK range takes at least 2 bytes, so we can bound the capacity by the remaining length of the frame.
+	// Don't trust the number of blocks sent by the peer, it might be arbitrarily large.
+	if numRanges := utils.MinUint64(numBlocks, uint64(r.Len()/2)) + 1; uint64(cap(frame.AckRanges)) < numRanges {
+		frame.AckRanges = make([]AckRange, 0, numRanges)
+	}
 	// read all the other ACK ranges
 	frame.AckRanges = append(frame.AckRanges, AckRange{Smallest: smallest, Largest: largestAcked})
 	for i := uint64(0); i < numBlocks; i++ {
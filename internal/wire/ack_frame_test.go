@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"math"
+	"runtime"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
@@ -111,9 +112,44 @@ var _ = Describe("ACK Frame (for IETF QUIC)", func() {
 				{Largest: 98, Smallest: 98},
 				{Largest: 95, Smallest: 94},
 			}))
+			Expect(cap(frame.AckRanges)).To(Equal(3)) // the ACK ranges are allocated at once
 			Expect(b.Len()).To(BeZero())
 		})
 
+		It("doesn't trust the number of blocks when allocating the ACK ranges", func() {
+			data := []byte{0x2}
+			data = append(data, encodeVarInt(100)...)        // largest acked
+			data = append(data, encodeVarInt(0)...)          // delay
+			data = append(data, encodeVarInt(1<<20)...)      // num blocks
+			data = append(data, encodeVarInt(0)...)          // first ack block
+			data = append(data, []byte{0, 0, 0, 0, 0, 0}...) // 3 ACK ranges
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			_, err := parseAckFrame(bytes.NewReader(data), protocol.AckDelayExponent, versionIETFFrames)
+			runtime.ReadMemStats(&after)
+			Expect(err).To(MatchError(io.EOF))
+			// Allocating space for 1<<20 ACK ranges would take 16 MB.
+			Expect(after.TotalAlloc - before.TotalAlloc).To(BeNumerically("<", 1<<10))
+		})
+
+		It("doesn't allocate more ACK ranges than announced", func() {
+			data := []byte{0x2}
+			data = append(data, encodeVarInt(100)...)                        // largest acked
+			data = append(data, encodeVarInt(0)...)                          // delay
+			data = append(data, encodeVarInt(1)...)                          // num blocks
+			data = append(data, encodeVarInt(0)...)                          // first ack block
+			data = append(data, []byte{0, 0}...)                             // 1 ACK range
+			data = append(data, make([]byte, 4*protocol.MaxNumAckRanges)...) // the following frames
+			b := bytes.NewReader(data)
+			frame, err := parseAckFrame(b, protocol.AckDelayExponent, versionIETFFrames)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.AckRanges).To(HaveLen(2))
+			// The frame might have been taken from the pool, which only keeps up to MaxNumAckRanges ACK ranges.
+			// Bounding the capacity by the remaining length of the packet would allocate space for 2*MaxNumAckRanges ACK ranges.
+			Expect(cap(frame.AckRanges)).To(BeNumerically("<=", protocol.MaxNumAckRanges))
+			Expect(b.Len()).To(Equal(4 * protocol.MaxNumAckRanges))
+		})
+
 		It("uses the ack delay exponent", func() {
 			const delayTime = 1 << 10 * time.Millisecond
 			buf := &bytes.Buffer{}
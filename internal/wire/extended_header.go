@@ -23,6 +23,8 @@ type ExtendedHeader struct {
 	typeByte byte
 
 	KeyPhase protocol.KeyPhaseBit
+	// SpinBit is the latency spin bit. It is only used for short header packets.
+	SpinBit bool
 
 	PacketNumberLen protocol.PacketNumberLen
 	PacketNumber    protocol.PacketNumber
@@ -69,6 +71,7 @@ func (h *ExtendedHeader) parseShortHeader(b *bytes.Reader, _ protocol.VersionNum
 	if h.typeByte&0x4 > 0 {
 		h.KeyPhase = protocol.KeyPhaseOne
 	}
+	h.SpinBit = h.typeByte&0x20 > 0
 
 	if err := h.readPacketNumber(b); err != nil {
 		return false, err
@@ -171,6 +174,9 @@ func (h *ExtendedHeader) writeShortHeader(b *bytes.Buffer, _ protocol.VersionNum
 	if h.KeyPhase == protocol.KeyPhaseOne {
 		typeByte |= byte(1 << 2)
 	}
+	if h.SpinBit {
+		typeByte |= 0x20
+	}
 
 	b.WriteByte(typeByte)
 	b.Write(h.DestConnectionID.Bytes())
@@ -230,6 +236,6 @@ func (h *ExtendedHeader) Log(logger utils.Logger) {
 		}
 		logger.Debugf("\tLong Header{Type: %s, DestConnectionID: %s, SrcConnectionID: %s, %sPacketNumber: %d, PacketNumberLen: %d, Length: %d, Version: %s}", h.Type, h.DestConnectionID, h.SrcConnectionID, token, h.PacketNumber, h.PacketNumberLen, h.Length, h.Version)
 	} else {
-		logger.Debugf("\tShort Header{DestConnectionID: %s, PacketNumber: %d, PacketNumberLen: %d, KeyPhase: %s}", h.DestConnectionID, h.PacketNumber, h.PacketNumberLen, h.KeyPhase)
+		logger.Debugf("\tShort Header{DestConnectionID: %s, PacketNumber: %d, PacketNumberLen: %d, KeyPhase: %s, SpinBit: %t}", h.DestConnectionID, h.PacketNumber, h.PacketNumberLen, h.KeyPhase, h.SpinBit)
 	}
 }
@@ -182,6 +182,18 @@ var _ = Describe("Header", func() {
 					0x42, // packet number
 				}))
 			})
+
+			It("writes the Spin Bit", func() {
+				Expect((&ExtendedHeader{
+					SpinBit:         true,
+					PacketNumberLen: protocol.PacketNumberLen1,
+					PacketNumber:    0x42,
+				}).Write(buf, versionIETFHeader)).To(Succeed())
+				Expect(buf.Bytes()).To(Equal([]byte{
+					0x40 | 0x20,
+					0x42, // packet number
+				}))
+			})
 		})
 	})
 
@@ -384,7 +396,7 @@ var _ = Describe("Header", func() {
 				PacketNumber:    1337,
 				PacketNumberLen: 4,
 			}).Log(logger)
-			Expect(buf.String()).To(ContainSubstring("Short Header{DestConnectionID: 0xdeadbeefcafe1337, PacketNumber: 1337, PacketNumberLen: 4, KeyPhase: 1}"))
+			Expect(buf.String()).To(ContainSubstring("Short Header{DestConnectionID: 0xdeadbeefcafe1337, PacketNumber: 1337, PacketNumberLen: 4, KeyPhase: 1, SpinBit: false}"))
 		})
 	})
 })
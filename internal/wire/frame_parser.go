@@ -21,6 +21,12 @@ func NewFrameParser(v protocol.VersionNumber) FrameParser {
 	return &frameParser{version: v}
 }
 
+// SetVersion sets the version used to parse frames.
+// It is called when the connection is upgraded to a compatible version during the handshake.
+func (p *frameParser) SetVersion(v protocol.VersionNumber) {
+	p.version = v
+}
+
 // ParseNextFrame parses the next frame
 // It skips PADDING frames.
 func (p *frameParser) ParseNext(r *bytes.Reader, encLevel protocol.EncryptionLevel) (Frame, error) {
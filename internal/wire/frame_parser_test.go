@@ -80,6 +80,11 @@ var _ = Describe("Frame parsing", func() {
 		Expect(frame.(*AckFrame).DelayTime).To(Equal(time.Second))
 	})
 
+	It("sets the version", func() {
+		parser.SetVersion(protocol.VersionDraft32)
+		Expect(parser.(*frameParser).version).To(Equal(protocol.VersionDraft32))
+	})
+
 	It("unpacks RESET_STREAM frames", func() {
 		f := &ResetStreamFrame{
 			StreamID:  0xdeadbeef,
@@ -52,6 +52,17 @@ var _ = Describe("NEW_CONNECTION_ID frame", func() {
 			Expect(err).To(MatchError("invalid connection ID length: 21"))
 		})
 
+		It("errors when the connection ID has a length of zero", func() {
+			data := []byte{0x18}
+			data = append(data, encodeVarInt(0xdeadbeef)...)   // sequence number
+			data = append(data, encodeVarInt(0xcafe)...)       // retire prior to
+			data = append(data, 0)                             // connection ID length
+			data = append(data, []byte("deadbeefdecafbad")...) // stateless reset token
+			b := bytes.NewReader(data)
+			_, err := parseNewConnectionIDFrame(b, versionIETFFrames)
+			Expect(err).To(MatchError("invalid connection ID length: 0"))
+		})
+
 		It("errors on EOFs", func() {
 			data := []byte{0x18}
 			data = append(data, encodeVarInt(0xdeadbeef)...)              // sequence number
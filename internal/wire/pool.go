@@ -8,6 +8,8 @@ import (
 
 var pool sync.Pool
 
+var ackFramePool sync.Pool
+
 func init() {
 	pool.New = func() interface{} {
 		return &StreamFrame{
@@ -15,6 +17,9 @@ func init() {
 			fromPool: true,
 		}
 	}
+	ackFramePool.New = func() interface{} {
+		return &AckFrame{}
+	}
 }
 
 func GetStreamFrame() *StreamFrame {
@@ -31,3 +36,18 @@ func putStreamFrame(f *StreamFrame) {
 	}
 	pool.Put(f)
 }
+
+func getAckFrame() *AckFrame {
+	return ackFramePool.Get().(*AckFrame)
+}
+
+// PutAckFrame returns an ACK frame returned by the frame parser to the pool.
+// It must only be called once the frame is not referenced any more.
+func PutAckFrame(f *AckFrame) {
+	// Don't hold on to the ACK ranges of unusually large ACK frames.
+	if cap(f.AckRanges) > protocol.MaxNumAckRanges {
+		return
+	}
+	*f = AckFrame{AckRanges: f.AckRanges[:0]}
+	ackFramePool.Put(f)
+}
@@ -1,6 +1,8 @@
 package wire
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -21,4 +23,16 @@ var _ = Describe("Pool", func() {
 		f := &StreamFrame{Data: []byte("foobar")}
 		putStreamFrame(f)
 	})
+
+	It("resets ACK frames when putting them back", func() {
+		f := getAckFrame()
+		f.AckRanges = append(f.AckRanges, AckRange{Smallest: 1, Largest: 10})
+		f.DelayTime = time.Second
+		f.ECNCE = 1
+		PutAckFrame(f)
+		Expect(f.AckRanges).To(BeEmpty())
+		Expect(f.AckRanges).ToNot(BeNil())
+		Expect(f.DelayTime).To(BeZero())
+		Expect(f.ECNCE).To(BeZero())
+	})
 })
@@ -88,7 +88,7 @@ var _ = Describe("Transport Parameters", func() {
 			MaxAckDelay:                     42 * time.Millisecond,
 			ActiveConnectionIDLimit:         getRandomValue(),
 		}
-		data := params.Marshal(protocol.PerspectiveServer)
+		data := params.Marshal(protocol.PerspectiveServer, true)
 
 		p := &TransportParameters{}
 		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
@@ -109,10 +109,29 @@ var _ = Describe("Transport Parameters", func() {
 		Expect(p.ActiveConnectionIDLimit).To(Equal(params.ActiveConnectionIDLimit))
 	})
 
+	It("adds a greased transport parameter", func() {
+		data := (&TransportParameters{
+			StatelessResetToken: &protocol.StatelessResetToken{},
+		}).Marshal(protocol.PerspectiveServer, true)
+		id, err := utils.ReadVarInt(bytes.NewReader(data))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id % 31).To(BeEquivalentTo(27))
+	})
+
+	It("doesn't add a greased transport parameter, if greasing is disabled", func() {
+		data := (&TransportParameters{
+			StatelessResetToken: &protocol.StatelessResetToken{},
+		}).Marshal(protocol.PerspectiveServer, false)
+		id, err := utils.ReadVarInt(bytes.NewReader(data))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).To(BeEquivalentTo(initialMaxStreamDataBidiLocalParameterID))
+		Expect((&TransportParameters{}).Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
+	})
+
 	It("doesn't marshal a retry_source_connection_id, if no Retry was performed", func() {
 		data := (&TransportParameters{
 			StatelessResetToken: &protocol.StatelessResetToken{},
-		}).Marshal(protocol.PerspectiveServer)
+		}).Marshal(protocol.PerspectiveServer, true)
 		p := &TransportParameters{}
 		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
 		Expect(p.RetrySourceConnectionID).To(BeNil())
@@ -122,7 +141,7 @@ var _ = Describe("Transport Parameters", func() {
 		data := (&TransportParameters{
 			RetrySourceConnectionID: &protocol.ConnectionID{},
 			StatelessResetToken:     &protocol.StatelessResetToken{},
-		}).Marshal(protocol.PerspectiveServer)
+		}).Marshal(protocol.PerspectiveServer, true)
 		p := &TransportParameters{}
 		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
 		Expect(p.RetrySourceConnectionID).ToNot(BeNil())
@@ -170,7 +189,7 @@ var _ = Describe("Transport Parameters", func() {
 		data := (&TransportParameters{
 			MaxAckDelay:         1 << 14 * time.Millisecond,
 			StatelessResetToken: &protocol.StatelessResetToken{},
-		}).Marshal(protocol.PerspectiveServer)
+		}).Marshal(protocol.PerspectiveServer, true)
 		p := &TransportParameters{}
 		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(MatchError("TRANSPORT_PARAMETER_ERROR: invalid value for max_ack_delay: 16384ms (maximum 16383ms)"))
 	})
@@ -184,12 +203,12 @@ var _ = Describe("Transport Parameters", func() {
 			dataDefault := (&TransportParameters{
 				MaxAckDelay:         protocol.DefaultMaxAckDelay,
 				StatelessResetToken: &protocol.StatelessResetToken{},
-			}).Marshal(protocol.PerspectiveServer)
+			}).Marshal(protocol.PerspectiveServer, true)
 			defaultLen += len(dataDefault)
 			data := (&TransportParameters{
 				MaxAckDelay:         maxAckDelay,
 				StatelessResetToken: &protocol.StatelessResetToken{},
-			}).Marshal(protocol.PerspectiveServer)
+			}).Marshal(protocol.PerspectiveServer, true)
 			dataLen += len(data)
 		}
 		entryLen := utils.VarIntLen(uint64(ackDelayExponentParameterID)) /* parameter id */ + utils.VarIntLen(uint64(utils.VarIntLen(uint64(maxAckDelay.Milliseconds())))) /*length */ + utils.VarIntLen(uint64(maxAckDelay.Milliseconds())) /* value */
@@ -200,7 +219,7 @@ var _ = Describe("Transport Parameters", func() {
 		data := (&TransportParameters{
 			AckDelayExponent:    21,
 			StatelessResetToken: &protocol.StatelessResetToken{},
-		}).Marshal(protocol.PerspectiveServer)
+		}).Marshal(protocol.PerspectiveServer, true)
 		p := &TransportParameters{}
 		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(MatchError("TRANSPORT_PARAMETER_ERROR: invalid value for ack_delay_exponent: 21 (maximum 20)"))
 	})
@@ -213,12 +232,12 @@ var _ = Describe("Transport Parameters", func() {
 			dataDefault := (&TransportParameters{
 				AckDelayExponent:    protocol.DefaultAckDelayExponent,
 				StatelessResetToken: &protocol.StatelessResetToken{},
-			}).Marshal(protocol.PerspectiveServer)
+			}).Marshal(protocol.PerspectiveServer, true)
 			defaultLen += len(dataDefault)
 			data := (&TransportParameters{
 				AckDelayExponent:    protocol.DefaultAckDelayExponent + 1,
 				StatelessResetToken: &protocol.StatelessResetToken{},
-			}).Marshal(protocol.PerspectiveServer)
+			}).Marshal(protocol.PerspectiveServer, true)
 			dataLen += len(data)
 		}
 		entryLen := utils.VarIntLen(uint64(ackDelayExponentParameterID)) /* parameter id */ + utils.VarIntLen(uint64(utils.VarIntLen(protocol.DefaultAckDelayExponent+1))) /* length */ + utils.VarIntLen(protocol.DefaultAckDelayExponent+1) /* value */
@@ -229,7 +248,7 @@ var _ = Describe("Transport Parameters", func() {
 		data := (&TransportParameters{
 			AckDelayExponent:    protocol.DefaultAckDelayExponent,
 			StatelessResetToken: &protocol.StatelessResetToken{},
-		}).Marshal(protocol.PerspectiveServer)
+		}).Marshal(protocol.PerspectiveServer, true)
 		p := &TransportParameters{}
 		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
 		Expect(p.AckDelayExponent).To(BeEquivalentTo(protocol.DefaultAckDelayExponent))
@@ -344,7 +363,7 @@ var _ = Describe("Transport Parameters", func() {
 			data := (&TransportParameters{
 				PreferredAddress:    pa,
 				StatelessResetToken: &protocol.StatelessResetToken{},
-			}).Marshal(protocol.PerspectiveServer)
+			}).Marshal(protocol.PerspectiveServer, true)
 			p := &TransportParameters{}
 			Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
 			Expect(p.PreferredAddress.IPv4.String()).To(Equal(pa.IPv4.String()))
@@ -369,7 +388,7 @@ var _ = Describe("Transport Parameters", func() {
 			data := (&TransportParameters{
 				PreferredAddress:    pa,
 				StatelessResetToken: &protocol.StatelessResetToken{},
-			}).Marshal(protocol.PerspectiveServer)
+			}).Marshal(protocol.PerspectiveServer, true)
 			p := &TransportParameters{}
 			Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(MatchError("TRANSPORT_PARAMETER_ERROR: invalid connection ID length: 0"))
 		})
@@ -380,7 +399,7 @@ var _ = Describe("Transport Parameters", func() {
 			data := (&TransportParameters{
 				PreferredAddress:    pa,
 				StatelessResetToken: &protocol.StatelessResetToken{},
-			}).Marshal(protocol.PerspectiveServer)
+			}).Marshal(protocol.PerspectiveServer, true)
 			p := &TransportParameters{}
 			Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(MatchError("TRANSPORT_PARAMETER_ERROR: invalid connection ID length: 21"))
 		})
@@ -405,6 +424,48 @@ var _ = Describe("Transport Parameters", func() {
 		})
 	})
 
+	Context("version information", func() {
+		It("marshals and unmarshals", func() {
+			data := (&TransportParameters{
+				InitialSourceConnectionID: protocol.ConnectionID{1, 2, 3, 4},
+				VersionInformation: &VersionInformation{
+					ChosenVersion:     protocol.VersionDraft29,
+					AvailableVersions: []protocol.VersionNumber{protocol.VersionDraft32, protocol.VersionDraft29},
+				},
+			}).Marshal(protocol.PerspectiveClient, true)
+			p := &TransportParameters{}
+			Expect(p.Unmarshal(data, protocol.PerspectiveClient)).To(Succeed())
+			Expect(p.VersionInformation).ToNot(BeNil())
+			Expect(p.VersionInformation.ChosenVersion).To(Equal(protocol.VersionDraft29))
+			Expect(p.VersionInformation.AvailableVersions).To(Equal([]protocol.VersionNumber{protocol.VersionDraft32, protocol.VersionDraft29}))
+		})
+
+		It("doesn't marshal the version_information, if not set", func() {
+			data := (&TransportParameters{InitialSourceConnectionID: protocol.ConnectionID{1, 2, 3, 4}}).Marshal(protocol.PerspectiveClient, true)
+			p := &TransportParameters{}
+			Expect(p.Unmarshal(data, protocol.PerspectiveClient)).To(Succeed())
+			Expect(p.VersionInformation).To(BeNil())
+		})
+
+		It("errors if the length is not a multiple of 4", func() {
+			b := &bytes.Buffer{}
+			utils.WriteVarInt(b, uint64(versionInformationParameterID))
+			utils.WriteVarInt(b, 6)
+			b.Write([]byte("foobar"))
+			addInitialSourceConnectionID(b)
+			Expect((&TransportParameters{}).Unmarshal(b.Bytes(), protocol.PerspectiveClient)).To(MatchError("TRANSPORT_PARAMETER_ERROR: invalid length for version_information: 6"))
+		})
+
+		It("errors if the chosen version is 0", func() {
+			b := &bytes.Buffer{}
+			utils.WriteVarInt(b, uint64(versionInformationParameterID))
+			utils.WriteVarInt(b, 4)
+			utils.BigEndian.WriteUint32(b, 0)
+			addInitialSourceConnectionID(b)
+			Expect((&TransportParameters{}).Unmarshal(b.Bytes(), protocol.PerspectiveClient)).To(MatchError("TRANSPORT_PARAMETER_ERROR: version_information: chosen version must not be 0"))
+		})
+	})
+
 	Context("saving and retrieving from a session ticket", func() {
 		It("saves and retrieves the parameters", func() {
 			params := &TransportParameters{
@@ -42,6 +42,7 @@ const (
 	activeConnectionIDLimitParameterID         transportParameterID = 0xe
 	initialSourceConnectionIDParameterID       transportParameterID = 0xf
 	retrySourceConnectionIDParameterID         transportParameterID = 0x10
+	versionInformationParameterID              transportParameterID = 0x11
 )
 
 // PreferredAddress is the value encoding in the preferred_address transport parameter
@@ -54,6 +55,15 @@ type PreferredAddress struct {
 	StatelessResetToken protocol.StatelessResetToken
 }
 
+// VersionInformation is the value encoded in the version_information transport parameter.
+// It is used to authenticate the outcome of version negotiation.
+type VersionInformation struct {
+	// ChosenVersion is the version that the sender of the transport parameters is using.
+	ChosenVersion protocol.VersionNumber
+	// AvailableVersions are the versions supported by the sender of the transport parameters.
+	AvailableVersions []protocol.VersionNumber
+}
+
 // TransportParameters are parameters sent to the peer during the handshake
 type TransportParameters struct {
 	InitialMaxStreamDataBidiLocal  protocol.ByteCount
@@ -81,6 +91,8 @@ type TransportParameters struct {
 
 	StatelessResetToken     *protocol.StatelessResetToken
 	ActiveConnectionIDLimit uint64
+
+	VersionInformation *VersionInformation
 }
 
 // Unmarshal the transport parameters
@@ -176,6 +188,10 @@ func (p *TransportParameters) unmarshal(r *bytes.Reader, sentBy protocol.Perspec
 			}
 			connID, _ := protocol.ReadConnectionID(r, int(paramLen))
 			p.RetrySourceConnectionID = &connID
+		case versionInformationParameterID:
+			if err := p.readVersionInformation(r, int(paramLen)); err != nil {
+				return err
+			}
 		default:
 			r.Seek(int64(paramLen), io.SeekCurrent)
 		}
@@ -255,6 +271,29 @@ func (p *TransportParameters) readPreferredAddress(r *bytes.Reader, expectedLen
 	return nil
 }
 
+func (p *TransportParameters) readVersionInformation(r *bytes.Reader, length int) error {
+	if length < 4 || length%4 != 0 {
+		return fmt.Errorf("invalid length for version_information: %d", length)
+	}
+	chosenVersion, err := utils.BigEndian.ReadUint32(r)
+	if err != nil {
+		return err
+	}
+	if chosenVersion == 0 {
+		return errors.New("version_information: chosen version must not be 0")
+	}
+	vi := &VersionInformation{ChosenVersion: protocol.VersionNumber(chosenVersion)}
+	for i := 4; i < length; i += 4 {
+		v, err := utils.BigEndian.ReadUint32(r)
+		if err != nil {
+			return err
+		}
+		vi.AvailableVersions = append(vi.AvailableVersions, protocol.VersionNumber(v))
+	}
+	p.VersionInformation = vi
+	return nil
+}
+
 func (p *TransportParameters) readNumericTransportParameter(
 	r *bytes.Reader,
 	paramID transportParameterID,
@@ -311,17 +350,20 @@ func (p *TransportParameters) readNumericTransportParameter(
 	return nil
 }
 
-// Marshal the transport parameters
-func (p *TransportParameters) Marshal(pers protocol.Perspective) []byte {
+// Marshal the transport parameters.
+// If grease is set, a reserved transport parameter is added.
+func (p *TransportParameters) Marshal(pers protocol.Perspective, grease bool) []byte {
 	b := &bytes.Buffer{}
 
-	// add a greased value
-	utils.WriteVarInt(b, uint64(27+31*rand.Intn(100)))
-	length := rand.Intn(16)
-	randomData := make([]byte, length)
-	rand.Read(randomData)
-	utils.WriteVarInt(b, uint64(length))
-	b.Write(randomData)
+	if grease {
+		// add a greased value
+		utils.WriteVarInt(b, uint64(27+31*rand.Intn(100)))
+		length := rand.Intn(16)
+		randomData := make([]byte, length)
+		rand.Read(randomData)
+		utils.WriteVarInt(b, uint64(length))
+		b.Write(randomData)
+	}
 
 	// initial_max_stream_data_bidi_local
 	p.marshalVarintParam(b, initialMaxStreamDataBidiLocalParameterID, uint64(p.InitialMaxStreamDataBidiLocal))
@@ -391,6 +433,15 @@ func (p *TransportParameters) Marshal(pers protocol.Perspective) []byte {
 		utils.WriteVarInt(b, uint64(p.RetrySourceConnectionID.Len()))
 		b.Write(p.RetrySourceConnectionID.Bytes())
 	}
+	// version_information
+	if p.VersionInformation != nil {
+		utils.WriteVarInt(b, uint64(versionInformationParameterID))
+		utils.WriteVarInt(b, 4+4*uint64(len(p.VersionInformation.AvailableVersions)))
+		utils.BigEndian.WriteUint32(b, uint32(p.VersionInformation.ChosenVersion))
+		for _, v := range p.VersionInformation.AvailableVersions {
+			utils.BigEndian.WriteUint32(b, uint32(v))
+		}
+	}
 	return b.Bytes()
 }
 
@@ -34,10 +34,10 @@ func ParseVersionNegotiationPacket(b *bytes.Reader) (*Header, []protocol.Version
 	return hdr, versions, nil
 }
 
-// ComposeVersionNegotiation composes a Version Negotiation
+// ComposeVersionNegotiation composes a Version Negotiation.
+// It doesn't add a reserved version number, that's the responsibility of the caller.
 func ComposeVersionNegotiation(destConnID, srcConnID protocol.ConnectionID, versions []protocol.VersionNumber) ([]byte, error) {
-	greasedVersions := protocol.GetGreasedVersions(versions)
-	expectedLen := 1 /* type byte */ + 4 /* version field */ + 1 /* dest connection ID length field */ + destConnID.Len() + 1 /* src connection ID length field */ + srcConnID.Len() + len(greasedVersions)*4
+	expectedLen := 1 /* type byte */ + 4 /* version field */ + 1 /* dest connection ID length field */ + destConnID.Len() + 1 /* src connection ID length field */ + srcConnID.Len() + len(versions)*4
 	buf := bytes.NewBuffer(make([]byte, 0, expectedLen))
 	r := make([]byte, 1)
 	_, _ = rand.Read(r) // ignore the error here. It is not critical to have perfect random here.
@@ -47,7 +47,7 @@ func ComposeVersionNegotiation(destConnID, srcConnID protocol.ConnectionID, vers
 	buf.Write(destConnID)
 	buf.WriteByte(uint8(srcConnID.Len()))
 	buf.Write(srcConnID)
-	for _, v := range greasedVersions {
+	for _, v := range versions {
 		utils.BigEndian.WriteUint32(buf, uint32(v))
 	}
 	return buf.Bytes(), nil
@@ -47,13 +47,12 @@ var _ = Describe("Version Negotiation Packets", func() {
 		versions := []protocol.VersionNumber{0x22334455}
 		data, err := ComposeVersionNegotiation(connID, connID, versions)
 		Expect(err).ToNot(HaveOccurred())
-		// remove 8 bytes (two versions), since ComposeVersionNegotiation also added a reserved version number
-		data = data[:len(data)-8]
+		data = data[:len(data)-4]
 		_, _, err = ParseVersionNegotiationPacket(bytes.NewReader(data))
 		Expect(err).To(MatchError("Version Negotiation packet has empty version list"))
 	})
 
-	It("adds a reserved version", func() {
+	It("writes a Version Negotiation packet", func() {
 		srcConnID := protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef, 0xca, 0xfe, 0x13, 0x37}
 		destConnID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}
 		versions := []protocol.VersionNumber{1001, 1003}
@@ -65,22 +64,6 @@ var _ = Describe("Version Negotiation Packets", func() {
 		Expect(hdr.DestConnectionID).To(Equal(destConnID))
 		Expect(hdr.SrcConnectionID).To(Equal(srcConnID))
 		Expect(hdr.Version).To(BeZero())
-		// the supported versions should include one reserved version number
-		Expect(supportedVersions).To(HaveLen(len(versions) + 1))
-		for _, v := range versions {
-			Expect(supportedVersions).To(ContainElement(v))
-		}
-		var reservedVersion protocol.VersionNumber
-	versionLoop:
-		for _, ver := range supportedVersions {
-			for _, v := range versions {
-				if v == ver {
-					continue versionLoop
-				}
-			}
-			reservedVersion = ver
-		}
-		Expect(reservedVersion).ToNot(BeZero())
-		Expect(reservedVersion&0x0f0f0f0f == 0x0a0a0a0a).To(BeTrue()) // check that it's a greased version number
+		Expect(supportedVersions).To(Equal(versions))
 	})
 })
@@ -107,6 +107,7 @@ type ConnectionTracer interface {
 	ClosedConnection(CloseReason)
 	SentTransportParameters(*TransportParameters)
 	ReceivedTransportParameters(*TransportParameters)
+	// The ACK frame is reused after SentPacket returns. It must not be retained.
 	SentPacket(hdr *ExtendedHeader, size ByteCount, ack *AckFrame, frames []Frame)
 	ReceivedVersionNegotiationPacket(*Header, []VersionNumber)
 	ReceivedRetry(*Header)
@@ -115,7 +116,18 @@ type ConnectionTracer interface {
 	DroppedPacket(PacketType, ByteCount, PacketDropReason)
 	UpdatedMetrics(rttStats *RTTStats, cwnd, bytesInFlight ByteCount, packetsInFlight int)
 	LostPacket(EncryptionLevel, PacketNumber, PacketLossReason)
+	// QueuedForRetransmission is called when the frames of a packet are queued for retransmission,
+	// either because the packet was declared lost, or because it is retransmitted in a probe packet.
+	QueuedForRetransmission(EncryptionLevel, PacketNumber, []Frame)
+	// DetectedSpuriousLoss is called when a packet that was declared lost is acknowledged.
+	DetectedSpuriousLoss(EncryptionLevel, PacketNumber)
+	// CompletedStream is called when a stream is completed, with the statistics of that stream.
+	// It may be called from any goroutine.
+	CompletedStream(StreamID, StreamStats)
 	UpdatedCongestionState(CongestionState)
+	// SetCorrelationID is called when the application sets a correlation ID for the connection.
+	// It may be called from any goroutine.
+	SetCorrelationID(string)
 	UpdatedPTOCount(value uint32)
 	UpdatedKeyFromTLS(EncryptionLevel, Perspective)
 	UpdatedKey(generation KeyPhase, remote bool)
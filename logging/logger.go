@@ -0,0 +1,23 @@
+package logging
+
+// LogLevel is the level of a log message.
+type LogLevel uint8
+
+const (
+	// LogLevelError is used for errors.
+	LogLevelError LogLevel = 1 + iota
+	// LogLevelInfo is used for informational messages, e.g. when a connection is established.
+	LogLevelInfo
+	// LogLevelDebug is used for debug messages, e.g. for the contents of every packet.
+	LogLevelDebug
+)
+
+// A Logger receives the log messages of quic-go.
+// It can be used to route quic-go's logs into an application's logging stack.
+type Logger interface {
+	// Enabled says if messages of the given level are logged.
+	// It is called before a message is formatted, so it should be cheap.
+	Enabled(LogLevel) bool
+	// Log logs a message. keyvals are alternating keys and values.
+	Log(level LogLevel, msg string, keyvals ...interface{})
+}
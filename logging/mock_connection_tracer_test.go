@@ -74,6 +74,30 @@ func (mr *MockConnectionTracerMockRecorder) ClosedConnection(arg0 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClosedConnection", reflect.TypeOf((*MockConnectionTracer)(nil).ClosedConnection), arg0)
 }
 
+// CompletedStream mocks base method
+func (m *MockConnectionTracer) CompletedStream(arg0 protocol.StreamID, arg1 StreamStats) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CompletedStream", arg0, arg1)
+}
+
+// CompletedStream indicates an expected call of CompletedStream
+func (mr *MockConnectionTracerMockRecorder) CompletedStream(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompletedStream", reflect.TypeOf((*MockConnectionTracer)(nil).CompletedStream), arg0, arg1)
+}
+
+// DetectedSpuriousLoss mocks base method
+func (m *MockConnectionTracer) DetectedSpuriousLoss(arg0 protocol.EncryptionLevel, arg1 protocol.PacketNumber) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DetectedSpuriousLoss", arg0, arg1)
+}
+
+// DetectedSpuriousLoss indicates an expected call of DetectedSpuriousLoss
+func (mr *MockConnectionTracerMockRecorder) DetectedSpuriousLoss(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectedSpuriousLoss", reflect.TypeOf((*MockConnectionTracer)(nil).DetectedSpuriousLoss), arg0, arg1)
+}
+
 // DroppedEncryptionLevel mocks base method
 func (m *MockConnectionTracer) DroppedEncryptionLevel(arg0 protocol.EncryptionLevel) {
 	m.ctrl.T.Helper()
@@ -146,6 +170,18 @@ func (mr *MockConnectionTracerMockRecorder) LostPacket(arg0, arg1, arg2 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LostPacket", reflect.TypeOf((*MockConnectionTracer)(nil).LostPacket), arg0, arg1, arg2)
 }
 
+// QueuedForRetransmission mocks base method
+func (m *MockConnectionTracer) QueuedForRetransmission(arg0 protocol.EncryptionLevel, arg1 protocol.PacketNumber, arg2 []Frame) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "QueuedForRetransmission", arg0, arg1, arg2)
+}
+
+// QueuedForRetransmission indicates an expected call of QueuedForRetransmission
+func (mr *MockConnectionTracerMockRecorder) QueuedForRetransmission(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueuedForRetransmission", reflect.TypeOf((*MockConnectionTracer)(nil).QueuedForRetransmission), arg0, arg1, arg2)
+}
+
 // ReceivedPacket mocks base method
 func (m *MockConnectionTracer) ReceivedPacket(arg0 *wire.ExtendedHeader, arg1 protocol.ByteCount, arg2 []Frame) {
 	m.ctrl.T.Helper()
@@ -218,6 +254,18 @@ func (mr *MockConnectionTracerMockRecorder) SentTransportParameters(arg0 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentTransportParameters", reflect.TypeOf((*MockConnectionTracer)(nil).SentTransportParameters), arg0)
 }
 
+// SetCorrelationID mocks base method
+func (m *MockConnectionTracer) SetCorrelationID(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetCorrelationID", arg0)
+}
+
+// SetCorrelationID indicates an expected call of SetCorrelationID
+func (mr *MockConnectionTracerMockRecorder) SetCorrelationID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCorrelationID", reflect.TypeOf((*MockConnectionTracer)(nil).SetCorrelationID), arg0)
+}
+
 // SetLossTimer mocks base method
 func (m *MockConnectionTracer) SetLossTimer(arg0 TimerType, arg1 protocol.EncryptionLevel, arg2 time.Time) {
 	m.ctrl.T.Helper()
@@ -138,6 +138,30 @@ func (m *connTracerMultiplexer) LostPacket(encLevel EncryptionLevel, pn PacketNu
 	}
 }
 
+func (m *connTracerMultiplexer) QueuedForRetransmission(encLevel EncryptionLevel, pn PacketNumber, frames []Frame) {
+	for _, t := range m.tracers {
+		t.QueuedForRetransmission(encLevel, pn, frames)
+	}
+}
+
+func (m *connTracerMultiplexer) DetectedSpuriousLoss(encLevel EncryptionLevel, pn PacketNumber) {
+	for _, t := range m.tracers {
+		t.DetectedSpuriousLoss(encLevel, pn)
+	}
+}
+
+func (m *connTracerMultiplexer) CompletedStream(id StreamID, stats StreamStats) {
+	for _, t := range m.tracers {
+		t.CompletedStream(id, stats)
+	}
+}
+
+func (m *connTracerMultiplexer) SetCorrelationID(id string) {
+	for _, t := range m.tracers {
+		t.SetCorrelationID(id)
+	}
+}
+
 func (m *connTracerMultiplexer) UpdatedPTOCount(value uint32) {
 	for _, t := range m.tracers {
 		t.UpdatedPTOCount(value)
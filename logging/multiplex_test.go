@@ -195,6 +195,12 @@ var _ = Describe("Tracing", func() {
 			tracer.UpdatedPTOCount(88)
 		})
 
+		It("traces the SetCorrelationID event", func() {
+			tr1.EXPECT().SetCorrelationID("foobar")
+			tr2.EXPECT().SetCorrelationID("foobar")
+			tracer.SetCorrelationID("foobar")
+		})
+
 		It("traces the UpdatedKeyFromTLS event", func() {
 			tr1.EXPECT().UpdatedKeyFromTLS(EncryptionHandshake, PerspectiveClient)
 			tr2.EXPECT().UpdatedKeyFromTLS(EncryptionHandshake, PerspectiveClient)
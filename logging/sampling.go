@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+type samplingTracer struct {
+	tracer Tracer
+	sample func(Perspective, ConnectionID) bool
+}
+
+var _ Tracer = &samplingTracer{}
+
+// NewSamplingTracer creates a new tracer that only traces every n-th connection.
+// Events that are not associated with a connection are always passed to the underlying tracer.
+func NewSamplingTracer(tracer Tracer, n uint32) Tracer {
+	if n <= 1 {
+		return tracer
+	}
+	var counter uint32
+	return NewFilteringTracer(tracer, func(Perspective, ConnectionID) bool {
+		return (atomic.AddUint32(&counter, 1)-1)%n == 0
+	})
+}
+
+// NewFilteringTracer creates a new tracer that only traces connections for which filter returns true.
+// The filter is called once for every new connection, and may be called concurrently.
+// Events that are not associated with a connection are always passed to the underlying tracer.
+func NewFilteringTracer(tracer Tracer, filter func(p Perspective, odcid ConnectionID) bool) Tracer {
+	return &samplingTracer{tracer: tracer, sample: filter}
+}
+
+func (t *samplingTracer) TracerForConnection(p Perspective, odcid ConnectionID) ConnectionTracer {
+	if !t.sample(p, odcid) {
+		return nil
+	}
+	return t.tracer.TracerForConnection(p, odcid)
+}
+
+func (t *samplingTracer) SentPacket(remote net.Addr, hdr *Header, size ByteCount, frames []Frame) {
+	t.tracer.SentPacket(remote, hdr, size, frames)
+}
+
+func (t *samplingTracer) DroppedPacket(remote net.Addr, typ PacketType, size ByteCount, reason PacketDropReason) {
+	t.tracer.DroppedPacket(remote, typ, size, reason)
+}
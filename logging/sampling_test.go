@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sampling Tracer", func() {
+	var tr *MockTracer
+
+	BeforeEach(func() {
+		tr = NewMockTracer(mockCtrl)
+	})
+
+	It("returns the raw tracer if every connection is sampled", func() {
+		Expect(NewSamplingTracer(tr, 1)).To(Equal(tr))
+	})
+
+	It("traces every n-th connection", func() {
+		tracer := NewSamplingTracer(tr, 3)
+		ctr := NewMockConnectionTracer(mockCtrl)
+		tr.EXPECT().TracerForConnection(PerspectiveServer, ConnectionID{1}).Return(ctr)
+		tr.EXPECT().TracerForConnection(PerspectiveServer, ConnectionID{4}).Return(ctr)
+		for i := byte(1); i <= 5; i++ {
+			connTracer := tracer.TracerForConnection(PerspectiveServer, ConnectionID{i})
+			if i == 1 || i == 4 {
+				Expect(connTracer).To(Equal(ctr))
+			} else {
+				Expect(connTracer).To(BeNil())
+			}
+		}
+	})
+
+	It("traces connections matching the filter", func() {
+		tracer := NewFilteringTracer(tr, func(p Perspective, odcid ConnectionID) bool {
+			return p == PerspectiveClient && odcid[0] == 0x42
+		})
+		ctr := NewMockConnectionTracer(mockCtrl)
+		tr.EXPECT().TracerForConnection(PerspectiveClient, ConnectionID{0x42}).Return(ctr)
+		Expect(tracer.TracerForConnection(PerspectiveClient, ConnectionID{0x42})).To(Equal(ctr))
+		Expect(tracer.TracerForConnection(PerspectiveClient, ConnectionID{0x43})).To(BeNil())
+		Expect(tracer.TracerForConnection(PerspectiveServer, ConnectionID{0x42})).To(BeNil())
+	})
+
+	It("passes on events not associated with a connection", func() {
+		tracer := NewFilteringTracer(tr, func(Perspective, ConnectionID) bool { return false })
+		remote := &net.UDPAddr{IP: net.IPv4(4, 3, 2, 1)}
+		hdr := &Header{DestConnectionID: ConnectionID{1, 2, 3}}
+		tr.EXPECT().SentPacket(remote, hdr, ByteCount(1024), nil)
+		tracer.SentPacket(remote, hdr, 1024, nil)
+		tr.EXPECT().DroppedPacket(remote, PacketTypeInitial, ByteCount(1024), PacketDropDuplicate)
+		tracer.DroppedPacket(remote, PacketTypeInitial, 1024, PacketDropDuplicate)
+	})
+})
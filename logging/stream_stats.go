@@ -0,0 +1,21 @@
+package logging
+
+import "time"
+
+// StreamStats are the statistics of a single stream.
+type StreamStats struct {
+	// BytesSent is the number of bytes of stream data sent, not counting retransmissions.
+	BytesSent ByteCount
+	// BytesRetransmitted is the number of bytes of stream data that had to be retransmitted.
+	BytesRetransmitted ByteCount
+	// BytesReceived is the number of bytes of stream data received, including duplicates.
+	BytesReceived ByteCount
+	// FramesSent is the number of STREAM frames sent, including retransmissions.
+	FramesSent uint64
+	// FramesReceived is the number of STREAM frames received.
+	FramesReceived uint64
+	// FlowControlBlocked is the time that sending was blocked by flow control.
+	// This includes both stream-level and connection-level flow control,
+	// since the stream's data can't be sent in either case.
+	FlowControlBlocked time.Duration
+}
@@ -80,6 +80,9 @@ const (
 	// TimeoutReasonIdle is used when the session is closed due to an idle timeout
 	// This reason is not defined in the qlog draft, but very useful for debugging.
 	TimeoutReasonIdle
+	// TimeoutReasonLivenessProbe is used when the session is closed because the peer didn't respond to liveness probes
+	// This reason is not defined in the qlog draft, but very useful for debugging.
+	TimeoutReasonLivenessProbe
 )
 
 type CongestionState uint8
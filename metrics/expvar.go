@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"expvar"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// The expvar variables, published under "quic-go".
+var (
+	expvarOnce sync.Once
+
+	expvarActiveSessions  = new(expvar.Int)
+	expvarSessions        = new(expvar.Int)
+	expvarPacketsSent     = new(expvar.Int)
+	expvarPacketsReceived = new(expvar.Int)
+	expvarPacketsDropped  = new(expvar.Int)
+	expvarPacketsLost     = new(expvar.Int)
+	expvarSpuriousLosses  = new(expvar.Int)
+	expvarBytesSent       = new(expvar.Int)
+	expvarBytesReceived   = new(expvar.Int)
+	expvarCloses          = new(expvar.Map).Init() // by close reason and error code
+
+	expvarStreams                  = new(expvar.Int)
+	expvarStreamBytesRetransmitted = new(expvar.Int)
+	expvarStreamFlowControlBlocked = new(expvar.Float) // in seconds
+)
+
+func publishExpvars() {
+	m := expvar.NewMap("quic-go")
+	m.Set("active_sessions", expvarActiveSessions)
+	m.Set("sessions", expvarSessions)
+	m.Set("packets_sent", expvarPacketsSent)
+	m.Set("packets_received", expvarPacketsReceived)
+	m.Set("packets_dropped", expvarPacketsDropped)
+	m.Set("packets_lost", expvarPacketsLost)
+	m.Set("spurious_losses", expvarSpuriousLosses)
+	m.Set("bytes_sent", expvarBytesSent)
+	m.Set("bytes_received", expvarBytesReceived)
+	m.Set("completed_streams", expvarStreams)
+	m.Set("stream_bytes_retransmitted", expvarStreamBytesRetransmitted)
+	m.Set("stream_flow_control_blocked_seconds", expvarStreamFlowControlBlocked)
+	m.Set("closes", expvarCloses)
+}
+
+type expvarTracer struct{}
+
+var _ logging.Tracer = &expvarTracer{}
+
+// NewExpvarTracer creates a tracer that publishes basic counters using the expvar package.
+// This allows introspection of small deployments without setting up a metrics pipeline.
+// The counters are published under "quic-go" when this function is first called.
+// Rates can be derived by polling the counters.
+func NewExpvarTracer() logging.Tracer {
+	expvarOnce.Do(publishExpvars)
+	return &expvarTracer{}
+}
+
+func (t *expvarTracer) TracerForConnection(logging.Perspective, logging.ConnectionID) logging.ConnectionTracer {
+	return &expvarConnTracer{}
+}
+
+func (t *expvarTracer) SentPacket(_ net.Addr, _ *logging.Header, size logging.ByteCount, _ []logging.Frame) {
+	expvarPacketsSent.Add(1)
+	expvarBytesSent.Add(int64(size))
+}
+
+func (t *expvarTracer) DroppedPacket(net.Addr, logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
+	expvarPacketsDropped.Add(1)
+}
+
+type expvarConnTracer struct {
+	started bool
+}
+
+var _ logging.ConnectionTracer = &expvarConnTracer{}
+
+func (t *expvarConnTracer) StartedConnection(net.Addr, net.Addr, logging.VersionNumber, logging.ConnectionID, logging.ConnectionID) {
+	expvarSessions.Add(1)
+	// StartedConnection is called again when the client recreates the session (after a Retry or Version Negotiation).
+	if !t.started {
+		t.started = true
+		expvarActiveSessions.Add(1)
+	}
+}
+
+func (t *expvarConnTracer) ClosedConnection(r logging.CloseReason) {
+	key := "unknown"
+	if timeout, ok := r.Timeout(); ok {
+		key = timeoutReason(timeout).String()
+	} else if _, ok := r.StatelessReset(); ok {
+		key = "stateless_reset"
+	} else if errorCode, _, ok := r.ApplicationError(); ok {
+		key = "application_error " + errorCode.String()
+	} else if errorCode, _, ok := r.TransportError(); ok {
+		key = "transport_error " + errorCode.String()
+	}
+	expvarCloses.Add(key, 1)
+}
+func (t *expvarConnTracer) SentTransportParameters(*logging.TransportParameters)     {}
+func (t *expvarConnTracer) ReceivedTransportParameters(*logging.TransportParameters) {}
+func (t *expvarConnTracer) SentPacket(_ *logging.ExtendedHeader, size logging.ByteCount, _ *logging.AckFrame, _ []logging.Frame) {
+	expvarPacketsSent.Add(1)
+	expvarBytesSent.Add(int64(size))
+}
+func (t *expvarConnTracer) ReceivedVersionNegotiationPacket(*logging.Header, []logging.VersionNumber) {
+}
+func (t *expvarConnTracer) ReceivedRetry(*logging.Header) {}
+func (t *expvarConnTracer) ReceivedPacket(_ *logging.ExtendedHeader, size logging.ByteCount, _ []logging.Frame) {
+	expvarPacketsReceived.Add(1)
+	expvarBytesReceived.Add(int64(size))
+}
+func (t *expvarConnTracer) BufferedPacket(logging.PacketType) {}
+func (t *expvarConnTracer) DroppedPacket(logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
+	expvarPacketsDropped.Add(1)
+}
+func (t *expvarConnTracer) UpdatedMetrics(*logging.RTTStats, logging.ByteCount, logging.ByteCount, int) {
+}
+func (t *expvarConnTracer) LostPacket(logging.EncryptionLevel, logging.PacketNumber, logging.PacketLossReason) {
+	expvarPacketsLost.Add(1)
+}
+func (t *expvarConnTracer) QueuedForRetransmission(logging.EncryptionLevel, logging.PacketNumber, []logging.Frame) {
+}
+func (t *expvarConnTracer) DetectedSpuriousLoss(logging.EncryptionLevel, logging.PacketNumber) {
+	expvarSpuriousLosses.Add(1)
+}
+func (t *expvarConnTracer) CompletedStream(_ logging.StreamID, s logging.StreamStats) {
+	expvarStreams.Add(1)
+	expvarStreamBytesRetransmitted.Add(int64(s.BytesRetransmitted))
+	expvarStreamFlowControlBlocked.Add(s.FlowControlBlocked.Seconds())
+}
+func (t *expvarConnTracer) UpdatedCongestionState(logging.CongestionState)                     {}
+func (t *expvarConnTracer) SetCorrelationID(string)                                            {}
+func (t *expvarConnTracer) UpdatedPTOCount(uint32)                                             {}
+func (t *expvarConnTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective)     {}
+func (t *expvarConnTracer) UpdatedKey(logging.KeyPhase, bool)                                  {}
+func (t *expvarConnTracer) DroppedEncryptionLevel(logging.EncryptionLevel)                     {}
+func (t *expvarConnTracer) DroppedKey(logging.KeyPhase)                                        {}
+func (t *expvarConnTracer) SetLossTimer(logging.TimerType, logging.EncryptionLevel, time.Time) {}
+func (t *expvarConnTracer) LossTimerExpired(logging.TimerType, logging.EncryptionLevel)        {}
+func (t *expvarConnTracer) LossTimerCanceled()                                                 {}
+func (t *expvarConnTracer) Close() {
+	if t.started {
+		expvarActiveSessions.Add(-1)
+	}
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"expvar"
+	"net"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("expvar Tracer", func() {
+	var (
+		tracer     logging.Tracer
+		connTracer logging.ConnectionTracer
+	)
+	remoteAddr := &net.UDPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 1234}
+
+	// The counters are global, so the tests check by how much they changed.
+	getInt := func(name string) int64 {
+		return expvar.Get("quic-go").(*expvar.Map).Get(name).(*expvar.Int).Value()
+	}
+	getClose := func(key string) int64 {
+		v := expvarCloses.Get(key)
+		if v == nil {
+			return 0
+		}
+		return v.(*expvar.Int).Value()
+	}
+
+	BeforeEach(func() {
+		tracer = NewExpvarTracer()
+		connTracer = tracer.TracerForConnection(logging.PerspectiveServer, logging.ConnectionID{1, 2, 3, 4})
+	})
+
+	It("publishes the counters", func() {
+		m, ok := expvar.Get("quic-go").(*expvar.Map)
+		Expect(ok).To(BeTrue())
+		for _, name := range []string{
+			"active_sessions",
+			"sessions",
+			"packets_sent",
+			"packets_received",
+			"packets_dropped",
+			"packets_lost",
+			"spurious_losses",
+			"bytes_sent",
+			"bytes_received",
+			"completed_streams",
+			"stream_bytes_retransmitted",
+			"stream_flow_control_blocked_seconds",
+			"closes",
+		} {
+			Expect(m.Get(name)).ToNot(BeNil())
+		}
+	})
+
+	It("counts sessions", func() {
+		sessions := getInt("sessions")
+		active := getInt("active_sessions")
+		connTracer.StartedConnection(nil, remoteAddr, protocol.VersionTLS, nil, nil)
+		// called again when the client recreates the session
+		connTracer.StartedConnection(nil, remoteAddr, protocol.VersionTLS, nil, nil)
+		Expect(getInt("sessions")).To(Equal(sessions + 2))
+		Expect(getInt("active_sessions")).To(Equal(active + 1))
+		connTracer.Close()
+		Expect(getInt("active_sessions")).To(Equal(active))
+	})
+
+	It("doesn't decrease the active sessions for sessions that were never started", func() {
+		active := getInt("active_sessions")
+		connTracer.Close()
+		Expect(getInt("active_sessions")).To(Equal(active))
+	})
+
+	It("counts sent and received packets", func() {
+		sent := getInt("packets_sent")
+		bytesSent := getInt("bytes_sent")
+		received := getInt("packets_received")
+		bytesReceived := getInt("bytes_received")
+		tracer.SentPacket(remoteAddr, &logging.Header{}, 1200, nil)
+		connTracer.SentPacket(&logging.ExtendedHeader{}, 1000, nil, nil)
+		connTracer.ReceivedPacket(&logging.ExtendedHeader{}, 500, nil)
+		Expect(getInt("packets_sent")).To(Equal(sent + 2))
+		Expect(getInt("bytes_sent")).To(Equal(bytesSent + 2200))
+		Expect(getInt("packets_received")).To(Equal(received + 1))
+		Expect(getInt("bytes_received")).To(Equal(bytesReceived + 500))
+	})
+
+	It("counts dropped, lost and spuriously lost packets", func() {
+		dropped := getInt("packets_dropped")
+		lost := getInt("packets_lost")
+		spurious := getInt("spurious_losses")
+		tracer.DroppedPacket(remoteAddr, logging.PacketTypeInitial, 1200, logging.PacketDropDOSPrevention)
+		connTracer.DroppedPacket(logging.PacketTypeHandshake, 1000, logging.PacketDropDuplicate)
+		connTracer.LostPacket(protocol.Encryption1RTT, 42, logging.PacketLossReorderingThreshold)
+		connTracer.DetectedSpuriousLoss(protocol.Encryption1RTT, 42)
+		Expect(getInt("packets_dropped")).To(Equal(dropped + 2))
+		Expect(getInt("packets_lost")).To(Equal(lost + 1))
+		Expect(getInt("spurious_losses")).To(Equal(spurious + 1))
+	})
+
+	It("counts completed streams", func() {
+		streams := getInt("completed_streams")
+		retransmitted := getInt("stream_bytes_retransmitted")
+		blocked := expvarStreamFlowControlBlocked.Value()
+		connTracer.CompletedStream(4, logging.StreamStats{BytesRetransmitted: 100, FlowControlBlocked: 1500 * time.Millisecond})
+		Expect(getInt("completed_streams")).To(Equal(streams + 1))
+		Expect(getInt("stream_bytes_retransmitted")).To(Equal(retransmitted + 100))
+		Expect(expvarStreamFlowControlBlocked.Value()).To(BeNumerically("~", blocked+1.5, 1e-9))
+	})
+
+	It("counts closes by reason", func() {
+		for _, tc := range []struct {
+			reason logging.CloseReason
+			key    string
+		}{
+			{reason: logging.NewTimeoutCloseReason(logging.TimeoutReasonIdle), key: "idle_timeout"},
+			{reason: logging.NewStatelessResetCloseReason(logging.StatelessResetToken{}), key: "stateless_reset"},
+			{reason: logging.NewApplicationCloseReason(0x1337, true), key: "application_error " + qerr.ErrorCode(0x1337).String()},
+			{reason: logging.NewTransportCloseReason(qerr.FlowControlError, false), key: "transport_error FLOW_CONTROL_ERROR"},
+			{reason: logging.CloseReason{}, key: "unknown"},
+		} {
+			before := getClose(tc.key)
+			connTracer.ClosedConnection(tc.reason)
+			Expect(getClose(tc.key)).To(Equal(before + 1))
+		}
+		Expect(expvarCloses.Get("")).To(BeNil())
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/utils"
@@ -18,13 +19,29 @@ import (
 
 // Measures
 var (
-	connections = stats.Int64("quic-go/connections", "number of QUIC connections", stats.UnitDimensionless)
-	lostPackets = stats.Int64("quic-go/lost-packets", "number of packets declared lost", stats.UnitDimensionless)
-	sentPackets = stats.Int64("quic-go/sent-packets", "number of packets sent", stats.UnitDimensionless)
-	ptos        = stats.Int64("quic-go/ptos", "number of times the PTO timer fired", stats.UnitDimensionless)
-	closes      = stats.Int64("quic-go/close", "number of connections closed", stats.UnitDimensionless)
+	connections       = stats.Int64("quic-go/connections", "number of QUIC connections", stats.UnitDimensionless)
+	activeConnections = stats.Int64("quic-go/active-connections", "number of active QUIC connections", stats.UnitDimensionless)
+	handshakes        = stats.Int64("quic-go/handshakes", "number of handshakes that completed or failed", stats.UnitDimensionless)
+	lostPackets       = stats.Int64("quic-go/lost-packets", "number of packets declared lost", stats.UnitDimensionless)
+	sentPackets       = stats.Int64("quic-go/sent-packets", "number of packets sent", stats.UnitDimensionless)
+	receivedPackets   = stats.Int64("quic-go/received-packets", "number of packets received", stats.UnitDimensionless)
+	droppedPackets    = stats.Int64("quic-go/dropped-packets", "number of packets dropped", stats.UnitDimensionless)
+	ptos              = stats.Int64("quic-go/ptos", "number of times the PTO timer fired", stats.UnitDimensionless)
+	closes            = stats.Int64("quic-go/close", "number of connections closed", stats.UnitDimensionless)
+	sentBytes         = stats.Int64("quic-go/sent-bytes", "number of bytes sent", stats.UnitBytes)
+	receivedBytes     = stats.Int64("quic-go/received-bytes", "number of bytes received", stats.UnitBytes)
+	smoothedRTT       = stats.Float64("quic-go/smoothed-rtt", "smoothed RTT at the end of a connection", stats.UnitMilliseconds)
+	congestionWindow  = stats.Int64("quic-go/congestion-window", "congestion window at the end of a connection", stats.UnitBytes)
+
+	// reported when a stream is completed
+	completedStreams         = stats.Int64("quic-go/completed-streams", "number of streams completed", stats.UnitDimensionless)
+	streamBytesRetransmitted = stats.Int64("quic-go/stream-bytes-retransmitted", "number of bytes of stream data retransmitted", stats.UnitBytes)
+	streamFlowControlBlocked = stats.Float64("quic-go/stream-flow-control-blocked", "time that sending on a stream was blocked by flow control", stats.UnitMilliseconds)
 )
 
+// the number of active connections, to be used as an atomic
+var numActiveConnections int64
+
 // Tags
 var (
 	keyPerspective, _      = tag.NewKey("perspective")
@@ -36,6 +53,8 @@ var (
 	keyCloseRemote, _      = tag.NewKey("close_remote")
 	keyErrorCode, _        = tag.NewKey("error_code")
 	keyHandshakePhase, _   = tag.NewKey("handshake_phase")
+	keyHandshakeResult, _  = tag.NewKey("handshake_result")
+	keyPacketDropReason, _ = tag.NewKey("packet_drop_reason")
 )
 
 // Views
@@ -45,6 +64,15 @@ var (
 		TagKeys:     []tag.Key{keyPerspective, keyIPVersion},
 		Aggregation: view.Count(),
 	}
+	ActiveConnectionsView = &view.View{
+		Measure:     activeConnections,
+		Aggregation: view.LastValue(),
+	}
+	HandshakesView = &view.View{
+		Measure:     handshakes,
+		TagKeys:     []tag.Key{keyPerspective, keyHandshakeResult},
+		Aggregation: view.Count(),
+	}
 	LostPacketsView = &view.View{
 		Measure:     lostPackets,
 		TagKeys:     []tag.Key{keyEncryptionLevel, keyPacketLossReason},
@@ -55,6 +83,16 @@ var (
 		TagKeys:     []tag.Key{keyPacketType},
 		Aggregation: view.Count(),
 	}
+	ReceivedPacketsView = &view.View{
+		Measure:     receivedPackets,
+		TagKeys:     []tag.Key{keyPacketType},
+		Aggregation: view.Count(),
+	}
+	DroppedPacketsView = &view.View{
+		Measure:     droppedPackets,
+		TagKeys:     []tag.Key{keyPacketType, keyPacketDropReason},
+		Aggregation: view.Count(),
+	}
 	PTOView = &view.View{
 		Measure:     ptos,
 		TagKeys:     []tag.Key{keyHandshakePhase},
@@ -65,14 +103,163 @@ var (
 		TagKeys:     []tag.Key{keyCloseReason, keyErrorCode},
 		Aggregation: view.Count(),
 	}
+	SentBytesView = &view.View{
+		Measure:     sentBytes,
+		TagKeys:     []tag.Key{keyPacketType},
+		Aggregation: view.Sum(),
+	}
+	ReceivedBytesView = &view.View{
+		Measure:     receivedBytes,
+		TagKeys:     []tag.Key{keyPacketType},
+		Aggregation: view.Sum(),
+	}
+	SmoothedRTTView = &view.View{
+		Measure:     smoothedRTT,
+		TagKeys:     []tag.Key{keyPerspective},
+		Aggregation: view.Distribution(1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000),
+	}
+	CongestionWindowView = &view.View{
+		Measure:     congestionWindow,
+		TagKeys:     []tag.Key{keyPerspective},
+		Aggregation: view.Distribution(1<<13, 1<<14, 1<<15, 1<<16, 1<<17, 1<<18, 1<<19, 1<<20, 1<<21, 1<<22, 1<<23),
+	}
+	CompletedStreamsView = &view.View{
+		Measure:     completedStreams,
+		TagKeys:     []tag.Key{keyPerspective},
+		Aggregation: view.Count(),
+	}
+	StreamBytesRetransmittedView = &view.View{
+		Measure:     streamBytesRetransmitted,
+		TagKeys:     []tag.Key{keyPerspective},
+		Aggregation: view.Sum(),
+	}
+	StreamFlowControlBlockedView = &view.View{
+		Measure:     streamFlowControlBlocked,
+		TagKeys:     []tag.Key{keyPerspective},
+		Aggregation: view.Distribution(1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000),
+	}
+)
+
+// RecordWithTags creates a new tag map on every call, which allocates.
+// The tagged contexts for the measurements that are recorded for every packet are therefore created once.
+var (
+	packetTypeContexts    map[logging.PacketType]context.Context
+	droppedPacketContexts map[droppedPacketKey]context.Context
+	lostPacketContexts    map[lostPacketKey]context.Context
+	ptoContexts           map[string]context.Context
 )
 
+type droppedPacketKey struct {
+	typ    logging.PacketType
+	reason logging.PacketDropReason
+}
+
+type lostPacketKey struct {
+	encLevel logging.EncryptionLevel
+	reason   logging.PacketLossReason
+}
+
+var (
+	packetTypes = []logging.PacketType{
+		logging.PacketTypeInitial,
+		logging.PacketTypeHandshake,
+		logging.PacketTypeRetry,
+		logging.PacketType0RTT,
+		logging.PacketTypeVersionNegotiation,
+		logging.PacketType1RTT,
+		logging.PacketTypeStatelessReset,
+		logging.PacketTypeNotDetermined,
+	}
+	encryptionLevels  = []logging.EncryptionLevel{logging.EncryptionInitial, logging.EncryptionHandshake, logging.Encryption0RTT, logging.Encryption1RTT}
+	packetLossReasons = []logging.PacketLossReason{logging.PacketLossReorderingThreshold, logging.PacketLossTimeThreshold}
+	handshakePhases   = []string{"during_handshake", "after_handshake"}
+)
+
+func init() {
+	packetTypeContexts = make(map[logging.PacketType]context.Context, len(packetTypes))
+	droppedPacketContexts = make(map[droppedPacketKey]context.Context)
+	for _, typ := range packetTypes {
+		packetTypeContexts[typ] = newPacketTypeContext(typ)
+		for reason := logging.PacketDropKeyUnavailable; reason <= logging.PacketDropDuplicate; reason++ {
+			droppedPacketContexts[droppedPacketKey{typ: typ, reason: reason}] = newDroppedPacketContext(typ, reason)
+		}
+	}
+	lostPacketContexts = make(map[lostPacketKey]context.Context)
+	for _, encLevel := range encryptionLevels {
+		for _, reason := range packetLossReasons {
+			lostPacketContexts[lostPacketKey{encLevel: encLevel, reason: reason}] = newLostPacketContext(encLevel, reason)
+		}
+	}
+	ptoContexts = make(map[string]context.Context, len(handshakePhases))
+	for _, phase := range handshakePhases {
+		ptoContexts[phase] = newTaggedContext(tag.Upsert(keyHandshakePhase, phase))
+	}
+}
+
+func newTaggedContext(mutators ...tag.Mutator) context.Context {
+	ctx, _ := tag.New(context.Background(), mutators...)
+	return ctx
+}
+
+func newPacketTypeContext(typ logging.PacketType) context.Context {
+	return newTaggedContext(tag.Upsert(keyPacketType, packetType(typ).String()))
+}
+
+func newDroppedPacketContext(typ logging.PacketType, reason logging.PacketDropReason) context.Context {
+	return newTaggedContext(
+		tag.Upsert(keyPacketType, packetType(typ).String()),
+		tag.Upsert(keyPacketDropReason, packetDropReason(reason).String()),
+	)
+}
+
+func newLostPacketContext(encLevel logging.EncryptionLevel, reason logging.PacketLossReason) context.Context {
+	return newTaggedContext(
+		tag.Upsert(keyEncryptionLevel, encryptionLevel(encLevel).String()),
+		tag.Upsert(keyPacketLossReason, packetLossReason(reason).String()),
+	)
+}
+
+// The following functions only create a new context for values that are not known to this package.
+
+func packetTypeContext(typ logging.PacketType) context.Context {
+	if ctx, ok := packetTypeContexts[typ]; ok {
+		return ctx
+	}
+	return newPacketTypeContext(typ)
+}
+
+func droppedPacketContext(typ logging.PacketType, reason logging.PacketDropReason) context.Context {
+	if ctx, ok := droppedPacketContexts[droppedPacketKey{typ: typ, reason: reason}]; ok {
+		return ctx
+	}
+	return newDroppedPacketContext(typ, reason)
+}
+
+func lostPacketContext(encLevel logging.EncryptionLevel, reason logging.PacketLossReason) context.Context {
+	if ctx, ok := lostPacketContexts[lostPacketKey{encLevel: encLevel, reason: reason}]; ok {
+		return ctx
+	}
+	return newLostPacketContext(encLevel, reason)
+}
+
 // DefaultViews collects all OpenCensus views for metric gathering purposes
 var DefaultViews = []*view.View{
 	ConnectionsView,
+	ActiveConnectionsView,
+	HandshakesView,
 	LostPacketsView,
 	SentPacketsView,
+	ReceivedPacketsView,
+	DroppedPacketsView,
+	PTOView,
 	CloseView,
+	SentBytesView,
+	ReceivedBytesView,
+	SmoothedRTTView,
+	CongestionWindowView,
+	CompletedStreamsView,
+	StreamBytesRetransmittedView,
+	StreamFlowControlBlockedView,
 }
 
 type tracer struct{}
@@ -86,24 +273,33 @@ func (t *tracer) TracerForConnection(p logging.Perspective, _ logging.Connection
 	return newConnTracer(t, p)
 }
 
-func (t *tracer) SentPacket(_ net.Addr, hdr *logging.Header, _ protocol.ByteCount, _ []logging.Frame) {
-	stats.RecordWithTags(
-		context.Background(),
-		[]tag.Mutator{
-			tag.Upsert(keyPacketType, packetType(logging.PacketTypeFromHeader(hdr)).String()),
-		},
+func (t *tracer) SentPacket(_ net.Addr, hdr *logging.Header, size protocol.ByteCount, _ []logging.Frame) {
+	stats.Record(
+		packetTypeContext(logging.PacketTypeFromHeader(hdr)),
 		sentPackets.M(1),
+		sentBytes.M(int64(size)),
 	)
 }
 
-func (t *tracer) DroppedPacket(net.Addr, logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
+func (t *tracer) DroppedPacket(_ net.Addr, typ logging.PacketType, _ logging.ByteCount, reason logging.PacketDropReason) {
+	recordDroppedPacket(typ, reason)
+}
+
+func recordDroppedPacket(typ logging.PacketType, reason logging.PacketDropReason) {
+	stats.Record(droppedPacketContext(typ, reason), droppedPackets.M(1))
 }
 
 type connTracer struct {
 	perspective logging.Perspective
 	tracer      logging.Tracer
 
-	handshakeComplete bool
+	handshakeComplete  bool
+	handshakeConfirmed bool
+	started            bool
+
+	// the most recent values reported by UpdatedMetrics
+	smoothedRTT time.Duration
+	cwnd        logging.ByteCount
 }
 
 func newConnTracer(tracer logging.Tracer, perspective logging.Perspective) logging.ConnectionTracer {
@@ -134,6 +330,22 @@ func (t *connTracer) StartedConnection(local, _ net.Addr, _ logging.VersionNumbe
 		[]tag.Mutator{perspectiveTag, ipVersionTag},
 		connections.M(1),
 	)
+	// StartedConnection is called again when the client recreates the session (after a Retry or Version Negotiation).
+	if !t.started {
+		t.started = true
+		stats.Record(context.Background(), activeConnections.M(atomic.AddInt64(&numActiveConnections, 1)))
+	}
+}
+
+func (t *connTracer) recordHandshake(result string) {
+	stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{
+			tag.Upsert(keyPerspective, perspective(t.perspective).String()),
+			tag.Upsert(keyHandshakeResult, result),
+		},
+		handshakes.M(1),
+	)
 }
 
 func (t *connTracer) ClosedConnection(r logging.CloseReason) {
@@ -162,41 +374,57 @@ func (t *connTracer) ClosedConnection(r logging.CloseReason) {
 		}
 	}
 	stats.RecordWithTags(context.Background(), tags, closes.M(1))
+	if !t.handshakeConfirmed {
+		t.recordHandshake("failed")
+	}
 }
 func (t *connTracer) SentTransportParameters(*logging.TransportParameters)     {}
 func (t *connTracer) ReceivedTransportParameters(*logging.TransportParameters) {}
-func (t *connTracer) SentPacket(hdr *logging.ExtendedHeader, _ logging.ByteCount, _ *logging.AckFrame, _ []logging.Frame) {
+func (t *connTracer) SentPacket(hdr *logging.ExtendedHeader, size logging.ByteCount, _ *logging.AckFrame, _ []logging.Frame) {
 	typ := logging.PacketTypeFromHeader(&hdr.Header)
 	if typ == logging.PacketType1RTT {
 		t.handshakeComplete = true
 	}
-	stats.RecordWithTags(
-		context.Background(),
-		[]tag.Mutator{
-			tag.Upsert(keyPacketType, packetType(typ).String()),
-		},
-		sentPackets.M(1),
-	)
+	stats.Record(packetTypeContext(typ), sentPackets.M(1), sentBytes.M(int64(size)))
 }
 func (t *connTracer) ReceivedVersionNegotiationPacket(*logging.Header, []logging.VersionNumber) {}
 func (t *connTracer) ReceivedRetry(*logging.Header)                                             {}
-func (t *connTracer) ReceivedPacket(*logging.ExtendedHeader, logging.ByteCount, []logging.Frame) {
+func (t *connTracer) ReceivedPacket(hdr *logging.ExtendedHeader, size logging.ByteCount, _ []logging.Frame) {
+	stats.Record(
+		packetTypeContext(logging.PacketTypeFromHeader(&hdr.Header)),
+		receivedPackets.M(1),
+		receivedBytes.M(int64(size)),
+	)
+}
+func (t *connTracer) BufferedPacket(logging.PacketType) {}
+func (t *connTracer) DroppedPacket(typ logging.PacketType, _ logging.ByteCount, reason logging.PacketDropReason) {
+	recordDroppedPacket(typ, reason)
+}
+func (t *connTracer) UpdatedCongestionState(logging.CongestionState) {}
+func (t *connTracer) UpdatedMetrics(rttStats *logging.RTTStats, cwnd, _ logging.ByteCount, _ int) {
+	t.smoothedRTT = rttStats.SmoothedRTT()
+	t.cwnd = cwnd
 }
-func (t *connTracer) BufferedPacket(logging.PacketType)                                             {}
-func (t *connTracer) DroppedPacket(logging.PacketType, logging.ByteCount, logging.PacketDropReason) {}
-func (t *connTracer) UpdatedCongestionState(logging.CongestionState)                                {}
-func (t *connTracer) UpdatedMetrics(*logging.RTTStats, logging.ByteCount, logging.ByteCount, int)   {}
 func (t *connTracer) LostPacket(encLevel logging.EncryptionLevel, _ logging.PacketNumber, reason logging.PacketLossReason) {
+	stats.Record(lostPacketContext(encLevel, reason), lostPackets.M(1))
+}
+
+func (t *connTracer) QueuedForRetransmission(logging.EncryptionLevel, logging.PacketNumber, []logging.Frame) {
+}
+func (t *connTracer) DetectedSpuriousLoss(logging.EncryptionLevel, logging.PacketNumber) {}
+
+func (t *connTracer) CompletedStream(_ logging.StreamID, s logging.StreamStats) {
 	stats.RecordWithTags(
 		context.Background(),
-		[]tag.Mutator{
-			tag.Upsert(keyEncryptionLevel, encryptionLevel(encLevel).String()),
-			tag.Upsert(keyPacketLossReason, packetLossReason(reason).String()),
-		},
-		lostPackets.M(1),
+		[]tag.Mutator{tag.Upsert(keyPerspective, perspective(t.perspective).String())},
+		completedStreams.M(1),
+		streamBytesRetransmitted.M(int64(s.BytesRetransmitted)),
+		streamFlowControlBlocked.M(float64(s.FlowControlBlocked)/float64(time.Millisecond)),
 	)
 }
 
+func (t *connTracer) SetCorrelationID(string) {}
+
 func (t *connTracer) UpdatedPTOCount(value uint32) {
 	if value == 0 {
 		return
@@ -205,17 +433,32 @@ func (t *connTracer) UpdatedPTOCount(value uint32) {
 	if t.handshakeComplete {
 		phase = "after_handshake"
 	}
-	stats.RecordWithTags(
-		context.Background(),
-		[]tag.Mutator{tag.Upsert(keyHandshakePhase, phase)},
-		ptos.M(1),
-	)
+	stats.Record(ptoContexts[phase], ptos.M(1))
+}
+func (t *connTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective) {}
+func (t *connTracer) UpdatedKey(logging.KeyPhase, bool)                              {}
+func (t *connTracer) DroppedEncryptionLevel(encLevel logging.EncryptionLevel) {
+	// The Handshake keys are dropped when the handshake is confirmed.
+	if encLevel == logging.EncryptionHandshake && !t.handshakeConfirmed {
+		t.handshakeConfirmed = true
+		t.recordHandshake("completed")
+	}
 }
-func (t *connTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective)     {}
-func (t *connTracer) UpdatedKey(logging.KeyPhase, bool)                                  {}
-func (t *connTracer) DroppedEncryptionLevel(logging.EncryptionLevel)                     {}
 func (t *connTracer) DroppedKey(logging.KeyPhase)                                        {}
 func (t *connTracer) SetLossTimer(logging.TimerType, logging.EncryptionLevel, time.Time) {}
 func (t *connTracer) LossTimerExpired(logging.TimerType, logging.EncryptionLevel)        {}
 func (t *connTracer) LossTimerCanceled()                                                 {}
-func (t *connTracer) Close()                                                             {}
+func (t *connTracer) Close() {
+	if t.started {
+		stats.Record(context.Background(), activeConnections.M(atomic.AddInt64(&numActiveConnections, -1)))
+	}
+	// UpdatedMetrics is only called once an RTT sample was obtained
+	if t.smoothedRTT > 0 {
+		stats.RecordWithTags(
+			context.Background(),
+			[]tag.Mutator{tag.Upsert(keyPerspective, perspective(t.perspective).String())},
+			smoothedRTT.M(float64(t.smoothedRTT)/float64(time.Millisecond)),
+			congestionWindow.M(int64(t.cwnd)),
+		)
+	}
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"net"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics Tracer", func() {
+	var (
+		tracer     logging.Tracer
+		connTracer logging.ConnectionTracer
+	)
+	remoteAddr := &net.UDPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 1234}
+
+	getRows := func(v *view.View) []*view.Row {
+		rows, err := view.RetrieveData(v.Measure.Name())
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		return rows
+	}
+
+	BeforeEach(func() {
+		Expect(view.Register(DefaultViews...)).To(Succeed())
+		tracer = NewTracer()
+		connTracer = tracer.TracerForConnection(logging.PerspectiveServer, logging.ConnectionID{1, 2, 3, 4})
+	})
+
+	AfterEach(func() {
+		view.Unregister(DefaultViews...)
+	})
+
+	It("records sent packets", func() {
+		hdr := &logging.Header{IsLongHeader: true, Type: protocol.PacketTypeInitial, Version: protocol.VersionTLS}
+		tracer.SentPacket(remoteAddr, hdr, 1200, nil)
+		connTracer.SentPacket(&logging.ExtendedHeader{Header: *hdr}, 1000, nil, nil)
+		rows := getRows(SentPacketsView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Tags).To(ConsistOf(tag.Tag{Key: keyPacketType, Value: "initial"}))
+		Expect(rows[0].Data.(*view.CountData).Value).To(BeEquivalentTo(2))
+		rows = getRows(SentBytesView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Tags).To(ConsistOf(tag.Tag{Key: keyPacketType, Value: "initial"}))
+		Expect(rows[0].Data.(*view.SumData).Value).To(BeEquivalentTo(2200))
+	})
+
+	It("records received packets", func() {
+		connTracer.ReceivedPacket(&logging.ExtendedHeader{}, 1000, nil)
+		connTracer.ReceivedPacket(&logging.ExtendedHeader{}, 500, nil)
+		rows := getRows(ReceivedPacketsView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Tags).To(ConsistOf(tag.Tag{Key: keyPacketType, Value: "1-RTT"}))
+		Expect(rows[0].Data.(*view.CountData).Value).To(BeEquivalentTo(2))
+		rows = getRows(ReceivedBytesView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Data.(*view.SumData).Value).To(BeEquivalentTo(1500))
+	})
+
+	It("records dropped packets", func() {
+		tracer.DroppedPacket(remoteAddr, logging.PacketTypeInitial, 1200, logging.PacketDropDOSPrevention)
+		connTracer.DroppedPacket(logging.PacketTypeHandshake, 1000, logging.PacketDropDuplicate)
+		rows := getRows(DroppedPacketsView)
+		Expect(rows).To(HaveLen(2))
+		var tags [][]tag.Tag
+		for _, row := range rows {
+			Expect(row.Data.(*view.CountData).Value).To(BeEquivalentTo(1))
+			tags = append(tags, row.Tags)
+		}
+		Expect(tags).To(ConsistOf(
+			ConsistOf(tag.Tag{Key: keyPacketType, Value: "initial"}, tag.Tag{Key: keyPacketDropReason, Value: "dos_prevention"}),
+			ConsistOf(tag.Tag{Key: keyPacketType, Value: "handshake"}, tag.Tag{Key: keyPacketDropReason, Value: "duplicate"}),
+		))
+	})
+
+	It("records dropped packets with a reason it doesn't know", func() {
+		connTracer.DroppedPacket(logging.PacketTypeHandshake, 1000, logging.PacketDropReason(200))
+		rows := getRows(DroppedPacketsView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Tags).To(ConsistOf(
+			tag.Tag{Key: keyPacketType, Value: "handshake"},
+			tag.Tag{Key: keyPacketDropReason, Value: "unknown packet drop reason"},
+		))
+	})
+
+	It("records lost packets", func() {
+		connTracer.LostPacket(logging.Encryption1RTT, 42, logging.PacketLossReorderingThreshold)
+		connTracer.LostPacket(logging.Encryption1RTT, 43, logging.PacketLossReorderingThreshold)
+		rows := getRows(LostPacketsView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Tags).To(ConsistOf(
+			tag.Tag{Key: keyEncryptionLevel, Value: "1-RTT"},
+			tag.Tag{Key: keyPacketLossReason, Value: "reordering_threshold"},
+		))
+		Expect(rows[0].Data.(*view.CountData).Value).To(BeEquivalentTo(2))
+	})
+
+	It("records PTOs", func() {
+		connTracer.UpdatedPTOCount(1)
+		connTracer.UpdatedPTOCount(0)
+		rows := getRows(PTOView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Tags).To(ConsistOf(tag.Tag{Key: keyHandshakePhase, Value: "during_handshake"}))
+		Expect(rows[0].Data.(*view.CountData).Value).To(BeEquivalentTo(1))
+	})
+
+	It("records closed connections", func() {
+		connTracer.ClosedConnection(logging.NewTimeoutCloseReason(logging.TimeoutReasonIdle))
+		rows := getRows(CloseView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Tags).To(ContainElement(tag.Tag{Key: keyCloseReason, Value: "idle_timeout"}))
+	})
+
+	It("records completed streams", func() {
+		connTracer.CompletedStream(4, logging.StreamStats{BytesRetransmitted: 1000, FlowControlBlocked: 20 * time.Millisecond})
+		connTracer.CompletedStream(8, logging.StreamStats{BytesRetransmitted: 337})
+		rows := getRows(CompletedStreamsView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Tags).To(ConsistOf(tag.Tag{Key: keyPerspective, Value: "server"}))
+		Expect(rows[0].Data.(*view.CountData).Value).To(BeEquivalentTo(2))
+		rows = getRows(StreamBytesRetransmittedView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Data.(*view.SumData).Value).To(BeEquivalentTo(1337))
+		rows = getRows(StreamFlowControlBlockedView)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Data.(*view.DistributionData).Count).To(BeEquivalentTo(2))
+		Expect(rows[0].Data.(*view.DistributionData).Max).To(BeNumerically("==", 20))
+	})
+
+	It("doesn't create new tagged contexts for every packet", func() {
+		Expect(packetTypeContext(logging.PacketType1RTT)).To(BeIdenticalTo(packetTypeContext(logging.PacketType1RTT)))
+		Expect(droppedPacketContext(logging.PacketTypeInitial, logging.PacketDropDuplicate)).To(BeIdenticalTo(droppedPacketContext(logging.PacketTypeInitial, logging.PacketDropDuplicate)))
+		Expect(lostPacketContext(logging.EncryptionHandshake, logging.PacketLossTimeThreshold)).To(BeIdenticalTo(lostPacketContext(logging.EncryptionHandshake, logging.PacketLossTimeThreshold)))
+	})
+})
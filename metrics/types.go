@@ -66,6 +66,37 @@ func (t packetType) String() string {
 	}
 }
 
+type packetDropReason logging.PacketDropReason
+
+func (r packetDropReason) String() string {
+	switch logging.PacketDropReason(r) {
+	case logging.PacketDropKeyUnavailable:
+		return "key_unavailable"
+	case logging.PacketDropUnknownConnectionID:
+		return "unknown_connection_id"
+	case logging.PacketDropHeaderParseError:
+		return "header_parse_error"
+	case logging.PacketDropPayloadDecryptError:
+		return "payload_decrypt_error"
+	case logging.PacketDropProtocolViolation:
+		return "protocol_violation"
+	case logging.PacketDropDOSPrevention:
+		return "dos_prevention"
+	case logging.PacketDropUnsupportedVersion:
+		return "unsupported_version"
+	case logging.PacketDropUnexpectedPacket:
+		return "unexpected_packet"
+	case logging.PacketDropUnexpectedSourceConnectionID:
+		return "unexpected_source_connection_id"
+	case logging.PacketDropUnexpectedVersion:
+		return "unexpected_version"
+	case logging.PacketDropDuplicate:
+		return "duplicate"
+	default:
+		return "unknown packet drop reason"
+	}
+}
+
 type timeoutReason logging.TimeoutReason
 
 func (r timeoutReason) String() string {
@@ -74,6 +105,8 @@ func (r timeoutReason) String() string {
 		return "handshake_timeout"
 	case logging.TimeoutReasonIdle:
 		return "idle_timeout"
+	case logging.TimeoutReasonLivenessProbe:
+		return "liveness_probe_timeout"
 	default:
 		return "unknown timeout reason"
 	}
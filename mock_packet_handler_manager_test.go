@@ -114,6 +114,20 @@ func (mr *MockPacketHandlerManagerMockRecorder) GetStatelessResetToken(arg0 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatelessResetToken", reflect.TypeOf((*MockPacketHandlerManager)(nil).GetStatelessResetToken), arg0)
 }
 
+// ReceiveBufferDrops mocks base method
+func (m *MockPacketHandlerManager) ReceiveBufferDrops() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReceiveBufferDrops")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// ReceiveBufferDrops indicates an expected call of ReceiveBufferDrops
+func (mr *MockPacketHandlerManagerMockRecorder) ReceiveBufferDrops() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceiveBufferDrops", reflect.TypeOf((*MockPacketHandlerManager)(nil).ReceiveBufferDrops))
+}
+
 // Remove mocks base method
 func (m *MockPacketHandlerManager) Remove(arg0 protocol.ConnectionID) {
 	m.ctrl.T.Helper()
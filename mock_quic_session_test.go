@@ -8,9 +8,11 @@ import (
 	context "context"
 	net "net"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
+	qerr "github.com/lucas-clemente/quic-go/internal/qerr"
 )
 
 // MockQuicSession is a mock of QuicSession interface
@@ -66,6 +68,20 @@ func (mr *MockQuicSessionMockRecorder) AcceptUniStream(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptUniStream", reflect.TypeOf((*MockQuicSession)(nil).AcceptUniStream), arg0)
 }
 
+// CloseError mocks base method
+func (m *MockQuicSession) CloseError() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseError")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseError indicates an expected call of CloseError
+func (mr *MockQuicSessionMockRecorder) CloseError() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseError", reflect.TypeOf((*MockQuicSession)(nil).CloseError))
+}
+
 // CloseWithError mocks base method
 func (m *MockQuicSession) CloseWithError(arg0 protocol.ApplicationErrorCode, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -210,6 +226,21 @@ func (mr *MockQuicSessionMockRecorder) OpenUniStreamSync(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenUniStreamSync", reflect.TypeOf((*MockQuicSession)(nil).OpenUniStreamSync), arg0)
 }
 
+// Ping mocks base method
+func (m *MockQuicSession) Ping(arg0 context.Context) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Ping indicates an expected call of Ping
+func (mr *MockQuicSessionMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockQuicSession)(nil).Ping), arg0)
+}
+
 // RemoteAddr mocks base method
 func (m *MockQuicSession) RemoteAddr() net.Addr {
 	m.ctrl.T.Helper()
@@ -224,6 +255,44 @@ func (mr *MockQuicSessionMockRecorder) RemoteAddr() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteAddr", reflect.TypeOf((*MockQuicSession)(nil).RemoteAddr))
 }
 
+// SetCorrelationID mocks base method
+func (m *MockQuicSession) SetCorrelationID(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetCorrelationID", arg0)
+}
+
+// SetCorrelationID indicates an expected call of SetCorrelationID
+func (mr *MockQuicSessionMockRecorder) SetCorrelationID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCorrelationID", reflect.TypeOf((*MockQuicSession)(nil).SetCorrelationID), arg0)
+}
+
+// Stats mocks base method
+func (m *MockQuicSession) Stats() ConnectionStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(ConnectionStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats
+func (mr *MockQuicSessionMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockQuicSession)(nil).Stats))
+}
+
+// closeWithTransportError mocks base method
+func (m *MockQuicSession) closeWithTransportError(arg0 qerr.ErrorCode, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "closeWithTransportError", arg0, arg1)
+}
+
+// closeWithTransportError indicates an expected call of closeWithTransportError
+func (mr *MockQuicSessionMockRecorder) closeWithTransportError(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "closeWithTransportError", reflect.TypeOf((*MockQuicSession)(nil).closeWithTransportError), arg0, arg1)
+}
+
 // destroy mocks base method
 func (m *MockQuicSession) destroy(arg0 error) {
 	m.ctrl.T.Helper()
@@ -276,6 +345,20 @@ func (mr *MockQuicSessionMockRecorder) handlePacket(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "handlePacket", reflect.TypeOf((*MockQuicSession)(nil).handlePacket), arg0)
 }
 
+// lastActivity mocks base method
+func (m *MockQuicSession) lastActivity() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "lastActivity")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// lastActivity indicates an expected call of lastActivity
+func (mr *MockQuicSessionMockRecorder) lastActivity() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "lastActivity", reflect.TypeOf((*MockQuicSession)(nil).lastActivity))
+}
+
 // run mocks base method
 func (m *MockQuicSession) run() error {
 	m.ctrl.T.Helper()
@@ -290,6 +373,18 @@ func (mr *MockQuicSessionMockRecorder) run() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "run", reflect.TypeOf((*MockQuicSession)(nil).run))
 }
 
+// setDebugLogging mocks base method
+func (m *MockQuicSession) setDebugLogging(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "setDebugLogging", arg0)
+}
+
+// setDebugLogging indicates an expected call of setDebugLogging
+func (mr *MockQuicSessionMockRecorder) setDebugLogging(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "setDebugLogging", reflect.TypeOf((*MockQuicSession)(nil).setDebugLogging), arg0)
+}
+
 // shutdown mocks base method
 func (m *MockQuicSession) shutdown() {
 	m.ctrl.T.Helper()
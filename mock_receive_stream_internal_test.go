@@ -11,6 +11,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 	wire "github.com/lucas-clemente/quic-go/internal/wire"
+	logging "github.com/lucas-clemente/quic-go/logging"
 )
 
 // MockReceiveStreamI is a mock of ReceiveStreamI interface
@@ -103,6 +104,20 @@ func (mr *MockReceiveStreamIMockRecorder) closeForShutdown(arg0 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "closeForShutdown", reflect.TypeOf((*MockReceiveStreamI)(nil).closeForShutdown), arg0)
 }
 
+// getStats mocks base method
+func (m *MockReceiveStreamI) getStats() logging.StreamStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "getStats")
+	ret0, _ := ret[0].(logging.StreamStats)
+	return ret0
+}
+
+// getStats indicates an expected call of getStats
+func (mr *MockReceiveStreamIMockRecorder) getStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getStats", reflect.TypeOf((*MockReceiveStreamI)(nil).getStats))
+}
+
 // getWindowUpdate mocks base method
 func (m *MockReceiveStreamI) getWindowUpdate() protocol.ByteCount {
 	m.ctrl.T.Helper()
@@ -144,3 +159,15 @@ func (mr *MockReceiveStreamIMockRecorder) handleStreamFrame(arg0 interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "handleStreamFrame", reflect.TypeOf((*MockReceiveStreamI)(nil).handleStreamFrame), arg0)
 }
+
+// setVersion mocks base method
+func (m *MockReceiveStreamI) setVersion(arg0 protocol.VersionNumber) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "setVersion", arg0)
+}
+
+// setVersion indicates an expected call of setVersion
+func (mr *MockReceiveStreamIMockRecorder) setVersion(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "setVersion", reflect.TypeOf((*MockReceiveStreamI)(nil).setVersion), arg0)
+}
@@ -13,6 +13,7 @@ import (
 	ackhandler "github.com/lucas-clemente/quic-go/internal/ackhandler"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 	wire "github.com/lucas-clemente/quic-go/internal/wire"
+	logging "github.com/lucas-clemente/quic-go/logging"
 )
 
 // MockSendStreamI is a mock of SendStreamI interface
@@ -133,6 +134,20 @@ func (mr *MockSendStreamIMockRecorder) closeForShutdown(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "closeForShutdown", reflect.TypeOf((*MockSendStreamI)(nil).closeForShutdown), arg0)
 }
 
+// getStats mocks base method
+func (m *MockSendStreamI) getStats() logging.StreamStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "getStats")
+	ret0, _ := ret[0].(logging.StreamStats)
+	return ret0
+}
+
+// getStats indicates an expected call of getStats
+func (mr *MockSendStreamIMockRecorder) getStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getStats", reflect.TypeOf((*MockSendStreamI)(nil).getStats))
+}
+
 // handleMaxStreamDataFrame mocks base method
 func (m *MockSendStreamI) handleMaxStreamDataFrame(arg0 *wire.MaxStreamDataFrame) {
 	m.ctrl.T.Helper()
@@ -185,3 +200,15 @@ func (mr *MockSendStreamIMockRecorder) popStreamFrame(arg0 interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "popStreamFrame", reflect.TypeOf((*MockSendStreamI)(nil).popStreamFrame), arg0)
 }
+
+// setVersion mocks base method
+func (m *MockSendStreamI) setVersion(arg0 protocol.VersionNumber) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "setVersion", arg0)
+}
+
+// setVersion indicates an expected call of setVersion
+func (mr *MockSendStreamIMockRecorder) setVersion(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "setVersion", reflect.TypeOf((*MockSendStreamI)(nil).setVersion), arg0)
+}
@@ -13,6 +13,7 @@ import (
 	ackhandler "github.com/lucas-clemente/quic-go/internal/ackhandler"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 	wire "github.com/lucas-clemente/quic-go/internal/wire"
+	logging "github.com/lucas-clemente/quic-go/logging"
 )
 
 // MockStreamI is a mock of StreamI interface
@@ -188,6 +189,20 @@ func (mr *MockStreamIMockRecorder) closeForShutdown(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "closeForShutdown", reflect.TypeOf((*MockStreamI)(nil).closeForShutdown), arg0)
 }
 
+// getStats mocks base method
+func (m *MockStreamI) getStats() logging.StreamStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "getStats")
+	ret0, _ := ret[0].(logging.StreamStats)
+	return ret0
+}
+
+// getStats indicates an expected call of getStats
+func (mr *MockStreamIMockRecorder) getStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getStats", reflect.TypeOf((*MockStreamI)(nil).getStats))
+}
+
 // getWindowUpdate mocks base method
 func (m *MockStreamI) getWindowUpdate() protocol.ByteCount {
 	m.ctrl.T.Helper()
@@ -282,3 +297,15 @@ func (mr *MockStreamIMockRecorder) popStreamFrame(arg0 interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "popStreamFrame", reflect.TypeOf((*MockStreamI)(nil).popStreamFrame), arg0)
 }
+
+// setVersion mocks base method
+func (m *MockStreamI) setVersion(arg0 protocol.VersionNumber) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "setVersion", arg0)
+}
+
+// setVersion indicates an expected call of setVersion
+func (mr *MockStreamIMockRecorder) setVersion(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "setVersion", reflect.TypeOf((*MockStreamI)(nil).setVersion), arg0)
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
 	"github.com/lucas-clemente/quic-go/logging"
@@ -25,6 +26,12 @@ func (e statelessResetErr) Error() string {
 	return fmt.Sprintf("received a stateless reset with token %x", e.token)
 }
 
+func (statelessResetErr) Is(target error) bool { return target == qerr.ErrStatelessReset }
+func (statelessResetErr) Temporary() bool      { return false }
+func (statelessResetErr) Timeout() bool        { return false }
+
+var _ net.Error = statelessResetErr{}
+
 // The packetHandlerMap stores packetHandlers, identified by connection ID.
 // It is used:
 // * by the server to store sessions
@@ -54,49 +61,72 @@ type packetHandlerMap struct {
 
 var _ packetHandlerManager = &packetHandlerMap{}
 
-func setReceiveBuffer(c net.PacketConn, logger utils.Logger) {
+func setReceiveBuffer(c net.PacketConn, desired int, logger utils.Logger) {
 	conn, ok := c.(interface{ SetReadBuffer(int) error })
 	if !ok {
 		logger.Debugf("Connection doesn't allow setting of receive buffer size")
 		return
 	}
-	size, err := inspectReadBuffer(c)
+	setSocketBuffer("receive", desired, func() (int, error) { return inspectReadBuffer(c) }, conn.SetReadBuffer, logger)
+}
+
+func setSendBuffer(c net.PacketConn, desired int, logger utils.Logger) {
+	conn, ok := c.(interface{ SetWriteBuffer(int) error })
+	if !ok {
+		logger.Debugf("Connection doesn't allow setting of send buffer size")
+		return
+	}
+	setSocketBuffer("send", desired, func() (int, error) { return inspectWriteBuffer(c) }, conn.SetWriteBuffer, logger)
+}
+
+// setSocketBuffer increases a kernel socket buffer to the desired size, and reports the size it was able to achieve.
+// The buffer is never decreased.
+// Failures are logged using the log package, independent of the log level:
+// a socket buffer that is too small leads to packet loss, so the user should know about it.
+func setSocketBuffer(name string, desired int, inspect func() (int, error), set func(int) error, logger utils.Logger) {
+	if desired <= 0 {
+		return
+	}
+	size, err := inspect()
 	if err != nil {
-		log.Printf("Failed to determine receive buffer size: %s", err)
+		log.Printf("Failed to determine %s buffer size: %s", name, err)
 		return
 	}
-	if size >= protocol.DesiredReceiveBufferSize {
-		logger.Debugf("Conn has receive buffer of %d kiB (wanted: at least %d kiB)", size/1024, protocol.DesiredReceiveBufferSize/1024)
+	if size >= desired {
+		logger.Debugf("Conn has %s buffer of %d kiB (wanted: at least %d kiB)", name, size/1024, desired/1024)
+		return
 	}
-	if err := conn.SetReadBuffer(protocol.DesiredReceiveBufferSize); err != nil {
-		log.Printf("Failed to increase receive buffer size: %s\n", err)
+	if err := set(desired); err != nil {
+		log.Printf("Failed to increase %s buffer size: %s", name, err)
 		return
 	}
-	newSize, err := inspectReadBuffer(c)
+	newSize, err := inspect()
 	if err != nil {
-		log.Printf("Failed to determine receive buffer size: %s", err)
+		log.Printf("Failed to determine %s buffer size: %s", name, err)
 		return
 	}
 	if newSize == size {
-		log.Printf("Failed to determine receive buffer size: %s", err)
+		log.Printf("Failed to increase %s buffer size. Was: %d kiB, wanted: %d kiB.", name, size/1024, desired/1024)
 		return
 	}
-	if newSize < protocol.DesiredReceiveBufferSize {
-		log.Printf("Failed to sufficiently increase receive buffer size. Was: %d kiB, wanted: %d kiB, got: %d kiB.", size/1024, protocol.DesiredReceiveBufferSize/1024, newSize/1024)
+	if newSize < desired {
+		log.Printf("Failed to sufficiently increase %s buffer size. Was: %d kiB, wanted: %d kiB, got: %d kiB.", name, size/1024, desired/1024, newSize/1024)
 		return
 	}
-	logger.Debugf("Increased receive buffer size to %d kiB", newSize/1024)
+	logger.Debugf("Increased %s buffer size to %d kiB", name, newSize/1024)
 }
 
 func newPacketHandlerMap(
 	c net.PacketConn,
 	connIDLen int,
 	statelessResetKey []byte,
+	receiveBufferSize, sendBufferSize int,
 	tracer logging.Tracer,
 	logger utils.Logger,
 ) (packetHandlerManager, error) {
-	setReceiveBuffer(c, logger)
-	conn, err := wrapConn(c)
+	setReceiveBuffer(c, receiveBufferSize, logger)
+	setSendBuffer(c, sendBufferSize, logger)
+	conn, err := wrapConn(c, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -236,6 +266,15 @@ func (h *packetHandlerMap) SetServer(s unknownPacketHandler) {
 	h.mutex.Unlock()
 }
 
+// ReceiveBufferDrops returns the number of packets that the kernel dropped because the receive buffer was full.
+// It returns 0 if the connection doesn't support detecting these drops.
+func (h *packetHandlerMap) ReceiveBufferDrops() uint64 {
+	if c, ok := h.conn.(receiveBufferDropCounter); ok {
+		return c.receiveBufferDrops()
+	}
+	return 0
+}
+
 func (h *packetHandlerMap) CloseServer() {
 	h.mutex.Lock()
 	if h.server == nil {
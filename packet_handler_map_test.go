@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"crypto/rand"
 	"errors"
+	"log"
 	"net"
+	"os"
 	"time"
 
 	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
@@ -72,11 +74,30 @@ var _ = Describe("Packet Handler Map", func() {
 			}
 			return copy(b, p.data), p.addr, p.err
 		}).AnyTimes()
-		phm, err := newPacketHandlerMap(conn, connIDLen, statelessResetKey, tracer, utils.DefaultLogger)
+		phm, err := newPacketHandlerMap(conn, connIDLen, statelessResetKey, 0, 0, tracer, utils.DefaultLogger)
 		Expect(err).ToNot(HaveOccurred())
 		handler = phm.(*packetHandlerMap)
 	})
 
+	It("warns if the socket buffer can't be increased, independent of the log level", func() {
+		buf := &bytes.Buffer{}
+		log.SetOutput(buf)
+		defer log.SetOutput(os.Stdout)
+		setSocketBuffer("receive", 1<<20, func() (int, error) { return 1 << 10, nil }, func(int) error { return nil }, utils.DefaultLogger)
+		Expect(buf.String()).To(ContainSubstring("Failed to increase receive buffer size. Was: 1 kiB, wanted: 1024 kiB."))
+		buf.Reset()
+		setSocketBuffer("send", 1<<20, func() (int, error) { return 1 << 10, nil }, func(int) error { return errors.New("permission denied") }, utils.DefaultLogger)
+		Expect(buf.String()).To(ContainSubstring("Failed to increase send buffer size: permission denied"))
+	})
+
+	It("doesn't warn if the socket buffer is large enough", func() {
+		buf := &bytes.Buffer{}
+		log.SetOutput(buf)
+		defer log.SetOutput(os.Stdout)
+		setSocketBuffer("receive", 1<<20, func() (int, error) { return 1 << 21, nil }, func(int) error { panic("shouldn't be called") }, utils.DefaultLogger)
+		Expect(buf.Len()).To(BeZero())
+	})
+
 	It("closes", func() {
 		getMultiplexer() // make the sync.Once execute
 		// replace the clientMuxer. getClientMultiplexer will now return the MockMultiplexer
@@ -295,6 +316,9 @@ var _ = Describe("Packet Handler Map", func() {
 						Expect(errors.As(err, &resetErr)).To(BeTrue())
 						Expect(err.Error()).To(ContainSubstring("received a stateless reset"))
 						Expect(resetErr.token).To(Equal(token))
+						Expect(errors.Is(err, ErrStatelessReset)).To(BeTrue())
+						Expect(resetErr.Timeout()).To(BeFalse())
+						Expect(resetErr.Temporary()).To(BeFalse())
 					})
 					packetChan <- packetToRead{data: packet}
 					Eventually(destroyed).Should(BeClosed())
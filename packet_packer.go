@@ -25,6 +25,7 @@ type packer interface {
 
 	HandleTransportParameters(*wire.TransportParameters)
 	SetToken([]byte)
+	SetVersion(protocol.VersionNumber)
 }
 
 type sealer interface {
@@ -144,6 +145,7 @@ type ackFrameSource interface {
 type packetPacker struct {
 	srcConnID     protocol.ConnectionID
 	getDestConnID func() protocol.ConnectionID
+	getSpinBit    func() bool
 
 	perspective protocol.Perspective
 	version     protocol.VersionNumber
@@ -159,8 +161,15 @@ type packetPacker struct {
 	acks                ackFrameSource
 	retransmissionQueue *retransmissionQueue
 
+	// called with the plaintext of every packet, before it is encrypted
+	capturePacket func(*wire.ExtendedHeader, protocol.EncryptionLevel, []byte)
+
 	maxPacketSize          protocol.ByteCount
 	numNonAckElicitingAcks int
+
+	// Used to write packets into the packet buffer.
+	// It is reused to avoid allocating a new bytes.Buffer for every packet.
+	buf bytes.Buffer
 }
 
 var _ packer = &packetPacker{}
@@ -168,6 +177,7 @@ var _ packer = &packetPacker{}
 func newPacketPacker(
 	srcConnID protocol.ConnectionID,
 	getDestConnID func() protocol.ConnectionID,
+	getSpinBit func() bool,
 	initialStream cryptoStream,
 	handshakeStream cryptoStream,
 	packetNumberManager packetNumberManager,
@@ -176,12 +186,14 @@ func newPacketPacker(
 	cryptoSetup sealingManager,
 	framer frameSource,
 	acks ackFrameSource,
+	capturePacket func(*wire.ExtendedHeader, protocol.EncryptionLevel, []byte),
 	perspective protocol.Perspective,
 	version protocol.VersionNumber,
 ) *packetPacker {
 	return &packetPacker{
 		cryptoSetup:         cryptoSetup,
 		getDestConnID:       getDestConnID,
+		getSpinBit:          getSpinBit,
 		srcConnID:           srcConnID,
 		initialStream:       initialStream,
 		handshakeStream:     handshakeStream,
@@ -190,6 +202,7 @@ func newPacketPacker(
 		version:             version,
 		framer:              framer,
 		acks:                acks,
+		capturePacket:       capturePacket,
 		pnManager:           packetNumberManager,
 		maxPacketSize:       getMaxPacketSize(remoteAddr),
 	}
@@ -635,6 +648,7 @@ func (p *packetPacker) getShortHeader(kp protocol.KeyPhaseBit) *wire.ExtendedHea
 	hdr.PacketNumberLen = pnLen
 	hdr.DestConnectionID = p.getDestConnID()
 	hdr.KeyPhase = kp
+	hdr.SpinBit = p.getSpinBit()
 	return hdr
 }
 
@@ -702,7 +716,8 @@ func (p *packetPacker) appendPacket(
 	}
 
 	hdrOffset := buffer.Len()
-	buf := bytes.NewBuffer(buffer.Data)
+	buf := &p.buf
+	*buf = *bytes.NewBuffer(buffer.Data)
 	if err := header.Write(buf, p.version); err != nil {
 		return nil, err
 	}
@@ -713,8 +728,8 @@ func (p *packetPacker) appendPacket(
 			return nil, err
 		}
 	}
-	if paddingLen > 0 {
-		buf.Write(bytes.Repeat([]byte{0}, int(paddingLen)))
+	for i := protocol.ByteCount(0); i < paddingLen; i++ {
+		buf.WriteByte(0)
 	}
 	for _, frame := range payload.frames {
 		if err := frame.Write(buf, p.version); err != nil {
@@ -730,8 +745,11 @@ func (p *packetPacker) appendPacket(
 	}
 
 	raw := buffer.Data
-	// encrypt the packet
 	raw = raw[:buf.Len()]
+	if p.capturePacket != nil {
+		p.capturePacket(header, encLevel, raw[payloadOffset:])
+	}
+	// encrypt the packet
 	_ = sealer.Seal(raw[payloadOffset:payloadOffset], raw[payloadOffset:], header.PacketNumber, raw[hdrOffset:payloadOffset])
 	raw = raw[0 : buf.Len()+sealer.Overhead()]
 	// apply header protection
@@ -755,6 +773,12 @@ func (p *packetPacker) SetToken(token []byte) {
 	p.token = token
 }
 
+// SetVersion sets the version used in long header packets, and to calculate frame lengths.
+// It is called when the connection is upgraded to a compatible version during the handshake.
+func (p *packetPacker) SetVersion(v protocol.VersionNumber) {
+	p.version = v
+}
+
 func (p *packetPacker) HandleTransportParameters(params *wire.TransportParameters) {
 	if params.MaxUDPPayloadSize != 0 {
 		p.maxPacketSize = utils.MinByteCount(p.maxPacketSize, params.MaxUDPPayloadSize)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"testing"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/qerr"
@@ -23,6 +24,17 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// fixedPacketNumberManager always returns the same packet number.
+type fixedPacketNumberManager protocol.PacketNumber
+
+func (m fixedPacketNumberManager) PeekPacketNumber(protocol.EncryptionLevel) (protocol.PacketNumber, protocol.PacketNumberLen) {
+	return protocol.PacketNumber(m), protocol.PacketNumberLen2
+}
+
+func (m fixedPacketNumberManager) PopPacketNumber(protocol.EncryptionLevel) protocol.PacketNumber {
+	return protocol.PacketNumber(m)
+}
+
 var _ = Describe("Packet packer", func() {
 	const maxPacketSize protocol.ByteCount = 1357
 	const version = protocol.VersionTLS
@@ -82,6 +94,7 @@ var _ = Describe("Packet packer", func() {
 		packer = newPacketPacker(
 			protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8},
 			func() protocol.ConnectionID { return protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8} },
+			func() bool { return false },
 			initialStream,
 			handshakeStream,
 			pnManager,
@@ -90,6 +103,7 @@ var _ = Describe("Packet packer", func() {
 			sealingManager,
 			framer,
 			ackFramer,
+			nil,
 			protocol.PerspectiveServer,
 			version,
 		)
@@ -124,6 +138,13 @@ var _ = Describe("Packet packer", func() {
 			Expect(h.Version).To(Equal(packer.version))
 		})
 
+		It("uses the version set when upgrading to a compatible version", func() {
+			packer.SetVersion(protocol.VersionDraft32)
+			pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+			h := packer.getLongHeader(protocol.EncryptionHandshake)
+			Expect(h.Version).To(Equal(protocol.VersionDraft32))
+		})
+
 		It("sets source and destination connection ID", func() {
 			pnManager.EXPECT().PeekPacketNumber(protocol.EncryptionHandshake).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
 			srcConnID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}
@@ -142,6 +163,14 @@ var _ = Describe("Packet packer", func() {
 			Expect(h.PacketNumber).To(Equal(protocol.PacketNumber(0x1337)))
 			Expect(h.PacketNumberLen).To(Equal(protocol.PacketNumberLen4))
 			Expect(h.KeyPhase).To(Equal(protocol.KeyPhaseOne))
+			Expect(h.SpinBit).To(BeFalse())
+		})
+
+		It("sets the spin bit on the short header", func() {
+			packer.getSpinBit = func() bool { return true }
+			pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x1337), protocol.PacketNumberLen4)
+			h := packer.getShortHeader(protocol.KeyPhaseZero)
+			Expect(h.SpinBit).To(BeTrue())
 		})
 	})
 
@@ -192,6 +221,27 @@ var _ = Describe("Packet packer", func() {
 			Expect(p.buffer.Data[0:len(hdrRaw)]).To(Equal(hdrRawEncrypted))
 			Expect(p.buffer.Data[p.buffer.Len()-4:]).To(Equal([]byte{0xde, 0xca, 0xfb, 0xad}))
 		})
+
+		It("only allocates the packet contents when writing and encrypting a packet", func() {
+			// Use a real sealer and packet number manager, since gomock allocates on every call.
+			sealer, _ := handshake.NewInitialAEAD(protocol.ConnectionID{1, 2, 3, 4}, protocol.PerspectiveClient)
+			packer.pnManager = fixedPacketNumberManager(0x1337)
+			hdr := &wire.ExtendedHeader{
+				Header:          wire.Header{DestConnectionID: protocol.ConnectionID{1, 2, 3, 4}},
+				PacketNumber:    0x1337,
+				PacketNumberLen: protocol.PacketNumberLen2,
+			}
+			frames := []ackhandler.Frame{{Frame: &wire.PingFrame{}}}
+			buffer := getPacketBuffer()
+			allocs := testing.AllocsPerRun(100, func() {
+				buffer.Data = buffer.Data[:0]
+				_, err := packer.appendPacket(buffer, hdr, payload{frames: frames, length: 1}, protocol.Encryption1RTT, sealer)
+				if err != nil {
+					Fail(err.Error())
+				}
+			})
+			Expect(allocs).To(BeNumerically("<=", 1))
+		})
 	})
 
 	Context("packing packets", func() {
@@ -475,6 +525,31 @@ var _ = Describe("Packet packer", func() {
 				Expect(p.buffer.Data).To(ContainSubstring(b.String()))
 			})
 
+			It("passes the plaintext to the packet capture callback", func() {
+				var captured []byte
+				packer.capturePacket = func(hdr *wire.ExtendedHeader, encLevel protocol.EncryptionLevel, payload []byte) {
+					Expect(hdr.PacketNumber).To(Equal(protocol.PacketNumber(0x42)))
+					Expect(encLevel).To(Equal(protocol.Encryption1RTT))
+					captured = append([]byte{}, payload...)
+				}
+				pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
+				sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+				framer.EXPECT().HasData().Return(true)
+				ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT, false)
+				expectAppendControlFrames()
+				f := &wire.StreamFrame{
+					StreamID: 5,
+					Data:     []byte{0xde, 0xca, 0xfb, 0xad},
+				}
+				expectAppendStreamFrames(ackhandler.Frame{Frame: f})
+				_, err := packer.PackPacket()
+				Expect(err).ToNot(HaveOccurred())
+				b := &bytes.Buffer{}
+				f.Write(b, packer.version)
+				Expect(captured).To(Equal(b.Bytes()))
+			})
+
 			It("stores the encryption level a packet was sealed with", func() {
 				pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
 				pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
@@ -47,6 +47,9 @@ type packetUnpacker struct {
 	largestRcvdPacketNumber protocol.PacketNumber
 
 	version protocol.VersionNumber
+
+	// reused for parsing the header of every packet
+	reader bytes.Reader
 }
 
 var _ unpacker = &packetUnpacker{}
@@ -58,6 +61,11 @@ func newPacketUnpacker(cs handshake.CryptoSetup, version protocol.VersionNumber)
 	}
 }
 
+// SetVersion sets the version used to parse the packet header and the frames.
+func (u *packetUnpacker) SetVersion(v protocol.VersionNumber) {
+	u.version = v
+}
+
 // If the reserved bits are invalid, the error is wire.ErrInvalidReservedBits.
 // If any other error occurred when parsing the header, the error is of type headerParseError.
 // If decrypting the payload fails for any reason, the error is the error returned by the AEAD.
@@ -168,7 +176,8 @@ func (u *packetUnpacker) unpackShortHeaderPacket(
 
 // The error is either nil, a wire.ErrInvalidReservedBits or of type headerParseError.
 func (u *packetUnpacker) unpackHeader(hd headerDecryptor, hdr *wire.Header, data []byte) (*wire.ExtendedHeader, error) {
-	extHdr, err := unpackHeader(hd, hdr, data, u.version)
+	u.reader.Reset(data)
+	extHdr, err := unpackHeader(hd, hdr, data, &u.reader, u.version)
 	if err != nil && err != wire.ErrInvalidReservedBits {
 		return nil, &headerParseError{err: err}
 	}
@@ -180,9 +189,8 @@ func (u *packetUnpacker) unpackHeader(hd headerDecryptor, hdr *wire.Header, data
 	return extHdr, err
 }
 
-func unpackHeader(hd headerDecryptor, hdr *wire.Header, data []byte, version protocol.VersionNumber) (*wire.ExtendedHeader, error) {
-	r := bytes.NewReader(data)
-
+// The reader r must read from the beginning of data.
+func unpackHeader(hd headerDecryptor, hdr *wire.Header, data []byte, r *bytes.Reader, version protocol.VersionNumber) (*wire.ExtendedHeader, error) {
 	hdrLen := hdr.ParsedLen()
 	if protocol.ByteCount(len(data)) < hdrLen+4+16 {
 		//nolint:stylecheck
@@ -0,0 +1,131 @@
+package quic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// The pinger sends PING frames to check that the peer is still alive.
+// It implements Session.Ping, as well as the automatic liveness probes (see Config.LivenessProbeInterval).
+type pinger struct {
+	queueFrame      func(ackhandler.Frame)
+	scheduleSending func()
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	closeErr  error
+
+	// The following fields are only accessed from the session's run loop.
+	probeInterval       time.Duration
+	maxUnansweredProbes int
+	unansweredProbes    int
+	nextProbeTime       time.Time
+}
+
+func newPinger(
+	queueFrame func(ackhandler.Frame),
+	scheduleSending func(),
+	probeInterval time.Duration,
+	maxUnansweredProbes int,
+) *pinger {
+	return &pinger{
+		queueFrame:          queueFrame,
+		scheduleSending:     scheduleSending,
+		closed:              make(chan struct{}),
+		probeInterval:       probeInterval,
+		maxUnansweredProbes: maxUnansweredProbes,
+	}
+}
+
+// Ping sends a PING frame and waits until it is acknowledged.
+// It can be called from any goroutine.
+func (p *pinger) Ping(ctx context.Context) (time.Duration, error) {
+	select {
+	case <-p.closed:
+		return 0, p.closeErr
+	default:
+	}
+
+	acked := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+
+	// OnAcked and OnLost are called from the session's run loop.
+	var isAcked bool
+	frame := ackhandler.Frame{Frame: &wire.PingFrame{}}
+	frame.OnAcked = func(wire.Frame) {
+		if !isAcked {
+			isAcked = true
+			close(acked)
+		}
+	}
+	frame.OnLost = func(wire.Frame) {
+		// Retransmit the PING, unless Ping already returned.
+		select {
+		case <-done:
+		default:
+			p.queueFrame(frame)
+		}
+	}
+	start := time.Now()
+	p.queueFrame(frame)
+	p.scheduleSending()
+
+	select {
+	case <-acked:
+		return time.Since(start), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-p.closed:
+		return 0, p.closeErr
+	}
+}
+
+// Start starts sending liveness probes, if enabled.
+// It is called when the handshake completes.
+func (p *pinger) Start(now time.Time) {
+	if p.probeInterval > 0 {
+		p.nextProbeTime = now.Add(p.probeInterval)
+	}
+}
+
+// NextProbeTime returns the time when the next liveness probe is due.
+// It returns a zero time if liveness probes are disabled, or not started yet.
+func (p *pinger) NextProbeTime() time.Time {
+	return p.nextProbeTime
+}
+
+// MaybeSendProbe sends a liveness probe, if one is due.
+// A probe is considered unanswered if it wasn't acknowledged by the time the next probe is due.
+// It returns an error if the peer didn't respond to maxUnansweredProbes probes in a row.
+func (p *pinger) MaybeSendProbe(now time.Time) error {
+	if p.nextProbeTime.IsZero() || now.Before(p.nextProbeTime) {
+		return nil
+	}
+	if p.unansweredProbes >= p.maxUnansweredProbes {
+		return qerr.NewIdleTimeoutError(fmt.Sprintf("No response to %d liveness probes", p.unansweredProbes))
+	}
+	p.unansweredProbes++
+	p.nextProbeTime = now.Add(p.probeInterval)
+	p.queueFrame(ackhandler.Frame{
+		Frame:   &wire.PingFrame{},
+		OnAcked: func(wire.Frame) { p.unansweredProbes = 0 },
+		// Lost probes are not retransmitted. The next probe will be sent soon enough.
+		OnLost: func(wire.Frame) {},
+	})
+	return nil
+}
+
+// Close unblocks all pending calls to Ping.
+func (p *pinger) Close(e error) {
+	p.closeOnce.Do(func() {
+		p.closeErr = e
+		close(p.closed)
+	})
+}
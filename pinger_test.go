@@ -0,0 +1,163 @@
+package quic
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pinger", func() {
+	var (
+		p                *pinger
+		queuedFrames     chan ackhandler.Frame
+		sendingScheduled chan struct{}
+	)
+
+	BeforeEach(func() {
+		queuedFrames = make(chan ackhandler.Frame, 10)
+		sendingScheduled = make(chan struct{}, 10)
+		p = newPinger(
+			func(f ackhandler.Frame) { queuedFrames <- f },
+			func() { sendingScheduled <- struct{}{} },
+			time.Second,
+			3,
+		)
+	})
+
+	Context("pinging", func() {
+		It("returns when the PING is acknowledged", func() {
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				rtt, err := p.Ping(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rtt).To(BeNumerically(">=", 10*time.Millisecond))
+			}()
+			var f ackhandler.Frame
+			Eventually(queuedFrames).Should(Receive(&f))
+			Expect(f.Frame).To(Equal(&wire.PingFrame{}))
+			Expect(sendingScheduled).To(Receive())
+			time.Sleep(10 * time.Millisecond)
+			Consistently(done).ShouldNot(BeClosed())
+			f.OnAcked(f.Frame)
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("retransmits lost PINGs", func() {
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				_, err := p.Ping(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+			}()
+			var f ackhandler.Frame
+			Eventually(queuedFrames).Should(Receive(&f))
+			f.OnLost(f.Frame)
+			var retransmission ackhandler.Frame
+			Expect(queuedFrames).To(Receive(&retransmission))
+			Expect(retransmission.Frame).To(Equal(&wire.PingFrame{}))
+			Consistently(done).ShouldNot(BeClosed())
+			retransmission.OnAcked(retransmission.Frame)
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("returns when the context is canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				_, err := p.Ping(ctx)
+				Expect(err).To(MatchError(context.Canceled))
+			}()
+			var f ackhandler.Frame
+			Eventually(queuedFrames).Should(Receive(&f))
+			cancel()
+			Eventually(done).Should(BeClosed())
+			// lost PINGs are not retransmitted after Ping returned
+			f.OnLost(f.Frame)
+			Expect(queuedFrames).ToNot(Receive())
+		})
+
+		It("returns when the session is closed", func() {
+			testErr := errors.New("test error")
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				_, err := p.Ping(context.Background())
+				Expect(err).To(MatchError(testErr))
+			}()
+			Eventually(queuedFrames).Should(Receive())
+			p.Close(testErr)
+			Eventually(done).Should(BeClosed())
+			_, err := p.Ping(context.Background())
+			Expect(err).To(MatchError(testErr))
+		})
+	})
+
+	Context("liveness probes", func() {
+		It("doesn't send probes before it is started", func() {
+			Expect(p.NextProbeTime()).To(BeZero())
+			Expect(p.MaybeSendProbe(time.Now().Add(time.Hour))).To(Succeed())
+			Expect(queuedFrames).To(BeEmpty())
+		})
+
+		It("doesn't send probes if disabled", func() {
+			p = newPinger(func(ackhandler.Frame) { Fail("unexpected frame") }, func() {}, 0, 3)
+			p.Start(time.Now())
+			Expect(p.NextProbeTime()).To(BeZero())
+			Expect(p.MaybeSendProbe(time.Now().Add(time.Hour))).To(Succeed())
+		})
+
+		It("sends probes at the configured interval", func() {
+			now := time.Now()
+			p.Start(now)
+			Expect(p.NextProbeTime()).To(Equal(now.Add(time.Second)))
+			Expect(p.MaybeSendProbe(now.Add(time.Second / 2))).To(Succeed())
+			Expect(queuedFrames).To(BeEmpty())
+			Expect(p.MaybeSendProbe(now.Add(time.Second))).To(Succeed())
+			var f ackhandler.Frame
+			Expect(queuedFrames).To(Receive(&f))
+			Expect(f.Frame).To(Equal(&wire.PingFrame{}))
+			Expect(p.NextProbeTime()).To(Equal(now.Add(2 * time.Second)))
+		})
+
+		It("closes the session after too many unanswered probes", func() {
+			now := time.Now()
+			p.Start(now)
+			for i := 1; i <= 3; i++ {
+				Expect(p.MaybeSendProbe(now.Add(time.Duration(i) * time.Second))).To(Succeed())
+				Expect(queuedFrames).To(Receive())
+			}
+			err := p.MaybeSendProbe(now.Add(4 * time.Second))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No response to 3 liveness probes"))
+			nerr, ok := err.(net.Error)
+			Expect(ok).To(BeTrue())
+			Expect(nerr.Timeout()).To(BeTrue())
+		})
+
+		It("resets the counter when a probe is acknowledged", func() {
+			now := time.Now()
+			p.Start(now)
+			var f ackhandler.Frame
+			for i := 1; i <= 3; i++ {
+				Expect(p.MaybeSendProbe(now.Add(time.Duration(i) * time.Second))).To(Succeed())
+				Expect(queuedFrames).To(Receive(&f))
+			}
+			f.OnAcked(f.Frame)
+			Expect(p.MaybeSendProbe(now.Add(4 * time.Second))).To(Succeed())
+			Expect(queuedFrames).To(Receive())
+		})
+	})
+})
@@ -189,6 +189,37 @@ func (e eventStatelessResetReceived) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.StringKey("stateless_reset_token", fmt.Sprintf("%x", e.Token))
 }
 
+type eventStreamCompleted struct {
+	StreamID protocol.StreamID
+	Stats    logging.StreamStats
+}
+
+func (e eventStreamCompleted) Category() category { return categoryTransport }
+func (e eventStreamCompleted) Name() string       { return "stream_completed" }
+func (e eventStreamCompleted) IsNil() bool        { return false }
+
+func (e eventStreamCompleted) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Int64Key("stream_id", int64(e.StreamID))
+	enc.Int64Key("bytes_sent", int64(e.Stats.BytesSent))
+	enc.Int64Key("bytes_retransmitted", int64(e.Stats.BytesRetransmitted))
+	enc.Int64Key("bytes_received", int64(e.Stats.BytesReceived))
+	enc.Uint64Key("frames_sent", e.Stats.FramesSent)
+	enc.Uint64Key("frames_received", e.Stats.FramesReceived)
+	enc.FloatKey("flow_control_blocked", milliseconds(e.Stats.FlowControlBlocked))
+}
+
+type eventCorrelationIDSet struct {
+	CorrelationID string
+}
+
+func (e eventCorrelationIDSet) Category() category { return categoryTransport }
+func (e eventCorrelationIDSet) Name() string       { return "correlation_id_set" }
+func (e eventCorrelationIDSet) IsNil() bool        { return false }
+
+func (e eventCorrelationIDSet) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("correlation_id", e.CorrelationID)
+}
+
 type eventPacketBuffered struct {
 	PacketType packetType
 }
@@ -291,6 +322,32 @@ func (e eventPacketLost) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.StringKey("trigger", e.Trigger.String())
 }
 
+type eventSpuriousLossDetected struct {
+	PacketType   packetType
+	PacketNumber protocol.PacketNumber
+}
+
+func (e eventSpuriousLossDetected) Category() category { return categoryRecovery }
+func (e eventSpuriousLossDetected) Name() string       { return "spurious_loss_detected" }
+func (e eventSpuriousLossDetected) IsNil() bool        { return false }
+
+func (e eventSpuriousLossDetected) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("packet_type", e.PacketType.String())
+	enc.Int64Key("packet_number", int64(e.PacketNumber))
+}
+
+type eventMarkedForRetransmit struct {
+	Frames frames
+}
+
+func (e eventMarkedForRetransmit) Category() category { return categoryRecovery }
+func (e eventMarkedForRetransmit) Name() string       { return "marked_for_retransmit" }
+func (e eventMarkedForRetransmit) IsNil() bool        { return false }
+
+func (e eventMarkedForRetransmit) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.ArrayKey("frames", e.Frames)
+}
+
 type eventKeyUpdated struct {
 	Trigger    keyUpdateTrigger
 	KeyType    keyType
@@ -231,7 +231,10 @@ func (t *connectionTracer) SentPacket(hdr *wire.ExtendedHeader, packetSize proto
 	}
 	fs := make([]frame, 0, numFrames)
 	if ack != nil {
-		fs = append(fs, frame{Frame: ack})
+		// The ACK frame is reused for the next ACK, but events are serialized asynchronously.
+		a := *ack
+		a.AckRanges = append([]logging.AckRange(nil), ack.AckRanges...)
+		fs = append(fs, frame{Frame: &a})
 	}
 	for _, f := range frames {
 		fs = append(fs, frame{Frame: f})
@@ -329,12 +332,43 @@ func (t *connectionTracer) LostPacket(encLevel protocol.EncryptionLevel, pn prot
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) QueuedForRetransmission(_ protocol.EncryptionLevel, _ protocol.PacketNumber, frames []logging.Frame) {
+	fs := make([]frame, len(frames))
+	for i, f := range frames {
+		fs[i] = frame{Frame: f}
+	}
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventMarkedForRetransmit{Frames: fs})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) DetectedSpuriousLoss(encLevel protocol.EncryptionLevel, pn protocol.PacketNumber) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventSpuriousLossDetected{
+		PacketType:   getPacketTypeFromEncryptionLevel(encLevel),
+		PacketNumber: pn,
+	})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) CompletedStream(id protocol.StreamID, stats logging.StreamStats) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventStreamCompleted{StreamID: id, Stats: stats})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) UpdatedCongestionState(state logging.CongestionState) {
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventCongestionStateUpdated{state: congestionState(state)})
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) SetCorrelationID(id string) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventCorrelationIDSet{CorrelationID: id})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) UpdatedPTOCount(value uint32) {
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventUpdatedPTO{Value: value})
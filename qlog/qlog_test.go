@@ -357,6 +357,25 @@ var _ = Describe("Tracing", func() {
 				Expect(frames[1].(map[string]interface{})).To(HaveKeyWithValue("frame_type", "max_data"))
 			})
 
+			It("doesn't retain the ACK frame of a sent packet", func() {
+				ack := &logging.AckFrame{AckRanges: []logging.AckRange{{Smallest: 1, Largest: 10}}}
+				tracer.SentPacket(
+					&logging.ExtendedHeader{
+						Header:       logging.Header{DestConnectionID: protocol.ConnectionID{1, 2, 3, 4}},
+						PacketNumber: 1337,
+					},
+					123,
+					ack,
+					nil,
+				)
+				// the ACK frame is reused for the next ACK
+				ack.AckRanges[0] = logging.AckRange{Smallest: 20, Largest: 30}
+				entry := exportAndParseSingle()
+				frames := entry.Event["frames"].([]interface{})
+				Expect(frames).To(HaveLen(1))
+				Expect(frames[0].(map[string]interface{})).To(HaveKeyWithValue("acked_ranges", []interface{}{[]interface{}{float64(1), float64(10)}}))
+			})
+
 			It("records a received packet", func() {
 				tracer.ReceivedPacket(
 					&logging.ExtendedHeader{
@@ -555,6 +574,34 @@ var _ = Describe("Tracing", func() {
 				Expect(ev).To(HaveKeyWithValue("trigger", "reordering_threshold"))
 			})
 
+			It("records spurious losses", func() {
+				tracer.DetectedSpuriousLoss(protocol.Encryption1RTT, 42)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Category).To(Equal("recovery"))
+				Expect(entry.Name).To(Equal("spurious_loss_detected"))
+				ev := entry.Event
+				Expect(ev).To(HaveKeyWithValue("packet_type", "1RTT"))
+				Expect(ev).To(HaveKeyWithValue("packet_number", float64(42)))
+			})
+
+			It("records frames marked for retransmission", func() {
+				tracer.QueuedForRetransmission(protocol.Encryption1RTT, 42, []logging.Frame{
+					&logging.MaxDataFrame{MaximumData: 1337},
+					&logging.PingFrame{},
+				})
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Category).To(Equal("recovery"))
+				Expect(entry.Name).To(Equal("marked_for_retransmit"))
+				ev := entry.Event
+				Expect(ev).To(HaveKey("frames"))
+				frames := ev["frames"].([]interface{})
+				Expect(frames).To(HaveLen(2))
+				Expect(frames[0].(map[string]interface{})).To(HaveKeyWithValue("frame_type", "max_data"))
+				Expect(frames[1].(map[string]interface{})).To(HaveKeyWithValue("frame_type", "ping"))
+			})
+
 			It("records congestion state updates", func() {
 				tracer.UpdatedCongestionState(logging.CongestionStateCongestionAvoidance)
 				entry := exportAndParseSingle()
@@ -574,6 +621,38 @@ var _ = Describe("Tracing", func() {
 				Expect(entry.Event).To(HaveKeyWithValue("pto_count", float64(42)))
 			})
 
+			It("records completed streams", func() {
+				tracer.CompletedStream(4, logging.StreamStats{
+					BytesSent:          1000,
+					BytesRetransmitted: 200,
+					BytesReceived:      300,
+					FramesSent:         5,
+					FramesReceived:     3,
+					FlowControlBlocked: 25 * time.Millisecond,
+				})
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Category).To(Equal("transport"))
+				Expect(entry.Name).To(Equal("stream_completed"))
+				ev := entry.Event
+				Expect(ev).To(HaveKeyWithValue("stream_id", float64(4)))
+				Expect(ev).To(HaveKeyWithValue("bytes_sent", float64(1000)))
+				Expect(ev).To(HaveKeyWithValue("bytes_retransmitted", float64(200)))
+				Expect(ev).To(HaveKeyWithValue("bytes_received", float64(300)))
+				Expect(ev).To(HaveKeyWithValue("frames_sent", float64(5)))
+				Expect(ev).To(HaveKeyWithValue("frames_received", float64(3)))
+				Expect(ev).To(HaveKeyWithValue("flow_control_blocked", float64(25)))
+			})
+
+			It("records the correlation ID", func() {
+				tracer.SetCorrelationID("foobar")
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Category).To(Equal("transport"))
+				Expect(entry.Name).To(Equal("correlation_id_set"))
+				Expect(entry.Event).To(HaveKeyWithValue("correlation_id", "foobar"))
+			})
+
 			It("records TLS key updates", func() {
 				tracer.UpdatedKeyFromTLS(protocol.EncryptionHandshake, protocol.PerspectiveClient)
 				entry := exportAndParseSingle()
@@ -215,6 +215,8 @@ func (e transportError) String() string {
 		return "aead_limit_reached"
 	case qerr.NoViablePathError:
 		return "no_viable_path"
+	case qerr.VersionNegotiationError:
+		return "version_negotiation_error"
 	default:
 		return ""
 	}
@@ -310,6 +312,8 @@ func (r timeoutReason) String() string {
 		return "handshake_timeout"
 	case logging.TimeoutReasonIdle:
 		return "idle_timeout"
+	case logging.TimeoutReasonLivenessProbe:
+		return "liveness_probe_timeout"
 	default:
 		return "unknown close reason"
 	}
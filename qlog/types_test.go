@@ -61,6 +61,7 @@ var _ = Describe("Types", func() {
 	It("has a string representation for the close reason", func() {
 		Expect(timeoutReason(logging.TimeoutReasonHandshake).String()).To(Equal("handshake_timeout"))
 		Expect(timeoutReason(logging.TimeoutReasonIdle).String()).To(Equal("idle_timeout"))
+		Expect(timeoutReason(logging.TimeoutReasonLivenessProbe).String()).To(Equal("liveness_probe_timeout"))
 	})
 
 	It("has a string representation for the key type", func() {
@@ -122,6 +123,7 @@ var _ = Describe("Types", func() {
 			Expect(transportError(qerr.ApplicationError).String()).To(Equal("application_error"))
 			Expect(transportError(qerr.CryptoBufferExceeded).String()).To(Equal("crypto_buffer_exceeded"))
 			Expect(transportError(qerr.NoViablePathError).String()).To(Equal("no_viable_path"))
+			Expect(transportError(qerr.VersionNegotiationError).String()).To(Equal("version_negotiation_error"))
 			Expect(transportError(1337).String()).To(BeEmpty())
 		})
 	})
@@ -47,4 +47,6 @@ type TransportState struct {
 	CongestionWindow protocol.ByteCount
 	InSlowStart      bool
 	InRecovery       bool
+
+	PacketsLost uint64 // the total number of packets declared lost
 }
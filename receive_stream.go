@@ -10,6 +10,7 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 type receiveStreamI interface {
@@ -18,7 +19,9 @@ type receiveStreamI interface {
 	handleStreamFrame(*wire.StreamFrame) error
 	handleResetStreamFrame(*wire.ResetStreamFrame) error
 	closeForShutdown(error)
+	setVersion(protocol.VersionNumber)
 	getWindowUpdate() protocol.ByteCount
+	getStats() logging.StreamStats
 }
 
 type receiveStream struct {
@@ -49,6 +52,7 @@ type receiveStream struct {
 	deadline time.Time
 
 	flowController flowcontrol.StreamFlowController
+	stats          logging.StreamStats
 	version        protocol.VersionNumber
 }
 
@@ -61,9 +65,10 @@ func newReceiveStream(
 	streamID protocol.StreamID,
 	sender streamSender,
 	flowController flowcontrol.StreamFlowController,
+	strictValidation bool,
 	version protocol.VersionNumber,
 ) *receiveStream {
-	return &receiveStream{
+	s := &receiveStream{
 		streamID:       streamID,
 		sender:         sender,
 		flowController: flowController,
@@ -72,6 +77,8 @@ func newReceiveStream(
 		finalOffset:    protocol.MaxByteCount,
 		version:        version,
 	}
+	s.frameQueue.checkConsistency = strictValidation
+	return s
 }
 
 func (s *receiveStream) StreamID() protocol.StreamID {
@@ -239,12 +246,16 @@ func (s *receiveStream) handleStreamFrameImpl(frame *wire.StreamFrame) (bool /*
 	if err := s.flowController.UpdateHighestReceived(maxOffset, frame.Fin); err != nil {
 		return false, err
 	}
+	s.stats.FramesReceived++
+	s.stats.BytesReceived += frame.DataLen()
 	var newlyRcvdFinalOffset bool
 	if frame.Fin {
 		newlyRcvdFinalOffset = s.finalOffset == protocol.MaxByteCount
 		s.finalOffset = maxOffset
 	}
-	if s.canceledRead {
+	// Don't buffer any data if the application won't read it anyway.
+	// The data was still accounted for in the flow controller.
+	if s.canceledRead || s.resetRemotely {
 		return newlyRcvdFinalOffset, nil
 	}
 	if err := s.frameQueue.Push(frame.Data, frame.Offset, frame.PutBack); err != nil {
@@ -254,6 +265,12 @@ func (s *receiveStream) handleStreamFrameImpl(frame *wire.StreamFrame) (bool /*
 	return false, nil
 }
 
+func (s *receiveStream) getStats() logging.StreamStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.stats
+}
+
 func (s *receiveStream) handleResetStreamFrame(frame *wire.ResetStreamFrame) error {
 	s.mutex.Lock()
 	completed, err := s.handleResetStreamFrameImpl(frame)
@@ -286,7 +303,13 @@ func (s *receiveStream) handleResetStreamFrameImpl(frame *wire.ResetStreamFrame)
 		error:     fmt.Errorf("stream %d was reset with error code %d", s.streamID, frame.ErrorCode),
 	}
 	s.signalRead()
-	return newlyRcvdFinalOffset, nil
+	// If the read side was canceled, the stream was already completed when the final offset was received.
+	if s.canceledRead {
+		return newlyRcvdFinalOffset, nil
+	}
+	// Otherwise, the stream is completed now, even if the final offset was already received via a STREAM frame.
+	// The application won't read the remaining data, so the flow control credit can be released right away.
+	return !s.finRead, nil
 }
 
 func (s *receiveStream) CloseRemote(offset protocol.ByteCount) {
@@ -312,6 +335,12 @@ func (s *receiveStream) closeForShutdown(err error) {
 	s.signalRead()
 }
 
+func (s *receiveStream) setVersion(v protocol.VersionNumber) {
+	s.mutex.Lock()
+	s.version = v
+	s.mutex.Unlock()
+}
+
 func (s *receiveStream) getWindowUpdate() protocol.ByteCount {
 	return s.flowController.GetWindowUpdate()
 }
@@ -29,7 +29,7 @@ var _ = Describe("Receive Stream", func() {
 	BeforeEach(func() {
 		mockSender = NewMockStreamSender(mockCtrl)
 		mockFC = mocks.NewMockStreamFlowController(mockCtrl)
-		str = newReceiveStream(streamID, mockSender, mockFC, protocol.VersionWhatever)
+		str = newReceiveStream(streamID, mockSender, mockFC, false, protocol.VersionWhatever)
 
 		timeout := scaleDuration(250 * time.Millisecond)
 		strWithTimeout = gbytes.TimeoutReader(str, timeout)
@@ -190,6 +190,9 @@ var _ = Describe("Receive Stream", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(n).To(Equal(4))
 			Expect(b).To(Equal([]byte{0xDE, 0xAD, 0xBE, 0xEF}))
+			stats := str.getStats()
+			Expect(stats.FramesReceived).To(BeEquivalentTo(3))
+			Expect(stats.BytesReceived).To(Equal(protocol.ByteCount(6)))
 		})
 
 		It("doesn't rejects a STREAM frames with an overlapping data range", func() {
@@ -630,6 +633,33 @@ var _ = Describe("Receive Stream", func() {
 				Expect(str.handleResetStreamFrame(rst)).To(Succeed())
 			})
 
+			It("completes the stream when receiving a RESET_STREAM after the Fin", func() {
+				mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(42), true).Times(2)
+				Expect(str.handleStreamFrame(&wire.StreamFrame{
+					StreamID: streamID,
+					Offset:   rst.FinalSize - 6,
+					Data:     []byte("foobar"),
+					Fin:      true,
+				})).To(Succeed())
+				// the data wasn't read yet, so the flow control credit needs to be returned
+				mockSender.EXPECT().onStreamCompleted(streamID)
+				mockFC.EXPECT().Abandon()
+				Expect(str.handleResetStreamFrame(rst)).To(Succeed())
+			})
+
+			It("doesn't buffer data received after the RESET_STREAM", func() {
+				mockSender.EXPECT().onStreamCompleted(streamID)
+				mockFC.EXPECT().Abandon()
+				mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(42), true)
+				Expect(str.handleResetStreamFrame(rst)).To(Succeed())
+				mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(6), false)
+				Expect(str.handleStreamFrame(&wire.StreamFrame{
+					StreamID: streamID,
+					Data:     []byte("foobar"),
+				})).To(Succeed())
+				Expect(str.frameQueue.HasMoreData()).To(BeFalse())
+			})
+
 			It("doesn't do anyting when it was closed for shutdown", func() {
 				str.closeForShutdown(nil)
 				err := str.handleResetStreamFrame(rst)
@@ -16,6 +16,11 @@ type retransmissionQueue struct {
 
 	appData []wire.Frame
 
+	// the number of bytes queued in each packet number space, see ByteLength
+	initialLen   protocol.ByteCount
+	handshakeLen protocol.ByteCount
+	appDataLen   protocol.ByteCount
+
 	version protocol.VersionNumber
 }
 
@@ -23,7 +28,13 @@ func newRetransmissionQueue(ver protocol.VersionNumber) *retransmissionQueue {
 	return &retransmissionQueue{version: ver}
 }
 
+// SetVersion sets the version used to calculate frame lengths.
+func (q *retransmissionQueue) SetVersion(v protocol.VersionNumber) {
+	q.version = v
+}
+
 func (q *retransmissionQueue) AddInitial(f wire.Frame) {
+	q.initialLen += f.Length(q.version)
 	if cf, ok := f.(*wire.CryptoFrame); ok {
 		q.initialCryptoData = append(q.initialCryptoData, cf)
 		return
@@ -32,6 +43,7 @@ func (q *retransmissionQueue) AddInitial(f wire.Frame) {
 }
 
 func (q *retransmissionQueue) AddHandshake(f wire.Frame) {
+	q.handshakeLen += f.Length(q.version)
 	if cf, ok := f.(*wire.CryptoFrame); ok {
 		q.handshakeCryptoData = append(q.handshakeCryptoData, cf)
 		return
@@ -51,22 +63,32 @@ func (q *retransmissionQueue) HasAppData() bool {
 	return len(q.appData) > 0
 }
 
+// ByteLength returns the total length of all queued frames.
+// The length is updated when frames are added and removed, so this doesn't need to iterate over the queued frames.
+func (q *retransmissionQueue) ByteLength() protocol.ByteCount {
+	return q.initialLen + q.handshakeLen + q.appDataLen
+}
+
 func (q *retransmissionQueue) AddAppData(f wire.Frame) {
 	if _, ok := f.(*wire.StreamFrame); ok {
 		panic("STREAM frames are handled with their respective streams.")
 	}
+	q.appDataLen += f.Length(q.version)
 	q.appData = append(q.appData, f)
 }
 
 func (q *retransmissionQueue) GetInitialFrame(maxLen protocol.ByteCount) wire.Frame {
 	if len(q.initialCryptoData) > 0 {
 		f := q.initialCryptoData[0]
+		l := f.Length(q.version)
 		newFrame, needsSplit := f.MaybeSplitOffFrame(maxLen, q.version)
 		if newFrame == nil && !needsSplit { // the whole frame fits
 			q.initialCryptoData = q.initialCryptoData[1:]
+			q.initialLen -= l
 			return f
 		}
 		if newFrame != nil { // frame was split. Leave the original frame in the queue.
+			q.initialLen -= l - f.Length(q.version)
 			return newFrame
 		}
 	}
@@ -74,22 +96,27 @@ func (q *retransmissionQueue) GetInitialFrame(maxLen protocol.ByteCount) wire.Fr
 		return nil
 	}
 	f := q.initial[0]
-	if f.Length(q.version) > maxLen {
+	l := f.Length(q.version)
+	if l > maxLen {
 		return nil
 	}
 	q.initial = q.initial[1:]
+	q.initialLen -= l
 	return f
 }
 
 func (q *retransmissionQueue) GetHandshakeFrame(maxLen protocol.ByteCount) wire.Frame {
 	if len(q.handshakeCryptoData) > 0 {
 		f := q.handshakeCryptoData[0]
+		l := f.Length(q.version)
 		newFrame, needsSplit := f.MaybeSplitOffFrame(maxLen, q.version)
 		if newFrame == nil && !needsSplit { // the whole frame fits
 			q.handshakeCryptoData = q.handshakeCryptoData[1:]
+			q.handshakeLen -= l
 			return f
 		}
 		if newFrame != nil { // frame was split. Leave the original frame in the queue.
+			q.handshakeLen -= l - f.Length(q.version)
 			return newFrame
 		}
 	}
@@ -97,10 +124,12 @@ func (q *retransmissionQueue) GetHandshakeFrame(maxLen protocol.ByteCount) wire.
 		return nil
 	}
 	f := q.handshake[0]
-	if f.Length(q.version) > maxLen {
+	l := f.Length(q.version)
+	if l > maxLen {
 		return nil
 	}
 	q.handshake = q.handshake[1:]
+	q.handshakeLen -= l
 	return f
 }
 
@@ -109,10 +138,12 @@ func (q *retransmissionQueue) GetAppDataFrame(maxLen protocol.ByteCount) wire.Fr
 		return nil
 	}
 	f := q.appData[0]
-	if f.Length(q.version) > maxLen {
+	l := f.Length(q.version)
+	if l > maxLen {
 		return nil
 	}
 	q.appData = q.appData[1:]
+	q.appDataLen -= l
 	return f
 }
 
@@ -122,9 +153,11 @@ func (q *retransmissionQueue) DropPackets(encLevel protocol.EncryptionLevel) {
 	case protocol.EncryptionInitial:
 		q.initial = nil
 		q.initialCryptoData = nil
+		q.initialLen = 0
 	case protocol.EncryptionHandshake:
 		q.handshake = nil
 		q.handshakeCryptoData = nil
+		q.handshakeLen = 0
 	default:
 		panic(fmt.Sprintf("unexpected encryption level: %s", encLevel))
 	}
@@ -17,6 +17,37 @@ var _ = Describe("Retransmission queue", func() {
 		q = newRetransmissionQueue(version)
 	})
 
+	It("says how many bytes are queued", func() {
+		Expect(q.ByteLength()).To(BeZero())
+		f1 := &wire.CryptoFrame{Data: []byte("foobar")}
+		f2 := &wire.MaxDataFrame{MaximumData: 0x42}
+		f3 := &wire.CryptoFrame{Data: []byte("lorem ipsum")}
+		f4 := &wire.ResetStreamFrame{StreamID: 3}
+		q.AddInitial(f1)
+		q.AddHandshake(f3)
+		q.AddAppData(f2)
+		q.AddAppData(f4)
+		Expect(q.ByteLength()).To(Equal(f1.Length(version) + f2.Length(version) + f3.Length(version) + f4.Length(version)))
+	})
+
+	It("updates the number of queued bytes when frames are dequeued", func() {
+		f1 := &wire.CryptoFrame{Data: []byte("foobar")}
+		f2 := &wire.MaxDataFrame{MaximumData: 0x42}
+		f3 := &wire.CryptoFrame{Data: []byte("lorem ipsum")}
+		q.AddInitial(f1)
+		q.AddHandshake(f3)
+		q.AddAppData(f2)
+		Expect(q.GetAppDataFrame(protocol.MaxByteCount)).To(Equal(f2))
+		Expect(q.ByteLength()).To(Equal(f1.Length(version) + f3.Length(version)))
+		// split the CRYPTO frame
+		Expect(q.GetInitialFrame(f1.Length(version) - 3)).ToNot(BeNil())
+		Expect(q.ByteLength()).To(Equal(f1.Length(version) + f3.Length(version)))
+		Expect(q.GetInitialFrame(protocol.MaxByteCount)).To(Equal(f1))
+		Expect(q.ByteLength()).To(Equal(f3.Length(version)))
+		q.DropPackets(protocol.EncryptionHandshake)
+		Expect(q.ByteLength()).To(BeZero())
+	})
+
 	Context("Initial data", func() {
 		It("doesn't dequeue anything when it's empty", func() {
 			Expect(q.HasInitialData()).To(BeFalse())
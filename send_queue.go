@@ -7,12 +7,12 @@ type sendQueue struct {
 	conn        sendConn
 }
 
-func newSendQueue(conn sendConn) *sendQueue {
+func newSendQueue(conn sendConn, size int) *sendQueue {
 	s := &sendQueue{
 		conn:        conn,
 		runStopped:  make(chan struct{}),
 		closeCalled: make(chan struct{}),
-		queue:       make(chan *packetBuffer, 1),
+		queue:       make(chan *packetBuffer, size),
 	}
 	return s
 }
@@ -14,7 +14,7 @@ var _ = Describe("Send Queue", func() {
 
 	BeforeEach(func() {
 		c = NewMockSendConn(mockCtrl)
-		q = newSendQueue(c)
+		q = newSendQueue(c, 1)
 	})
 
 	getPacket := func(b []byte) *packetBuffer {
@@ -12,6 +12,7 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 type sendStreamI interface {
@@ -20,7 +21,9 @@ type sendStreamI interface {
 	hasData() bool
 	popStreamFrame(maxBytes protocol.ByteCount) (*ackhandler.Frame, bool)
 	closeForShutdown(error)
+	setVersion(protocol.VersionNumber)
 	handleMaxStreamDataFrame(*wire.MaxStreamDataFrame)
+	getStats() logging.StreamStats
 }
 
 type sendStream struct {
@@ -54,6 +57,9 @@ type sendStream struct {
 
 	flowController flowcontrol.StreamFlowController
 
+	stats        logging.StreamStats
+	blockedSince time.Time // the time when we became blocked by flow control, zero if not blocked
+
 	version protocol.VersionNumber
 }
 
@@ -201,6 +207,7 @@ func (s *sendStream) popStreamFrame(maxBytes protocol.ByteCount) (*ackhandler.Fr
 	f, hasMoreData := s.popNewOrRetransmittedStreamFrame(maxBytes)
 	if f != nil {
 		s.numOutstandingFrames++
+		s.stats.FramesSent++
 	}
 	s.mutex.Unlock()
 
@@ -242,6 +249,9 @@ func (s *sendStream) popNewOrRetransmittedStreamFrame(maxBytes protocol.ByteCoun
 
 	sendWindow := s.flowController.SendWindowSize()
 	if sendWindow == 0 {
+		if s.blockedSince.IsZero() {
+			s.blockedSince = time.Now()
+		}
 		if isBlocked, offset := s.flowController.IsNewlyBlocked(); isBlocked {
 			s.sender.queueControlFrame(&wire.StreamDataBlockedFrame{
 				StreamID:          s.streamID,
@@ -251,11 +261,16 @@ func (s *sendStream) popNewOrRetransmittedStreamFrame(maxBytes protocol.ByteCoun
 		}
 		return nil, true
 	}
+	if !s.blockedSince.IsZero() {
+		s.stats.FlowControlBlocked += time.Since(s.blockedSince)
+		s.blockedSince = time.Time{}
+	}
 
 	f, hasMoreData := s.popNewStreamFrame(maxBytes, sendWindow)
 	if dataLen := f.DataLen(); dataLen > 0 {
 		s.writeOffset += f.DataLen()
 		s.flowController.AddBytesSent(f.DataLen())
+		s.stats.BytesSent += f.DataLen()
 	}
 	f.Fin = s.finishedWriting && s.dataForWriting == nil && s.nextFrame == nil && !s.finSent
 	if f.Fin {
@@ -371,6 +386,7 @@ func (s *sendStream) queueRetransmission(f wire.Frame) {
 	sf := f.(*wire.StreamFrame)
 	sf.DataLenPresent = true
 	s.mutex.Lock()
+	s.stats.BytesRetransmitted += sf.DataLen()
 	s.retransmissionQueue = append(s.retransmissionQueue, sf)
 	s.numOutstandingFrames--
 	if s.numOutstandingFrames < 0 {
@@ -381,6 +397,17 @@ func (s *sendStream) queueRetransmission(f wire.Frame) {
 	s.sender.onHasStreamData(s.streamID)
 }
 
+func (s *sendStream) getStats() logging.StreamStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := s.stats
+	if !s.blockedSince.IsZero() {
+		stats.FlowControlBlocked += time.Since(s.blockedSince)
+	}
+	return stats
+}
+
 func (s *sendStream) Close() error {
 	s.mutex.Lock()
 	if s.closedForShutdown {
@@ -470,6 +497,14 @@ func (s *sendStream) closeForShutdown(err error) {
 	s.signalWrite()
 }
 
+// setVersion sets the version used to calculate the length of STREAM frames.
+// It is called when the connection is upgraded to a compatible version during the handshake.
+func (s *sendStream) setVersion(v protocol.VersionNumber) {
+	s.mutex.Lock()
+	s.version = v
+	s.mutex.Unlock()
+}
+
 // signalWrite performs a non-blocking send on the writeChan
 func (s *sendStream) signalWrite() {
 	select {
@@ -364,6 +364,30 @@ var _ = Describe("Send Stream", func() {
 				str.closeForShutdown(nil)
 				Eventually(done).Should(BeClosed())
 			})
+
+			It("records how long it was blocked", func() {
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(done)
+					mockSender.EXPECT().onHasStreamData(streamID)
+					_, err := str.Write([]byte("foobar"))
+					Expect(err).ToNot(HaveOccurred())
+				}()
+				waitForWrite()
+				mockFC.EXPECT().SendWindowSize()
+				mockFC.EXPECT().IsNewlyBlocked()
+				f, hasMoreData := str.popStreamFrame(1000)
+				Expect(f).To(BeNil())
+				Expect(hasMoreData).To(BeTrue())
+				time.Sleep(scaleDuration(20 * time.Millisecond))
+				mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(9999))
+				mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+				f, _ = str.popStreamFrame(1000)
+				Expect(f).ToNot(BeNil())
+				Eventually(done).Should(BeClosed())
+				Expect(str.getStats().FlowControlBlocked).To(BeNumerically(">=", scaleDuration(20*time.Millisecond)))
+			})
 		})
 
 		Context("deadlines", func() {
@@ -954,6 +978,10 @@ var _ = Describe("Send Stream", func() {
 			newFrame, _ := str.popStreamFrame(protocol.MaxByteCount)
 			Expect(newFrame).ToNot(BeNil())
 			Expect(newFrame.Frame.(*wire.StreamFrame).Data).To(Equal([]byte("foobar")))
+			stats := str.getStats()
+			Expect(stats.BytesSent).To(Equal(protocol.ByteCount(6)))
+			Expect(stats.BytesRetransmitted).To(Equal(protocol.ByteCount(6)))
+			Expect(stats.FramesSent).To(BeEquivalentTo(2))
 		})
 
 		It("doesn't get a retransmission after a stream was canceled", func() {
@@ -8,10 +8,12 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/lucas-clemente/quic-go/internal/flowcontrol"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
@@ -39,6 +41,7 @@ type packetHandlerManager interface {
 	sessionRunner
 	SetServer(unknownPacketHandler)
 	CloseServer()
+	ReceiveBufferDrops() uint64
 }
 
 type quicSession interface {
@@ -50,10 +53,38 @@ type quicSession interface {
 	run() error
 	destroy(error)
 	shutdown()
+	closeWithTransportError(qerr.ErrorCode, string)
+	lastActivity() time.Time
+	setDebugLogging(bool)
+}
+
+// A connectionAttempt is an Initial packet whose token was accepted.
+// If the handshake limit is reached, it waits in the handshake queue until one of the running handshakes completes.
+type connectionAttempt struct {
+	packet   *receivedPacket
+	hdr      *wire.Header
+	versions []protocol.VersionNumber
+
+	token          *Token
+	origDestConnID protocol.ConnectionID
+	retrySrcConnID *protocol.ConnectionID
+
+	queuedAt time.Time
+}
+
+type sessionInfo struct {
+	remoteAddr net.Addr
+	// The connection ID used to identify the session, e.g. in qlogs.
+	// This is the original destination connection ID chosen by the client.
+	connID protocol.ConnectionID
 }
 
 // A Listener of QUIC
 type baseServer struct {
+	// 64 bit values accessed atomically must be the first fields, for alignment on 32 bit platforms
+	acceptQueueOverflows uint64
+	rejectedHandshakes   uint64
+
 	mutex sync.Mutex
 
 	acceptEarlySessions bool
@@ -67,6 +98,7 @@ type baseServer struct {
 	createdPacketConn bool
 
 	tokenGenerator *handshake.TokenGenerator
+	memoryBudget   *flowcontrol.MemoryBudget // nil if no memory budget is configured
 
 	zeroRTTQueue   *zeroRTTQueue
 	sessionHandler packetHandlerManager
@@ -86,6 +118,8 @@ type baseServer struct {
 		*Config,
 		*tls.Config,
 		*handshake.TokenGenerator,
+		*flowcontrol.MemoryBudget,
+		bool, /* client address validated by an address validation token */
 		bool, /* enable 0-RTT */
 		logging.ConnectionTracer,
 		utils.Logger,
@@ -100,6 +134,17 @@ type baseServer struct {
 	sessionQueue    chan quicSession
 	sessionQueueLen int32 // to be used as an atomic
 
+	// nil if no connection limits are configured
+	connLimiter *connectionLimiter
+	// Connection attempts waiting for a handshake slot. Only accessed from the run loop.
+	handshakeQueue     []connectionAttempt
+	handshakeSlotFreed chan struct{}
+
+	// sessions that are still running, used for a graceful shutdown
+	sessions     map[quicSession]sessionInfo
+	shuttingDown bool
+	drainWaiters []chan struct{} // closed when the last session has been removed
+
 	logger utils.Logger
 }
 
@@ -141,6 +186,9 @@ func listenAddr(addr string, tlsConf *tls.Config, config *Config, acceptEarly bo
 	if err != nil {
 		return nil, err
 	}
+	if err := setDF(conn); err != nil {
+		utils.DefaultLogger.Debugf("Failed to set the DF bit: %s", err)
+	}
 	serv, err := listen(conn, tlsConf, config, acceptEarly)
 	if err != nil {
 		return nil, err
@@ -149,15 +197,61 @@ func listenAddr(addr string, tlsConf *tls.Config, config *Config, acceptEarly bo
 	return serv, nil
 }
 
+// ListenFile creates a QUIC server listening on the UDP socket referred to by f.
+// This allows using a socket inherited from the parent process,
+// e.g. when using systemd socket activation, or when handing over a socket to a new process during a graceful restart.
+// The file descriptor is duplicated, so f can (and should) be closed by the caller after ListenFile returns.
+// The tls.Config must not be nil and must contain a certificate configuration.
+// The quic.Config may be nil, in that case the default values will be used.
+func ListenFile(f *os.File, tlsConf *tls.Config, config *Config) (Listener, error) {
+	return listenFile(f, tlsConf, config, false)
+}
+
+// ListenFileEarly works like ListenFile, but it returns sessions before the handshake completes.
+func ListenFileEarly(f *os.File, tlsConf *tls.Config, config *Config) (EarlyListener, error) {
+	s, err := listenFile(f, tlsConf, config, true)
+	if err != nil {
+		return nil, err
+	}
+	return &earlyServer{s}, nil
+}
+
+func listenFile(f *os.File, tlsConf *tls.Config, config *Config, acceptEarly bool) (*baseServer, error) {
+	conn, err := net.FilePacketConn(f)
+	if err != nil {
+		return nil, err
+	}
+	if udpConn, ok := conn.(*net.UDPConn); ok {
+		if err := setDF(udpConn); err != nil {
+			utils.DefaultLogger.Debugf("Failed to set the DF bit: %s", err)
+		}
+	}
+	serv, err := listen(conn, tlsConf, config, acceptEarly)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	serv.createdPacketConn = true
+	return serv, nil
+}
+
 // Listen listens for QUIC connections on a given net.PacketConn.
 // If the PacketConn satisfies the ECNCapablePacketConn interface (as a net.UDPConn does), ECN support will be enabled.
 // In this case, ReadMsgUDP will be used instead of ReadFrom to read packets.
-// A single net.PacketConn only be used for a single call to Listen.
+// Otherwise, the remote address of a session is the address returned by ReadFrom,
+// and packets are sent to that address using WriteTo.
+// A PacketConn that wraps the underlying connection can therefore be used to pass
+// the real client address to the server, e.g. when it is running behind a load balancer.
+// A single net.PacketConn can only be used for a single call to Listen.
 // The PacketConn can be used for simultaneous calls to Dial.
 // QUIC connection IDs are used for demultiplexing the different connections.
 // The tls.Config must not be nil and must contain a certificate configuration.
 // The tls.Config.CipherSuites allows setting of TLS 1.3 cipher suites.
 // Furthermore, it must define an application control (using NextProtos).
+// Certificates can be rotated at runtime using tls.Config.GetCertificate or tls.Config.GetConfigForClient.
+// These callbacks are invoked for every new handshake, so existing sessions are not affected.
+// GetConfigForClient can also be used to serve different certificates depending on the SNI.
+// Accepted sessions can then be dispatched to per-hostname handlers using ConnectionState().ServerName.
 // The quic.Config may be nil, in that case the default values will be used.
 func Listen(conn net.PacketConn, tlsConf *tls.Config, config *Config) (Listener, error) {
 	return listen(conn, tlsConf, config, false)
@@ -180,13 +274,8 @@ func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarl
 		return nil, err
 	}
 	config = populateServerConfig(config)
-	for _, v := range config.Versions {
-		if !protocol.IsValidVersion(v) {
-			return nil, fmt.Errorf("%s is not a valid QUIC version", v)
-		}
-	}
 
-	sessionHandler, err := getMultiplexer().AddConn(conn, config.ConnectionIDLength, config.StatelessResetKey, config.Tracer)
+	sessionHandler, err := getMultiplexer().AddConn(conn, config.ConnectionIDLength, config.StatelessResetKey, config.ReceiveBufferSize, config.SendBufferSize, config.Tracer)
 	if err != nil {
 		return nil, err
 	}
@@ -202,13 +291,27 @@ func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarl
 		sessionHandler:      sessionHandler,
 		zeroRTTQueue:        newZeroRTTQueue(),
 		sessionQueue:        make(chan quicSession),
+		sessions:            make(map[quicSession]sessionInfo),
 		errorChan:           make(chan struct{}),
 		running:             make(chan struct{}),
 		receivedPackets:     make(chan *receivedPacket, protocol.MaxServerUnprocessedPackets),
+		handshakeSlotFreed:  make(chan struct{}, 1),
 		newSession:          newSession,
-		logger:              utils.DefaultLogger.WithPrefix("server"),
+		logger:              getLogger(config).WithPrefix("server"),
 		acceptEarlySessions: acceptEarly,
 	}
+	if config.MaxIncomingConnections > 0 || config.MaxIncomingConnectionsPerIP > 0 || config.MaxHandshakesPerSecond > 0 || config.MaxConcurrentHandshakes > 0 {
+		s.connLimiter = newConnectionLimiter(
+			config.MaxIncomingConnections,
+			config.MaxIncomingConnectionsPerIP,
+			config.IPv6PrefixLength,
+			config.MaxHandshakesPerSecond,
+			config.MaxConcurrentHandshakes,
+		)
+	}
+	if config.ReceiveMemoryBudget > 0 {
+		s.memoryBudget = flowcontrol.NewMemoryBudget(protocol.ByteCount(config.ReceiveMemoryBudget))
+	}
 	go s.run()
 	sessionHandler.SetServer(s)
 	s.logger.Debugf("Listening for %s connections on %s", conn.LocalAddr().Network(), conn.LocalAddr().String())
@@ -217,6 +320,12 @@ func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarl
 
 func (s *baseServer) run() {
 	defer close(s.running)
+	defer func() {
+		for _, q := range s.handshakeQueue {
+			q.packet.buffer.Release()
+		}
+		s.handshakeQueue = nil
+	}()
 	for {
 		select {
 		case <-s.errorChan:
@@ -230,6 +339,8 @@ func (s *baseServer) run() {
 			if bufferStillInUse := s.handlePacketImpl(p); !bufferStillInUse {
 				p.buffer.Release()
 			}
+		case <-s.handshakeSlotFreed:
+			s.startQueuedHandshakes()
 		}
 	}
 }
@@ -245,11 +356,16 @@ var defaultAcceptToken = func(clientAddr net.Addr, token *Token) bool {
 	if time.Now().After(token.SentTime.Add(validity)) {
 		return false
 	}
+	return tokenMatchesAddr(token, clientAddr)
+}
+
+// tokenMatchesAddr checks if the token was issued to the given address.
+func tokenMatchesAddr(token *Token, addr net.Addr) bool {
 	var sourceAddr string
-	if udpAddr, ok := clientAddr.(*net.UDPAddr); ok {
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
 		sourceAddr = udpAddr.IP.String()
 	} else {
-		sourceAddr = clientAddr.String()
+		sourceAddr = addr.String()
 	}
 	return sourceAddr == token.RemoteAddr
 }
@@ -306,6 +422,137 @@ func (s *baseServer) setCloseError(e error) {
 	close(s.errorChan)
 }
 
+// Shutdown stops accepting new connections and waits for all sessions to be closed.
+// When the context is done before that, the remaining sessions are closed.
+func (s *baseServer) Shutdown(ctx context.Context) error {
+	drained := make(chan struct{})
+	s.mutex.Lock()
+	s.shuttingDown = true
+	if len(s.sessions) == 0 {
+		close(drained)
+	} else {
+		s.drainWaiters = append(s.drainWaiters, drained)
+	}
+	s.mutex.Unlock()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+		s.mutex.Lock()
+		sessions := make([]quicSession, 0, len(s.sessions))
+		for sess := range s.sessions {
+			sessions = append(sessions, sess)
+		}
+		s.mutex.Unlock()
+		var wg sync.WaitGroup
+		for _, sess := range sessions {
+			wg.Add(1)
+			go func(sess quicSession) {
+				// Use a transport error, so that the peer can distinguish this from the application closing the session.
+				// This blocks until the CONNECTION_CLOSE has been sent and the run-loop has stopped.
+				sess.closeWithTransportError(qerr.NoError, "server shutting down")
+				wg.Done()
+			}(sess)
+		}
+		wg.Wait()
+	}
+	if cerr := s.Close(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// CloseIdleSessions closes all sessions that haven't received any packets for at least olderThan.
+// It returns the number of sessions that were closed.
+func (s *baseServer) CloseIdleSessions(olderThan time.Duration) int {
+	now := time.Now()
+	s.mutex.Lock()
+	var idle []quicSession
+	for sess := range s.sessions {
+		if now.Sub(sess.lastActivity()) >= olderThan {
+			idle = append(idle, sess)
+		}
+	}
+	s.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, sess := range idle {
+		wg.Add(1)
+		go func(sess quicSession) {
+			// blocks until the CONNECTION_CLOSE has been sent and the run-loop has stopped
+			sess.CloseWithError(0, "idle session closed")
+			wg.Done()
+		}(sess)
+	}
+	wg.Wait()
+	return len(idle)
+}
+
+// NumSessions returns the number of sessions, by state.
+func (s *baseServer) NumSessions() SessionCount {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var count SessionCount
+	for sess := range s.sessions {
+		select {
+		case <-sess.HandshakeComplete().Done():
+			count.Established++
+		default:
+			count.Handshaking++
+		}
+	}
+	return count
+}
+
+// Stats returns statistics about the server.
+func (s *baseServer) Stats() ListenerStats {
+	return ListenerStats{
+		AcceptQueueOverflows: atomic.LoadUint64(&s.acceptQueueOverflows),
+		ReceiveBufferDrops:   s.sessionHandler.ReceiveBufferDrops(),
+		RejectedHandshakes:   atomic.LoadUint64(&s.rejectedHandshakes),
+	}
+}
+
+// SetDebugLogging enables or disables debug logging for the session with the given connection ID.
+// It returns false if no such session exists.
+func (s *baseServer) SetDebugLogging(connID []byte, enabled bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for sess, info := range s.sessions {
+		if info.connID.Equal(connID) {
+			sess.setDebugLogging(enabled)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *baseServer) isShuttingDown() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.shuttingDown
+}
+
+func (s *baseServer) removeSession(sess quicSession) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if info, ok := s.sessions[sess]; ok && s.connLimiter != nil {
+		s.connLimiter.Release(info.remoteAddr)
+	}
+	delete(s.sessions, sess)
+	if len(s.sessions) > 0 {
+		return
+	}
+	for _, c := range s.drainWaiters {
+		close(c)
+	}
+	s.drainWaiters = nil
+}
+
 // Addr returns the server's network address
 func (s *baseServer) Addr() net.Addr {
 	return s.conn.LocalAddr()
@@ -352,7 +599,8 @@ func (s *baseServer) handlePacketImpl(p *receivedPacket) bool /* is the buffer s
 		return false
 	}
 	// send a Version Negotiation Packet if the client is speaking a different protocol version
-	if !protocol.IsSupportedVersion(s.config.Versions, hdr.Version) {
+	versions := s.offeredVersions(p.remoteAddr)
+	if !protocol.IsSupportedVersion(versions, hdr.Version) {
 		if p.Size() < protocol.MinUnknownVersionPacketSize {
 			s.logger.Debugf("Dropping a packet with an unknown version that is too small (%d bytes)", p.Size())
 			if s.config.Tracer != nil {
@@ -360,7 +608,7 @@ func (s *baseServer) handlePacketImpl(p *receivedPacket) bool /* is the buffer s
 			}
 			return false
 		}
-		go s.sendVersionNegotiationPacket(p, hdr)
+		go s.sendVersionNegotiationPacket(p, hdr, versions)
 		return false
 	}
 	if hdr.IsLongHeader {
@@ -381,7 +629,7 @@ func (s *baseServer) handlePacketImpl(p *receivedPacket) bool /* is the buffer s
 
 	s.logger.Debugf("<- Received Initial packet.")
 
-	if err := s.handleInitialImpl(p, hdr); err != nil {
+	if err := s.handleInitialImpl(p, hdr, versions); err != nil {
 		s.logger.Errorf("Error occurred handling initial packet: %s", err)
 	}
 	// Don't put the packet buffer back.
@@ -389,7 +637,21 @@ func (s *baseServer) handlePacketImpl(p *receivedPacket) bool /* is the buffer s
 	return true
 }
 
-func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) error {
+// offeredVersions returns the versions offered to a client, ordered by preference.
+func (s *baseServer) offeredVersions(clientAddr net.Addr) []protocol.VersionNumber {
+	if s.config.OfferedVersions == nil {
+		return s.config.Versions
+	}
+	var versions []protocol.VersionNumber
+	for _, v := range s.config.OfferedVersions(clientAddr) {
+		if protocol.IsSupportedVersion(s.config.Versions, v) {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header, versions []protocol.VersionNumber) error {
 	if len(hdr.Token) == 0 && hdr.DestConnectionID.Len() < protocol.MinConnectionIDLenInitial {
 		p.buffer.Release()
 		if s.config.Tracer != nil {
@@ -398,66 +660,157 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 		return errors.New("too short connection ID")
 	}
 
-	var (
-		token                *Token
-		retrySrcConnectionID *protocol.ConnectionID
-	)
-	origDestConnectionID := hdr.DestConnectionID
+	if s.config.ConnectionFilter != nil {
+		switch s.config.ConnectionFilter(p.remoteAddr, hdr.Version, hdr.DestConnectionID) {
+		case ConnectionFilterDrop:
+			s.logger.Debugf("Dropping Initial packet from %s. Rejected by the connection filter.", p.remoteAddr)
+			if s.config.Tracer != nil {
+				s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropDOSPrevention)
+			}
+			p.buffer.Release()
+			return nil
+		case ConnectionFilterReject:
+			s.logger.Debugf("Rejecting new connection from %s. Rejected by the connection filter.", p.remoteAddr)
+			go func() {
+				defer p.buffer.Release()
+				if err := s.sendConnectionRefused(p.remoteAddr, hdr); err != nil {
+					s.logger.Debugf("Error rejecting connection: %s", err)
+				}
+			}()
+			return nil
+		}
+	}
+
+	if s.isShuttingDown() {
+		s.logger.Debugf("Rejecting new connection. Server is shutting down.")
+		s.refuseConnection(p, hdr)
+		return nil
+	}
+
+	if queueLen := atomic.LoadInt32(&s.sessionQueueLen); queueLen >= int32(s.config.MaxAcceptQueueSize) {
+		atomic.AddUint64(&s.acceptQueueOverflows, 1)
+		if s.config.AcceptQueueOverflowPolicy == OverflowDrop {
+			s.logger.Debugf("Dropping Initial packet. Server currently busy. Accept queue length: %d (max %d)", queueLen, s.config.MaxAcceptQueueSize)
+			if s.config.Tracer != nil {
+				s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropDOSPrevention)
+			}
+			p.buffer.Release()
+			return nil
+		}
+		s.logger.Debugf("Rejecting new connection. Server currently busy. Accept queue length: %d (max %d)", queueLen, s.config.MaxAcceptQueueSize)
+		s.refuseConnection(p, hdr)
+		return nil
+	}
+
+	attempt, ok := s.validateToken(p, hdr)
+	if !ok {
+		return nil
+	}
+	attempt.versions = versions
+
+	// The connection limits are only charged for connection attempts that passed the token validation.
+	// Otherwise, a flood of spoofed Initial packets, which are only answered with a Retry,
+	// could use up the limits and lock out all legitimate clients.
+	if s.connLimiter != nil {
+		// Don't let this connection attempt overtake the ones that are already waiting for a handshake slot.
+		if len(s.handshakeQueue) > 0 {
+			s.enqueueHandshake(attempt)
+			return nil
+		}
+		switch s.connLimiter.Allow(p.remoteAddr, time.Now()) {
+		case limitHandshakesReached:
+			s.enqueueHandshake(attempt)
+			return nil
+		case limitReached:
+			s.logger.Debugf("Rejecting new connection from %s. Connection limit reached.", p.remoteAddr)
+			atomic.AddUint64(&s.rejectedHandshakes, 1)
+			s.refuseConnection(p, hdr)
+			return nil
+		}
+	}
+	return s.handleAllowedInitial(attempt)
+}
+
+// validateToken decodes the token and passes it to the AcceptToken callback.
+// If the token is not accepted, it sends a Retry (or an INVALID_TOKEN error),
+// takes care of releasing the packet buffer, and returns false.
+func (s *baseServer) validateToken(p *receivedPacket, hdr *wire.Header) (connectionAttempt, bool) {
+	attempt := connectionAttempt{
+		packet:         p,
+		hdr:            hdr,
+		origDestConnID: hdr.DestConnectionID,
+	}
 	if len(hdr.Token) > 0 {
 		c, err := s.tokenGenerator.DecodeToken(hdr.Token)
 		if err == nil {
-			token = &Token{
+			attempt.token = &Token{
 				IsRetryToken: c.IsRetryToken,
 				RemoteAddr:   c.RemoteAddr,
 				SentTime:     c.SentTime,
 			}
-			if token.IsRetryToken {
-				origDestConnectionID = c.OriginalDestConnectionID
-				retrySrcConnectionID = &c.RetrySrcConnectionID
+			if c.IsRetryToken {
+				attempt.origDestConnID = c.OriginalDestConnectionID
+				attempt.retrySrcConnID = &c.RetrySrcConnectionID
 			}
 		}
 	}
-	if !s.config.AcceptToken(p.remoteAddr, token) {
-		go func() {
-			defer p.buffer.Release()
-			if token != nil && token.IsRetryToken {
-				if err := s.maybeSendInvalidToken(p, hdr); err != nil {
-					s.logger.Debugf("Error sending INVALID_TOKEN error: %s", err)
-				}
-				return
-			}
-			if err := s.sendRetry(p.remoteAddr, hdr); err != nil {
-				s.logger.Debugf("Error sending Retry: %s", err)
-			}
-		}()
-		return nil
+	if s.config.AcceptToken(p.remoteAddr, attempt.token) {
+		return attempt, true
 	}
-
-	if queueLen := atomic.LoadInt32(&s.sessionQueueLen); queueLen >= protocol.MaxAcceptQueueSize {
-		s.logger.Debugf("Rejecting new connection. Server currently busy. Accept queue length: %d (max %d)", queueLen, protocol.MaxAcceptQueueSize)
-		go func() {
-			if err := s.sendConnectionRefused(p.remoteAddr, hdr); err != nil {
-				s.logger.Debugf("Error rejecting connection: %s", err)
+	go func() {
+		defer p.buffer.Release()
+		if attempt.token != nil && attempt.token.IsRetryToken {
+			if err := s.maybeSendInvalidToken(p, hdr); err != nil {
+				s.logger.Debugf("Error sending INVALID_TOKEN error: %s", err)
 			}
-		}()
-		return nil
-	}
+			return
+		}
+		if err := s.sendRetry(p.remoteAddr, hdr); err != nil {
+			s.logger.Debugf("Error sending Retry: %s", err)
+		}
+	}()
+	return connectionAttempt{}, false
+}
 
-	connID, err := protocol.GenerateConnectionID(s.config.ConnectionIDLength)
+// handleAllowedInitial creates the session.
+// It is called for connection attempts that passed the token validation and the connection limits.
+func (s *baseServer) handleAllowedInitial(attempt connectionAttempt) error {
+	p := attempt.packet
+	hdr := attempt.hdr
+	connID, err := generateConnectionIDWithConfig(s.config)
 	if err != nil {
+		s.releaseConnLimits(p.remoteAddr)
+		p.buffer.Release()
 		return err
 	}
 	s.logger.Debugf("Changing connection ID to %s.", connID)
+	// Shutdown might have been called since we checked above.
+	// Checking again while holding the mutex that is used to insert the session into s.sessions
+	// makes sure that Shutdown waits for every session that is created.
+	s.mutex.Lock()
+	if s.shuttingDown {
+		s.mutex.Unlock()
+		s.logger.Debugf("Rejecting new connection. Server is shutting down.")
+		s.releaseConnLimits(p.remoteAddr)
+		s.refuseConnection(p, hdr)
+		return nil
+	}
 	sess := s.createNewSession(
 		p.remoteAddr,
-		origDestConnectionID,
-		retrySrcConnectionID,
+		attempt.origDestConnID,
+		attempt.retrySrcConnID,
 		hdr.DestConnectionID,
 		hdr.SrcConnectionID,
 		connID,
+		// If the token was accepted, and it was issued to this address, the client's address has been validated.
+		// A custom AcceptToken might accept tokens that were issued to a different address.
+		attempt.token != nil && tokenMatchesAddr(attempt.token, p.remoteAddr),
 		hdr.Version,
+		attempt.versions,
 	)
+	s.mutex.Unlock()
 	if sess == nil {
+		s.releaseConnLimits(p.remoteAddr)
 		p.buffer.Release()
 		return nil
 	}
@@ -472,6 +825,111 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 	return nil
 }
 
+// refuseConnection sends a CONNECTION_REFUSED error in response to an Initial packet.
+// It takes care of releasing the packet buffer.
+func (s *baseServer) refuseConnection(p *receivedPacket, hdr *wire.Header) {
+	go func() {
+		defer p.buffer.Release()
+		if err := s.sendConnectionRefused(p.remoteAddr, hdr); err != nil {
+			s.logger.Debugf("Error rejecting connection: %s", err)
+		}
+	}()
+}
+
+// releaseConnLimits releases the slots reserved by the connection limiter,
+// if the connection attempt was allowed, but no session was created.
+func (s *baseServer) releaseConnLimits(remoteAddr net.Addr) {
+	if s.connLimiter == nil {
+		return
+	}
+	s.connLimiter.Release(remoteAddr)
+	s.handshakeDone()
+}
+
+// handshakeDone releases the handshake slot reserved by the connection limiter.
+// Queued connection attempts are started by the run loop.
+func (s *baseServer) handshakeDone() {
+	s.connLimiter.HandshakeDone()
+	select {
+	case s.handshakeSlotFreed <- struct{}{}:
+	default:
+	}
+}
+
+// enqueueHandshake queues a connection attempt until a handshake slot becomes available.
+// If the queue is full, the connection attempt is rejected.
+// It must only be called from the run loop.
+func (s *baseServer) enqueueHandshake(attempt connectionAttempt) {
+	p := attempt.packet
+	now := time.Now()
+	s.dropExpiredHandshakes(now)
+	for _, q := range s.handshakeQueue {
+		if q.hdr.DestConnectionID.Equal(attempt.hdr.DestConnectionID) {
+			// The client retransmitted its Initial, and we already queued one.
+			s.logger.Debugf("Dropping retransmitted Initial packet from %s. Connection attempt already queued.", p.remoteAddr)
+			if s.config.Tracer != nil {
+				s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropDuplicate)
+			}
+			p.buffer.Release()
+			return
+		}
+	}
+	if len(s.handshakeQueue) >= s.config.MaxQueuedHandshakes {
+		s.logger.Debugf("Rejecting new connection from %s. Handshake limit reached.", p.remoteAddr)
+		atomic.AddUint64(&s.rejectedHandshakes, 1)
+		s.refuseConnection(p, attempt.hdr)
+		return
+	}
+	s.logger.Debugf("Queueing connection attempt from %s. Handshake limit reached.", p.remoteAddr)
+	attempt.queuedAt = now
+	s.handshakeQueue = append(s.handshakeQueue, attempt)
+}
+
+// dropExpiredHandshakes drops queued connection attempts that have been waiting for longer than the handshake timeout.
+// The client will have given up on these connection attempts by now.
+// It must only be called from the run loop.
+func (s *baseServer) dropExpiredHandshakes(now time.Time) {
+	for len(s.handshakeQueue) > 0 {
+		q := s.handshakeQueue[0]
+		if now.Sub(q.queuedAt) < s.config.HandshakeTimeout {
+			return
+		}
+		s.logger.Debugf("Dropping queued connection attempt from %s. It expired.", q.packet.remoteAddr)
+		atomic.AddUint64(&s.rejectedHandshakes, 1)
+		if s.config.Tracer != nil {
+			s.config.Tracer.DroppedPacket(q.packet.remoteAddr, logging.PacketTypeInitial, q.packet.Size(), logging.PacketDropDOSPrevention)
+		}
+		q.packet.buffer.Release()
+		s.handshakeQueue[0] = connectionAttempt{}
+		s.handshakeQueue = s.handshakeQueue[1:]
+	}
+}
+
+// startQueuedHandshakes starts queued connection attempts, as long as the connection limits allow it.
+// It must only be called from the run loop.
+func (s *baseServer) startQueuedHandshakes() {
+	s.dropExpiredHandshakes(time.Now())
+	for len(s.handshakeQueue) > 0 {
+		q := s.handshakeQueue[0]
+		res := s.connLimiter.Allow(q.packet.remoteAddr, time.Now())
+		if res == limitHandshakesReached {
+			return
+		}
+		s.handshakeQueue[0] = connectionAttempt{}
+		s.handshakeQueue = s.handshakeQueue[1:]
+		if res == limitReached {
+			s.logger.Debugf("Rejecting queued connection attempt from %s. Connection limit reached.", q.packet.remoteAddr)
+			atomic.AddUint64(&s.rejectedHandshakes, 1)
+			s.refuseConnection(q.packet, q.hdr)
+			continue
+		}
+		if err := s.handleAllowedInitial(q); err != nil {
+			s.logger.Errorf("Error occurred handling initial packet: %s", err)
+		}
+	}
+}
+
+// createNewSession must be called with the mutex held.
 func (s *baseServer) createNewSession(
 	remoteAddr net.Addr,
 	origDestConnID protocol.ConnectionID,
@@ -479,17 +937,26 @@ func (s *baseServer) createNewSession(
 	clientDestConnID protocol.ConnectionID,
 	destConnID protocol.ConnectionID,
 	srcConnID protocol.ConnectionID,
+	clientAddrIsValid bool,
 	version protocol.VersionNumber,
+	offeredVersions []protocol.VersionNumber,
 ) quicSession {
+	// Use the same connection ID that is passed to the client's GetLogWriter callback.
+	connID := clientDestConnID
+	if origDestConnID.Len() > 0 {
+		connID = origDestConnID
+	}
+	// The session advertises the versions it supports in its transport parameters.
+	// Make sure it only advertises the versions offered to this client.
+	config := s.config
+	if s.config.OfferedVersions != nil {
+		config = s.config.Clone()
+		config.Versions = offeredVersions
+	}
 	var sess quicSession
 	if added := s.sessionHandler.AddWithConnID(clientDestConnID, srcConnID, func() packetHandler {
 		var tracer logging.ConnectionTracer
 		if s.config.Tracer != nil {
-			// Use the same connection ID that is passed to the client's GetLogWriter callback.
-			connID := clientDestConnID
-			if origDestConnID.Len() > 0 {
-				connID = origDestConnID
-			}
 			tracer = s.config.Tracer.TracerForConnection(protocol.PerspectiveServer, connID)
 		}
 		sess = s.newSession(
@@ -501,9 +968,11 @@ func (s *baseServer) createNewSession(
 			destConnID,
 			srcConnID,
 			s.sessionHandler.GetStatelessResetToken(srcConnID),
-			s.config,
+			config,
 			s.tlsConf,
 			s.tokenGenerator,
+			s.memoryBudget,
+			clientAddrIsValid,
 			s.acceptEarlySessions,
 			tracer,
 			s.logger,
@@ -513,7 +982,20 @@ func (s *baseServer) createNewSession(
 	}); !added {
 		return nil
 	}
-	go sess.run()
+	s.sessions[sess] = sessionInfo{remoteAddr: remoteAddr, connID: connID}
+	go func() {
+		sess.run()
+		s.removeSession(sess)
+	}()
+	if s.connLimiter != nil && s.config.MaxConcurrentHandshakes > 0 {
+		go func() {
+			select {
+			case <-sess.HandshakeComplete().Done():
+			case <-sess.Context().Done():
+			}
+			s.handshakeDone()
+		}()
+	}
 	go s.handleNewSession(sess)
 	return sess
 }
@@ -550,7 +1032,7 @@ func (s *baseServer) sendRetry(remoteAddr net.Addr, hdr *wire.Header) error {
 	// Log the Initial packet now.
 	// If no Retry is sent, the packet will be logged by the session.
 	(&wire.ExtendedHeader{Header: *hdr}).Log(s.logger)
-	srcConnID, err := protocol.GenerateConnectionID(s.config.ConnectionIDLength)
+	srcConnID, err := generateConnectionIDWithConfig(s.config)
 	if err != nil {
 		return err
 	}
@@ -587,7 +1069,7 @@ func (s *baseServer) maybeSendInvalidToken(p *receivedPacket, hdr *wire.Header)
 	// This makes sure that we won't send it for packets that were corrupted.
 	sealer, opener := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveServer)
 	data := p.data[:hdr.ParsedLen()+hdr.Length]
-	extHdr, err := unpackHeader(opener, hdr, data, hdr.Version)
+	extHdr, err := unpackHeader(opener, hdr, data, bytes.NewReader(data), hdr.Version)
 	if err != nil {
 		if s.config.Tracer != nil {
 			s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropHeaderParseError)
@@ -659,9 +1141,12 @@ func (s *baseServer) sendError(remoteAddr net.Addr, hdr *wire.Header, sealer han
 	return err
 }
 
-func (s *baseServer) sendVersionNegotiationPacket(p *receivedPacket, hdr *wire.Header) {
+func (s *baseServer) sendVersionNegotiationPacket(p *receivedPacket, hdr *wire.Header, versions []protocol.VersionNumber) {
 	s.logger.Debugf("Client offered version %s, sending Version Negotiation", hdr.Version)
-	data, err := wire.ComposeVersionNegotiation(hdr.SrcConnectionID, hdr.DestConnectionID, s.config.Versions)
+	if !s.config.DisableGreasing {
+		versions = protocol.GetGreasedVersions(versions)
+	}
+	data, err := wire.ComposeVersionNegotiation(hdr.SrcConnectionID, hdr.DestConnectionID, versions)
 	if err != nil {
 		s.logger.Debugf("Error composing Version Negotiation: %s", err)
 		return
@@ -0,0 +1,42 @@
+// +build !windows
+
+package quic
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Listening on a file descriptor", func() {
+	It("listens on an inherited socket", func() {
+		udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		f, err := udpConn.File()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(udpConn.Close()).To(Succeed())
+
+		tlsConf := testdata.GetTLSConfig()
+		tlsConf.NextProtos = []string{"proto1"}
+		ln, err := ListenFile(f, tlsConf, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+		Expect(ln.Addr()).To(Equal(udpConn.LocalAddr()))
+		Expect(ln.Close()).To(Succeed())
+		Eventually(areServersRunning).Should(BeFalse())
+	})
+
+	It("errors if the file is not a socket", func() {
+		f, err := ioutil.TempFile("", "quic-go")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(f.Name())
+		defer f.Close()
+		_, err = ListenFile(f, testdata.GetTLSConfig(), nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -14,6 +14,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/lucas-clemente/quic-go/internal/flowcontrol"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
@@ -161,6 +162,13 @@ var _ = Describe("Server", func() {
 		Expect(ln.Close()).To(Succeed())
 	})
 
+	It("creates a memory budget, if configured", func() {
+		ln, err := Listen(conn, tlsConf, &Config{ReceiveMemoryBudget: 1 << 20})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ln.(*baseServer).memoryBudget).ToNot(BeNil())
+		Expect(ln.Close()).To(Succeed())
+	})
+
 	It("listens on a given address", func() {
 		addr := "127.0.0.1:13579"
 		ln, err := ListenAddr(addr, tlsConf, &Config{})
@@ -243,6 +251,73 @@ var _ = Describe("Server", func() {
 				time.Sleep(50 * time.Millisecond)
 			})
 
+			It("passes the connection attempt to the connection filter", func() {
+				raddr := &net.UDPAddr{
+					IP:   net.IPv4(192, 168, 13, 37),
+					Port: 1337,
+				}
+				done := make(chan struct{})
+				serv.config.ConnectionFilter = func(addr net.Addr, version VersionNumber, destConnID []byte) ConnectionFilterResult {
+					Expect(addr).To(Equal(raddr))
+					Expect(version).To(Equal(serv.config.Versions[0]))
+					Expect(destConnID).To(Equal([]byte{8, 7, 6, 5, 4, 3, 2, 1}))
+					close(done)
+					return ConnectionFilterAccept
+				}
+				serv.config.AcceptToken = func(net.Addr, *Token) bool { return false }
+				packet := getPacket(&wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeInitial,
+					DestConnectionID: protocol.ConnectionID{8, 7, 6, 5, 4, 3, 2, 1},
+					Version:          serv.config.Versions[0],
+				}, make([]byte, protocol.MinInitialPacketSize))
+				packet.remoteAddr = raddr
+				conn.EXPECT().WriteTo(gomock.Any(), gomock.Any()).MaxTimes(1)
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).MaxTimes(1)
+				serv.handlePacket(packet)
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("drops Initial packets if the connection filter says so", func() {
+				serv.config.ConnectionFilter = func(net.Addr, VersionNumber, []byte) ConnectionFilterResult {
+					return ConnectionFilterDrop
+				}
+				serv.config.AcceptToken = func(net.Addr, *Token) bool {
+					Fail("didn't expect AcceptToken to be called")
+					return false
+				}
+				p := getInitialWithRandomDestConnID()
+				tracer.EXPECT().DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropDOSPrevention)
+				serv.handlePacket(p)
+				// make sure there are no Write calls on the packet conn
+				time.Sleep(50 * time.Millisecond)
+			})
+
+			It("rejects connection attempts if the connection filter says so", func() {
+				serv.config.ConnectionFilter = func(net.Addr, VersionNumber, []byte) ConnectionFilterResult {
+					return ConnectionFilterReject
+				}
+				serv.config.AcceptToken = func(net.Addr, *Token) bool {
+					Fail("didn't expect AcceptToken to be called")
+					return false
+				}
+				p := getInitialWithRandomDestConnID()
+				hdr, _, _, err := wire.ParsePacket(p.data, 0)
+				Expect(err).ToNot(HaveOccurred())
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				done := make(chan struct{})
+				conn.EXPECT().WriteTo(gomock.Any(), p.remoteAddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(done)
+					rejectHdr := parseHeader(b)
+					Expect(rejectHdr.Type).To(Equal(protocol.PacketTypeInitial))
+					Expect(rejectHdr.DestConnectionID).To(Equal(hdr.SrcConnectionID))
+					Expect(rejectHdr.SrcConnectionID).To(Equal(hdr.DestConnectionID))
+					return len(b), nil
+				})
+				serv.handlePacket(p)
+				Eventually(done).Should(BeClosed())
+			})
+
 			It("decodes the token from the Token field", func() {
 				raddr := &net.UDPAddr{
 					IP:   net.IPv4(192, 168, 13, 37),
@@ -298,7 +373,7 @@ var _ = Describe("Server", func() {
 			It("creates a session when the token is accepted", func() {
 				serv.config.AcceptToken = func(_ net.Addr, token *Token) bool { return true }
 				retryToken, err := serv.tokenGenerator.NewRetryToken(
-					&net.UDPAddr{},
+					&net.UDPAddr{IP: net.IPv4(4, 5, 6, 7), Port: 456},
 					protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde},
 					protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad},
 				)
@@ -340,11 +415,14 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					clientAddrValidated bool,
 					enable0RTT bool,
 					_ logging.ConnectionTracer,
 					_ utils.Logger,
 					_ protocol.VersionNumber,
 				) quicSession {
+					Expect(clientAddrValidated).To(BeTrue())
 					Expect(enable0RTT).To(BeFalse())
 					Expect(origDestConnID).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde}))
 					Expect(retrySrcConnID).To(Equal(&protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad}))
@@ -376,6 +454,61 @@ var _ = Describe("Server", func() {
 				Eventually(done).Should(BeClosed())
 			})
 
+			It("doesn't consider the client's address validated if the token was issued to a different address", func() {
+				serv.config.AcceptToken = func(_ net.Addr, token *Token) bool { return true }
+				retryToken, err := serv.tokenGenerator.NewRetryToken(
+					&net.UDPAddr{IP: net.IPv4(8, 9, 10, 11), Port: 456},
+					protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde},
+					protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad},
+				)
+				Expect(err).ToNot(HaveOccurred())
+				hdr := &wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeInitial,
+					SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+					DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+					Version:          protocol.VersionTLS,
+					Token:            retryToken,
+				}
+				p := getPacket(hdr, make([]byte, protocol.MinInitialPacketSize))
+				run := make(chan struct{})
+				phm.EXPECT().AddWithConnID(protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(gomock.Any())
+					fn()
+					return true
+				})
+				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde})
+				sess := NewMockQuicSession(mockCtrl)
+				serv.newSession = func(
+					_ sendConn,
+					_ sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					clientAddrValidated bool,
+					_ bool,
+					_ logging.ConnectionTracer,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					Expect(clientAddrValidated).To(BeFalse())
+					sess.EXPECT().handlePacket(p)
+					sess.EXPECT().run().Do(func() { close(run) })
+					sess.EXPECT().Context().Return(context.Background())
+					sess.EXPECT().HandshakeComplete().Return(context.Background())
+					return sess
+				}
+				serv.handlePacket(p)
+				Eventually(run).Should(BeClosed())
+			})
+
 			It("sends a Version Negotiation Packet for unsupported versions", func() {
 				srcConnID := protocol.ConnectionID{1, 2, 3, 4, 5}
 				destConnID := protocol.ConnectionID{1, 2, 3, 4, 5, 6}
@@ -403,6 +536,74 @@ var _ = Describe("Server", func() {
 					Expect(hdr.DestConnectionID).To(Equal(srcConnID))
 					Expect(hdr.SrcConnectionID).To(Equal(destConnID))
 					Expect(versions).ToNot(ContainElement(protocol.VersionNumber(0x42)))
+					Expect(versions).To(HaveLen(len(serv.config.Versions) + 1)) // one reserved version number
+					return len(b), nil
+				})
+				serv.handlePacket(packet)
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("doesn't add a reserved version number, if greasing is disabled", func() {
+				serv.config.DisableGreasing = true
+				srcConnID := protocol.ConnectionID{1, 2, 3, 4, 5}
+				destConnID := protocol.ConnectionID{1, 2, 3, 4, 5, 6}
+				packet := getPacket(&wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeHandshake,
+					SrcConnectionID:  srcConnID,
+					DestConnectionID: destConnID,
+					Version:          0x42,
+				}, make([]byte, protocol.MinUnknownVersionPacketSize))
+				raddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1337}
+				packet.remoteAddr = raddr
+				tracer.EXPECT().SentPacket(packet.remoteAddr, gomock.Any(), gomock.Any(), nil).Do(func(_ net.Addr, replyHdr *logging.Header, _ logging.ByteCount, _ []logging.Frame) {
+					Expect(replyHdr.IsLongHeader).To(BeTrue())
+					Expect(replyHdr.Version).To(BeZero())
+					Expect(replyHdr.SrcConnectionID).To(Equal(destConnID))
+					Expect(replyHdr.DestConnectionID).To(Equal(srcConnID))
+				})
+				done := make(chan struct{})
+				conn.EXPECT().WriteTo(gomock.Any(), raddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(done)
+					Expect(wire.IsVersionNegotiationPacket(b)).To(BeTrue())
+					hdr, versions, err := wire.ParseVersionNegotiationPacket(bytes.NewReader(b))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(hdr.DestConnectionID).To(Equal(srcConnID))
+					Expect(hdr.SrcConnectionID).To(Equal(destConnID))
+					Expect(versions).To(Equal(serv.config.Versions))
+					return len(b), nil
+				})
+				serv.handlePacket(packet)
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("only offers the versions returned by the OfferedVersions callback", func() {
+				serv.config.DisableGreasing = true
+				serv.config.Versions = []protocol.VersionNumber{protocol.VersionDraft32, protocol.VersionDraft29}
+				raddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1337}
+				serv.config.OfferedVersions = func(addr net.Addr) []protocol.VersionNumber {
+					Expect(addr).To(Equal(raddr))
+					// versions not contained in Config.Versions are ignored
+					return []protocol.VersionNumber{0x1337, protocol.VersionDraft29}
+				}
+				srcConnID := protocol.ConnectionID{1, 2, 3, 4, 5}
+				destConnID := protocol.ConnectionID{1, 2, 3, 4, 5, 6}
+				packet := getPacket(&wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeHandshake,
+					SrcConnectionID:  srcConnID,
+					DestConnectionID: destConnID,
+					Version:          protocol.VersionDraft32,
+				}, make([]byte, protocol.MinUnknownVersionPacketSize))
+				packet.remoteAddr = raddr
+				tracer.EXPECT().SentPacket(packet.remoteAddr, gomock.Any(), gomock.Any(), nil)
+				done := make(chan struct{})
+				conn.EXPECT().WriteTo(gomock.Any(), raddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(done)
+					Expect(wire.IsVersionNegotiationPacket(b)).To(BeTrue())
+					_, versions, err := wire.ParseVersionNegotiationPacket(bytes.NewReader(b))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(versions).To(Equal([]protocol.VersionNumber{protocol.VersionDraft29}))
 					return len(b), nil
 				})
 				serv.handlePacket(packet)
@@ -521,7 +722,7 @@ var _ = Describe("Server", func() {
 					Expect(replyHdr.SrcConnectionID).To(Equal(hdr.DestConnectionID))
 					Expect(replyHdr.DestConnectionID).To(Equal(hdr.SrcConnectionID))
 					_, opener := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveClient)
-					extHdr, err := unpackHeader(opener, replyHdr, b, hdr.Version)
+					extHdr, err := unpackHeader(opener, replyHdr, b, bytes.NewReader(b), hdr.Version)
 					Expect(err).ToNot(HaveOccurred())
 					data, err := opener.Open(nil, b[extHdr.ParsedLen():], extHdr.PacketNumber, b[:extHdr.ParsedLen()])
 					Expect(err).ToNot(HaveOccurred())
@@ -597,11 +798,14 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					clientAddrValidated bool,
 					enable0RTT bool,
 					_ logging.ConnectionTracer,
 					_ utils.Logger,
 					_ protocol.VersionNumber,
 				) quicSession {
+					Expect(clientAddrValidated).To(BeFalse())
 					Expect(enable0RTT).To(BeFalse())
 					Expect(origDestConnID).To(Equal(hdr.DestConnectionID))
 					Expect(retrySrcConnID).To(BeNil())
@@ -665,6 +869,8 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ utils.Logger,
@@ -710,6 +916,8 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ utils.Logger,
@@ -763,6 +971,8 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ utils.Logger,
@@ -793,6 +1003,8 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ utils.Logger,
@@ -845,13 +1057,33 @@ var _ = Describe("Server", func() {
 				Eventually(done).Should(BeClosed())
 			})
 
-			It("doesn't accept new sessions if they were closed in the mean time", func() {
+			It("rejects new connection attempts if the connection limit is reached", func() {
 				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+				serv.connLimiter = newConnectionLimiter(0, 1, 64, 0, 0)
+				p := getInitialWithRandomDestConnID()
+				Expect(serv.connLimiter.Allow(p.remoteAddr, time.Now())).To(Equal(limitOK))
+				hdr, _, _, err := wire.ParsePacket(p.data, 0)
+				Expect(err).ToNot(HaveOccurred())
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				done := make(chan struct{})
+				conn.EXPECT().WriteTo(gomock.Any(), p.remoteAddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(done)
+					rejectHdr := parseHeader(b)
+					Expect(rejectHdr.Type).To(Equal(protocol.PacketTypeInitial))
+					Expect(rejectHdr.DestConnectionID).To(Equal(hdr.SrcConnectionID))
+					Expect(rejectHdr.SrcConnectionID).To(Equal(hdr.DestConnectionID))
+					return len(b), nil
+				})
+				serv.handlePacket(p)
+				Eventually(done).Should(BeClosed())
+				phm.EXPECT().ReceiveBufferDrops()
+				Expect(serv.Stats().RejectedHandshakes).To(BeEquivalentTo(1))
+			})
 
-				p := getInitial(protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
-				ctx, cancel := context.WithCancel(context.Background())
-				sessionCreated := make(chan struct{})
-				sess := NewMockQuicSession(mockCtrl)
+			It("releases the connection limit when a session is closed", func() {
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+				serv.connLimiter = newConnectionLimiter(0, 1, 64, 0, 0)
+				run := make(chan struct{})
 				serv.newSession = func(
 					_ sendConn,
 					runner sessionRunner,
@@ -864,56 +1096,533 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ utils.Logger,
 					_ protocol.VersionNumber,
 				) quicSession {
-					sess.EXPECT().handlePacket(p)
-					sess.EXPECT().run()
-					sess.EXPECT().Context().Return(ctx)
+					sess := NewMockQuicSession(mockCtrl)
+					sess.EXPECT().handlePacket(gomock.Any())
+					sess.EXPECT().run().Do(func() { <-run })
+					sess.EXPECT().Context().Return(context.Background())
 					ctx, cancel := context.WithCancel(context.Background())
 					cancel()
 					sess.EXPECT().HandshakeComplete().Return(ctx)
-					close(sessionCreated)
 					return sess
 				}
-
 				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
 					phm.EXPECT().GetStatelessResetToken(gomock.Any())
 					fn()
 					return true
 				})
 				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
+				p := getInitialWithRandomDestConnID()
+				serv.handlePacket(p)
+				Eventually(func() bool {
+					serv.mutex.Lock()
+					defer serv.mutex.Unlock()
+					return len(serv.sessions) == 1
+				}).Should(BeTrue())
+				Expect(serv.connLimiter.Allow(p.remoteAddr, time.Now())).To(Equal(limitReached))
+				close(run)
+				Eventually(func() bool {
+					serv.mutex.Lock()
+					defer serv.mutex.Unlock()
+					return len(serv.sessions) == 0
+				}).Should(BeTrue())
+				Expect(serv.connLimiter.Allow(p.remoteAddr, time.Now())).To(Equal(limitOK))
+			})
 
+			It("releases the handshake slot when the handshake completes", func() {
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+				serv.config.MaxConcurrentHandshakes = 1
+				serv.connLimiter = newConnectionLimiter(0, 0, 64, 0, 1)
+				run := make(chan struct{})
+				defer close(run)
+				handshakeCtx, handshakeComplete := context.WithCancel(context.Background())
+				serv.newSession = func(
+					_ sendConn,
+					runner sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
+					_ bool,
+					_ logging.ConnectionTracer,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					sess := NewMockQuicSession(mockCtrl)
+					sess.EXPECT().handlePacket(gomock.Any())
+					sess.EXPECT().run().Do(func() { <-run })
+					sess.EXPECT().Context().Return(context.Background()).AnyTimes()
+					sess.EXPECT().HandshakeComplete().Return(handshakeCtx).AnyTimes()
+					return sess
+				}
+				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(gomock.Any())
+					fn()
+					return true
+				})
+				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
+				p := getInitialWithRandomDestConnID()
 				serv.handlePacket(p)
-				// make sure there are no Write calls on the packet conn
-				time.Sleep(50 * time.Millisecond)
-				Eventually(sessionCreated).Should(BeClosed())
-				cancel()
-				time.Sleep(scaleDuration(200 * time.Millisecond))
+				Eventually(func() bool {
+					serv.mutex.Lock()
+					defer serv.mutex.Unlock()
+					return len(serv.sessions) == 1
+				}).Should(BeTrue())
+				Expect(serv.connLimiter.Allow(p.remoteAddr, time.Now())).To(Equal(limitHandshakesReached))
+				handshakeComplete()
+				Eventually(func() limitResult { return serv.connLimiter.Allow(p.remoteAddr, time.Now()) }).Should(Equal(limitOK))
+			})
 
+			It("validates the token before checking the connection limits", func() {
+				serv.config.AcceptToken = func(net.Addr, *Token) bool { return false }
+				serv.connLimiter = newConnectionLimiter(0, 1, 64, 0, 0)
+				p := getInitialWithRandomDestConnID()
+				Expect(serv.connLimiter.Allow(p.remoteAddr, time.Now())).To(Equal(limitOK))
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 				done := make(chan struct{})
-				go func() {
-					defer GinkgoRecover()
-					serv.Accept(context.Background())
-					close(done)
-				}()
-				Consistently(done).ShouldNot(BeClosed())
-
-				// make the go routine return
-				phm.EXPECT().CloseServer()
-				sess.EXPECT().getPerspective().MaxTimes(2) // once for every conn ID
-				Expect(serv.Close()).To(Succeed())
+				conn.EXPECT().WriteTo(gomock.Any(), p.remoteAddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(done)
+					Expect(parseHeader(b).Type).To(Equal(protocol.PacketTypeRetry))
+					return len(b), nil
+				})
+				serv.handlePacket(p)
 				Eventually(done).Should(BeClosed())
 			})
-		})
-
-		Context("accepting sessions", func() {
-			It("returns Accept when an error occurs", func() {
-				testErr := errors.New("test err")
 
-				done := make(chan struct{})
+			It("doesn't charge the connection limits if the token is not accepted", func() {
+				serv.config.AcceptToken = func(net.Addr, *Token) bool { return false }
+				serv.config.MaxConcurrentHandshakes = 1
+				serv.connLimiter = newConnectionLimiter(0, 1, 64, 1, 1)
+				p := getInitialWithRandomDestConnID()
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				done := make(chan struct{})
+				conn.EXPECT().WriteTo(gomock.Any(), p.remoteAddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(done)
+					Expect(parseHeader(b).Type).To(Equal(protocol.PacketTypeRetry))
+					return len(b), nil
+				})
+				serv.handlePacket(p)
+				Eventually(done).Should(BeClosed())
+				Expect(serv.connLimiter.Allow(p.remoteAddr, time.Now())).To(Equal(limitOK))
+			})
+
+			It("accepts a client with a valid token during a flood of Initials without a token", func() {
+				serv.config.AcceptToken = defaultAcceptToken
+				serv.config.MaxHandshakesPerSecond = 1
+				serv.config.MaxConcurrentHandshakes = 1
+				serv.config.MaxQueuedHandshakes = 1
+				serv.connLimiter = newConnectionLimiter(0, 0, 64, 1, 1)
+				run := make(chan struct{})
+				defer close(run)
+				serv.newSession = func(
+					_ sendConn,
+					runner sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					clientAddressValidated bool,
+					_ bool,
+					_ logging.ConnectionTracer,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					Expect(clientAddressValidated).To(BeTrue())
+					sess := NewMockQuicSession(mockCtrl)
+					sess.EXPECT().handlePacket(gomock.Any())
+					sess.EXPECT().run().Do(func() { <-run })
+					sess.EXPECT().Context().Return(context.Background()).AnyTimes()
+					sess.EXPECT().HandshakeComplete().Return(context.Background()).AnyTimes()
+					return sess
+				}
+				const numSpoofed = 10
+				var retries int32
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(numSpoofed)
+				conn.EXPECT().WriteTo(gomock.Any(), gomock.Any()).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					Expect(parseHeader(b).Type).To(Equal(protocol.PacketTypeRetry))
+					atomic.AddInt32(&retries, 1)
+					return len(b), nil
+				}).Times(numSpoofed)
+				for i := 0; i < numSpoofed; i++ {
+					serv.handlePacket(getInitialWithRandomDestConnID())
+				}
+				Eventually(func() int32 { return atomic.LoadInt32(&retries) }).Should(BeEquivalentTo(numSpoofed))
+
+				raddr := &net.UDPAddr{IP: net.IPv4(192, 168, 13, 37), Port: 1337}
+				token, err := serv.tokenGenerator.NewRetryToken(raddr, protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef, 0xca, 0xfe, 0x13, 0x37}, protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad})
+				Expect(err).ToNot(HaveOccurred())
+				p := getPacket(&wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeInitial,
+					SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+					DestConnectionID: protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad},
+					Token:            token,
+					Version:          protocol.VersionTLS,
+				}, make([]byte, protocol.MinInitialPacketSize))
+				p.remoteAddr = raddr
+				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(gomock.Any())
+					fn()
+					return true
+				})
+				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
+				serv.handlePacket(p)
+				Eventually(func() int {
+					serv.mutex.Lock()
+					defer serv.mutex.Unlock()
+					return len(serv.sessions)
+				}).Should(Equal(1))
+			})
+
+			It("queues connection attempts if the handshake limit is reached", func() {
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+				serv.config.MaxConcurrentHandshakes = 1
+				serv.config.MaxQueuedHandshakes = 1
+				serv.connLimiter = newConnectionLimiter(0, 0, 64, 0, 1)
+				run := make(chan struct{})
+				defer close(run)
+				handshakeCtx, handshakeComplete := context.WithCancel(context.Background())
+				serv.newSession = func(
+					_ sendConn,
+					runner sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
+					_ bool,
+					_ logging.ConnectionTracer,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					sess := NewMockQuicSession(mockCtrl)
+					sess.EXPECT().handlePacket(gomock.Any())
+					sess.EXPECT().run().Do(func() { <-run })
+					sess.EXPECT().Context().Return(context.Background()).AnyTimes()
+					sess.EXPECT().HandshakeComplete().Return(handshakeCtx).AnyTimes()
+					return sess
+				}
+				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(gomock.Any())
+					fn()
+					return true
+				}).Times(2)
+				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any()).Times(2)
+				numSessions := func() int {
+					serv.mutex.Lock()
+					defer serv.mutex.Unlock()
+					return len(serv.sessions)
+				}
+
+				serv.handlePacket(getInitialWithRandomDestConnID())
+				Eventually(numSessions).Should(Equal(1))
+				// this connection attempt is queued
+				serv.handlePacket(getInitial(protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}))
+				// a retransmission of the queued Initial is dropped
+				dropped := make(chan struct{})
+				tracer.EXPECT().DroppedPacket(gomock.Any(), logging.PacketTypeInitial, gomock.Any(), logging.PacketDropDuplicate).Do(func(net.Addr, logging.PacketType, logging.ByteCount, logging.PacketDropReason) { close(dropped) })
+				serv.handlePacket(getInitial(protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}))
+				Eventually(dropped).Should(BeClosed())
+				// the queue is full, this connection attempt is rejected
+				p := getInitialWithRandomDestConnID()
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				refused := make(chan struct{})
+				conn.EXPECT().WriteTo(gomock.Any(), p.remoteAddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(refused)
+					Expect(parseHeader(b).Type).To(Equal(protocol.PacketTypeInitial))
+					return len(b), nil
+				})
+				serv.handlePacket(p)
+				Eventually(refused).Should(BeClosed())
+				Consistently(numSessions).Should(Equal(1))
+				// the queued connection attempt is started when the first handshake completes
+				handshakeComplete()
+				Eventually(numSessions).Should(Equal(2))
+			})
+
+			It("drops queued connection attempts after the handshake timeout", func() {
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+				serv.config.HandshakeTimeout = scaleDuration(20 * time.Millisecond)
+				serv.config.MaxConcurrentHandshakes = 1
+				serv.config.MaxQueuedHandshakes = 1
+				serv.connLimiter = newConnectionLimiter(0, 0, 64, 0, 1)
+				run := make(chan struct{})
+				defer close(run)
+				handshakeCtx, handshakeComplete := context.WithCancel(context.Background())
+				serv.newSession = func(
+					_ sendConn,
+					runner sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
+					_ bool,
+					_ logging.ConnectionTracer,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					sess := NewMockQuicSession(mockCtrl)
+					sess.EXPECT().handlePacket(gomock.Any())
+					sess.EXPECT().run().Do(func() { <-run })
+					sess.EXPECT().Context().Return(context.Background()).AnyTimes()
+					sess.EXPECT().HandshakeComplete().Return(handshakeCtx).AnyTimes()
+					return sess
+				}
+				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(gomock.Any())
+					fn()
+					return true
+				})
+				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
+				numSessions := func() int {
+					serv.mutex.Lock()
+					defer serv.mutex.Unlock()
+					return len(serv.sessions)
+				}
+
+				serv.handlePacket(getInitialWithRandomDestConnID())
+				Eventually(numSessions).Should(Equal(1))
+				// this connection attempt is queued
+				serv.handlePacket(getInitialWithRandomDestConnID())
+				time.Sleep(scaleDuration(30 * time.Millisecond))
+				dropped := make(chan struct{})
+				tracer.EXPECT().DroppedPacket(gomock.Any(), logging.PacketTypeInitial, gomock.Any(), logging.PacketDropDOSPrevention).Do(func(net.Addr, logging.PacketType, logging.ByteCount, logging.PacketDropReason) { close(dropped) })
+				handshakeComplete()
+				Eventually(dropped).Should(BeClosed())
+				Consistently(numSessions).Should(Equal(1))
+				phm.EXPECT().ReceiveBufferDrops()
+				Expect(serv.Stats().RejectedHandshakes).To(BeEquivalentTo(1))
+			})
+
+			It("uses the configured accept queue size", func() {
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+				serv.config.MaxAcceptQueueSize = 5
+
+				serv.newSession = func(
+					_ sendConn,
+					runner sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
+					_ bool,
+					_ logging.ConnectionTracer,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					sess := NewMockQuicSession(mockCtrl)
+					sess.EXPECT().handlePacket(gomock.Any())
+					sess.EXPECT().run()
+					sess.EXPECT().Context().Return(context.Background())
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+					sess.EXPECT().HandshakeComplete().Return(ctx)
+					return sess
+				}
+
+				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(gomock.Any())
+					fn()
+					return true
+				}).Times(5)
+				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any()).Times(5)
+
+				var wg sync.WaitGroup
+				wg.Add(5)
+				for i := 0; i < 5; i++ {
+					go func() {
+						defer GinkgoRecover()
+						defer wg.Done()
+						serv.handlePacket(getInitialWithRandomDestConnID())
+						// make sure there are no Write calls on the packet conn
+						time.Sleep(50 * time.Millisecond)
+					}()
+				}
+				wg.Wait()
+				p := getInitialWithRandomDestConnID()
+				hdr, _, _, err := wire.ParsePacket(p.data, 0)
+				Expect(err).ToNot(HaveOccurred())
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				done := make(chan struct{})
+				conn.EXPECT().WriteTo(gomock.Any(), p.remoteAddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(done)
+					rejectHdr := parseHeader(b)
+					Expect(rejectHdr.Type).To(Equal(protocol.PacketTypeInitial))
+					Expect(rejectHdr.Version).To(Equal(hdr.Version))
+					Expect(rejectHdr.DestConnectionID).To(Equal(hdr.SrcConnectionID))
+					Expect(rejectHdr.SrcConnectionID).To(Equal(hdr.DestConnectionID))
+					return len(b), nil
+				})
+				serv.handlePacket(p)
+				Eventually(done).Should(BeClosed())
+				phm.EXPECT().ReceiveBufferDrops()
+				Expect(serv.Stats().AcceptQueueOverflows).To(BeEquivalentTo(1))
+			})
+
+			It("drops new connection attempts if the accept queue is full, if configured to do so", func() {
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+				serv.config.MaxAcceptQueueSize = 1
+				serv.config.AcceptQueueOverflowPolicy = OverflowDrop
+
+				serv.newSession = func(
+					_ sendConn,
+					runner sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
+					_ bool,
+					_ logging.ConnectionTracer,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					sess := NewMockQuicSession(mockCtrl)
+					sess.EXPECT().handlePacket(gomock.Any())
+					sess.EXPECT().run()
+					sess.EXPECT().Context().Return(context.Background())
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+					sess.EXPECT().HandshakeComplete().Return(ctx)
+					return sess
+				}
+
+				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(gomock.Any())
+					fn()
+					return true
+				})
+				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
+				serv.handlePacket(getInitialWithRandomDestConnID())
+				Eventually(func() int32 { return atomic.LoadInt32(&serv.sessionQueueLen) }).Should(BeEquivalentTo(1))
+
+				p := getInitialWithRandomDestConnID()
+				done := make(chan struct{})
+				tracer.EXPECT().DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropDOSPrevention).Do(func(net.Addr, logging.PacketType, logging.ByteCount, logging.PacketDropReason) { close(done) })
+				serv.handlePacket(p)
+				Eventually(done).Should(BeClosed())
+				// make sure there are no Write calls on the packet conn
+				time.Sleep(50 * time.Millisecond)
+				phm.EXPECT().ReceiveBufferDrops()
+				Expect(serv.Stats().AcceptQueueOverflows).To(BeEquivalentTo(1))
+			})
+
+			It("doesn't accept new sessions if they were closed in the mean time", func() {
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+
+				p := getInitial(protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+				ctx, cancel := context.WithCancel(context.Background())
+				sessionCreated := make(chan struct{})
+				sess := NewMockQuicSession(mockCtrl)
+				serv.newSession = func(
+					_ sendConn,
+					runner sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
+					_ bool,
+					_ logging.ConnectionTracer,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					sess.EXPECT().handlePacket(p)
+					sess.EXPECT().run()
+					sess.EXPECT().Context().Return(ctx)
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+					sess.EXPECT().HandshakeComplete().Return(ctx)
+					close(sessionCreated)
+					return sess
+				}
+
+				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(gomock.Any())
+					fn()
+					return true
+				})
+				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
+
+				serv.handlePacket(p)
+				// make sure there are no Write calls on the packet conn
+				time.Sleep(50 * time.Millisecond)
+				Eventually(sessionCreated).Should(BeClosed())
+				cancel()
+				time.Sleep(scaleDuration(200 * time.Millisecond))
+
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					serv.Accept(context.Background())
+					close(done)
+				}()
+				Consistently(done).ShouldNot(BeClosed())
+
+				// make the go routine return
+				phm.EXPECT().CloseServer()
+				sess.EXPECT().getPerspective().MaxTimes(2) // once for every conn ID
+				Expect(serv.Close()).To(Succeed())
+				Eventually(done).Should(BeClosed())
+			})
+		})
+
+		Context("accepting sessions", func() {
+			It("returns Accept when an error occurs", func() {
+				testErr := errors.New("test err")
+
+				done := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
 					_, err := serv.Accept(context.Background())
@@ -974,6 +1683,8 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ utils.Logger,
@@ -990,12 +1701,205 @@ var _ = Describe("Server", func() {
 					return true
 				})
 				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
-				serv.createNewSession(&net.UDPAddr{}, nil, nil, nil, nil, nil, protocol.VersionWhatever)
+				serv.mutex.Lock()
+				serv.createNewSession(&net.UDPAddr{}, nil, nil, nil, nil, nil, false, protocol.VersionWhatever, nil)
+				serv.mutex.Unlock()
 				Consistently(done).ShouldNot(BeClosed())
 				cancel() // complete the handshake
 				Eventually(done).Should(BeClosed())
 			})
 		})
+
+		It("reports the number of packets dropped because the receive buffer was full", func() {
+			phm.EXPECT().ReceiveBufferDrops().Return(uint64(42))
+			Expect(serv.Stats().ReceiveBufferDrops).To(BeEquivalentTo(42))
+		})
+
+		Context("closing idle sessions", func() {
+			addSession := func(lastActivity time.Time, handshakeComplete bool) *MockQuicSession {
+				sess := NewMockQuicSession(mockCtrl)
+				sess.EXPECT().lastActivity().Return(lastActivity).AnyTimes()
+				ctx := context.Background()
+				if handshakeComplete {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithCancel(ctx)
+					cancel()
+				}
+				sess.EXPECT().HandshakeComplete().Return(ctx).AnyTimes()
+				serv.mutex.Lock()
+				serv.sessions[sess] = sessionInfo{remoteAddr: &net.UDPAddr{}}
+				serv.mutex.Unlock()
+				return sess
+			}
+
+			It("closes sessions that have been idle for too long", func() {
+				idle := addSession(time.Now().Add(-time.Hour), true)
+				addSession(time.Now(), true)
+				idle.EXPECT().CloseWithError(ErrorCode(0), gomock.Any())
+				Expect(serv.CloseIdleSessions(time.Minute)).To(Equal(1))
+			})
+
+			It("counts sessions by state", func() {
+				addSession(time.Now(), true)
+				addSession(time.Now(), true)
+				addSession(time.Now(), false)
+				Expect(serv.NumSessions()).To(Equal(SessionCount{Handshaking: 1, Established: 2}))
+			})
+
+			It("enables debug logging for a session", func() {
+				sess := NewMockQuicSession(mockCtrl)
+				serv.mutex.Lock()
+				serv.sessions[sess] = sessionInfo{remoteAddr: &net.UDPAddr{}, connID: protocol.ConnectionID{1, 2, 3, 4}}
+				serv.mutex.Unlock()
+				Expect(serv.SetDebugLogging([]byte{4, 3, 2, 1}, true)).To(BeFalse())
+				sess.EXPECT().setDebugLogging(true)
+				Expect(serv.SetDebugLogging([]byte{1, 2, 3, 4}, true)).To(BeTrue())
+			})
+		})
+
+		Context("shutting down", func() {
+			newRunningSession := func() (*MockQuicSession, context.CancelFunc) {
+				sess := NewMockQuicSession(mockCtrl)
+				sessCtx, sessCancel := context.WithCancel(context.Background())
+				serv.newSession = func(
+					_ sendConn,
+					runner sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
+					_ bool,
+					_ logging.ConnectionTracer,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					sess.EXPECT().run().DoAndReturn(func() error {
+						<-sessCtx.Done()
+						return nil
+					})
+					sess.EXPECT().Context().Return(sessCtx)
+					sess.EXPECT().HandshakeComplete().Return(context.Background())
+					return sess
+				}
+				phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+					phm.EXPECT().GetStatelessResetToken(gomock.Any())
+					fn()
+					return true
+				})
+				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
+				serv.mutex.Lock()
+				Expect(serv.createNewSession(&net.UDPAddr{}, nil, nil, nil, nil, nil, false, protocol.VersionWhatever, nil)).ToNot(BeNil())
+				serv.mutex.Unlock()
+				return sess, sessCancel
+			}
+
+			It("closes the server if there are no active sessions", func() {
+				phm.EXPECT().CloseServer()
+				Expect(serv.Shutdown(context.Background())).To(Succeed())
+				_, err := serv.Accept(context.Background())
+				Expect(err).To(MatchError("server closed"))
+			})
+
+			It("waits until all sessions are closed", func() {
+				_, sessCancel := newRunningSession()
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(done)
+					Expect(serv.Shutdown(context.Background())).To(Succeed())
+				}()
+				Consistently(done).ShouldNot(BeClosed())
+				phm.EXPECT().CloseServer()
+				sessCancel()
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("closes the remaining sessions when the context is canceled", func() {
+				sess, sessCancel := newRunningSession()
+				ctx, cancel := context.WithCancel(context.Background())
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(done)
+					Expect(serv.Shutdown(ctx)).To(MatchError(context.Canceled))
+				}()
+				Consistently(done).ShouldNot(BeClosed())
+				sess.EXPECT().closeWithTransportError(qerr.NoError, "server shutting down").Do(func(qerr.ErrorCode, string) { sessCancel() })
+				phm.EXPECT().CloseServer()
+				cancel()
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("rejects new connection attempts while shutting down", func() {
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool { return true }
+				serv.shuttingDown = true
+				p := getInitialWithRandomDestConnID()
+				hdr, _, _, err := wire.ParsePacket(p.data, 0)
+				Expect(err).ToNot(HaveOccurred())
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				done := make(chan struct{})
+				conn.EXPECT().WriteTo(gomock.Any(), p.remoteAddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(done)
+					rejectHdr := parseHeader(b)
+					Expect(rejectHdr.Type).To(Equal(protocol.PacketTypeInitial))
+					Expect(rejectHdr.DestConnectionID).To(Equal(hdr.SrcConnectionID))
+					Expect(rejectHdr.SrcConnectionID).To(Equal(hdr.DestConnectionID))
+					return len(b), nil
+				})
+				serv.handlePacket(p)
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("rejects new connection attempts if the server starts shutting down while processing the Initial", func() {
+				// AcceptToken is called after the first check, but before the session is created
+				serv.config.AcceptToken = func(_ net.Addr, _ *Token) bool {
+					serv.mutex.Lock()
+					serv.shuttingDown = true
+					serv.mutex.Unlock()
+					return true
+				}
+				serv.newSession = func(
+					_ sendConn,
+					_ sessionRunner,
+					_ protocol.ConnectionID,
+					_ *protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.ConnectionID,
+					_ protocol.StatelessResetToken,
+					_ *Config,
+					_ *tls.Config,
+					_ *handshake.TokenGenerator,
+					_ *flowcontrol.MemoryBudget,
+					_ bool,
+					_ bool,
+					_ logging.ConnectionTracer,
+					_ utils.Logger,
+					_ protocol.VersionNumber,
+				) quicSession {
+					Fail("didn't expect a session to be created")
+					return nil
+				}
+				p := getInitialWithRandomDestConnID()
+				tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				done := make(chan struct{})
+				conn.EXPECT().WriteTo(gomock.Any(), p.remoteAddr).DoAndReturn(func(b []byte, _ net.Addr) (int, error) {
+					defer close(done)
+					Expect(parseHeader(b).Type).To(Equal(protocol.PacketTypeInitial))
+					return len(b), nil
+				})
+				serv.handlePacket(p)
+				Eventually(done).Should(BeClosed())
+				Expect(serv.sessions).To(BeEmpty())
+			})
+		})
 	})
 
 	Context("server accepting sessions that haven't completed the handshake", func() {
@@ -1042,6 +1946,8 @@ var _ = Describe("Server", func() {
 				_ *Config,
 				_ *tls.Config,
 				_ *handshake.TokenGenerator,
+				_ *flowcontrol.MemoryBudget,
+				_ bool,
 				enable0RTT bool,
 				_ logging.ConnectionTracer,
 				_ utils.Logger,
@@ -1058,7 +1964,9 @@ var _ = Describe("Server", func() {
 				fn()
 				return true
 			})
-			serv.createNewSession(&net.UDPAddr{}, nil, nil, nil, nil, nil, protocol.VersionWhatever)
+			serv.mutex.Lock()
+			serv.createNewSession(&net.UDPAddr{}, nil, nil, nil, nil, nil, false, protocol.VersionWhatever, nil)
+			serv.mutex.Unlock()
 			Consistently(done).ShouldNot(BeClosed())
 			close(ready)
 			Eventually(done).Should(BeClosed())
@@ -1080,6 +1988,8 @@ var _ = Describe("Server", func() {
 				_ *Config,
 				_ *tls.Config,
 				_ *handshake.TokenGenerator,
+				_ *flowcontrol.MemoryBudget,
+				_ bool,
 				_ bool,
 				_ logging.ConnectionTracer,
 				_ utils.Logger,
@@ -1143,6 +2053,8 @@ var _ = Describe("Server", func() {
 				_ *Config,
 				_ *tls.Config,
 				_ *handshake.TokenGenerator,
+				_ *flowcontrol.MemoryBudget,
+				_ bool,
 				_ bool,
 				_ logging.ConnectionTracer,
 				_ utils.Logger,
@@ -9,10 +9,13 @@ import (
 	"io"
 	"net"
 	"reflect"
+	"runtime/pprof"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/flowcontrol"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/logutils"
@@ -26,6 +29,7 @@ import (
 
 type unpacker interface {
 	Unpack(hdr *wire.Header, rcvTime time.Time, data []byte) (*unpackedPacket, error)
+	SetVersion(protocol.VersionNumber)
 }
 
 type streamGetter interface {
@@ -45,6 +49,7 @@ type streamManager interface {
 	DeleteStream(protocol.StreamID) error
 	UpdateLimits(*wire.TransportParameters) error
 	HandleMaxStreamsFrame(*wire.MaxStreamsFrame) error
+	SetVersion(protocol.VersionNumber)
 	CloseWithError(error)
 }
 
@@ -125,6 +130,10 @@ func (errCloseForRecreating) Is(target error) bool {
 
 // A Session is a QUIC session
 type session struct {
+	// 64 bit values accessed atomically must be the first fields, for alignment on 32 bit platforms
+	lastActivityTime int64 // the same as lastPacketReceivedTime (as UnixNano), but safe to access from other goroutines
+	stats            connectionStats
+
 	// Destination connection ID used during the handshake.
 	// Used to check source connection ID on incoming packets.
 	handshakeDestConnID protocol.ConnectionID
@@ -139,14 +148,21 @@ type session struct {
 	version        protocol.VersionNumber
 	config         *Config
 
+	// only set if the server upgraded the connection to a compatible version during the handshake
+	preUpgradeVersion protocol.VersionNumber
+
 	conn      sendConn
 	sendQueue *sendQueue
 
 	streamsMap      streamManager
 	connIDManager   *connIDManager
 	connIDGenerator *connIDGenerator
+	spinBit         *spinBit
 
 	rttStats *utils.RTTStats
+	// The clock is updated once per iteration of the run loop.
+	// It is only accessed from the run loop.
+	clock *congestion.CachedClock
 
 	cryptoStreamManager   *cryptoStreamManager
 	sentPacketHandler     ackhandler.SentPacketHandler
@@ -157,11 +173,14 @@ type session struct {
 	connFlowController    flowcontrol.ConnectionFlowController
 	tokenStoreKey         string                    // only set for the client
 	tokenGenerator        *handshake.TokenGenerator // only set for the server
+	memoryBudget          *flowcontrol.MemoryBudget // only set for the server
 
 	unpacker    unpacker
 	frameParser wire.FrameParser
 	packer      packer
 
+	pinger *pinger
+
 	oneRTTStream        cryptoStream // only set for the server
 	cryptoStreamHandler cryptoStreamHandler
 
@@ -172,6 +191,10 @@ type session struct {
 	// closeChan is used to notify the run loop that it should terminate
 	closeChan chan closeError
 
+	// closeReason is the error the session was closed with.
+	// It is set before ctx is canceled.
+	closeReason error
+
 	ctx                context.Context
 	ctxCancel          context.CancelFunc
 	handshakeCtx       context.Context
@@ -191,6 +214,7 @@ type session struct {
 
 	idleTimeout         time.Duration
 	sessionCreationTime time.Time
+	connIDsRotatedAt    time.Time
 	// The idle timeout is set based on the max of the time we received the last packet...
 	lastPacketReceivedTime time.Time
 	// ... and the time we sent a new ack-eliciting packet after receiving a packet.
@@ -208,9 +232,16 @@ type session struct {
 
 	traceCallback func(quictrace.Event)
 
+	// the context holding the pprof labels of the run loop goroutine
+	pprofCtx context.Context
+
 	logID  string
 	tracer logging.ConnectionTracer
 	logger utils.Logger
+	// an application-defined ID, added to all log messages
+	correlationID *utils.AtomicString
+	// allows enabling debug logging for this session at runtime
+	debugLogger *utils.DebugToggleLogger
 }
 
 var (
@@ -231,29 +262,36 @@ var newSession = func(
 	conf *Config,
 	tlsConf *tls.Config,
 	tokenGenerator *handshake.TokenGenerator,
+	memoryBudget *flowcontrol.MemoryBudget,
+	clientAddressValidated bool,
 	enable0RTT bool,
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 	v protocol.VersionNumber,
 ) quicSession {
+	logConnID := destConnID
+	if origDestConnID != nil {
+		logConnID = origDestConnID
+	}
+	correlationID := &utils.AtomicString{}
+	debugLogger := newSessionLogger(logger, protocol.PerspectiveServer, logConnID, correlationID)
 	s := &session{
 		conn:                  conn,
 		config:                conf,
 		handshakeDestConnID:   destConnID,
 		srcConnIDLen:          srcConnID.Len(),
 		tokenGenerator:        tokenGenerator,
+		memoryBudget:          memoryBudget,
 		oneRTTStream:          newCryptoStream(),
 		perspective:           protocol.PerspectiveServer,
 		handshakeCompleteChan: make(chan struct{}),
 		tracer:                tracer,
-		logger:                logger,
+		logID:                 logConnID.String(),
+		logger:                debugLogger,
+		debugLogger:           debugLogger,
+		correlationID:         correlationID,
 		version:               v,
 	}
-	if origDestConnID != nil {
-		s.logID = origDestConnID.String()
-	} else {
-		s.logID = destConnID.String()
-	}
 	s.connIDManager = newConnIDManager(
 		destConnID,
 		func(token protocol.StatelessResetToken) { runner.AddResetToken(token, s) },
@@ -264,11 +302,21 @@ var newSession = func(
 	s.connIDGenerator = newConnIDGenerator(
 		srcConnID,
 		clientDestConnID,
+		func() (protocol.ConnectionID, error) { return generateConnectionIDWithConfig(s.config) },
 		func(connID protocol.ConnectionID) { runner.Add(connID, s) },
 		runner.GetStatelessResetToken,
-		runner.Remove,
-		runner.Retire,
-		runner.ReplaceWithClosed,
+		func(connID protocol.ConnectionID) {
+			runner.Remove(connID)
+			s.connIDRetired(connID)
+		},
+		func(connID protocol.ConnectionID) {
+			runner.Retire(connID)
+			s.connIDRetired(connID)
+		},
+		func(connID protocol.ConnectionID, handler packetHandler) {
+			runner.ReplaceWithClosed(connID, handler)
+			s.connIDRetired(connID)
+		},
 		s.queueControlFrame,
 		s.version,
 	)
@@ -276,7 +324,11 @@ var newSession = func(
 	s.sentPacketHandler, s.receivedPacketHandler = ackhandler.NewAckHandler(
 		0,
 		s.rttStats,
+		clientAddressValidated,
+		s.config.MaxPacingBurstPackets,
+		s.config.MinPacingDelay,
 		s.perspective,
+		s.clock,
 		s.traceCallback,
 		s.tracer,
 		s.logger,
@@ -300,6 +352,7 @@ var newSession = func(
 		ActiveConnectionIDLimit:         protocol.MaxActiveConnectionIDs,
 		InitialSourceConnectionID:       srcConnID,
 		RetrySourceConnectionID:         retrySrcConnID,
+		VersionInformation:              &wire.VersionInformation{ChosenVersion: s.version, AvailableVersions: s.config.Versions},
 	}
 	if s.tracer != nil {
 		s.tracer.SentTransportParameters(params)
@@ -322,15 +375,17 @@ var newSession = func(
 		},
 		tlsConf,
 		enable0RTT,
+		s.config.DisableGreasing,
 		s.rttStats,
 		tracer,
-		logger,
+		s.logger,
 		s.version,
 	)
 	s.cryptoStreamHandler = cs
 	s.packer = newPacketPacker(
 		srcConnID,
 		s.connIDManager.Get,
+		s.spinBit.Get,
 		initialStream,
 		handshakeStream,
 		s.sentPacketHandler,
@@ -339,6 +394,7 @@ var newSession = func(
 		cs,
 		s.framer,
 		s.receivedPacketHandler,
+		s.packetCapturer(),
 		s.perspective,
 		s.version,
 	)
@@ -363,6 +419,8 @@ var newClientSession = func(
 	logger utils.Logger,
 	v protocol.VersionNumber,
 ) quicSession {
+	correlationID := &utils.AtomicString{}
+	debugLogger := newSessionLogger(logger, protocol.PerspectiveClient, destConnID, correlationID)
 	s := &session{
 		conn:                  conn,
 		config:                conf,
@@ -372,7 +430,9 @@ var newClientSession = func(
 		perspective:           protocol.PerspectiveClient,
 		handshakeCompleteChan: make(chan struct{}),
 		logID:                 destConnID.String(),
-		logger:                logger,
+		logger:                debugLogger,
+		debugLogger:           debugLogger,
+		correlationID:         correlationID,
 		tracer:                tracer,
 		initialVersion:        initialVersion,
 		versionNegotiated:     hasNegotiatedVersion,
@@ -388,11 +448,21 @@ var newClientSession = func(
 	s.connIDGenerator = newConnIDGenerator(
 		srcConnID,
 		nil,
+		func() (protocol.ConnectionID, error) { return protocol.GenerateConnectionID(srcConnID.Len()) },
 		func(connID protocol.ConnectionID) { runner.Add(connID, s) },
 		runner.GetStatelessResetToken,
-		runner.Remove,
-		runner.Retire,
-		runner.ReplaceWithClosed,
+		func(connID protocol.ConnectionID) {
+			runner.Remove(connID)
+			s.connIDRetired(connID)
+		},
+		func(connID protocol.ConnectionID) {
+			runner.Retire(connID)
+			s.connIDRetired(connID)
+		},
+		func(connID protocol.ConnectionID, handler packetHandler) {
+			runner.ReplaceWithClosed(connID, handler)
+			s.connIDRetired(connID)
+		},
 		s.queueControlFrame,
 		s.version,
 	)
@@ -400,7 +470,11 @@ var newClientSession = func(
 	s.sentPacketHandler, s.receivedPacketHandler = ackhandler.NewAckHandler(
 		initialPacketNumber,
 		s.rttStats,
+		false, /* has no effect */
+		s.config.MaxPacingBurstPackets,
+		s.config.MinPacingDelay,
 		s.perspective,
+		s.clock,
 		s.traceCallback,
 		s.tracer,
 		s.logger,
@@ -421,6 +495,7 @@ var newClientSession = func(
 		DisableActiveMigration:         true,
 		ActiveConnectionIDLimit:        protocol.MaxActiveConnectionIDs,
 		InitialSourceConnectionID:      srcConnID,
+		VersionInformation:             &wire.VersionInformation{ChosenVersion: s.version, AvailableVersions: s.config.Versions},
 	}
 	if s.tracer != nil {
 		s.tracer.SentTransportParameters(params)
@@ -440,9 +515,10 @@ var newClientSession = func(
 		},
 		tlsConf,
 		enable0RTT,
+		s.config.DisableGreasing,
 		s.rttStats,
 		tracer,
-		logger,
+		s.logger,
 		s.version,
 	)
 	s.clientHelloWritten = clientHelloWritten
@@ -452,6 +528,7 @@ var newClientSession = func(
 	s.packer = newPacketPacker(
 		srcConnID,
 		s.connIDManager.Get,
+		s.spinBit.Get,
 		initialStream,
 		handshakeStream,
 		s.sentPacketHandler,
@@ -460,6 +537,7 @@ var newClientSession = func(
 		cs,
 		s.framer,
 		s.receivedPacketHandler,
+		s.packetCapturer(),
 		s.perspective,
 		s.version,
 	)
@@ -477,13 +555,15 @@ var newClientSession = func(
 }
 
 func (s *session) preSetup() {
-	s.sendQueue = newSendQueue(s.conn)
+	s.sendQueue = newSendQueue(s.conn, s.config.SendQueueSize)
 	s.retransmissionQueue = newRetransmissionQueue(s.version)
 	s.frameParser = wire.NewFrameParser(s.version)
 	s.rttStats = &utils.RTTStats{}
+	s.clock = congestion.NewCachedClock(congestion.DefaultClock{})
 	s.connFlowController = flowcontrol.NewConnectionFlowController(
 		protocol.InitialMaxData,
 		protocol.ByteCount(s.config.MaxReceiveConnectionFlowControlWindow),
+		s.memoryBudget,
 		s.onHasConnectionWindowUpdate,
 		s.rttStats,
 		s.logger,
@@ -494,20 +574,36 @@ func (s *session) preSetup() {
 		s.newFlowController,
 		uint64(s.config.MaxIncomingStreams),
 		uint64(s.config.MaxIncomingUniStreams),
+		s.config.StrictFrameValidation,
 		s.perspective,
 		s.version,
+		s.config.StreamIDsLowThreshold,
+		s.onStreamIDsLow,
 	)
 	s.framer = newFramer(s.streamsMap, s.version)
-	s.receivedPackets = make(chan *receivedPacket, protocol.MaxSessionUnprocessedPackets)
+	s.pinger = newPinger(
+		s.framer.QueueTrackedControlFrame,
+		s.scheduleSending,
+		s.config.LivenessProbeInterval,
+		s.config.MaxUnansweredLivenessProbes,
+	)
+	s.spinBit = newSpinBit(s.config.DisableSpinBit, s.config.SpinBitDisableRate, s.perspective)
+	s.receivedPackets = make(chan *receivedPacket, s.config.MaxUnprocessedPackets)
 	s.closeChan = make(chan closeError, 1)
 	s.sendingScheduled = make(chan struct{}, 1)
 	s.undecryptablePackets = make([]*receivedPacket, 0, protocol.MaxUndecryptablePackets)
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 	s.handshakeCtx, s.handshakeCtxCancel = context.WithCancel(context.Background())
+	s.pprofCtx = pprof.WithLabels(context.Background(), pprof.Labels(
+		"quic_connection_id", s.logID,
+		"quic_remote_addr", s.conn.RemoteAddr().String(),
+	))
 
-	now := time.Now()
+	now := s.clock.Now()
 	s.lastPacketReceivedTime = now
+	atomic.StoreInt64(&s.lastActivityTime, now.UnixNano())
 	s.sessionCreationTime = now
+	s.connIDsRotatedAt = now
 
 	s.windowUpdateQueue = newWindowUpdateQueue(s.streamsMap, s.connFlowController, s.framer.QueueControlFrame)
 
@@ -524,6 +620,10 @@ func (s *session) run() error {
 
 	s.timer = utils.NewTimer()
 
+	// Label the run loop goroutine and all goroutines started by it,
+	// so that CPU profiles can be broken down by connection.
+	pprof.SetGoroutineLabels(s.pprofCtx)
+
 	go s.cryptoStreamHandler.RunHandshake()
 	go func() {
 		if err := s.sendQueue.Run(); err != nil {
@@ -545,6 +645,10 @@ func (s *session) run() error {
 		}
 	}
 
+	if s.perspective == protocol.PerspectiveClient && s.version != s.initialVersion {
+		s.sessionEvent(SessionEvent{Type: SessionEventVersionNegotiated, Version: s.version})
+	}
+
 	var closeErr closeError
 
 runLoop:
@@ -571,6 +675,7 @@ runLoop:
 			// We do all the interesting stuff after the switch statement, so
 			// nothing to see here.
 		case p := <-s.receivedPackets:
+			s.clock.Update()
 			// Only reset the timers if this packet was actually processed.
 			// This avoids modifying any state when handling undecryptable packets,
 			// which could be injected by an attacker.
@@ -587,7 +692,7 @@ runLoop:
 			s.handleHandshakeComplete()
 		}
 
-		now := time.Now()
+		now := s.clock.Update()
 		if timeout := s.sentPacketHandler.GetLossDetectionTimeout(); !timeout.IsZero() && timeout.Before(now) {
 			// This could cause packets to be retransmitted.
 			// Check it before trying to send packets.
@@ -605,32 +710,137 @@ runLoop:
 			if s.tracer != nil {
 				s.tracer.ClosedConnection(logging.NewTimeoutCloseReason(logging.TimeoutReasonHandshake))
 			}
-			s.destroyImpl(qerr.NewTimeoutError("Handshake did not complete in time"))
+			s.destroyImpl(qerr.NewHandshakeTimeoutError("Handshake did not complete in time"))
 			continue
 		} else if s.handshakeComplete && now.Sub(s.idleTimeoutStartTime()) >= s.idleTimeout {
+			s.sessionEvent(SessionEvent{Type: SessionEventIdleTimeout})
 			if s.tracer != nil {
 				s.tracer.ClosedConnection(logging.NewTimeoutCloseReason(logging.TimeoutReasonIdle))
 			}
-			s.destroyImpl(qerr.NewTimeoutError("No recent network activity"))
+			s.destroyImpl(qerr.NewIdleTimeoutError("No recent network activity"))
 			continue
 		}
 
+		if err := s.pinger.MaybeSendProbe(now); err != nil {
+			if s.tracer != nil {
+				s.tracer.ClosedConnection(logging.NewTimeoutCloseReason(logging.TimeoutReasonLivenessProbe))
+			}
+			s.destroyImpl(err)
+			continue
+		}
+
+		if interval := s.config.ConnectionIDRotationInterval; interval > 0 && s.handshakeConfirmed && now.Sub(s.connIDsRotatedAt) >= interval {
+			s.logger.Debugf("Rotating connection IDs.")
+			if err := s.connIDGenerator.Rotate(); err != nil {
+				s.closeLocal(err)
+			}
+			s.connIDsRotatedAt = now
+		}
+
 		if err := s.sendPackets(); err != nil {
 			s.closeLocal(err)
 		}
+		transportState := s.sentPacketHandler.GetStats()
+		s.stats.UpdateTransportState(transportState)
+		memoryUsage := s.memoryUsage()
+		s.stats.UpdateMemoryUsage(memoryUsage)
+		if limit := s.config.MaxSessionMemory; limit > 0 && uint64(memoryUsage) > limit {
+			s.closeLocal(qerr.NewMemoryLimitError(fmt.Sprintf("session memory limit exceeded (%d bytes, allowed %d bytes)", memoryUsage, limit)))
+			// closeLocal queued a close error (unless the session was already being closed).
+			// Don't run another iteration that would check the limit again.
+			closeErr = <-s.closeChan
+			break runLoop
+		}
 	}
 
 	s.handleCloseError(closeErr)
-	if !errors.Is(closeErr.err, errCloseForRecreating{}) && s.tracer != nil {
-		s.tracer.Close()
+	if !errors.Is(closeErr.err, errCloseForRecreating{}) {
+		if s.tracer != nil {
+			s.tracer.Close()
+		}
+		s.sessionEvent(SessionEvent{Type: SessionEventClosed, Err: closeErr.err})
 	}
 	s.logger.Infof("Connection %s closed.", s.logID)
 	s.cryptoStreamHandler.Close()
 	s.sendQueue.Close()
+	s.connFlowController.Close()
 	s.timer.Stop()
 	return closeErr.err
 }
 
+// lastActivity returns the time when the last packet was received.
+// It can be called from any goroutine.
+func (s *session) lastActivity() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&s.lastActivityTime))
+}
+
+// connIDRetired is called when a connection ID is not used to route packets to this session any more
+func (s *session) connIDRetired(connID protocol.ConnectionID) {
+	if s.config.ConnectionIDRetired != nil {
+		s.config.ConnectionIDRetired(connID)
+	}
+}
+
+// newSessionLogger creates the logger used by a session.
+// All messages carry the perspective, the connection ID and the correlation ID set by the application.
+func newSessionLogger(logger utils.Logger, perspective protocol.Perspective, connID protocol.ConnectionID, correlationID *utils.AtomicString) *utils.DebugToggleLogger {
+	return utils.NewDebugToggleLogger(logger.With("perspective", perspective).With("conn_id", connID).With("correlation_id", correlationID))
+}
+
+func (s *session) SetCorrelationID(id string) {
+	s.correlationID.Set(id)
+	if s.tracer != nil {
+		s.tracer.SetCorrelationID(id)
+	}
+}
+
+// setDebugLogging enables or disables debug logging for this session.
+// It can be called from any goroutine.
+func (s *session) setDebugLogging(enabled bool) {
+	s.debugLogger.SetDebug(enabled)
+}
+
+// packetCapturer returns the function the packet packer uses to capture sent packets.
+// It returns nil if no CapturePacket callback is configured.
+func (s *session) packetCapturer() func(*wire.ExtendedHeader, protocol.EncryptionLevel, []byte) {
+	if s.config.CapturePacket == nil {
+		return nil
+	}
+	return func(hdr *wire.ExtendedHeader, encLevel protocol.EncryptionLevel, payload []byte) {
+		s.capturePacket(PacketSent, hdr, hdr.PacketNumber, encLevel, payload)
+	}
+}
+
+// capturePacket calls the CapturePacket callback, if one is configured
+func (s *session) capturePacket(dir PacketDirection, hdr *wire.ExtendedHeader, pn protocol.PacketNumber, encLevel protocol.EncryptionLevel, payload []byte) {
+	if s.config.CapturePacket == nil {
+		return
+	}
+	s.config.CapturePacket(s, CapturedPacket{
+		Direction:       dir,
+		Header:          hdr,
+		PacketNumber:    pn,
+		EncryptionLevel: encLevel,
+		Payload:         payload,
+	})
+}
+
+// sessionEvent calls the SessionEventHandler, if one is configured
+func (s *session) sessionEvent(e SessionEvent) {
+	if s.config.SessionEventHandler != nil {
+		s.config.SessionEventHandler(s, e)
+	}
+}
+
+// onStreamIDsLow is called by the streams map when only a few stream IDs of a type are left.
+// It is called while the streams map holds a lock, so the event is delivered from a separate goroutine.
+func (s *session) onStreamIDsLow(t protocol.StreamType) {
+	if s.config.SessionEventHandler == nil {
+		return
+	}
+	go s.sessionEvent(SessionEvent{Type: SessionEventStreamIDsLow, Unidirectional: t == protocol.StreamTypeUni})
+}
+
 // blocks until the early session can be used
 func (s *session) earlySessionReady() <-chan struct{} {
 	return s.earlySessionReadyChan
@@ -640,6 +850,10 @@ func (s *session) HandshakeComplete() context.Context {
 	return s.handshakeCtx
 }
 
+func (s *session) Ping(ctx context.Context) (time.Duration, error) {
+	return s.pinger.Ping(ctx)
+}
+
 func (s *session) Context() context.Context {
 	return s.ctx
 }
@@ -648,6 +862,20 @@ func (s *session) ConnectionState() ConnectionState {
 	return s.cryptoStreamHandler.ConnectionState()
 }
 
+func (s *session) Stats() ConnectionStats {
+	return s.stats.Get()
+}
+
+// memoryUsage estimates the number of bytes that the peer made the session hold.
+// ACK state is not included, since it is bounded by protocol.MaxNumAckRanges.
+// Packets in flight are not included either, since the peer can't increase the congestion window.
+func (s *session) memoryUsage() protocol.ByteCount {
+	numPackets := len(s.receivedPackets) + len(s.undecryptablePackets)
+	return protocol.ByteCount(numPackets)*protocol.MaxReceivePacketSize +
+		s.connFlowController.BufferedBytes() +
+		s.retransmissionQueue.ByteLength()
+}
+
 // Time when the next keep-alive packet should be sent.
 // It returns a zero time if no keep-alive should be sent.
 func (s *session) nextKeepAliveTime() time.Time {
@@ -678,6 +906,12 @@ func (s *session) maybeResetTimer() {
 	if !s.pacingDeadline.IsZero() {
 		deadline = utils.MinTime(deadline, s.pacingDeadline)
 	}
+	if probeTime := s.pinger.NextProbeTime(); !probeTime.IsZero() {
+		deadline = utils.MinTime(deadline, probeTime)
+	}
+	if interval := s.config.ConnectionIDRotationInterval; interval > 0 && s.handshakeConfirmed {
+		deadline = utils.MinTime(deadline, s.connIDsRotatedAt.Add(interval))
+	}
 
 	s.timer.Reset(deadline)
 }
@@ -690,6 +924,13 @@ func (s *session) handleHandshakeComplete() {
 	s.handshakeComplete = true
 	s.handshakeCompleteChan = nil // prevent this case from ever being selected again
 	s.handshakeCtxCancel()
+	now := s.clock.Update()
+	s.stats.CompletedHandshake(now.Sub(s.sessionCreationTime))
+	s.pinger.Start(now)
+	s.sessionEvent(SessionEvent{Type: SessionEventHandshakeComplete})
+	// the application protocol is only known once the handshake completes
+	s.pprofCtx = pprof.WithLabels(s.pprofCtx, pprof.Labels("quic_alpn", s.cryptoStreamHandler.ConnectionState().NegotiatedProtocol))
+	pprof.SetGoroutineLabels(s.pprofCtx)
 
 	s.connIDManager.SetHandshakeComplete()
 	s.connIDGenerator.SetHandshakeComplete()
@@ -748,7 +989,7 @@ func (s *session) handlePacketImpl(rp *receivedPacket) bool {
 			break
 		}
 
-		if hdr.IsLongHeader && hdr.Version != s.version {
+		if hdr.IsLongHeader && hdr.Version != s.version && !s.acceptsCompatibleVersion(hdr.Version) {
 			if s.tracer != nil {
 				s.tracer.DroppedPacket(logging.PacketTypeFromHeader(hdr), protocol.ByteCount(len(data)), logging.PacketDropUnexpectedVersion)
 			}
@@ -991,12 +1232,18 @@ func (s *session) handleUnpackedPacket(
 	rcvTime time.Time,
 	packetSize protocol.ByteCount, // only for logging
 ) error {
+	s.capturePacket(PacketReceived, packet.hdr, packet.packetNumber, packet.encryptionLevel, packet.data)
+
 	if len(packet.data) == 0 {
 		return qerr.NewError(qerr.ProtocolViolation, "empty packet")
 	}
 
 	if !s.receivedFirstPacket {
 		s.receivedFirstPacket = true
+		// The server might have upgraded the connection to a compatible version.
+		if s.perspective == protocol.PerspectiveClient && packet.hdr.IsLongHeader && packet.hdr.Version != s.version {
+			s.upgradeVersion(packet.hdr.Version)
+		}
 		// The server can change the source connection ID with the first Handshake packet.
 		if s.perspective == protocol.PerspectiveClient && packet.hdr.IsLongHeader && !packet.hdr.SrcConnectionID.Equal(s.handshakeDestConnID) {
 			cid := packet.hdr.SrcConnectionID
@@ -1027,8 +1274,12 @@ func (s *session) handleUnpackedPacket(
 	}
 
 	s.lastPacketReceivedTime = rcvTime
+	atomic.StoreInt64(&s.lastActivityTime, rcvTime.UnixNano())
 	s.firstAckElicitingPacketAfterIdleSentTime = time.Time{}
 	s.keepAlivePingSent = false
+	if packet.encryptionLevel == protocol.Encryption1RTT {
+		s.spinBit.ReceivedPacket(packet.packetNumber, packet.hdr.SpinBit)
+	}
 
 	// Only used for tracing.
 	// If we're not tracing, this slice will always remain empty.
@@ -1057,11 +1308,18 @@ func (s *session) handleUnpackedPacket(
 			if err := s.handleFrame(frame, packet.encryptionLevel, packet.hdr.DestConnectionID); err != nil {
 				return err
 			}
+			// ACK frames are not retained after handling, unless they were passed to the tracers.
+			if ack, ok := frame.(*wire.AckFrame); ok && s.traceCallback == nil {
+				wire.PutAckFrame(ack)
+			}
 		}
 	}
 
+	s.stats.ReceivedPacket(packetSize)
+
 	if s.traceCallback != nil {
-		transportState = s.sentPacketHandler.GetStats()
+		state := s.sentPacketHandler.GetStats()
+		transportState = &state
 		s.traceCallback(quictrace.Event{
 			Time:            rcvTime,
 			EventType:       quictrace.PacketReceived,
@@ -1136,7 +1394,7 @@ func (s *session) handleFrame(f wire.Frame, encLevel protocol.EncryptionLevel, d
 // handlePacket is called by the server with a new packet
 func (s *session) handlePacket(p *receivedPacket) {
 	// Discard packets once the amount of queued packets is larger than
-	// the channel size, protocol.MaxSessionUnprocessedPackets
+	// the channel size, Config.MaxUnprocessedPackets
 	select {
 	case s.receivedPackets <- p:
 	default:
@@ -1144,12 +1402,13 @@ func (s *session) handlePacket(p *receivedPacket) {
 }
 
 func (s *session) handleConnectionCloseFrame(frame *wire.ConnectionCloseFrame) {
-	var e error
+	var e *qerr.QuicError
 	if frame.IsApplicationError {
 		e = qerr.NewApplicationError(frame.ErrorCode, frame.ReasonPhrase)
 	} else {
 		e = qerr.NewError(frame.ErrorCode, frame.ReasonPhrase)
 	}
+	e.Remote = true
 	s.closeRemote(e)
 }
 
@@ -1308,12 +1567,28 @@ func (s *session) shutdown() {
 	<-s.ctx.Done()
 }
 
+// closeWithTransportError closes the session with a transport error.
+// It waits until the run loop has stopped before returning.
+func (s *session) closeWithTransportError(code qerr.ErrorCode, desc string) {
+	s.closeLocal(qerr.NewError(code, desc))
+	<-s.ctx.Done()
+}
+
 func (s *session) CloseWithError(code protocol.ApplicationErrorCode, desc string) error {
 	s.closeLocal(qerr.NewApplicationError(qerr.ErrorCode(code), desc))
 	<-s.ctx.Done()
 	return nil
 }
 
+func (s *session) CloseError() error {
+	select {
+	case <-s.ctx.Done():
+		return s.closeReason
+	default:
+		return nil
+	}
+}
+
 func (s *session) handleCloseError(closeErr closeError) {
 	if closeErr.err == nil {
 		closeErr.err = qerr.NewApplicationError(0, "")
@@ -1322,10 +1597,16 @@ func (s *session) handleCloseError(closeErr closeError) {
 	var quicErr *qerr.QuicError
 	var ok bool
 	if quicErr, ok = closeErr.err.(*qerr.QuicError); !ok {
-		quicErr = qerr.ToQuicError(closeErr.err)
+		if errors.As(closeErr.err, &statelessResetErr{}) {
+			quicErr = qerr.NewStatelessResetError(closeErr.err)
+		} else {
+			quicErr = qerr.ToQuicError(closeErr.err)
+		}
 	}
 
+	s.closeReason = quicErr
 	s.streamsMap.CloseWithError(quicErr)
+	s.pinger.Close(quicErr)
 	s.connIDManager.Close()
 
 	if s.tracer != nil {
@@ -1401,6 +1682,16 @@ func (s *session) processTransportParametersImpl(params *wire.TransportParameter
 		return qerr.NewError(qerr.TransportParameterError, fmt.Sprintf("expected initial_source_connection_id to equal %s, is %s", s.handshakeDestConnID, params.InitialSourceConnectionID))
 	}
 
+	if err := s.checkVersionInformation(params.VersionInformation); err != nil {
+		return err
+	}
+	// The crypto setup makes the same decision, and announces the new version in our transport parameters.
+	if s.perspective == protocol.PerspectiveServer && params.VersionInformation != nil {
+		if v := protocol.ChooseCompatibleVersion(s.version, s.config.Versions, params.VersionInformation.AvailableVersions); v != s.version {
+			s.upgradeVersion(v)
+		}
+	}
+
 	if s.perspective == protocol.PerspectiveClient {
 		// check the original_destination_connection_id
 		if !params.OriginalDestinationConnectionID.Equal(s.origDestConnID) {
@@ -1446,6 +1737,64 @@ func (s *session) processTransportParametersImpl(params *wire.TransportParameter
 	return nil
 }
 
+// checkVersionInformation makes sure that an on-path attacker didn't force a version downgrade.
+// The version_information transport parameter is authenticated by the TLS handshake,
+// so it can be used to check the outcome of the (unauthenticated) version negotiation.
+func (s *session) checkVersionInformation(vi *wire.VersionInformation) error {
+	if vi == nil {
+		// Peers that don't implement the version_information extension don't send it.
+		// For these peers, we can't detect a downgrade, even if we switched versions due to a Version Negotiation packet.
+		if s.perspective == protocol.PerspectiveClient && s.versionNegotiated {
+			s.logger.Debugf("Server didn't send a version_information. Can't check the outcome of the version negotiation.")
+		}
+		return nil
+	}
+	if vi.ChosenVersion != s.version {
+		return qerr.NewError(qerr.VersionNegotiationError, fmt.Sprintf("expected chosen version to be %s, is %s", s.version, vi.ChosenVersion))
+	}
+	if s.perspective == protocol.PerspectiveClient && s.versionNegotiated {
+		// Check that we would have chosen the same version, if the Version Negotiation packet
+		// had contained the versions that the server actually supports.
+		// The server might then have upgraded to a compatible version that it prefers.
+		negotiated := s.version
+		if s.preUpgradeVersion != 0 {
+			negotiated = s.preUpgradeVersion
+		}
+		if v, ok := protocol.ChooseSupportedVersion(s.config.Versions, vi.AvailableVersions); !ok || v != negotiated {
+			return qerr.NewError(qerr.VersionNegotiationError, fmt.Sprintf("version downgrade detected: using %s, server supports %s", negotiated, vi.AvailableVersions))
+		}
+	}
+	return nil
+}
+
+// acceptsCompatibleVersion says if a long header packet with a version other than the version of the session is processed.
+// This happens during the handshake, if the server upgraded the connection to a compatible version.
+func (s *session) acceptsCompatibleVersion(v protocol.VersionNumber) bool {
+	if s.perspective == protocol.PerspectiveServer {
+		// The client keeps sending packets with the old version until it receives our first packet.
+		return s.preUpgradeVersion != 0 && v == s.preUpgradeVersion
+	}
+	// Only the server's first packet can switch to a different version.
+	// The version_information transport parameter is used to authenticate this choice.
+	return !s.receivedFirstPacket && protocol.IsSupportedVersion(s.config.Versions, v) && protocol.IsCompatibleVersion(s.version, v)
+}
+
+// upgradeVersion upgrades the connection to a compatible version.
+// Compatible versions use the same Initial packet protection, so the handshake can continue,
+// but every component that depends on the version needs to use the new version from now on.
+func (s *session) upgradeVersion(v protocol.VersionNumber) {
+	s.logger.Debugf("Upgrading to compatible version %s (from %s)", v, s.version)
+	s.preUpgradeVersion = s.version
+	s.version = v
+	s.packer.SetVersion(v)
+	s.unpacker.SetVersion(v)
+	s.frameParser.SetVersion(v)
+	s.framer.SetVersion(v)
+	s.retransmissionQueue.SetVersion(v)
+	s.connIDGenerator.SetVersion(v)
+	s.streamsMap.SetVersion(v)
+}
+
 func (s *session) sendPackets() error {
 	s.pacingDeadline = time.Time{}
 
@@ -1554,7 +1903,7 @@ func (s *session) sendPacket() (bool, error) {
 	s.windowUpdateQueue.QueueAll()
 
 	if !s.handshakeConfirmed {
-		now := time.Now()
+		now := s.clock.Now()
 		packet, err := s.packer.PackCoalescedPacket()
 		if err != nil || packet == nil {
 			return false, err
@@ -1565,6 +1914,7 @@ func (s *session) sendPacket() (bool, error) {
 				s.firstAckElicitingPacketAfterIdleSentTime = now
 			}
 			s.sentPacketHandler.SentPacket(p.ToAckHandlerPacket(now, s.retransmissionQueue))
+			s.stats.SentPacket(p.length)
 		}
 		s.connIDManager.SentPacket()
 		s.sendQueue.Send(packet.buffer)
@@ -1579,11 +1929,12 @@ func (s *session) sendPacket() (bool, error) {
 }
 
 func (s *session) sendPackedPacket(packet *packedPacket) {
-	now := time.Now()
+	now := s.clock.Now()
 	if s.firstAckElicitingPacketAfterIdleSentTime.IsZero() && packet.IsAckEliciting() {
 		s.firstAckElicitingPacketAfterIdleSentTime = now
 	}
-	s.sentPacketHandler.SentPacket(packet.ToAckHandlerPacket(time.Now(), s.retransmissionQueue))
+	s.sentPacketHandler.SentPacket(packet.ToAckHandlerPacket(now, s.retransmissionQueue))
+	s.stats.SentPacket(packet.length)
 	s.connIDManager.SentPacket()
 	s.logPacket(now, packet)
 	s.sendQueue.Send(packet.buffer)
@@ -1614,10 +1965,11 @@ func (s *session) logPacketContents(now time.Time, p *packetContents) {
 		for _, f := range p.frames {
 			frames = append(frames, f.Frame)
 		}
+		transportState := s.sentPacketHandler.GetStats()
 		s.traceCallback(quictrace.Event{
 			Time:            now,
 			EventType:       quictrace.PacketSent,
-			TransportState:  s.sentPacketHandler.GetStats(),
+			TransportState:  &transportState,
 			EncryptionLevel: p.EncryptionLevel(),
 			PacketNumber:    p.header.PacketNumber,
 			PacketSize:      p.length,
@@ -1684,7 +2036,9 @@ func (s *session) OpenUniStreamSync(ctx context.Context) (SendStream, error) {
 	return s.streamsMap.OpenUniStreamSync(ctx)
 }
 
+// newFlowController is called by the streams map for every new stream (opened by us or by the peer).
 func (s *session) newFlowController(id protocol.StreamID) flowcontrol.StreamFlowController {
+	s.stats.OpenedStream()
 	var initialSendWindow protocol.ByteCount
 	if s.peerParams != nil {
 		if id.Type() == protocol.StreamTypeUni {
@@ -1760,11 +2114,33 @@ func (s *session) onHasStreamData(id protocol.StreamID) {
 }
 
 func (s *session) onStreamCompleted(id protocol.StreamID) {
+	if s.tracer != nil {
+		if stats, ok := s.getStreamStats(id); ok {
+			s.tracer.CompletedStream(id, stats)
+		}
+	}
 	if err := s.streamsMap.DeleteStream(id); err != nil {
 		s.closeLocal(err)
 	}
 }
 
+// getStreamStats returns the statistics of a stream that hasn't been deleted yet
+func (s *session) getStreamStats(id protocol.StreamID) (logging.StreamStats, bool) {
+	// an incoming unidirectional stream is the only stream that is not a send stream
+	if id.Type() == protocol.StreamTypeUni && id.InitiatedBy() != s.perspective {
+		str, err := s.streamsMap.GetOrOpenReceiveStream(id)
+		if err != nil || str == nil {
+			return logging.StreamStats{}, false
+		}
+		return str.getStats(), true
+	}
+	str, err := s.streamsMap.GetOrOpenSendStream(id)
+	if err != nil || str == nil {
+		return logging.StreamStats{}, false
+	}
+	return str.getStats(), true
+}
+
 func (s *session) LocalAddr() net.Addr {
 	return s.conn.LocalAddr()
 }
@@ -8,12 +8,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net"
+	"os"
 	"runtime/pprof"
 	"strings"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/mocks"
 	mockackhandler "github.com/lucas-clemente/quic-go/internal/mocks/ackhandler"
@@ -37,6 +40,10 @@ func areSessionsRunning() bool {
 	return strings.Contains(b.String(), "quic-go.(*session).run")
 }
 
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
 func areClosedSessionsRunning() bool {
 	var b bytes.Buffer
 	pprof.Lookup("goroutine").WriteTo(&b, 1)
@@ -105,6 +112,8 @@ var _ = Describe("Session", func() {
 			populateServerConfig(&Config{}),
 			nil, // tls.Config
 			tokenGenerator,
+			nil,
+			false,
 			false,
 			tracer,
 			utils.DefaultLogger,
@@ -115,6 +124,7 @@ var _ = Describe("Session", func() {
 		packer = NewMockPacker(mockCtrl)
 		sess.packer = packer
 		cryptoSetup = mocks.NewMockCryptoSetup(mockCtrl)
+		cryptoSetup.EXPECT().ConnectionState().AnyTimes()
 		sess.cryptoStreamHandler = cryptoSetup
 		sess.handshakeComplete = true
 		sess.idleTimeout = time.Hour
@@ -156,12 +166,49 @@ var _ = Describe("Session", func() {
 					Data:     []byte("foobar"),
 				})).To(Succeed())
 			})
+
+			It("rejects retransmitted STREAM frames with inconsistent data, if strict frame validation is enabled", func() {
+				newSess := func(strict bool) *session {
+					return newSession(
+						mconn,
+						sessionRunner,
+						nil,
+						nil,
+						clientDestConnID,
+						destConnID,
+						srcConnID,
+						protocol.StatelessResetToken{},
+						populateServerConfig(&Config{StrictFrameValidation: strict}),
+						nil, // tls.Config
+						nil, // token generator
+						nil,
+						false,
+						false,
+						nil, // tracer
+						utils.DefaultLogger,
+						protocol.VersionTLS,
+					).(*session)
+				}
+				f1 := &wire.StreamFrame{StreamID: 0, Offset: 10, Data: []byte("foobar")}
+				f2 := &wire.StreamFrame{StreamID: 0, Offset: 12, Data: []byte("obaz")}
+				// by default, the inconsistency is not detected
+				s := newSess(false)
+				Expect(s.handleFrame(f1, protocol.Encryption1RTT, destConnID)).To(Succeed())
+				Expect(s.handleFrame(f2, protocol.Encryption1RTT, destConnID)).To(Succeed())
+				s = newSess(true)
+				Expect(s.handleFrame(f1, protocol.Encryption1RTT, destConnID)).To(Succeed())
+				err := s.handleFrame(f2, protocol.Encryption1RTT, destConnID)
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(BeAssignableToTypeOf(&qerr.QuicError{}))
+				Expect(err.(*qerr.QuicError).ErrorCode).To(Equal(qerr.ProtocolViolation))
+			})
 		})
 
 		Context("handling ACK frames", func() {
 			It("informs the SentPacketHandler about ACKs", func() {
 				f := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 3}}}
 				sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				sph.EXPECT().GetStats().AnyTimes()
 				sph.EXPECT().ReceivedAck(f, protocol.EncryptionHandshake, gomock.Any())
 				sess.sentPacketHandler = sph
 				err := sess.handleAckFrame(f, protocol.EncryptionHandshake)
@@ -332,6 +379,7 @@ var _ = Describe("Session", func() {
 
 		It("handles CONNECTION_CLOSE frames, with a transport error code", func() {
 			testErr := qerr.NewError(qerr.StreamLimitError, "foobar")
+			testErr.Remote = true
 			streamManager.EXPECT().CloseWithError(testErr)
 			sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler) {
 				Expect(s).To(BeAssignableToTypeOf(&closedRemoteSession{}))
@@ -355,15 +403,23 @@ var _ = Describe("Session", func() {
 				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
 				Expect(sess.run()).To(MatchError(testErr))
 			}()
+			Expect(sess.CloseError()).To(BeNil())
 			Expect(sess.handleFrame(&wire.ConnectionCloseFrame{
 				ErrorCode:    qerr.StreamLimitError,
 				ReasonPhrase: "foobar",
 			}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
 			Eventually(sess.Context().Done()).Should(BeClosed())
+			var closeErr ConnectionCloseError
+			Expect(errors.As(sess.CloseError(), &closeErr)).To(BeTrue())
+			Expect(closeErr.IsRemote()).To(BeTrue())
+			Expect(closeErr.IsApplicationError()).To(BeFalse())
+			Expect(closeErr.Code()).To(BeEquivalentTo(qerr.StreamLimitError))
+			Expect(closeErr.ReasonPhrase()).To(Equal("foobar"))
 		})
 
 		It("handles CONNECTION_CLOSE frames, with an application error code", func() {
 			testErr := qerr.NewApplicationError(0x1337, "foobar")
+			testErr.Remote = true
 			streamManager.EXPECT().CloseWithError(testErr)
 			sessionRunner.EXPECT().ReplaceWithClosed(srcConnID, gomock.Any()).Do(func(_ protocol.ConnectionID, s packetHandler) {
 				Expect(s).To(BeAssignableToTypeOf(&closedRemoteSession{}))
@@ -394,6 +450,12 @@ var _ = Describe("Session", func() {
 			}
 			Expect(sess.handleFrame(ccf, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
 			Eventually(sess.Context().Done()).Should(BeClosed())
+			var closeErr ConnectionCloseError
+			Expect(errors.As(sess.CloseError(), &closeErr)).To(BeTrue())
+			Expect(closeErr.IsRemote()).To(BeTrue())
+			Expect(closeErr.IsApplicationError()).To(BeTrue())
+			Expect(closeErr.Code()).To(BeEquivalentTo(0x1337))
+			Expect(closeErr.ReasonPhrase()).To(Equal("foobar"))
 		})
 
 		It("errors on HANDSHAKE_DONE frames", func() {
@@ -401,6 +463,28 @@ var _ = Describe("Session", func() {
 		})
 	})
 
+	Context("completing streams", func() {
+		It("reports the statistics of a completed bidirectional stream to the tracer", func() {
+			str := NewMockSendStreamI(mockCtrl)
+			stats := logging.StreamStats{BytesSent: 1337, BytesReceived: 42}
+			str.EXPECT().getStats().Return(stats)
+			streamManager.EXPECT().GetOrOpenSendStream(protocol.StreamID(4)).Return(str, nil)
+			tracer.EXPECT().CompletedStream(protocol.StreamID(4), stats)
+			streamManager.EXPECT().DeleteStream(protocol.StreamID(4))
+			sess.onStreamCompleted(4)
+		})
+
+		It("reports the statistics of a completed incoming unidirectional stream to the tracer", func() {
+			str := NewMockReceiveStreamI(mockCtrl)
+			stats := logging.StreamStats{BytesReceived: 42}
+			str.EXPECT().getStats().Return(stats)
+			streamManager.EXPECT().GetOrOpenReceiveStream(protocol.StreamID(2)).Return(str, nil)
+			tracer.EXPECT().CompletedStream(protocol.StreamID(2), stats)
+			streamManager.EXPECT().DeleteStream(protocol.StreamID(2))
+			sess.onStreamCompleted(2)
+		})
+	})
+
 	It("tells its versions", func() {
 		sess.version = 4242
 		Expect(sess.GetVersion()).To(Equal(protocol.VersionNumber(4242)))
@@ -501,6 +585,10 @@ var _ = Describe("Session", func() {
 			sess.CloseWithError(0x1337, "test error")
 			Eventually(areSessionsRunning).Should(BeFalse())
 			Expect(sess.Context().Done()).To(BeClosed())
+			var closeErr ConnectionCloseError
+			Expect(errors.As(sess.CloseError(), &closeErr)).To(BeTrue())
+			Expect(closeErr.IsRemote()).To(BeFalse())
+			Expect(closeErr.Code()).To(BeEquivalentTo(0x1337))
 		})
 
 		It("includes the frame type in transport-level close frames", func() {
@@ -531,6 +619,56 @@ var _ = Describe("Session", func() {
 			Expect(sess.Context().Done()).To(BeClosed())
 		})
 
+		It("closes with a transport error", func() {
+			runSession()
+			streamManager.EXPECT().CloseWithError(qerr.NewError(qerr.NoError, "shutting down"))
+			expectReplaceWithClosed()
+			cryptoSetup.EXPECT().Close()
+			packer.EXPECT().PackConnectionClose(gomock.Any()).DoAndReturn(func(quicErr *qerr.QuicError) (*coalescedPacket, error) {
+				Expect(quicErr.IsApplicationError()).To(BeFalse())
+				Expect(quicErr.ErrorCode).To(Equal(qerr.NoError))
+				Expect(quicErr.ErrorMessage).To(Equal("shutting down"))
+				return &coalescedPacket{buffer: getPacketBuffer()}, nil
+			})
+			mconn.EXPECT().Write(gomock.Any())
+			gomock.InOrder(
+				tracer.EXPECT().ClosedConnection(gomock.Any()).Do(func(reason logging.CloseReason) {
+					errorCode, remote, ok := reason.TransportError()
+					Expect(ok).To(BeTrue())
+					Expect(remote).To(BeFalse())
+					Expect(errorCode).To(Equal(logging.TransportError(qerr.NoError)))
+				}),
+				tracer.EXPECT().Close(),
+			)
+			sess.closeWithTransportError(qerr.NoError, "shutting down")
+			Eventually(areSessionsRunning).Should(BeFalse())
+			Expect(sess.Context().Done()).To(BeClosed())
+		})
+
+		It("closes the session once when the memory limit is exceeded", func() {
+			sess.config.MaxSessionMemory = 1
+			sess.undecryptablePackets = append(sess.undecryptablePackets, &receivedPacket{})
+			packer.EXPECT().PackCoalescedPacket().AnyTimes()
+			runSession()
+			streamManager.EXPECT().CloseWithError(gomock.Any())
+			expectReplaceWithClosed()
+			cryptoSetup.EXPECT().Close()
+			packer.EXPECT().PackConnectionClose(gomock.Any()).DoAndReturn(func(quicErr *qerr.QuicError) (*coalescedPacket, error) {
+				Expect(quicErr.IsApplicationError()).To(BeFalse())
+				Expect(quicErr.ErrorCode).To(Equal(qerr.NoError))
+				Expect(quicErr.ErrorMessage).To(ContainSubstring("session memory limit exceeded"))
+				return &coalescedPacket{buffer: getPacketBuffer()}, nil
+			})
+			mconn.EXPECT().Write(gomock.Any())
+			gomock.InOrder(
+				tracer.EXPECT().ClosedConnection(gomock.Any()),
+				tracer.EXPECT().Close(),
+			)
+			sess.scheduleSending()
+			Eventually(areSessionsRunning).Should(BeFalse())
+			Expect(errors.Is(sess.CloseError(), ErrMemoryLimitExceeded)).To(BeTrue())
+		})
+
 		It("destroys the session", func() {
 			runSession()
 			testErr := errors.New("close")
@@ -618,8 +756,9 @@ var _ = Describe("Session", func() {
 			sess.handshakeConfirmed = true
 			conn := NewMockSendConn(mockCtrl)
 			conn.EXPECT().Write(gomock.Any()).Return(io.ErrClosedPipe).AnyTimes()
-			sess.sendQueue = newSendQueue(conn)
+			sess.sendQueue = newSendQueue(conn, 1)
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().Return(time.Now().Add(time.Hour)).AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
 			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
@@ -656,6 +795,14 @@ var _ = Describe("Session", func() {
 			sessionRunner.EXPECT().Remove(gomock.Any()).AnyTimes()
 			cryptoSetup.EXPECT().Close()
 			sess.destroy(statelessResetErr{token: token})
+			Eventually(sess.Context().Done()).Should(BeClosed())
+			err := sess.CloseError()
+			Expect(errors.Is(err, ErrStatelessReset)).To(BeTrue())
+			var cerr ConnectionCloseError
+			Expect(errors.As(err, &cerr)).To(BeTrue())
+			Expect(cerr.Code()).To(BeEquivalentTo(qerr.NoError))
+			Expect(cerr.IsRemote()).To(BeFalse())
+			Expect(cerr.IsApplicationError()).To(BeFalse())
 		})
 	})
 
@@ -773,6 +920,37 @@ var _ = Describe("Session", func() {
 			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			tracer.EXPECT().ReceivedPacket(hdr, protocol.ByteCount(len(packet.data)), []logging.Frame{})
 			Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+			Expect(sess.lastActivity()).To(BeTemporally("==", rcvTime))
+		})
+
+		It("passes received packets to the CapturePacket callback", func() {
+			var captured []CapturedPacket
+			sess.config.CapturePacket = func(s Session, p CapturedPacket) {
+				Expect(s).To(BeIdenticalTo(sess))
+				captured = append(captured, p)
+			}
+			hdr := &wire.ExtendedHeader{
+				Header:          wire.Header{DestConnectionID: srcConnID},
+				PacketNumber:    0x37,
+				PacketNumberLen: protocol.PacketNumberLen1,
+			}
+			packet := getPacket(hdr, nil)
+			unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(&unpackedPacket{
+				packetNumber:    0x1337,
+				encryptionLevel: protocol.EncryptionInitial,
+				hdr:             hdr,
+				data:            []byte{0}, // one PADDING frame
+			}, nil)
+			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any())
+			Expect(sess.handlePacketImpl(packet)).To(BeTrue())
+			Expect(captured).To(Equal([]CapturedPacket{{
+				Direction:       PacketReceived,
+				Header:          hdr,
+				PacketNumber:    0x1337,
+				EncryptionLevel: protocol.EncryptionInitial,
+				Payload:         []byte{0},
+			}}))
 		})
 
 		It("informs the ReceivedPacketHandler about ack-eliciting packets", func() {
@@ -1193,6 +1371,7 @@ var _ = Describe("Session", func() {
 		It("sends packets", func() {
 			sess.handshakeConfirmed = true
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sph.EXPECT().TimeUntilSend().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
@@ -1210,6 +1389,31 @@ var _ = Describe("Session", func() {
 			Eventually(sent).Should(BeClosed())
 		})
 
+		It("timestamps sent packets using the session's clock", func() {
+			now := time.Now()
+			sess.clock = congestion.NewCachedClock(fixedClock(now))
+			sess.handshakeConfirmed = true
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
+			sph.EXPECT().TimeUntilSend().AnyTimes()
+			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
+			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
+			sph.EXPECT().SentPacket(gomock.Any()).Do(func(p *ackhandler.Packet) {
+				Expect(p.SendTime).To(Equal(now))
+			})
+			sess.sentPacketHandler = sph
+			runSession()
+			p := getPacket(1)
+			packer.EXPECT().PackPacket().Return(p, nil)
+			packer.EXPECT().PackPacket().Return(nil, nil).AnyTimes()
+			sent := make(chan struct{})
+			mconn.EXPECT().Write(gomock.Any()).Do(func([]byte) { close(sent) })
+			tracer.EXPECT().SentPacket(p.header, p.buffer.Len(), nil, []logging.Frame{})
+			sess.scheduleSending()
+			Eventually(sent).Should(BeClosed())
+		})
+
 		It("doesn't send packets if there's nothing to send", func() {
 			sess.handshakeConfirmed = true
 			runSession()
@@ -1221,6 +1425,7 @@ var _ = Describe("Session", func() {
 
 		It("sends ACK only packets", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sph.EXPECT().TimeUntilSend().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAck)
@@ -1235,6 +1440,7 @@ var _ = Describe("Session", func() {
 		It("adds a BLOCKED frame when it is connection-level flow control blocked", func() {
 			sess.handshakeConfirmed = true
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sph.EXPECT().TimeUntilSend().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
@@ -1244,6 +1450,8 @@ var _ = Describe("Session", func() {
 			fc := mocks.NewMockConnectionFlowController(mockCtrl)
 			fc.EXPECT().IsNewlyBlocked().Return(true, protocol.ByteCount(1337))
 			fc.EXPECT().IsNewlyBlocked()
+			fc.EXPECT().Close()
+			fc.EXPECT().BufferedBytes().AnyTimes()
 			p := getPacket(1)
 			packer.EXPECT().PackPacket().Return(p, nil)
 			packer.EXPECT().PackPacket().Return(nil, nil).AnyTimes()
@@ -1260,6 +1468,7 @@ var _ = Describe("Session", func() {
 
 		It("doesn't send when the SentPacketHandler doesn't allow it", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendNone).AnyTimes()
 			sph.EXPECT().TimeUntilSend().AnyTimes()
@@ -1293,6 +1502,7 @@ var _ = Describe("Session", func() {
 
 				It("sends a probe packet", func() {
 					sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+					sph.EXPECT().GetStats().AnyTimes()
 					sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 					sph.EXPECT().TimeUntilSend().AnyTimes()
 					sph.EXPECT().SendMode().Return(sendMode)
@@ -1314,6 +1524,7 @@ var _ = Describe("Session", func() {
 
 				It("sends a PING as a probe packet", func() {
 					sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+					sph.EXPECT().GetStats().AnyTimes()
 					sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 					sph.EXPECT().TimeUntilSend().AnyTimes()
 					sph.EXPECT().SendMode().Return(sendMode)
@@ -1345,6 +1556,7 @@ var _ = Describe("Session", func() {
 		BeforeEach(func() {
 			tracer.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			sph = mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sess.handshakeConfirmed = true
 			sess.handshakeComplete = true
@@ -1489,6 +1701,7 @@ var _ = Describe("Session", func() {
 
 		It("sends when scheduleSending is called", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().TimeUntilSend().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
@@ -1517,6 +1730,7 @@ var _ = Describe("Session", func() {
 			packer.EXPECT().PackPacket().Return(getPacket(1234), nil)
 			packer.EXPECT().PackPacket().Return(nil, nil)
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
 			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
@@ -1546,6 +1760,7 @@ var _ = Describe("Session", func() {
 		sess.handshakeComplete = false
 		sess.handshakeConfirmed = false
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().GetStats().AnyTimes()
 		sess.sentPacketHandler = sph
 		buffer := getPacketBuffer()
 		buffer.Data = append(buffer.Data, []byte("foobar")...)
@@ -1624,10 +1839,49 @@ var _ = Describe("Session", func() {
 		Eventually(sess.Context().Done()).Should(BeClosed())
 	})
 
+	It("sets pprof labels", func() {
+		connID, ok := pprof.Label(sess.pprofCtx, "quic_connection_id")
+		Expect(ok).To(BeTrue())
+		Expect(connID).To(Equal(sess.logID))
+		addr, ok := pprof.Label(sess.pprofCtx, "quic_remote_addr")
+		Expect(ok).To(BeTrue())
+		Expect(addr).To(Equal(remoteAddr.String()))
+	})
+
+	It("sets the correlation ID", func() {
+		Expect(sess.correlationID.Get()).To(BeEmpty())
+		tracer.EXPECT().SetCorrelationID("foobar")
+		sess.SetCorrelationID("foobar")
+		Expect(sess.correlationID.Get()).To(Equal("foobar"))
+	})
+
+	It("adds the perspective, the connection ID and the correlation ID to log messages", func() {
+		b := &bytes.Buffer{}
+		log.SetOutput(b)
+		defer log.SetOutput(os.Stdout)
+		correlationID := &utils.AtomicString{}
+		logger := newSessionLogger(utils.DefaultLogger.WithLogLevel(utils.LogLevelDebug), protocol.PerspectiveClient, protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}, correlationID)
+		logger.Debugf("foo")
+		Expect(b.String()).To(ContainSubstring("perspective=Client conn_id=0xdeadbeef foo"))
+		b.Reset()
+		correlationID.Set("foobar")
+		logger.Debugf("bar")
+		Expect(b.String()).To(ContainSubstring("perspective=Client conn_id=0xdeadbeef correlation_id=foobar bar"))
+	})
+
+	It("estimates the memory usage", func() {
+		Expect(sess.memoryUsage()).To(BeZero())
+		sess.undecryptablePackets = append(sess.undecryptablePackets, &receivedPacket{}, &receivedPacket{})
+		f := &wire.MaxDataFrame{MaximumData: 1337}
+		sess.retransmissionQueue.AddAppData(f)
+		Expect(sess.memoryUsage()).To(Equal(2*protocol.MaxReceivePacketSize + f.Length(sess.version)))
+	})
+
 	It("cancels the HandshakeComplete context when the handshake completes", func() {
 		packer.EXPECT().PackCoalescedPacket().AnyTimes()
 		finishHandshake := make(chan struct{})
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().GetStats().AnyTimes()
 		sess.sentPacketHandler = sph
 		sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 		sph.EXPECT().TimeUntilSend().AnyTimes()
@@ -1727,6 +1981,7 @@ var _ = Describe("Session", func() {
 
 	It("sends a HANDSHAKE_DONE frame when the handshake completes", func() {
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().GetStats().AnyTimes()
 		sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
 		sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 		sph.EXPECT().TimeUntilSend().AnyTimes()
@@ -1833,6 +2088,116 @@ var _ = Describe("Session", func() {
 			sess.processTransportParameters(params)
 			Expect(sess.earlySessionReady()).To(BeClosed())
 		})
+
+		It("upgrades to a compatible version supported by the client", func() {
+			sess.config.Versions = []protocol.VersionNumber{protocol.VersionDraft32, protocol.VersionDraft29}
+			sess.version = protocol.VersionDraft29
+			params := &wire.TransportParameters{
+				ActiveConnectionIDLimit:   3,
+				MaxUDPPayloadSize:         protocol.MaxReceivePacketSize,
+				InitialSourceConnectionID: destConnID,
+				VersionInformation: &wire.VersionInformation{
+					ChosenVersion:     protocol.VersionDraft29,
+					AvailableVersions: []protocol.VersionNumber{protocol.VersionDraft29, protocol.VersionDraft32},
+				},
+			}
+			streamManager.EXPECT().UpdateLimits(params)
+			packer.EXPECT().HandleTransportParameters(params)
+			packer.EXPECT().SetVersion(protocol.VersionDraft32)
+			streamManager.EXPECT().SetVersion(protocol.VersionDraft32)
+			packer.EXPECT().PackCoalescedPacket().MaxTimes(3)
+			sessionRunner.EXPECT().GetStatelessResetToken(gomock.Any()).Times(2)
+			sessionRunner.EXPECT().Add(gomock.Any(), sess).Times(2)
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.processTransportParameters(params)
+			Expect(sess.version).To(Equal(protocol.VersionDraft32))
+			Expect(sess.unpacker.(*packetUnpacker).version).To(Equal(protocol.VersionDraft32))
+			Expect(sess.framer.(*framerI).version).To(Equal(protocol.VersionDraft32))
+			Expect(sess.retransmissionQueue.version).To(Equal(protocol.VersionDraft32))
+			Expect(sess.connIDGenerator.version).To(Equal(protocol.VersionDraft32))
+			// the client keeps using the original version until it receives our first packet
+			Expect(sess.acceptsCompatibleVersion(protocol.VersionDraft29)).To(BeTrue())
+			Expect(sess.acceptsCompatibleVersion(protocol.VersionTLS)).To(BeFalse())
+		})
+	})
+
+	Context("connection ID rotation", func() {
+		AfterEach(func() {
+			// make the go routine return
+			expectReplaceWithClosed()
+			streamManager.EXPECT().CloseWithError(gomock.Any())
+			packer.EXPECT().PackConnectionClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+			cryptoSetup.EXPECT().Close()
+			mconn.EXPECT().Write(gomock.Any())
+			tracer.EXPECT().ClosedConnection(gomock.Any())
+			tracer.EXPECT().Close()
+			sess.shutdown()
+			Eventually(sess.Context().Done()).Should(BeClosed())
+		})
+
+		It("rotates connection IDs when the interval expires", func() {
+			sess.config.ConnectionIDRotationInterval = time.Minute
+			sess.handshakeConfirmed = true
+			sess.connIDsRotatedAt = time.Now().Add(-time.Minute)
+			added := make(chan struct{})
+			sessionRunner.EXPECT().GetStatelessResetToken(gomock.Any())
+			sessionRunner.EXPECT().Add(gomock.Any(), sess).Do(func(protocol.ConnectionID, packetHandler) { close(added) })
+			packer.EXPECT().PackCoalescedPacket().AnyTimes()
+			sess.scheduleSending()
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				sess.run()
+			}()
+			Eventually(added).Should(BeClosed())
+			frames, _ := sess.framer.AppendControlFrames(nil, protocol.MaxByteCount)
+			Expect(frames).To(HaveLen(1))
+			Expect(frames[0].Frame).To(BeAssignableToTypeOf(&wire.NewConnectionIDFrame{}))
+			nf := frames[0].Frame.(*wire.NewConnectionIDFrame)
+			Expect(nf.RetirePriorTo).To(BeEquivalentTo(1))
+			sessionRunner.EXPECT().ReplaceWithClosed(nf.ConnectionID, gomock.Any())
+		})
+
+		It("rotates connection IDs when the session is idle", func() {
+			sess.config.ConnectionIDRotationInterval = time.Minute
+			sess.handshakeConfirmed = true
+			sess.connIDsRotatedAt = time.Now().Add(-time.Minute + scaleDuration(50*time.Millisecond))
+			added := make(chan struct{})
+			sessionRunner.EXPECT().GetStatelessResetToken(gomock.Any())
+			sessionRunner.EXPECT().Add(gomock.Any(), sess).Do(func(protocol.ConnectionID, packetHandler) { close(added) })
+			packer.EXPECT().PackCoalescedPacket().AnyTimes()
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				sess.run()
+			}()
+			Eventually(added).Should(BeClosed())
+			frames, _ := sess.framer.AppendControlFrames(nil, protocol.MaxByteCount)
+			Expect(frames).To(HaveLen(1))
+			nf := frames[0].Frame.(*wire.NewConnectionIDFrame)
+			sessionRunner.EXPECT().ReplaceWithClosed(nf.ConnectionID, gomock.Any())
+		})
+
+		It("doesn't rotate connection IDs before the handshake is confirmed", func() {
+			sess.config.ConnectionIDRotationInterval = time.Minute
+			sess.connIDsRotatedAt = time.Now().Add(-time.Minute)
+			packer.EXPECT().PackCoalescedPacket().AnyTimes()
+			sess.scheduleSending()
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				sess.run()
+			}()
+			Consistently(sess.framer.HasData).Should(BeFalse())
+		})
+	})
+
+	It("calls the callback when a connection ID is retired", func() {
+		var retired []byte
+		sess.config.ConnectionIDRetired = func(connID []byte) { retired = connID }
+		sessionRunner.EXPECT().Retire(srcConnID)
+		Expect(sess.connIDGenerator.Retire(0, protocol.ConnectionID{})).To(Succeed())
+		Expect(retired).To(Equal([]byte(srcConnID)))
 	})
 
 	Context("keep-alives", func() {
@@ -1943,6 +2308,7 @@ var _ = Describe("Session", func() {
 				nerr, ok := err.(net.Error)
 				Expect(ok).To(BeTrue())
 				Expect(nerr.Timeout()).To(BeTrue())
+				Expect(errors.Is(err, ErrIdleTimeout)).To(BeTrue())
 				Expect(err.Error()).To(ContainSubstring("No recent network activity"))
 				close(done)
 			}()
@@ -1970,12 +2336,41 @@ var _ = Describe("Session", func() {
 				nerr, ok := err.(net.Error)
 				Expect(ok).To(BeTrue())
 				Expect(nerr.Timeout()).To(BeTrue())
+				Expect(errors.Is(err, ErrHandshakeTimeout)).To(BeTrue())
 				Expect(err.Error()).To(ContainSubstring("Handshake did not complete in time"))
 				close(done)
 			}()
 			Eventually(done).Should(BeClosed())
 		})
 
+		It("times out when liveness probes go unanswered", func() {
+			sess.pinger = newPinger(sess.framer.QueueTrackedControlFrame, sess.scheduleSending, time.Second, 2)
+			sess.pinger.Start(time.Now().Add(-time.Hour))
+			sess.pinger.unansweredProbes = 2
+			sessionRunner.EXPECT().Remove(gomock.Any()).Times(2)
+			cryptoSetup.EXPECT().Close()
+			gomock.InOrder(
+				tracer.EXPECT().ClosedConnection(gomock.Any()).Do(func(reason logging.CloseReason) {
+					timeout, ok := reason.Timeout()
+					Expect(ok).To(BeTrue())
+					Expect(timeout).To(Equal(logging.TimeoutReasonLivenessProbe))
+				}),
+				tracer.EXPECT().Close(),
+			)
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				err := sess.run()
+				nerr, ok := err.(net.Error)
+				Expect(ok).To(BeTrue())
+				Expect(nerr.Timeout()).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("No response to 2 liveness probes"))
+				close(done)
+			}()
+			Eventually(done).Should(BeClosed())
+		})
+
 		It("does not use the idle timeout before the handshake complete", func() {
 			sess.handshakeComplete = false
 			sess.config.MaxIdleTimeout = 9999 * time.Second
@@ -2034,12 +2429,59 @@ var _ = Describe("Session", func() {
 				nerr, ok := err.(net.Error)
 				Expect(ok).To(BeTrue())
 				Expect(nerr.Timeout()).To(BeTrue())
+				Expect(errors.Is(err, ErrIdleTimeout)).To(BeTrue())
 				Expect(err.Error()).To(ContainSubstring("No recent network activity"))
 				close(done)
 			}()
 			Eventually(done).Should(BeClosed())
 		})
 
+		It("reports lifecycle events to the SessionEventHandler", func() {
+			packer.EXPECT().PackCoalescedPacket().AnyTimes()
+			sessionRunner.EXPECT().Retire(clientDestConnID)
+			sessionRunner.EXPECT().Remove(gomock.Any())
+			cryptoSetup.EXPECT().Close()
+			tracer.EXPECT().ClosedConnection(gomock.Any())
+			tracer.EXPECT().Close()
+			var events []SessionEvent
+			sess.config.SessionEventHandler = func(s Session, e SessionEvent) {
+				Expect(s).To(Equal(sess))
+				events = append(events, e)
+			}
+			sess.idleTimeout = 0
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				cryptoSetup.EXPECT().GetSessionTicket().MaxTimes(1)
+				cryptoSetup.EXPECT().SetHandshakeConfirmed().MaxTimes(1)
+				close(sess.handshakeCompleteChan)
+				sess.run()
+				close(done)
+			}()
+			Eventually(done).Should(BeClosed())
+			Expect(events).To(HaveLen(3))
+			Expect(events[0].Type).To(Equal(SessionEventHandshakeComplete))
+			Expect(events[1].Type).To(Equal(SessionEventIdleTimeout))
+			Expect(events[2].Type).To(Equal(SessionEventClosed))
+			nerr, ok := events[2].Err.(net.Error)
+			Expect(ok).To(BeTrue())
+			Expect(nerr.Timeout()).To(BeTrue())
+		})
+
+		It("reports when the stream IDs are running low", func() {
+			events := make(chan SessionEvent, 1)
+			sess.config.SessionEventHandler = func(s Session, e SessionEvent) {
+				Expect(s).To(Equal(sess))
+				events <- e
+			}
+			sess.onStreamIDsLow(protocol.StreamTypeUni)
+			var e SessionEvent
+			Eventually(events).Should(Receive(&e))
+			Expect(e.Type).To(Equal(SessionEventStreamIDsLow))
+			Expect(e.Unidirectional).To(BeTrue())
+		})
+
 		It("doesn't time out when it just sent a packet", func() {
 			sess.lastPacketReceivedTime = time.Now().Add(-time.Hour)
 			sess.firstAckElicitingPacketAfterIdleSentTime = time.Now().Add(-time.Second)
@@ -2062,11 +2504,12 @@ var _ = Describe("Session", func() {
 		})
 	})
 
-	It("stores up to MaxSessionUnprocessedPackets packets", func(done Done) {
+	It("stores up to MaxUnprocessedPackets packets", func(done Done) {
 		// Nothing here should block
 		for i := protocol.PacketNumber(0); i < protocol.MaxSessionUnprocessedPackets+10; i++ {
 			sess.handlePacket(&receivedPacket{})
 		}
+		Expect(sess.receivedPackets).To(HaveLen(protocol.MaxSessionUnprocessedPackets))
 		close(done)
 	}, 0.5)
 
@@ -2172,7 +2615,7 @@ var _ = Describe("Client Session", func() {
 		Eventually(areSessionsRunning).Should(BeFalse())
 
 		mconn = NewMockSendConn(mockCtrl)
-		mconn.EXPECT().RemoteAddr().Return(&net.UDPAddr{}).Times(2)
+		mconn.EXPECT().RemoteAddr().Return(&net.UDPAddr{}).Times(3)
 		mconn.EXPECT().LocalAddr().Return(&net.UDPAddr{})
 		if tlsConf == nil {
 			mconn.EXPECT().RemoteAddr().Return(&net.UDPAddr{})
@@ -2201,6 +2644,7 @@ var _ = Describe("Client Session", func() {
 		packer = NewMockPacker(mockCtrl)
 		sess.packer = packer
 		cryptoSetup = mocks.NewMockCryptoSetup(mockCtrl)
+		cryptoSetup.EXPECT().ConnectionState().AnyTimes()
 		sess.cryptoStreamHandler = cryptoSetup
 	})
 
@@ -2272,8 +2716,76 @@ var _ = Describe("Client Session", func() {
 		Expect(sess.handleSinglePacket(&receivedPacket{buffer: getPacketBuffer()}, hdr)).To(BeTrue())
 	})
 
+	It("reports the negotiated version to the SessionEventHandler", func() {
+		events := make(chan SessionEvent, 10)
+		sess.config.SessionEventHandler = func(_ Session, e SessionEvent) { events <- e }
+		// the session was created after version negotiation
+		sess.initialVersion = protocol.VersionDraft29
+		clientHelloWritten := make(chan *wire.TransportParameters)
+		close(clientHelloWritten)
+		sess.clientHelloWritten = clientHelloWritten
+		packer.EXPECT().PackCoalescedPacket().AnyTimes()
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+			sess.run()
+			close(done)
+		}()
+		var e SessionEvent
+		Eventually(events).Should(Receive(&e))
+		Expect(e.Type).To(Equal(SessionEventVersionNegotiated))
+		Expect(e.Version).To(Equal(protocol.VersionTLS))
+		// make sure the go routine returns
+		packer.EXPECT().PackConnectionClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+		expectReplaceWithClosed()
+		cryptoSetup.EXPECT().Close()
+		mconn.EXPECT().Write(gomock.Any())
+		tracer.EXPECT().ClosedConnection(gomock.Any())
+		tracer.EXPECT().Close()
+		sess.shutdown()
+		Eventually(done).Should(BeClosed())
+		Eventually(events).Should(Receive(&e))
+		Expect(e.Type).To(Equal(SessionEventClosed))
+	})
+
+	It("upgrades to a compatible version chosen by the server", func() {
+		sess.config.Versions = []protocol.VersionNumber{protocol.VersionDraft29, protocol.VersionDraft32}
+		sess.version = protocol.VersionDraft29
+		unpacker := NewMockUnpacker(mockCtrl)
+		sess.unpacker = unpacker
+		hdr := &wire.ExtendedHeader{
+			Header: wire.Header{
+				IsLongHeader:     true,
+				Type:             protocol.PacketTypeInitial,
+				DestConnectionID: srcConnID,
+				SrcConnectionID:  destConnID,
+				Length:           1,
+				Version:          protocol.VersionDraft32,
+			},
+			PacketNumberLen: protocol.PacketNumberLen1,
+		}
+		unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(&unpackedPacket{
+			encryptionLevel: protocol.EncryptionInitial,
+			hdr:             hdr,
+			data:            []byte{0}, // one PADDING frame
+		}, nil)
+		packer.EXPECT().SetVersion(protocol.VersionDraft32)
+		unpacker.EXPECT().SetVersion(protocol.VersionDraft32)
+		tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any())
+		Expect(sess.handlePacketImpl(getPacket(hdr, nil))).To(BeTrue())
+		Expect(sess.version).To(Equal(protocol.VersionDraft32))
+		Expect(sess.framer.(*framerI).version).To(Equal(protocol.VersionDraft32))
+		Expect(sess.retransmissionQueue.version).To(Equal(protocol.VersionDraft32))
+		Expect(sess.connIDGenerator.version).To(Equal(protocol.VersionDraft32))
+		Expect(sess.streamsMap.(*streamsMap).getVersion()).To(Equal(protocol.VersionDraft32))
+		// only the server's first packet can switch the version
+		Expect(sess.acceptsCompatibleVersion(protocol.VersionDraft29)).To(BeFalse())
+	})
+
 	It("handles HANDSHAKE_DONE frames", func() {
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().GetStats().AnyTimes()
 		sess.sentPacketHandler = sph
 		sph.EXPECT().SetHandshakeConfirmed()
 		cryptoSetup.EXPECT().SetHandshakeConfirmed()
@@ -2309,6 +2821,7 @@ var _ = Describe("Client Session", func() {
 
 		It("closes and returns the right error", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sess.sentPacketHandler = sph
 			sph.EXPECT().PeekPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(128), protocol.PacketNumberLen4)
 			sess.config.Versions = []protocol.VersionNumber{1234, 4321}
@@ -2400,6 +2913,7 @@ var _ = Describe("Client Session", func() {
 
 		It("handles Retry packets", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sess.sentPacketHandler = sph
 			sph.EXPECT().ReceivedBytes(gomock.Any())
 			sph.EXPECT().ResetForRetry()
@@ -2581,6 +3095,72 @@ var _ = Describe("Client Session", func() {
 			sess.processTransportParameters(params)
 			Eventually(errChan).Should(Receive(MatchError("TRANSPORT_PARAMETER_ERROR: expected original_destination_connection_id to equal 0xdeadbeef, is 0xdecafbad")))
 		})
+
+		It("errors if the version_information contains the wrong chosen version", func() {
+			sess.version = protocol.VersionDraft29
+			params := &wire.TransportParameters{
+				OriginalDestinationConnectionID: destConnID,
+				InitialSourceConnectionID:       destConnID,
+				VersionInformation:              &wire.VersionInformation{ChosenVersion: protocol.VersionDraft32},
+			}
+			expectClose()
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.processTransportParameters(params)
+			Eventually(errChan).Should(Receive(MatchError(fmt.Sprintf("VERSION_NEGOTIATION_ERROR: expected chosen version to be %s, is %s", protocol.VersionDraft29, protocol.VersionDraft32))))
+		})
+
+		It("accepts a missing version_information after version negotiation, for servers that don't implement the extension", func() {
+			sess.versionNegotiated = true
+			params := &wire.TransportParameters{
+				OriginalDestinationConnectionID: destConnID,
+				InitialSourceConnectionID:       destConnID,
+			}
+			packer.EXPECT().HandleTransportParameters(gomock.Any())
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.processTransportParameters(params)
+			Expect(sess.peerParams).To(Equal(params))
+			Consistently(errChan).ShouldNot(Receive())
+		})
+
+		It("detects a version downgrade", func() {
+			// We offered draft-32, and switched to draft-29 due to a Version Negotiation packet.
+			// However, the server actually supports draft-32.
+			sess.config.Versions = []protocol.VersionNumber{protocol.VersionDraft32, protocol.VersionDraft29}
+			sess.version = protocol.VersionDraft29
+			sess.versionNegotiated = true
+			params := &wire.TransportParameters{
+				OriginalDestinationConnectionID: destConnID,
+				InitialSourceConnectionID:       destConnID,
+				VersionInformation: &wire.VersionInformation{
+					ChosenVersion:     protocol.VersionDraft29,
+					AvailableVersions: []protocol.VersionNumber{protocol.VersionDraft29, protocol.VersionDraft32},
+				},
+			}
+			expectClose()
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.processTransportParameters(params)
+			var err error
+			Eventually(errChan).Should(Receive(&err))
+			Expect(err.Error()).To(HavePrefix("VERSION_NEGOTIATION_ERROR: version downgrade detected"))
+		})
+
+		It("accepts the version_information after a legitimate version negotiation", func() {
+			sess.config.Versions = []protocol.VersionNumber{protocol.VersionDraft32, protocol.VersionDraft29}
+			sess.version = protocol.VersionDraft29
+			sess.versionNegotiated = true
+			params := &wire.TransportParameters{
+				OriginalDestinationConnectionID: destConnID,
+				InitialSourceConnectionID:       destConnID,
+				VersionInformation: &wire.VersionInformation{
+					ChosenVersion:     protocol.VersionDraft29,
+					AvailableVersions: []protocol.VersionNumber{protocol.VersionDraft29},
+				},
+			}
+			packer.EXPECT().HandleTransportParameters(gomock.Any())
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.processTransportParameters(params)
+			Expect(sess.peerParams).To(Equal(params))
+		})
 	})
 
 	Context("handling potentially injected packets", func() {
@@ -2687,6 +3267,7 @@ var _ = Describe("Client Session", func() {
 		// can cause subsequent real Initial packets to be ignored
 		It("ignores Initial packets which use original source id, after accepting a Retry", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().GetStats().AnyTimes()
 			sess.sentPacketHandler = sph
 			sph.EXPECT().ReceivedBytes(gomock.Any()).Times(2)
 			sph.EXPECT().ResetForRetry()
@@ -0,0 +1,58 @@
+package quic
+
+import (
+	"crypto/rand"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// The spinBit implements the latency spin bit, see section 17.3.1 of draft-ietf-quic-transport.
+// The server echoes the spin bit of the packet with the highest packet number it received,
+// the client sends the inverted value.
+// This allows on-path observers to measure the RTT of a connection.
+type spinBit struct {
+	perspective protocol.Perspective
+	enabled     bool
+
+	value                   bool
+	receivedFirstPacket     bool
+	largestRcvdPacketNumber protocol.PacketNumber
+}
+
+func newSpinBit(disabled bool, disableRate int, pers protocol.Perspective) *spinBit {
+	b := make([]byte, 2)
+	_, _ = rand.Read(b) // ignore the error here. Failure to read random data doesn't break anything
+	s := &spinBit{
+		perspective: pers,
+		// Even if enabled, the spin bit is disabled on a fraction of connections,
+		// such that the spin bit can't be used to identify implementations that don't support it.
+		enabled: !disabled && b[0]%uint8(disableRate) != 0,
+	}
+	// If the spin bit is disabled, we use a random value for the whole connection.
+	if !s.enabled {
+		s.value = b[1]&1 > 0
+	}
+	return s
+}
+
+// ReceivedPacket is called for every 1-RTT packet received.
+func (s *spinBit) ReceivedPacket(pn protocol.PacketNumber, spin bool) {
+	if !s.enabled {
+		return
+	}
+	if s.receivedFirstPacket && pn <= s.largestRcvdPacketNumber {
+		return
+	}
+	s.receivedFirstPacket = true
+	s.largestRcvdPacketNumber = pn
+	if s.perspective == protocol.PerspectiveServer {
+		s.value = spin
+	} else {
+		s.value = !spin
+	}
+}
+
+// Get returns the value of the spin bit that should be sent on the next 1-RTT packet.
+func (s *spinBit) Get() bool {
+	return s.value
+}
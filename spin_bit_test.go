@@ -0,0 +1,96 @@
+package quic
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spin Bit", func() {
+	It("is disabled when configured", func() {
+		s := newSpinBit(true, protocol.DefaultSpinBitDisableRate, protocol.PerspectiveServer)
+		Expect(s.enabled).To(BeFalse())
+		val := s.Get()
+		s.ReceivedPacket(1, !val)
+		Expect(s.Get()).To(Equal(val))
+	})
+
+	It("is disabled on some connections", func() {
+		var disabled int
+		for i := 0; i < 100*protocol.DefaultSpinBitDisableRate; i++ {
+			if !newSpinBit(false, protocol.DefaultSpinBitDisableRate, protocol.PerspectiveClient).enabled {
+				disabled++
+			}
+		}
+		Expect(disabled).To(And(BeNumerically(">", 50), BeNumerically("<", 200)))
+	})
+
+	It("uses the configured disable rate", func() {
+		var disabled int
+		for i := 0; i < 400; i++ {
+			if !newSpinBit(false, 4, protocol.PerspectiveClient).enabled {
+				disabled++
+			}
+		}
+		Expect(disabled).To(And(BeNumerically(">", 50), BeNumerically("<", 150)))
+	})
+
+	It("is disabled on all connections, if the disable rate is 1", func() {
+		for i := 0; i < 100; i++ {
+			Expect(newSpinBit(false, 1, protocol.PerspectiveClient).enabled).To(BeFalse())
+		}
+	})
+
+	Context("for the server", func() {
+		var s *spinBit
+
+		BeforeEach(func() {
+			s = &spinBit{perspective: protocol.PerspectiveServer, enabled: true}
+		})
+
+		It("echoes the spin bit", func() {
+			Expect(s.Get()).To(BeFalse())
+			s.ReceivedPacket(1, true)
+			Expect(s.Get()).To(BeTrue())
+			s.ReceivedPacket(2, false)
+			Expect(s.Get()).To(BeFalse())
+		})
+
+		It("ignores reordered packets", func() {
+			s.ReceivedPacket(10, true)
+			Expect(s.Get()).To(BeTrue())
+			s.ReceivedPacket(9, false)
+			Expect(s.Get()).To(BeTrue())
+			s.ReceivedPacket(10, false)
+			Expect(s.Get()).To(BeTrue())
+		})
+
+		It("uses the first packet, if it has packet number 0", func() {
+			s.ReceivedPacket(0, true)
+			Expect(s.Get()).To(BeTrue())
+		})
+	})
+
+	Context("for the client", func() {
+		var s *spinBit
+
+		BeforeEach(func() {
+			s = &spinBit{perspective: protocol.PerspectiveClient, enabled: true}
+		})
+
+		It("inverts the spin bit", func() {
+			s.ReceivedPacket(1, false)
+			Expect(s.Get()).To(BeTrue())
+			s.ReceivedPacket(2, true)
+			Expect(s.Get()).To(BeFalse())
+		})
+
+		It("ignores reordered packets", func() {
+			s.ReceivedPacket(10, false)
+			Expect(s.Get()).To(BeTrue())
+			s.ReceivedPacket(9, true)
+			Expect(s.Get()).To(BeTrue())
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package quic
 
 import (
+	"net"
 	"sync"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/flowcontrol"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 // The streamSender is notified by the stream about various events.
@@ -42,6 +44,7 @@ var _ streamSender = &uniStreamSender{}
 type streamI interface {
 	Stream
 	closeForShutdown(error)
+	setVersion(protocol.VersionNumber)
 	// for receiving
 	handleStreamFrame(*wire.StreamFrame) error
 	handleResetStreamFrame(*wire.ResetStreamFrame) error
@@ -51,6 +54,7 @@ type streamI interface {
 	handleStopSendingFrame(*wire.StopSendingFrame)
 	popStreamFrame(maxBytes protocol.ByteCount) (*ackhandler.Frame, bool)
 	handleMaxStreamDataFrame(*wire.MaxStreamDataFrame)
+	getStats() logging.StreamStats
 }
 
 var (
@@ -82,13 +86,17 @@ type streamCanceledError struct {
 
 func (streamCanceledError) Canceled() bool                             { return true }
 func (e streamCanceledError) ErrorCode() protocol.ApplicationErrorCode { return e.errorCode }
+func (streamCanceledError) Temporary() bool                            { return false }
+func (streamCanceledError) Timeout() bool                              { return false }
 
 var _ StreamError = &streamCanceledError{}
+var _ net.Error = &streamCanceledError{}
 
 // newStream creates a new Stream
 func newStream(streamID protocol.StreamID,
 	sender streamSender,
 	flowController flowcontrol.StreamFlowController,
+	strictValidation bool,
 	version protocol.VersionNumber,
 ) *stream {
 	s := &stream{sender: sender, version: version}
@@ -111,7 +119,7 @@ func newStream(streamID protocol.StreamID,
 			s.completedMutex.Unlock()
 		},
 	}
-	s.receiveStream = *newReceiveStream(streamID, senderForReceiveStream, flowController, version)
+	s.receiveStream = *newReceiveStream(streamID, senderForReceiveStream, flowController, strictValidation, version)
 	return s
 }
 
@@ -131,6 +139,15 @@ func (s *stream) SetDeadline(t time.Time) error {
 	return nil
 }
 
+// getStats returns the statistics of both stream halves.
+func (s *stream) getStats() logging.StreamStats {
+	stats := s.sendStream.getStats()
+	rcvStats := s.receiveStream.getStats()
+	stats.BytesReceived = rcvStats.BytesReceived
+	stats.FramesReceived = rcvStats.FramesReceived
+	return stats
+}
+
 // CloseForShutdown closes a stream abruptly.
 // It makes Read and Write unblock (and return the error) immediately.
 // The peer will NOT be informed about this: the stream is closed without sending a FIN or RST.
@@ -139,6 +156,11 @@ func (s *stream) closeForShutdown(err error) {
 	s.receiveStream.closeForShutdown(err)
 }
 
+func (s *stream) setVersion(v protocol.VersionNumber) {
+	s.sendStream.setVersion(v)
+	s.receiveStream.setVersion(v)
+}
+
 // checkIfCompleted is called from the uniStreamSender, when one of the stream halves is completed.
 // It makes sure that the onStreamCompleted callback is only called if both receive and send side have completed.
 func (s *stream) checkIfCompleted() {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 
 	"github.com/lucas-clemente/quic-go/internal/flowcontrol"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
@@ -43,12 +44,20 @@ func (streamOpenErr) Timeout() bool     { return false }
 // errTooManyOpenStreams is used internally by the outgoing streams maps.
 var errTooManyOpenStreams = errors.New("too many open streams")
 
+// errStreamIDsExhausted is used internally by the outgoing streams maps.
+// It is returned when all stream IDs of a type have been used.
+// Unlike errTooManyOpenStreams, this error is permanent: A new connection is needed to open more streams.
+var errStreamIDsExhausted = errors.New("stream IDs exhausted")
+
 type streamsMap struct {
 	perspective protocol.Perspective
 
 	sender            streamSender
 	newFlowController func(protocol.StreamID) flowcontrol.StreamFlowController
 
+	versionMutex sync.RWMutex
+	version      protocol.VersionNumber
+
 	outgoingBidiStreams *outgoingBidiStreamsMap
 	outgoingUniStreams  *outgoingUniStreamsMap
 	incomingBidiStreams *incomingBidiStreamsMap
@@ -62,25 +71,31 @@ func newStreamsMap(
 	newFlowController func(protocol.StreamID) flowcontrol.StreamFlowController,
 	maxIncomingBidiStreams uint64,
 	maxIncomingUniStreams uint64,
+	strictValidation bool,
 	perspective protocol.Perspective,
 	version protocol.VersionNumber,
+	streamIDsLowThreshold uint64,
+	onStreamIDsLow func(protocol.StreamType),
 ) streamManager {
 	m := &streamsMap{
 		perspective:       perspective,
 		newFlowController: newFlowController,
 		sender:            sender,
+		version:           version,
 	}
 	m.outgoingBidiStreams = newOutgoingBidiStreamsMap(
 		func(num protocol.StreamNum) streamI {
 			id := num.StreamID(protocol.StreamTypeBidi, perspective)
-			return newStream(id, m.sender, m.newFlowController(id), version)
+			return newStream(id, m.sender, m.newFlowController(id), strictValidation, m.getVersion())
 		},
 		sender.queueControlFrame,
+		streamIDsLowThreshold,
+		func() { onStreamIDsLow(protocol.StreamTypeBidi) },
 	)
 	m.incomingBidiStreams = newIncomingBidiStreamsMap(
 		func(num protocol.StreamNum) streamI {
 			id := num.StreamID(protocol.StreamTypeBidi, perspective.Opposite())
-			return newStream(id, m.sender, m.newFlowController(id), version)
+			return newStream(id, m.sender, m.newFlowController(id), strictValidation, m.getVersion())
 		},
 		maxIncomingBidiStreams,
 		sender.queueControlFrame,
@@ -88,14 +103,16 @@ func newStreamsMap(
 	m.outgoingUniStreams = newOutgoingUniStreamsMap(
 		func(num protocol.StreamNum) sendStreamI {
 			id := num.StreamID(protocol.StreamTypeUni, perspective)
-			return newSendStream(id, m.sender, m.newFlowController(id), version)
+			return newSendStream(id, m.sender, m.newFlowController(id), m.getVersion())
 		},
 		sender.queueControlFrame,
+		streamIDsLowThreshold,
+		func() { onStreamIDsLow(protocol.StreamTypeUni) },
 	)
 	m.incomingUniStreams = newIncomingUniStreamsMap(
 		func(num protocol.StreamNum) receiveStreamI {
 			id := num.StreamID(protocol.StreamTypeUni, perspective.Opposite())
-			return newReceiveStream(id, m.sender, m.newFlowController(id), version)
+			return newReceiveStream(id, m.sender, m.newFlowController(id), strictValidation, m.getVersion())
 		},
 		maxIncomingUniStreams,
 		sender.queueControlFrame,
@@ -233,6 +250,24 @@ func (m *streamsMap) UpdateLimits(p *wire.TransportParameters) error {
 	return nil
 }
 
+func (m *streamsMap) getVersion() protocol.VersionNumber {
+	m.versionMutex.RLock()
+	defer m.versionMutex.RUnlock()
+	return m.version
+}
+
+// SetVersion sets the version used by all open streams, and by streams opened from now on.
+// It is called when the connection is upgraded to a compatible version during the handshake.
+func (m *streamsMap) SetVersion(v protocol.VersionNumber) {
+	m.versionMutex.Lock()
+	m.version = v
+	m.versionMutex.Unlock()
+	m.outgoingBidiStreams.SetVersion(v)
+	m.outgoingUniStreams.SetVersion(v)
+	m.incomingBidiStreams.SetVersion(v)
+	m.incomingUniStreams.SetVersion(v)
+}
+
 func (m *streamsMap) CloseWithError(err error) {
 	m.outgoingBidiStreams.CloseWithError(err)
 	m.outgoingUniStreams.CloseWithError(err)
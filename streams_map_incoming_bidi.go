@@ -172,6 +172,14 @@ func (m *incomingBidiStreamsMap) deleteStream(num protocol.StreamNum) error {
 	return nil
 }
 
+func (m *incomingBidiStreamsMap) SetVersion(v protocol.VersionNumber) {
+	m.mutex.Lock()
+	for _, str := range m.streams {
+		str.setVersion(v)
+	}
+	m.mutex.Unlock()
+}
+
 func (m *incomingBidiStreamsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
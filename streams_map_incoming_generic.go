@@ -170,6 +170,14 @@ func (m *incomingItemsMap) deleteStream(num protocol.StreamNum) error {
 	return nil
 }
 
+func (m *incomingItemsMap) SetVersion(v protocol.VersionNumber) {
+	m.mutex.Lock()
+	for _, str := range m.streams {
+		str.setVersion(v)
+	}
+	m.mutex.Unlock()
+}
+
 func (m *incomingItemsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
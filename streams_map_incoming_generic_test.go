@@ -18,6 +18,7 @@ type mockGenericStream struct {
 
 	closed   bool
 	closeErr error
+	version  protocol.VersionNumber
 }
 
 func (s *mockGenericStream) closeForShutdown(err error) {
@@ -25,6 +26,10 @@ func (s *mockGenericStream) closeForShutdown(err error) {
 	s.closeErr = err
 }
 
+func (s *mockGenericStream) setVersion(v protocol.VersionNumber) {
+	s.version = v
+}
+
 var _ = Describe("Streams Map (incoming)", func() {
 	var (
 		m              *incomingItemsMap
@@ -161,6 +166,16 @@ var _ = Describe("Streams Map (incoming)", func() {
 		Expect(str2.(*mockGenericStream).closeErr).To(MatchError(testErr))
 	})
 
+	It("sets the version of all streams", func() {
+		str1, err := m.GetOrOpenStream(1)
+		Expect(err).ToNot(HaveOccurred())
+		str2, err := m.GetOrOpenStream(3)
+		Expect(err).ToNot(HaveOccurred())
+		m.SetVersion(protocol.VersionDraft32)
+		Expect(str1.(*mockGenericStream).version).To(Equal(protocol.VersionDraft32))
+		Expect(str2.(*mockGenericStream).version).To(Equal(protocol.VersionDraft32))
+	})
+
 	It("deletes streams", func() {
 		mockSender.EXPECT().queueControlFrame(gomock.Any())
 		_, err := m.GetOrOpenStream(1)
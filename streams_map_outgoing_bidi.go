@@ -28,20 +28,29 @@ type outgoingBidiStreamsMap struct {
 	newStream            func(protocol.StreamNum) streamI
 	queueStreamIDBlocked func(*wire.StreamsBlockedFrame)
 
+	// onStreamIDsLow is called once, when the number of stream IDs that are left drops to streamIDsLowThreshold.
+	streamIDsLowThreshold uint64
+	onStreamIDsLow        func()
+	streamIDsLowSignaled  bool
+
 	closeErr error
 }
 
 func newOutgoingBidiStreamsMap(
 	newStream func(protocol.StreamNum) streamI,
 	queueControlFrame func(wire.Frame),
+	streamIDsLowThreshold uint64,
+	onStreamIDsLow func(),
 ) *outgoingBidiStreamsMap {
 	return &outgoingBidiStreamsMap{
-		streams:              make(map[protocol.StreamNum]streamI),
-		openQueue:            make(map[uint64]chan struct{}),
-		maxStream:            protocol.InvalidStreamNum,
-		nextStream:           1,
-		newStream:            newStream,
-		queueStreamIDBlocked: func(f *wire.StreamsBlockedFrame) { queueControlFrame(f) },
+		streams:               make(map[protocol.StreamNum]streamI),
+		openQueue:             make(map[uint64]chan struct{}),
+		maxStream:             protocol.InvalidStreamNum,
+		nextStream:            1,
+		newStream:             newStream,
+		queueStreamIDBlocked:  func(f *wire.StreamsBlockedFrame) { queueControlFrame(f) },
+		streamIDsLowThreshold: streamIDsLowThreshold,
+		onStreamIDsLow:        onStreamIDsLow,
 	}
 }
 
@@ -53,6 +62,9 @@ func (m *outgoingBidiStreamsMap) OpenStream() (streamI, error) {
 		return nil, m.closeErr
 	}
 
+	if m.nextStream > protocol.MaxStreamCount {
+		return nil, streamOpenErr{errStreamIDsExhausted}
+	}
 	// if there are OpenStreamSync calls waiting, return an error here
 	if len(m.openQueue) > 0 || m.nextStream > m.maxStream {
 		m.maybeSendBlockedFrame()
@@ -73,6 +85,9 @@ func (m *outgoingBidiStreamsMap) OpenStreamSync(ctx context.Context) (streamI, e
 		return nil, err
 	}
 
+	if m.nextStream > protocol.MaxStreamCount {
+		return nil, streamOpenErr{errStreamIDsExhausted}
+	}
 	if len(m.openQueue) == 0 && m.nextStream <= m.maxStream {
 		return m.openStream(), nil
 	}
@@ -100,6 +115,12 @@ func (m *outgoingBidiStreamsMap) OpenStreamSync(ctx context.Context) (streamI, e
 		if m.closeErr != nil {
 			return nil, m.closeErr
 		}
+		if m.nextStream > protocol.MaxStreamCount {
+			delete(m.openQueue, queuePos)
+			m.lowestInQueue = queuePos + 1
+			m.unblockOpenSync()
+			return nil, streamOpenErr{errStreamIDsExhausted}
+		}
 		if m.nextStream > m.maxStream {
 			// no stream available. Continue waiting
 			continue
@@ -116,6 +137,10 @@ func (m *outgoingBidiStreamsMap) openStream() streamI {
 	s := m.newStream(m.nextStream)
 	m.streams[m.nextStream] = s
 	m.nextStream++
+	if !m.streamIDsLowSignaled && uint64(protocol.MaxStreamCount-m.nextStream+1) <= m.streamIDsLowThreshold {
+		m.streamIDsLowSignaled = true
+		m.onStreamIDsLow()
+	}
 	return s
 }
 
@@ -200,6 +225,14 @@ func (m *outgoingBidiStreamsMap) unblockOpenSync() {
 	}
 }
 
+func (m *outgoingBidiStreamsMap) SetVersion(v protocol.VersionNumber) {
+	m.mutex.Lock()
+	for _, str := range m.streams {
+		str.setVersion(v)
+	}
+	m.mutex.Unlock()
+}
+
 func (m *outgoingBidiStreamsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
@@ -26,20 +26,29 @@ type outgoingItemsMap struct {
 	newStream            func(protocol.StreamNum) item
 	queueStreamIDBlocked func(*wire.StreamsBlockedFrame)
 
+	// onStreamIDsLow is called once, when the number of stream IDs that are left drops to streamIDsLowThreshold.
+	streamIDsLowThreshold uint64
+	onStreamIDsLow        func()
+	streamIDsLowSignaled  bool
+
 	closeErr error
 }
 
 func newOutgoingItemsMap(
 	newStream func(protocol.StreamNum) item,
 	queueControlFrame func(wire.Frame),
+	streamIDsLowThreshold uint64,
+	onStreamIDsLow func(),
 ) *outgoingItemsMap {
 	return &outgoingItemsMap{
-		streams:              make(map[protocol.StreamNum]item),
-		openQueue:            make(map[uint64]chan struct{}),
-		maxStream:            protocol.InvalidStreamNum,
-		nextStream:           1,
-		newStream:            newStream,
-		queueStreamIDBlocked: func(f *wire.StreamsBlockedFrame) { queueControlFrame(f) },
+		streams:               make(map[protocol.StreamNum]item),
+		openQueue:             make(map[uint64]chan struct{}),
+		maxStream:             protocol.InvalidStreamNum,
+		nextStream:            1,
+		newStream:             newStream,
+		queueStreamIDBlocked:  func(f *wire.StreamsBlockedFrame) { queueControlFrame(f) },
+		streamIDsLowThreshold: streamIDsLowThreshold,
+		onStreamIDsLow:        onStreamIDsLow,
 	}
 }
 
@@ -51,6 +60,9 @@ func (m *outgoingItemsMap) OpenStream() (item, error) {
 		return nil, m.closeErr
 	}
 
+	if m.nextStream > protocol.MaxStreamCount {
+		return nil, streamOpenErr{errStreamIDsExhausted}
+	}
 	// if there are OpenStreamSync calls waiting, return an error here
 	if len(m.openQueue) > 0 || m.nextStream > m.maxStream {
 		m.maybeSendBlockedFrame()
@@ -71,6 +83,9 @@ func (m *outgoingItemsMap) OpenStreamSync(ctx context.Context) (item, error) {
 		return nil, err
 	}
 
+	if m.nextStream > protocol.MaxStreamCount {
+		return nil, streamOpenErr{errStreamIDsExhausted}
+	}
 	if len(m.openQueue) == 0 && m.nextStream <= m.maxStream {
 		return m.openStream(), nil
 	}
@@ -98,6 +113,12 @@ func (m *outgoingItemsMap) OpenStreamSync(ctx context.Context) (item, error) {
 		if m.closeErr != nil {
 			return nil, m.closeErr
 		}
+		if m.nextStream > protocol.MaxStreamCount {
+			delete(m.openQueue, queuePos)
+			m.lowestInQueue = queuePos + 1
+			m.unblockOpenSync()
+			return nil, streamOpenErr{errStreamIDsExhausted}
+		}
 		if m.nextStream > m.maxStream {
 			// no stream available. Continue waiting
 			continue
@@ -114,6 +135,10 @@ func (m *outgoingItemsMap) openStream() item {
 	s := m.newStream(m.nextStream)
 	m.streams[m.nextStream] = s
 	m.nextStream++
+	if !m.streamIDsLowSignaled && uint64(protocol.MaxStreamCount-m.nextStream+1) <= m.streamIDsLowThreshold {
+		m.streamIDsLowSignaled = true
+		m.onStreamIDsLow()
+	}
 	return s
 }
 
@@ -198,6 +223,14 @@ func (m *outgoingItemsMap) unblockOpenSync() {
 	}
 }
 
+func (m *outgoingItemsMap) SetVersion(v protocol.VersionNumber) {
+	m.mutex.Lock()
+	for _, str := range m.streams {
+		str.setVersion(v)
+	}
+	m.mutex.Unlock()
+}
+
 func (m *outgoingItemsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
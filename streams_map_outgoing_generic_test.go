@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
 	"sort"
 	"sync"
 	"time"
@@ -18,10 +19,12 @@ import (
 
 var _ = Describe("Streams Map (outgoing)", func() {
 	var (
-		m          *outgoingItemsMap
-		newItem    func(num protocol.StreamNum) item
-		mockSender *MockStreamSender
+		m                 *outgoingItemsMap
+		newItem           func(num protocol.StreamNum) item
+		mockSender        *MockStreamSender
+		numStreamIDsLowCb int
 	)
+	const streamIDsLowThreshold = 3
 
 	// waitForEnqueued waits until there are n go routines waiting on OpenStreamSync()
 	waitForEnqueued := func(n int) {
@@ -37,7 +40,8 @@ var _ = Describe("Streams Map (outgoing)", func() {
 			return &mockGenericStream{num: num}
 		}
 		mockSender = NewMockStreamSender(mockCtrl)
-		m = newOutgoingItemsMap(newItem, mockSender.queueControlFrame)
+		numStreamIDsLowCb = 0
+		m = newOutgoingItemsMap(newItem, mockSender.queueControlFrame, streamIDsLowThreshold, func() { numStreamIDsLowCb++ })
 	})
 
 	Context("no stream ID limit", func() {
@@ -112,6 +116,16 @@ var _ = Describe("Streams Map (outgoing)", func() {
 			Expect(str2.(*mockGenericStream).closed).To(BeTrue())
 			Expect(str2.(*mockGenericStream).closeErr).To(MatchError(testErr))
 		})
+
+		It("sets the version of all streams", func() {
+			str1, err := m.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			str2, err := m.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			m.SetVersion(protocol.VersionDraft32)
+			Expect(str1.(*mockGenericStream).version).To(Equal(protocol.VersionDraft32))
+			Expect(str2.(*mockGenericStream).version).To(Equal(protocol.VersionDraft32))
+		})
 	})
 
 	Context("with stream ID limits", func() {
@@ -333,6 +347,66 @@ var _ = Describe("Streams Map (outgoing)", func() {
 			Expect(str.(*mockGenericStream).num).To(Equal(protocol.StreamNum(2)))
 		})
 
+		It("errors when all stream IDs have been used", func() {
+			m.SetMaxStream(protocol.MaxStreamCount)
+			m.nextStream = protocol.MaxStreamCount
+			str, err := m.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.(*mockGenericStream).num).To(Equal(protocol.MaxStreamCount))
+			_, err = m.OpenStream()
+			Expect(err).To(MatchError(errStreamIDsExhausted.Error()))
+			Expect(err.(net.Error).Temporary()).To(BeFalse())
+			// OpenStreamSync doesn't block
+			_, err = m.OpenStreamSync(context.Background())
+			Expect(err).To(MatchError(errStreamIDsExhausted.Error()))
+		})
+
+		It("notifies once when the number of stream IDs left drops to the threshold", func() {
+			m.SetMaxStream(protocol.MaxStreamCount)
+			m.nextStream = protocol.MaxStreamCount - streamIDsLowThreshold - 1
+			// after opening these streams, there are still 4 stream IDs left
+			for i := 0; i < 2; i++ {
+				_, err := m.OpenStream()
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(numStreamIDsLowCb).To(BeZero())
+			_, err := m.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(numStreamIDsLowCb).To(Equal(1))
+			// use up the remaining stream IDs
+			for i := 0; i < streamIDsLowThreshold; i++ {
+				_, err := m.OpenStreamSync(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+			}
+			_, err = m.OpenStream()
+			Expect(err).To(MatchError(errStreamIDsExhausted.Error()))
+			Expect(numStreamIDsLowCb).To(Equal(1))
+		})
+
+		It("unblocks OpenStreamSync calls when all stream IDs have been used", func() {
+			mockSender.EXPECT().queueControlFrame(gomock.Any()).AnyTimes()
+			m.nextStream = protocol.MaxStreamCount
+			errChan := make(chan error, 2)
+			for i := 0; i < 2; i++ {
+				go func() {
+					_, err := m.OpenStreamSync(context.Background())
+					errChan <- err
+				}()
+			}
+			waitForEnqueued(2)
+			m.SetMaxStream(protocol.MaxStreamCount)
+			var numErrs int
+			for i := 0; i < 2; i++ {
+				var err error
+				Eventually(errChan).Should(Receive(&err))
+				if err != nil {
+					Expect(err).To(MatchError(errStreamIDsExhausted.Error()))
+					numErrs++
+				}
+			}
+			Expect(numErrs).To(Equal(1))
+		})
+
 		It("queues a STREAMS_BLOCKED frame if no stream can be opened", func() {
 			m.SetMaxStream(6)
 			// open the 6 allowed streams
@@ -70,8 +70,9 @@ var _ = Describe("Streams Map", func() {
 
 		Context(perspective.String(), func() {
 			var (
-				m          *streamsMap
-				mockSender *MockStreamSender
+				m            *streamsMap
+				mockSender   *MockStreamSender
+				streamIDsLow []protocol.StreamType
 			)
 
 			const (
@@ -88,10 +89,23 @@ var _ = Describe("Streams Map", func() {
 
 			BeforeEach(func() {
 				mockSender = NewMockStreamSender(mockCtrl)
-				m = newStreamsMap(mockSender, newFlowController, MaxBidiStreamNum, MaxUniStreamNum, perspective, protocol.VersionWhatever).(*streamsMap)
+				streamIDsLow = nil
+				m = newStreamsMap(mockSender, newFlowController, MaxBidiStreamNum, MaxUniStreamNum, false, perspective, protocol.VersionWhatever, 10, func(t protocol.StreamType) { streamIDsLow = append(streamIDsLow, t) }).(*streamsMap)
 			})
 
 			Context("opening", func() {
+				It("notifies when the stream IDs are running low", func() {
+					allowUnlimitedStreams()
+					m.outgoingUniStreams.nextStream = protocol.MaxStreamCount - 10
+					_, err := m.OpenUniStream()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(streamIDsLow).To(Equal([]protocol.StreamType{protocol.StreamTypeUni}))
+					m.outgoingBidiStreams.nextStream = protocol.MaxStreamCount - 10
+					_, err = m.OpenStream()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(streamIDsLow).To(Equal([]protocol.StreamType{protocol.StreamTypeUni, protocol.StreamTypeBidi}))
+				})
+
 				It("opens bidirectional streams", func() {
 					allowUnlimitedStreams()
 					str, err := m.OpenStream()
@@ -321,6 +335,36 @@ var _ = Describe("Streams Map", func() {
 				})
 			})
 
+			Context("upgrading the version", func() {
+				It("sets the version of open streams", func() {
+					allowUnlimitedStreams()
+					bidiStr, err := m.OpenStream()
+					Expect(err).ToNot(HaveOccurred())
+					uniStr, err := m.OpenUniStream()
+					Expect(err).ToNot(HaveOccurred())
+					incomingStr, err := m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream)
+					Expect(err).ToNot(HaveOccurred())
+					m.SetVersion(protocol.VersionDraft32)
+					Expect(bidiStr.(*stream).sendStream.version).To(Equal(protocol.VersionDraft32))
+					Expect(uniStr.(*sendStream).version).To(Equal(protocol.VersionDraft32))
+					Expect(incomingStr.(*stream).sendStream.version).To(Equal(protocol.VersionDraft32))
+				})
+
+				It("uses the new version for new streams", func() {
+					allowUnlimitedStreams()
+					m.SetVersion(protocol.VersionDraft32)
+					bidiStr, err := m.OpenStream()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(bidiStr.(*stream).sendStream.version).To(Equal(protocol.VersionDraft32))
+					uniStr, err := m.OpenUniStream()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(uniStr.(*sendStream).version).To(Equal(protocol.VersionDraft32))
+					incomingStr, err := m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(incomingStr.(*stream).sendStream.version).To(Equal(protocol.VersionDraft32))
+				})
+			})
+
 			Context("updating stream ID limits", func() {
 				for _, p := range []protocol.Perspective{protocol.PerspectiveClient, protocol.PerspectiveServer} {
 					pers := p